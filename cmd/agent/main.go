@@ -1,14 +1,18 @@
 package main
 
 import (
+	"crypto/rsa"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"metrics/internal/collector"
 	"metrics/internal/config"
 	"metrics/internal/config/agent"
 	"metrics/internal/handlers"
 	"metrics/internal/logger"
-	"metrics/internal/repository"
+	"metrics/internal/security"
 	"metrics/internal/service"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -39,9 +43,6 @@ func run(loggerZap *zap.SugaredLogger) error {
 		return fmt.Errorf("failed to parse flags: %w", err)
 	}
 
-	memoryRepository := repository.NewMemoryRepository()
-	systemRepository := repository.NewSystemRepository()
-
 	agentService, err := serviceResolver(configs, loggerZap)
 	if err != nil {
 		return fmt.Errorf("failed to create agent service: %w", err)
@@ -49,8 +50,7 @@ func run(loggerZap *zap.SugaredLogger) error {
 
 	applicationHandlers := handlers.NewAgentHandler(
 		configs,
-		memoryRepository,
-		systemRepository,
+		collector.Defaults(),
 		agentService,
 		loggerZap,
 	)
@@ -62,14 +62,42 @@ func run(loggerZap *zap.SugaredLogger) error {
 }
 
 func serviceResolver(configs *config.AgentConfig, loggerZap *zap.SugaredLogger) (service.MetricSender, error) {
+	var tlsConfig *tls.Config
+	if configs.TLSEnabled() {
+		var err error
+		tlsConfig, err = security.ClientTLSConfig(configs.TLSCert, configs.TLSKey, configs.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure tls: %w", err)
+		}
+	}
+
 	if configs.Grpc {
-		client, err := service.NewGrpcClient()
+		client, err := service.NewGrpcClient(service.GrpcClientOptions{
+			Address:          configs.GrpcAddress,
+			DialTimeout:      time.Duration(configs.GrpcDialTimeout) * time.Second,
+			KeepaliveTime:    time.Duration(configs.GrpcKeepaliveTime) * time.Second,
+			KeepaliveTimeout: time.Duration(configs.GrpcKeepaliveTimeout) * time.Second,
+			MaxMessageSize:   configs.GrpcMaxMessageSize,
+			MaxAttempts:      configs.GrpcMaxAttempts,
+		}, tlsConfig)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create grpc client: %w", err)
 		}
-		return service.NewGRPCMetricSender(client), nil
+		var publicKey *rsa.PublicKey
+		if configs.CryptoKey != "" {
+			publicKey, err = security.LoadRSAPublicKeyFromCert(configs.CryptoKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load public key: %w", err)
+			}
+		}
+		if configs.GrpcStream {
+			return service.NewGRPCStreamSender(
+				client, publicKey, configs.AgentID, configs.Key, service.DefaultStreamWindow,
+			), nil
+		}
+		return service.NewGRPCMetricSender(client, publicKey, configs.AgentID, configs.Key), nil
 	}
-	client := service.NewClient(configs.Address, configs.Key, configs.CryptoKey, loggerZap)
+	client := service.NewClient(configs.Address, configs.AgentID, configs.Key, configs.CryptoKey, tlsConfig, loggerZap)
 
 	return service.NewHTTPMetricSender(client.RestyClient), nil
 }
@@ -40,6 +40,8 @@ func main() {
 		},
 		// разрешаем использование сертификата для 127.0.0.1 и ::1
 		IPAddresses: []net.IP{net.IPv4(ipv4Address, 0, 0, 1), net.ParseIP(ipv6Address)},
+		// и для имени localhost, которое агент использует по умолчанию
+		DNSNames: []string{"localhost"},
 		// сертификат верен, начиная со времени создания
 		NotBefore: time.Now(),
 		// время жизни сертификата — 10 лет
@@ -9,8 +9,10 @@ import (
 	"metrics/internal/logger"
 	"metrics/internal/repository"
 	"metrics/internal/server"
+	"metrics/internal/service"
 	"net/http"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -30,6 +32,8 @@ var (
 const (
 	timeoutServerShutdown = time.Second * 5
 	timeoutShutdown       = time.Second * 10
+	// metricEventsBuffer число изменений метрик, которое может отстать подписчик до отключения.
+	metricEventsBuffer = 256
 )
 
 func main() {
@@ -76,10 +80,84 @@ func run(loggerZap *zap.SugaredLogger) error {
 		return fmt.Errorf("repository error: %w", err)
 	}
 
+	credentials, closeCredentials, err := repository.NewCredentialStore(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("credential store error: %w", err)
+	}
+	// Учётные данные проверяются до остановки серверов, поэтому пул закрывается последним.
+	defer closeCredentials()
+
+	auditLog, err := server.NewAuditLog(cfg, loggerZap)
+	if err != nil {
+		return fmt.Errorf("audit log error: %w", err)
+	}
+	// Журнал закрывается после остановки серверов, когда административных запросов уже нет.
+	defer func() {
+		if err := auditLog.Close(); err != nil {
+			loggerZap.Infow("failed to close audit log", "error", err)
+		}
+	}()
+
+	hub := service.NewHub(metricEventsBuffer)
+	// Закрытие хаба завершает потоковые подписки, иначе остановка серверов ждала бы их отключения.
+	g.Go(func() error {
+		<-ctx.Done()
+		hub.Close()
+		return nil
+	})
+
+	alerts, webhooks, err := server.NewAlerting(memStorage, cfg, loggerZap)
+	if err != nil {
+		return fmt.Errorf("alerting error: %w", err)
+	}
+
+	// Приёмники StatsD и Graphite записывают накопленные значения при остановке,
+	// а правила алертов читают хранилище, поэтому оно закрывается после них.
+	var listeners sync.WaitGroup
+	if alerts != nil {
+		listeners.Add(1)
+		g.Go(func() error {
+			defer listeners.Done()
+			defer log.Print("alerting has been shutdown")
+
+			alerts.Run(ctx)
+			return nil
+		})
+		g.Go(func() error {
+			webhooks.Run(ctx)
+			return nil
+		})
+	}
+	if cfg.StatsdEnabled() {
+		listeners.Add(1)
+		g.Go(func() error {
+			defer listeners.Done()
+			defer log.Print("statsd listener has been shutdown")
+
+			if err := server.ServeStatsD(ctx, memStorage, hub, cfg, loggerZap); err != nil {
+				return fmt.Errorf("listen statsd has failed: %w", err)
+			}
+			return nil
+		})
+	}
+	if cfg.GraphiteEnabled() {
+		listeners.Add(1)
+		g.Go(func() error {
+			defer listeners.Done()
+			defer log.Print("graphite listener has been shutdown")
+
+			if err := server.ServeGraphite(ctx, memStorage, hub, cfg, loggerZap); err != nil {
+				return fmt.Errorf("listen graphite has failed: %w", err)
+			}
+			return nil
+		})
+	}
+
 	g.Go(func() error {
 		defer log.Print("closed DB")
 
 		<-ctx.Done()
+		listeners.Wait()
 
 		memStorage.Shutdown(ctx)
 		return nil
@@ -117,7 +195,7 @@ func run(loggerZap *zap.SugaredLogger) error {
 	}
 
 	g.Go(func() (err error) {
-		httpServer, err = server.ConfigureServerHandler(memStorage, cfg, loggerZap)
+		httpServer, err = server.ConfigureServerHandler(memStorage, hub, alerts, credentials, auditLog, cfg, loggerZap)
 		if err != nil {
 			if errors.Is(err, http.ErrServerClosed) {
 				return
@@ -145,7 +223,7 @@ func run(loggerZap *zap.SugaredLogger) error {
 	})
 
 	g.Go(func() (err error) {
-		grpcServer, err = server.Serve(memStorage, loggerZap)
+		grpcServer, err = server.Serve(memStorage, hub, credentials, auditLog, cfg, loggerZap)
 		if err != nil {
 			return fmt.Errorf("listen and server grpc has failed: %w", err)
 		}
@@ -0,0 +1,363 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"metrics/internal/repository"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// State состояние алерта.
+type State string
+
+const (
+	// StateInactive условие не выполняется.
+	StateInactive State = "inactive"
+	// StatePending условие выполняется меньше заданного в правиле времени.
+	StatePending State = "pending"
+	// StateFiring условие выполняется дольше заданного в правиле времени.
+	StateFiring State = "firing"
+	// StateResolved условие перестало выполняться после срабатывания.
+	StateResolved State = "resolved"
+)
+
+// Alert состояние правила.
+type Alert struct {
+	// Время перехода в pending.
+	ActiveAt *time.Time `json:"active_at,omitempty"`
+	// Время срабатывания.
+	FiredAt *time.Time `json:"fired_at,omitempty"`
+	// Время снятия.
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	// Значение при последнем вычислении, для rate — скорость в секунду.
+	Value *float64 `json:"value,omitempty"`
+	// Метки правила.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Имя правила.
+	Name string `json:"name"`
+	// Ключ серии, для которой вычислено условие.
+	Series string `json:"series,omitempty"`
+	// Условие правила.
+	Expr string `json:"expr"`
+	// Описание правила.
+	Description string `json:"description,omitempty"`
+	// Состояние.
+	State State `json:"state"`
+	// В хранилище нет серий, подходящих под условие.
+	NoData bool `json:"no_data,omitempty"`
+}
+
+// Notifier получает смену состояния алертов firing и resolved.
+type Notifier interface {
+	Notify(notification Notification)
+}
+
+// sample значение серии при прошлом вычислении, нужно для rate.
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+type ruleState struct {
+	alert Alert
+	prev  *sample
+}
+
+// ruleStates состояния правила: по алерту на каждую серию, подходящую под условие.
+type ruleStates struct {
+	series map[string]*ruleState
+	rule   Rule
+	noData bool
+}
+
+// alert возвращает алерт серии с полями правила.
+func (r *ruleStates) alert(series string) Alert {
+	return Alert{
+		Labels:      r.rule.Labels,
+		Name:        r.rule.Name,
+		Series:      series,
+		Expr:        r.rule.Expr,
+		Description: r.rule.Description,
+		State:       StateInactive,
+		NoData:      series == "" && r.noData,
+	}
+}
+
+// Engine вычисляет правила из файла с заданным интервалом. Правило проверяется для каждой серии
+// с именем из условия и метками условия. Файл перечитывается при изменении,
+// состояние правил с тем же именем и условием сохраняется.
+type Engine struct {
+	storage  repository.MetricStorage
+	notifier Notifier
+	logger   *zap.SugaredLogger
+	modTime  time.Time
+	states   map[string]*ruleStates
+	path     string
+	rules    []compiledRule
+	interval time.Duration
+	size     int64
+	mu       sync.RWMutex
+}
+
+// NewEngine создаёт вычислитель правил из файла path. Ошибка в файле при запуске возвращается,
+// при перечитывании — записывается в журнал, и продолжают действовать прежние правила.
+func NewEngine(
+	path string,
+	interval time.Duration,
+	storage repository.MetricStorage,
+	notifier Notifier,
+	logger *zap.SugaredLogger,
+) (*Engine, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("alert evaluation interval must be positive, got %s", interval)
+	}
+
+	e := &Engine{
+		storage:  storage,
+		notifier: notifier,
+		logger:   logger.With("component", "alerting"),
+		states:   make(map[string]*ruleStates),
+		path:     path,
+		interval: interval,
+	}
+	if _, err := e.reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Run вычисляет правила до отмены контекста.
+func (e *Engine) Run(ctx context.Context) {
+	e.logger.Infow("Starting alert rules evaluation", "rules", e.path, "interval", e.interval)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.Evaluate(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if changed, err := e.reload(); err != nil {
+			e.logger.Infow("failed to reload alert rules, keeping previous rules", "error", err)
+		} else if changed {
+			e.logger.Infow("Alert rules reloaded", "rules", len(e.Alerts()))
+		}
+	}
+}
+
+// reload перечитывает файл правил, если изменились время изменения или размер.
+func (e *Engine) reload() (bool, error) {
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return false, fmt.Errorf("stat alert rules: %w", err)
+	}
+
+	e.mu.RLock()
+	unchanged := info.ModTime().Equal(e.modTime) && info.Size() == e.size
+	e.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	rules, err := loadRules(e.path)
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.modTime, e.size = info.ModTime(), info.Size()
+	states := make(map[string]*ruleStates, len(rules))
+	for _, rule := range rules {
+		state, ok := e.states[rule.Name]
+		if !ok || state.rule.Expr != rule.Expr {
+			state = &ruleStates{series: make(map[string]*ruleState)}
+		}
+		state.rule = rule.Rule
+		for _, series := range state.series {
+			series.alert.Description = rule.Description
+			series.alert.Labels = rule.Labels
+		}
+		states[rule.Name] = state
+	}
+	e.rules, e.states = rules, states
+	return true, nil
+}
+
+// Evaluate вычисляет все правила на момент now и отправляет переходы в firing и resolved.
+// Если хранилище недоступно, состояние алертов не меняется.
+func (e *Engine) Evaluate(ctx context.Context, now time.Time) {
+	series, err := e.read(ctx)
+	if err != nil {
+		e.logger.Infow("failed to read metrics for alert rules", "error", err)
+		return
+	}
+
+	e.mu.Lock()
+	var notifications []Notification
+	for _, rule := range e.rules {
+		notifications = append(notifications, e.evaluate(rule, series, now)...)
+	}
+	e.mu.Unlock()
+
+	for _, notification := range notifications {
+		e.logger.Infow("Alert state changed", "alert", notification.Alert.Name,
+			"series", notification.Alert.Series, "status", notification.Status)
+		if e.notifier != nil {
+			e.notifier.Notify(notification)
+		}
+	}
+}
+
+// evaluate вычисляет правило для всех подходящих серий. Алерт серии, которой больше нет
+// в хранилище, удаляется, а сработавший алерт при этом снимается.
+func (e *Engine) evaluate(rule compiledRule, series map[string]float64, now time.Time) []Notification {
+	states := e.states[rule.Name]
+	values := rule.expr.Select(series)
+	if len(values) == 0 && !states.noData {
+		e.logger.Infow("no data for alert rule", "alert", rule.Name, "expr", rule.Expr)
+	}
+	states.noData = len(values) == 0
+
+	var notifications []Notification
+	for key, current := range values {
+		state, ok := states.series[key]
+		if !ok {
+			state = &ruleState{alert: states.alert(key)}
+			states.series[key] = state
+		}
+		value, ok := state.value(rule.expr, current, now)
+		if !ok {
+			continue
+		}
+		if notification, changed := state.transition(rule.expr, value, now); changed {
+			notifications = append(notifications, notification)
+		}
+	}
+
+	for key, state := range states.series {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if state.alert.State == StateFiring {
+			state.alert.State, state.alert.ResolvedAt = StateResolved, timePtr(now)
+			notifications = append(notifications, Notification{Status: StateResolved, Alert: state.alert.clone()})
+		}
+		delete(states.series, key)
+	}
+	return notifications
+}
+
+// value возвращает значение условия. Первое вычисление rate данных не даёт,
+// тогда состояние алерта не меняется.
+func (s *ruleState) value(expr Expr, current float64, now time.Time) (float64, bool) {
+	if !expr.Rate {
+		return current, true
+	}
+
+	prev := s.prev
+	s.prev = &sample{at: now, value: current}
+	if prev == nil || !now.After(prev.at) {
+		return 0, false
+	}
+	delta := current - prev.value
+	if delta < 0 {
+		// Сброс счётчика: значение после сброса считается приращением.
+		delta = current
+	}
+	return delta / now.Sub(prev.at).Seconds(), true
+}
+
+// read читает значения всех серий gauge и counter. Если серия с тем же ключом есть среди обоих типов,
+// берётся gauge.
+func (e *Engine) read(ctx context.Context) (map[string]float64, error) {
+	gauges, err := e.storage.Gauges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read gauges: %w", err)
+	}
+	counters, err := e.storage.Counters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read counters: %w", err)
+	}
+
+	series := make(map[string]float64, len(gauges)+len(counters))
+	for key, value := range counters {
+		series[key] = float64(value)
+	}
+	for key, value := range gauges {
+		series[key] = value
+	}
+	return series, nil
+}
+
+// transition применяет значение к состоянию алерта и возвращает уведомление о переходе
+// в firing или resolved.
+func (s *ruleState) transition(expr Expr, value float64, now time.Time) (Notification, bool) {
+	alert := &s.alert
+	alert.Value = &value
+
+	if !expr.Match(value) {
+		switch alert.State {
+		case StatePending:
+			alert.State, alert.ActiveAt = StateInactive, nil
+		case StateFiring:
+			alert.State, alert.ResolvedAt = StateResolved, timePtr(now)
+			return Notification{Status: StateResolved, Alert: alert.clone()}, true
+		}
+		return Notification{}, false
+	}
+
+	if alert.State != StatePending && alert.State != StateFiring {
+		alert.State, alert.ActiveAt, alert.FiredAt, alert.ResolvedAt = StatePending, timePtr(now), nil, nil
+	}
+	if alert.State == StatePending && now.Sub(*alert.ActiveAt) >= expr.For {
+		alert.State, alert.FiredAt = StateFiring, timePtr(now)
+		return Notification{Status: StateFiring, Alert: alert.clone()}, true
+	}
+	return Notification{}, false
+}
+
+// Alerts возвращает алерты всех серий, упорядоченные по имени правила и ключу серии.
+// Правило без подходящих серий возвращается одним неактивным алертом без серии.
+func (e *Engine) Alerts() []Alert {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	alerts := make([]Alert, 0, len(e.states))
+	for _, state := range e.states {
+		if len(state.series) == 0 {
+			alerts = append(alerts, state.alert(""))
+		}
+		for _, series := range state.series {
+			alerts = append(alerts, series.alert.clone())
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Name != alerts[j].Name {
+			return alerts[i].Name < alerts[j].Name
+		}
+		return alerts[i].Series < alerts[j].Series
+	})
+	return alerts
+}
+
+// clone копирует алерт, чтобы вызывающий код не видел последующих изменений.
+func (a Alert) clone() Alert {
+	if a.Value != nil {
+		value := *a.Value
+		a.Value = &value
+	}
+	return a
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,253 @@
+package alerting
+
+import (
+	"context"
+	"metrics/internal/repository"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type notificationRecorder struct {
+	notifications []Notification
+	mu            sync.Mutex
+}
+
+func (r *notificationRecorder) Notify(notification Notification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, notification)
+}
+
+func (r *notificationRecorder) statuses() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]string, 0, len(r.notifications))
+	for _, n := range r.notifications {
+		statuses = append(statuses, n.Alert.Name+" "+string(n.Status))
+	}
+	return statuses
+}
+
+func writeRules(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func alertByName(t *testing.T, e *Engine, name string) Alert {
+	t.Helper()
+	for _, alert := range e.Alerts() {
+		if alert.Name == name {
+			return alert
+		}
+	}
+	t.Fatalf("alert %s not found", name)
+	return Alert{}
+}
+
+func TestEngine_Threshold(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	writeRules(t, path, `{"rules": [{"name": "LowMemory", "expr": "FreeMemory < 500MB for 2m"}]}`)
+
+	storage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	recorder := &notificationRecorder{}
+	engine, err := NewEngine(path, time.Second, storage, recorder, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	start := time.Now()
+	engine.Evaluate(ctx, start)
+	assert.Equal(t, StateInactive, alertByName(t, engine, "LowMemory").State, "нет данных")
+
+	_, err = storage.SetGauge(ctx, "FreeMemory", 100<<20)
+	require.NoError(t, err)
+	engine.Evaluate(ctx, start)
+	assert.Equal(t, StatePending, alertByName(t, engine, "LowMemory").State)
+
+	engine.Evaluate(ctx, start.Add(time.Minute))
+	assert.Equal(t, StatePending, alertByName(t, engine, "LowMemory").State)
+
+	engine.Evaluate(ctx, start.Add(2*time.Minute))
+	alert := alertByName(t, engine, "LowMemory")
+	assert.Equal(t, StateFiring, alert.State)
+	require.NotNil(t, alert.Value)
+	assert.Equal(t, float64(100<<20), *alert.Value)
+
+	_, err = storage.SetGauge(ctx, "FreeMemory", 1<<30)
+	require.NoError(t, err)
+	engine.Evaluate(ctx, start.Add(3*time.Minute))
+	alert = alertByName(t, engine, "LowMemory")
+	assert.Equal(t, StateResolved, alert.State)
+	assert.NotNil(t, alert.ResolvedAt)
+
+	assert.Equal(t, []string{"LowMemory firing", "LowMemory resolved"}, recorder.statuses())
+}
+
+func TestEngine_PendingResetsWithoutNotification(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	writeRules(t, path, `{"rules": [{"name": "LowMemory", "expr": "FreeMemory < 500MB for 2m"}]}`)
+
+	storage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	recorder := &notificationRecorder{}
+	engine, err := NewEngine(path, time.Second, storage, recorder, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = storage.SetGauge(ctx, "FreeMemory", 100<<20)
+	require.NoError(t, err)
+	engine.Evaluate(ctx, start)
+
+	_, err = storage.SetGauge(ctx, "FreeMemory", 1<<30)
+	require.NoError(t, err)
+	engine.Evaluate(ctx, start.Add(time.Minute))
+	alert := alertByName(t, engine, "LowMemory")
+	assert.Equal(t, StateInactive, alert.State)
+	assert.Nil(t, alert.ActiveAt)
+	assert.Empty(t, recorder.statuses())
+}
+
+func TestEngine_Rate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	writeRules(t, path, `{"rules": [{"name": "NoPolls", "expr": "rate(PollCount) == 0"}]}`)
+
+	storage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	recorder := &notificationRecorder{}
+	engine, err := NewEngine(path, time.Second, storage, recorder, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = storage.SetCounter(ctx, "PollCount", 10)
+	require.NoError(t, err)
+	engine.Evaluate(ctx, start)
+	assert.Equal(t, StateInactive, alertByName(t, engine, "NoPolls").State, "первое значение не даёт скорости")
+
+	_, err = storage.SetCounter(ctx, "PollCount", 10)
+	require.NoError(t, err)
+	engine.Evaluate(ctx, start.Add(10*time.Second))
+	alert := alertByName(t, engine, "NoPolls")
+	assert.Equal(t, StateInactive, alert.State)
+	require.NotNil(t, alert.Value)
+	assert.InDelta(t, 1.0, *alert.Value, 1e-9)
+
+	engine.Evaluate(ctx, start.Add(20*time.Second))
+	assert.Equal(t, StateFiring, alertByName(t, engine, "NoPolls").State)
+	assert.Equal(t, []string{"NoPolls firing"}, recorder.statuses())
+}
+
+func TestEngine_AlertPerSeries(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	writeRules(t, path, `{"rules": [{"name": "HighAlloc", "expr": "Alloc > 1GB"}]}`)
+
+	storage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	recorder := &notificationRecorder{}
+	engine, err := NewEngine(path, time.Second, storage, recorder, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	engine.Evaluate(ctx, time.Now())
+	alerts := engine.Alerts()
+	require.Len(t, alerts, 1)
+	assert.True(t, alerts[0].NoData, "нет серий — правило без данных")
+	assert.Equal(t, StateInactive, alerts[0].State)
+
+	web1 := repository.SeriesKey("Alloc", repository.Labels{"host": "web-1"})
+	web2 := repository.SeriesKey("Alloc", repository.Labels{"host": "web-2"})
+	_, err = storage.SetGauge(ctx, web1, 2<<30)
+	require.NoError(t, err)
+	_, err = storage.SetGauge(ctx, web2, 1<<20)
+	require.NoError(t, err)
+	engine.Evaluate(ctx, time.Now())
+
+	alerts = engine.Alerts()
+	require.Len(t, alerts, 2)
+	assert.Equal(t, web1, alerts[0].Series)
+	assert.Equal(t, StateFiring, alerts[0].State)
+	assert.False(t, alerts[0].NoData)
+	assert.Equal(t, web2, alerts[1].Series)
+	assert.Equal(t, StateInactive, alerts[1].State)
+
+	require.NoError(t, storage.Delete(ctx, repository.MetricGauge, web1))
+	engine.Evaluate(ctx, time.Now())
+	alerts = engine.Alerts()
+	require.Len(t, alerts, 1, "алерт удалённой серии удаляется")
+	assert.Equal(t, web2, alerts[0].Series)
+
+	require.Len(t, recorder.notifications, 2)
+	assert.Equal(t, []string{"HighAlloc firing", "HighAlloc resolved"}, recorder.statuses())
+	assert.Equal(t, web1, recorder.notifications[1].Alert.Series, "сработавший алерт удалённой серии снимается")
+}
+
+func TestEngine_Reload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	writeRules(t, path, `{"rules": [
+		{"name": "LowMemory", "expr": "FreeMemory < 500MB"},
+		{"name": "HighAlloc", "expr": "Alloc > 1GB"}
+	]}`)
+
+	storage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	engine, err := NewEngine(path, time.Second, storage, nil, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	_, err = storage.SetGauge(ctx, "FreeMemory", 100<<20)
+	require.NoError(t, err)
+	engine.Evaluate(ctx, time.Now())
+	require.Equal(t, StateFiring, alertByName(t, engine, "LowMemory").State)
+
+	writeRules(t, path, `{"rules": [
+		{"name": "LowMemory", "expr": "FreeMemory < 500MB", "description": "мало памяти"},
+		{"name": "NoPolls", "expr": "rate(PollCount) == 0 for 1m"}
+	]}`)
+	changed, err := engine.reload()
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	alerts := engine.Alerts()
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "LowMemory", alerts[0].Name)
+	assert.Equal(t, StateFiring, alerts[0].State, "состояние правила с тем же условием сохраняется")
+	assert.Equal(t, "мало памяти", alerts[0].Description)
+	assert.Equal(t, "NoPolls", alerts[1].Name)
+
+	writeRules(t, path, `{"rules": [{"name": "Broken", "expr": "FreeMemory <"}]}`)
+	_, err = engine.reload()
+	assert.ErrorIs(t, err, ErrInvalidExpr)
+	assert.Len(t, engine.Alerts(), 2, "при ошибке действуют прежние правила")
+}
+
+func TestNewEngine_InvalidRules(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"без имени":      `{"rules": [{"expr": "Alloc > 1"}]}`,
+		"повтор имени":   `{"rules": [{"name": "A", "expr": "Alloc > 1"}, {"name": "A", "expr": "Alloc > 2"}]}`,
+		"не JSON":        `rules: []`,
+		"ошибка условия": `{"rules": [{"name": "A", "expr": "Alloc >"}]}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, "alerts.json")
+			writeRules(t, path, content)
+			_, err := NewEngine(path, time.Second, storage, nil, zap.NewNop().Sugar())
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = NewEngine(filepath.Join(dir, "missing.json"), time.Second, storage, nil, zap.NewNop().Sugar())
+	assert.Error(t, err)
+}
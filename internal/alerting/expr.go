@@ -0,0 +1,132 @@
+// Package alerting вычисляет пороговые правила по значениям хранилища метрик
+// и отправляет смену состояния алертов в webhook.
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"metrics/internal/repository"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidExpr = errors.New("invalid alert expression")
+
+// exprPattern: [rate(]metric[{labels}][)] op number[unit] [for duration].
+var exprPattern = regexp.MustCompile(
+	`^\s*(?:(\w+)\(\s*([^\s<>=!(){}]+(?:\{[^}]*\})?)\s*\)|([^\s<>=!(){}]+(?:\{[^}]*\})?))` +
+		`\s*(<=|>=|==|!=|<|>)\s*([-+]?[0-9]*\.?[0-9]+(?:[eE][-+]?[0-9]+)?)\s*([A-Za-z]*)` +
+		`(?:\s+for\s+(\S+))?\s*$`,
+)
+
+// units множители единиц порога, размеры — степени 1024.
+var units = map[string]float64{
+	"":    1,
+	"KB":  1 << 10,
+	"KiB": 1 << 10,
+	"MB":  1 << 20,
+	"MiB": 1 << 20,
+	"GB":  1 << 30,
+	"GiB": 1 << 30,
+	"TB":  1 << 40,
+	"TiB": 1 << 40,
+}
+
+// Expr разобранное условие правила.
+type Expr struct {
+	// Метки серии.
+	Labels repository.Labels
+	// Имя метрики.
+	Metric string
+	// Оператор сравнения: <, <=, >, >=, ==, !=.
+	Op string
+	// Порог с учётом единиц.
+	Threshold float64
+	// Время, в течение которого условие должно выполняться до срабатывания.
+	For time.Duration
+	// Сравнивать скорость изменения в секунду вместо значения.
+	Rate bool
+}
+
+// ParseExpr разбирает условие вида `FreeMemory < 500MB for 2m` или `rate(PollCount) == 0 for 1m`.
+// Метрика может задаваться с метками: `Alloc{host="web-1"} > 1GB`.
+func ParseExpr(s string) (Expr, error) {
+	m := exprPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Expr{}, fmt.Errorf("%w: %q", ErrInvalidExpr, s)
+	}
+
+	var expr Expr
+	operand := m[3]
+	if m[1] != "" {
+		if m[1] != "rate" {
+			return Expr{}, fmt.Errorf("%w: unknown function %q", ErrInvalidExpr, m[1])
+		}
+		expr.Rate = true
+		operand = m[2]
+	}
+
+	expr.Metric, expr.Labels = repository.ParseSeriesKey(operand)
+	if strings.ContainsRune(expr.Metric, '{') {
+		return Expr{}, fmt.Errorf("%w: invalid labels in %q", ErrInvalidExpr, operand)
+	}
+
+	threshold, err := strconv.ParseFloat(m[5], 64)
+	if err != nil {
+		return Expr{}, fmt.Errorf("%w: threshold %q", ErrInvalidExpr, m[5])
+	}
+	unit, ok := units[m[6]]
+	if !ok {
+		return Expr{}, fmt.Errorf("%w: unknown unit %q", ErrInvalidExpr, m[6])
+	}
+	expr.Op = m[4]
+	expr.Threshold = threshold * unit
+
+	if m[7] != "" {
+		if expr.For, err = time.ParseDuration(m[7]); err != nil || expr.For < 0 {
+			return Expr{}, fmt.Errorf("%w: duration %q", ErrInvalidExpr, m[7])
+		}
+	}
+	return expr, nil
+}
+
+// Select возвращает значения серий с именем метрики условия, у которых есть все метки условия.
+func (e Expr) Select(series map[string]float64) map[string]float64 {
+	selected := make(map[string]float64)
+	for key, value := range series {
+		name, labels := repository.ParseSeriesKey(key)
+		if name == e.Metric && e.matchLabels(labels) {
+			selected[key] = value
+		}
+	}
+	return selected
+}
+
+func (e Expr) matchLabels(labels repository.Labels) bool {
+	for name, value := range e.Labels {
+		if labelValue, ok := labels[name]; !ok || labelValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Match сравнивает значение с порогом.
+func (e Expr) Match(value float64) bool {
+	switch e.Op {
+	case "<":
+		return value < e.Threshold
+	case "<=":
+		return value <= e.Threshold
+	case ">":
+		return value > e.Threshold
+	case ">=":
+		return value >= e.Threshold
+	case "==":
+		return value == e.Threshold
+	default:
+		return value != e.Threshold
+	}
+}
@@ -0,0 +1,108 @@
+package alerting
+
+import (
+	"metrics/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want Expr
+	}{
+		{
+			name: "порог в мегабайтах",
+			expr: "FreeMemory < 500MB for 2m",
+			want: Expr{Metric: "FreeMemory", Op: "<", Threshold: 500 << 20, For: 2 * time.Minute},
+		},
+		{
+			name: "скорость счётчика",
+			expr: "rate(PollCount) == 0 for 1m",
+			want: Expr{Metric: "PollCount", Op: "==", For: time.Minute, Rate: true},
+		},
+		{
+			name: "метки и срабатывание сразу",
+			expr: `Alloc{host="web-1"} >= 1.5GiB`,
+			want: Expr{
+				Metric:    "Alloc",
+				Labels:    repository.Labels{"host": "web-1"},
+				Op:        ">=",
+				Threshold: 1.5 * (1 << 30),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExpr(tt.expr)
+			require.NoError(t, err)
+			if len(tt.want.Labels) == 0 {
+				assert.Empty(t, got.Labels)
+				got.Labels = nil
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseExpr_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"FreeMemory",
+		"FreeMemory < ",
+		"FreeMemory ~ 5",
+		"FreeMemory < 5PB",
+		"avg(FreeMemory) < 5",
+		"FreeMemory < 5 for soon",
+		`Alloc{host=web} > 1`,
+	} {
+		_, err := ParseExpr(expr)
+		assert.ErrorIs(t, err, ErrInvalidExpr, expr)
+	}
+}
+
+func TestExpr_Match(t *testing.T) {
+	expr, err := ParseExpr("FreeMemory < 1KB")
+	require.NoError(t, err)
+	assert.True(t, expr.Match(1023))
+	assert.False(t, expr.Match(1024))
+
+	expr, err = ParseExpr("Errors != 0")
+	require.NoError(t, err)
+	assert.True(t, expr.Match(1))
+	assert.False(t, expr.Match(0))
+}
+
+func TestExpr_Select(t *testing.T) {
+	series := map[string]float64{
+		"Alloc":                              1,
+		`Alloc{host="web-1"}`:                2,
+		`Alloc{dc="eu",host="web-1"}`:        3,
+		`Alloc{host="web-2"}`:                4,
+		`FreeMemory{host="web-1"}`:           5,
+		`AllocSize{host="web-1"}`:            6,
+		`Alloc{host="web-1",role="primary"}`: 7,
+	}
+
+	expr, err := ParseExpr("Alloc > 1GB")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{
+		"Alloc":                              1,
+		`Alloc{host="web-1"}`:                2,
+		`Alloc{dc="eu",host="web-1"}`:        3,
+		`Alloc{host="web-2"}`:                4,
+		`Alloc{host="web-1",role="primary"}`: 7,
+	}, expr.Select(series), "без меток подходят все серии с именем")
+
+	expr, err = ParseExpr(`Alloc{host="web-1"} > 1GB`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{
+		`Alloc{host="web-1"}`:                2,
+		`Alloc{dc="eu",host="web-1"}`:        3,
+		`Alloc{host="web-1",role="primary"}`: 7,
+	}, expr.Select(series), "подходят серии, у которых есть все метки условия")
+}
@@ -0,0 +1,69 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Rule правило алерта из файла правил.
+type Rule struct {
+	// Дополнительные метки, передаются в webhook.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Уникальное имя правила.
+	Name string `json:"name"`
+	// Условие, например `FreeMemory < 500MB for 2m`.
+	Expr string `json:"expr"`
+	// Описание для получателей уведомлений.
+	Description string `json:"description,omitempty"`
+}
+
+// rulesFile формат файла правил.
+type rulesFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// compiledRule правило с разобранным условием.
+type compiledRule struct {
+	Rule
+	expr Expr
+}
+
+// loadRules читает и проверяет файл правил: имена уникальны, условия разбираются.
+func loadRules(path string) ([]compiledRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read alert rules: %w", err)
+	}
+
+	var file rulesFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse alert rules %s: %w", path, err)
+	}
+	rules, err := compileRules(file.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("alert rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+func compileRules(rules []Rule) ([]compiledRule, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	names := make(map[string]struct{}, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %q: name is required", rule.Expr)
+		}
+		if _, ok := names[rule.Name]; ok {
+			return nil, fmt.Errorf("rule %q: duplicate name", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+
+		expr, err := ParseExpr(rule.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		compiled = append(compiled, compiledRule{Rule: rule, expr: expr})
+	}
+	return compiled, nil
+}
@@ -0,0 +1,129 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"go.uber.org/zap"
+)
+
+const (
+	// webhookQueueSize число уведомлений, ожидающих отправки в один webhook.
+	webhookQueueSize = 100
+	// webhookTimeout время ожидания ответа на одну попытку.
+	webhookTimeout = 10 * time.Second
+)
+
+// Notification смена состояния алерта, отправляемая в webhook.
+type Notification struct {
+	// Состояние после перехода: firing или resolved.
+	Status State `json:"status"`
+	Alert  Alert `json:"alert"`
+}
+
+// Retry настройки повторов отправки в webhook.
+type Retry struct {
+	// Число повторов после первой неудачной попытки.
+	Max int
+	// Минимальная и максимальная задержка между попытками, задержка растёт экспоненциально.
+	WaitMin time.Duration
+	WaitMax time.Duration
+}
+
+// Webhooks отправляет уведомления в webhook-адреса. У каждого адреса своя очередь,
+// поэтому недоступный получатель не задерживает остальных.
+type Webhooks struct {
+	logger *zap.SugaredLogger
+	hooks  []*webhook
+}
+
+type webhook struct {
+	client *retryablehttp.Client
+	queue  chan Notification
+	url    string
+}
+
+// NewWebhooks создаёт отправителя уведомлений в urls. Ответ 5xx, 429 и ошибка соединения повторяются.
+func NewWebhooks(urls []string, retry Retry, logger *zap.SugaredLogger) *Webhooks {
+	w := &Webhooks{logger: logger.With("component", "alert webhooks")}
+	for _, url := range urls {
+		client := retryablehttp.NewClient()
+		client.HTTPClient.Timeout = webhookTimeout
+		client.RetryMax = retry.Max
+		client.RetryWaitMin = retry.WaitMin
+		client.RetryWaitMax = retry.WaitMax
+		client.Logger = nil
+		w.hooks = append(w.hooks, &webhook{
+			client: client,
+			queue:  make(chan Notification, webhookQueueSize),
+			url:    url,
+		})
+	}
+	return w
+}
+
+// Notify ставит уведомление в очереди webhook. При заполненной очереди уведомление отбрасывается.
+func (w *Webhooks) Notify(notification Notification) {
+	for _, hook := range w.hooks {
+		select {
+		case hook.queue <- notification:
+		default:
+			w.logger.Infow("alert webhook queue is full, notification dropped",
+				"url", hook.url, "alert", notification.Alert.Name, "status", notification.Status)
+		}
+	}
+}
+
+// Run отправляет уведомления до отмены контекста.
+func (w *Webhooks) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, hook := range w.hooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case notification := <-hook.queue:
+					if err := hook.send(ctx, notification); err != nil {
+						w.logger.Infow("failed to deliver alert notification",
+							"url", hook.url, "alert", notification.Alert.Name, "status", notification.Status, "error", err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (h *webhook) send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWebhooks_RetryUntilDelivered(t *testing.T) {
+	var attempts atomic.Int32
+	delivered := make(chan Notification, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var n Notification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		delivered <- n
+	}))
+	defer srv.Close()
+
+	webhooks := NewWebhooks([]string{srv.URL}, Retry{Max: 3, WaitMin: time.Millisecond, WaitMax: time.Millisecond},
+		zap.NewNop().Sugar())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go webhooks.Run(ctx)
+
+	webhooks.Notify(Notification{Status: StateFiring, Alert: Alert{Name: "LowMemory", State: StateFiring}})
+
+	select {
+	case n := <-delivered:
+		assert.Equal(t, StateFiring, n.Status)
+		assert.Equal(t, "LowMemory", n.Alert.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not delivered")
+	}
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestWebhook_SendErrors(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	webhooks := NewWebhooks([]string{srv.URL + "/missing", srv.URL + "/broken"},
+		Retry{Max: 2, WaitMin: time.Millisecond, WaitMax: time.Millisecond}, zap.NewNop().Sugar())
+	require.Len(t, webhooks.hooks, 2)
+	notification := Notification{Status: StateResolved, Alert: Alert{Name: "LowMemory"}}
+
+	assert.Error(t, webhooks.hooks[0].send(context.Background(), notification))
+	assert.Equal(t, int32(1), attempts.Load(), "ответ 4xx не повторяется")
+
+	attempts.Store(0)
+	assert.Error(t, webhooks.hooks[1].send(context.Background(), notification))
+	assert.Equal(t, int32(3), attempts.Load(), "первая попытка и два повтора")
+}
@@ -0,0 +1,115 @@
+// Package audit журнал административных действий над метриками.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Действия журнала аудита.
+const (
+	ActionDelete       = "delete"
+	ActionResetCounter = "reset_counter"
+	ActionRename       = "rename"
+)
+
+// Entry запись журнала аудита.
+type Entry struct {
+	// Время действия.
+	Time time.Time `json:"time"`
+	// Метки серии.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Идентификатор агента, выполнившего действие.
+	Actor string `json:"actor"`
+	// Действие: delete, reset_counter или rename.
+	Action string `json:"action"`
+	// Тип метрики.
+	Type string `json:"type"`
+	// Имя метрики.
+	ID string `json:"id"`
+	// Новое имя метрики при переименовании.
+	NewID string `json:"new_id,omitempty"`
+	// Текст ошибки, пустой при успешном действии.
+	Error string `json:"error,omitempty"`
+}
+
+// Log журнал аудита.
+type Log interface {
+	Record(ctx context.Context, entry Entry) error
+	Close() error
+}
+
+// FileLog дописывает записи в файл в формате JSON Lines и синхронизирует файл с диском после каждой записи.
+type FileLog struct {
+	file *os.File
+	mu   sync.Mutex
+}
+
+func OpenFile(path string) (*FileLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error open audit log %s: %w", path, err)
+	}
+	return &FileLog{file: file}, nil
+}
+
+func (l *FileLog) Record(ctx context.Context, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encode audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err = l.file.Write(data); err != nil {
+		return fmt.Errorf("error write audit log: %w", err)
+	}
+	if err = l.file.Sync(); err != nil {
+		return fmt.Errorf("error sync audit log: %w", err)
+	}
+	return nil
+}
+
+func (l *FileLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("error close audit log: %w", err)
+	}
+	return nil
+}
+
+// LoggerLog пишет записи в журнал сервера, если файл аудита не задан.
+type LoggerLog struct {
+	logger *zap.SugaredLogger
+}
+
+func NewLoggerLog(logger *zap.SugaredLogger) *LoggerLog {
+	return &LoggerLog{logger: logger.With("audit", "metrics")}
+}
+
+func (l *LoggerLog) Record(ctx context.Context, entry Entry) error {
+	l.logger.Infow("Admin action",
+		"action", entry.Action,
+		"actor", entry.Actor,
+		"type", entry.Type,
+		"id", entry.ID,
+		"labels", entry.Labels,
+		"new_id", entry.NewID,
+		"error", entry.Error,
+	)
+	return nil
+}
+
+func (l *LoggerLog) Close() error {
+	return nil
+}
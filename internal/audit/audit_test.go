@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLog(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	log, err := OpenFile(path)
+	require.NoError(t, err)
+	require.NoError(t, log.Record(ctx, Entry{Time: at, Actor: "ops", Action: ActionDelete, Type: "gauge", ID: "CPUutilization8"}))
+	require.NoError(t, log.Close())
+
+	log, err = OpenFile(path)
+	require.NoError(t, err, "файл открывается на дозапись")
+	require.NoError(t, log.Record(ctx, Entry{
+		Time: at, Actor: "ops", Action: ActionRename, Type: "counter", ID: "requests", NewID: "http_requests",
+		Labels: map[string]string{"host": "web-1"}, Error: "metric already exists",
+	}))
+	require.NoError(t, log.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+	assert.Equal(t, ActionDelete, entries[0].Action)
+	assert.True(t, at.Equal(entries[0].Time))
+	assert.Equal(t, "http_requests", entries[1].NewID)
+	assert.Equal(t, map[string]string{"host": "web-1"}, entries[1].Labels)
+	assert.Equal(t, "metric already exists", entries[1].Error)
+}
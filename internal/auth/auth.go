@@ -0,0 +1,83 @@
+// Package auth описывает учётные данные агентов: индивидуальные ключи подписи и права доступа.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// AgentIDHeader заголовок, в котором агент передаёт свой идентификатор.
+const AgentIDHeader = "X-Agent-ID"
+
+// Scope право доступа агента.
+type Scope string
+
+const (
+	// ScopeWrite разрешает отправку метрик.
+	ScopeWrite Scope = "write"
+	// ScopeRead разрешает чтение метрик.
+	ScopeRead Scope = "read"
+	// ScopeAdmin разрешает всё, включая управление учётными данными.
+	ScopeAdmin Scope = "admin"
+)
+
+var (
+	ErrUnknownAgent = errors.New("unknown agent")
+	ErrRevoked      = errors.New("agent credentials revoked")
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// Credential учётные данные агента.
+type Credential struct {
+	AgentID string  `json:"id"`
+	Key     string  `json:"key"`
+	Scopes  []Scope `json:"scopes"`
+	Revoked bool    `json:"revoked,omitempty"`
+}
+
+// Allows сообщает, есть ли у агента право scope. Право admin включает все остальные.
+func (c Credential) Allows(scope Scope) bool {
+	return slices.Contains(c.Scopes, scope) || slices.Contains(c.Scopes, ScopeAdmin)
+}
+
+// Validate проверяет, что у агента есть идентификатор, ключ и только известные права.
+func (c Credential) Validate() error {
+	if c.AgentID == "" {
+		return errors.New("agent id is empty")
+	}
+	if c.Key == "" {
+		return fmt.Errorf("agent '%s' has empty key", c.AgentID)
+	}
+	for _, scope := range c.Scopes {
+		switch scope {
+		case ScopeWrite, ScopeRead, ScopeAdmin:
+		default:
+			return fmt.Errorf("agent '%s': %w '%s'", c.AgentID, ErrInvalidScope, scope)
+		}
+	}
+	return nil
+}
+
+// Store хранилище учётных данных агентов.
+type Store interface {
+	// Lookup возвращает действующие учётные данные агента,
+	// ErrUnknownAgent для неизвестного агента и ErrRevoked для отозванных данных.
+	Lookup(ctx context.Context, agentID string) (Credential, error)
+	// Revoke отзывает учётные данные агента без перезапуска сервера.
+	Revoke(ctx context.Context, agentID string) error
+}
+
+type credentialKey struct{}
+
+// WithCredential сохраняет учётные данные проверенного агента в контексте запроса.
+func WithCredential(ctx context.Context, credential Credential) context.Context {
+	return context.WithValue(ctx, credentialKey{}, credential)
+}
+
+// CredentialFromContext возвращает учётные данные агента, выполнившего запрос.
+func CredentialFromContext(ctx context.Context) (Credential, bool) {
+	credential, ok := ctx.Value(credentialKey{}).(Credential)
+	return credential, ok
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// reloadCheckInterval как часто FileStore проверяет, не изменился ли файл.
+const reloadCheckInterval = time.Second
+
+type credentialsFile struct {
+	Agents []Credential `json:"agents"`
+}
+
+// FileStore учётные данные из JSON-файла вида {"agents": [{"id", "key", "scopes", "revoked"}]}.
+// Изменения файла подхватываются без перезапуска сервера.
+type FileStore struct {
+	modTime     time.Time
+	lastCheck   time.Time
+	credentials map[string]Credential
+	path        string
+	mu          sync.Mutex
+}
+
+// NewFileStore загружает учётные данные из файла.
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{path: path}
+	if err := store.reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *FileStore) Lookup(ctx context.Context, agentID string) (Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reloadIfChanged(); err != nil {
+		return Credential{}, err
+	}
+
+	credential, ok := s.credentials[agentID]
+	if !ok {
+		return Credential{}, ErrUnknownAgent
+	}
+	if credential.Revoked {
+		return Credential{}, ErrRevoked
+	}
+	return credential, nil
+}
+
+// Revoke помечает агента отозванным и перезаписывает файл.
+func (s *FileStore) Revoke(ctx context.Context, agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		return err
+	}
+	credential, ok := s.credentials[agentID]
+	if !ok {
+		return ErrUnknownAgent
+	}
+	if credential.Revoked {
+		return nil
+	}
+
+	file, err := readCredentialsFile(s.path)
+	if err != nil {
+		return err
+	}
+	for i := range file.Agents {
+		if file.Agents[i].AgentID == agentID {
+			file.Agents[i].Revoked = true
+		}
+	}
+	if err := writeCredentialsFile(s.path, file); err != nil {
+		return err
+	}
+
+	credential.Revoked = true
+	s.credentials[agentID] = credential
+	return s.rememberModTime()
+}
+
+// reloadIfChanged перечитывает файл, если с прошлой проверки изменилось время его модификации.
+// Вызывается под захваченной блокировкой.
+func (s *FileStore) reloadIfChanged() error {
+	now := time.Now()
+	if now.Sub(s.lastCheck) < reloadCheckInterval {
+		return nil
+	}
+	s.lastCheck = now
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("stat credentials file %s: %w", s.path, err)
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+	return s.reload()
+}
+
+func (s *FileStore) reload() error {
+	file, err := readCredentialsFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	credentials := make(map[string]Credential, len(file.Agents))
+	for _, credential := range file.Agents {
+		if err := credential.Validate(); err != nil {
+			return fmt.Errorf("credentials file %s: %w", s.path, err)
+		}
+		if _, exists := credentials[credential.AgentID]; exists {
+			return fmt.Errorf("credentials file %s: duplicate agent '%s'", s.path, credential.AgentID)
+		}
+		credentials[credential.AgentID] = credential
+	}
+
+	s.credentials = credentials
+	s.lastCheck = time.Now()
+	return s.rememberModTime()
+}
+
+func (s *FileStore) rememberModTime() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("stat credentials file %s: %w", s.path, err)
+	}
+	s.modTime = info.ModTime()
+	return nil
+}
+
+func readCredentialsFile(path string) (credentialsFile, error) {
+	var file credentialsFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, fmt.Errorf("read credentials file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("decode credentials file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// writeCredentialsFile заменяет файл атомарно через временный файл и rename.
+func writeCredentialsFile(path string, file credentialsFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode credentials file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp credentials file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write temp credentials file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("sync temp credentials file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("close temp credentials file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace credentials file %s: %w", path, err)
+	}
+	return nil
+}
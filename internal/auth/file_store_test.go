@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCredentials = `{
+  "agents": [
+    {"id": "agent-1", "key": "key-1", "scopes": ["write"]},
+    {"id": "grafana", "key": "key-2", "scopes": ["read"]},
+    {"id": "old", "key": "key-3", "scopes": ["write"], "revoked": true}
+  ]
+}`
+
+func writeCredentials(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestFileStore_Lookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeCredentials(t, path, testCredentials)
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	credential, err := store.Lookup(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", credential.Key)
+	assert.True(t, credential.Allows(ScopeWrite))
+	assert.False(t, credential.Allows(ScopeRead))
+
+	_, err = store.Lookup(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrUnknownAgent)
+
+	_, err = store.Lookup(ctx, "old")
+	assert.ErrorIs(t, err, ErrRevoked)
+}
+
+func TestFileStore_ReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeCredentials(t, path, testCredentials)
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	writeCredentials(t, path, `{"agents": [{"id": "agent-1", "key": "key-1", "scopes": ["write"], "revoked": true}]}`)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, future, future))
+	store.lastCheck = time.Time{}
+
+	_, err = store.Lookup(context.Background(), "agent-1")
+	assert.ErrorIs(t, err, ErrRevoked, "отзыв в файле действует без перезапуска")
+}
+
+func TestFileStore_Revoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeCredentials(t, path, testCredentials)
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Revoke(ctx, "agent-1"))
+	_, err = store.Lookup(ctx, "agent-1")
+	assert.ErrorIs(t, err, ErrRevoked)
+	assert.ErrorIs(t, store.Revoke(ctx, "unknown"), ErrUnknownAgent)
+
+	reopened, err := NewFileStore(path)
+	require.NoError(t, err)
+	_, err = reopened.Lookup(ctx, "agent-1")
+	assert.ErrorIs(t, err, ErrRevoked, "отзыв сохраняется в файле")
+	_, err = reopened.Lookup(ctx, "grafana")
+	assert.NoError(t, err)
+}
+
+func TestFileStore_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"unknown scope": `{"agents": [{"id": "a", "key": "k", "scopes": ["root"]}]}`,
+		"empty key":     `{"agents": [{"id": "a", "scopes": ["read"]}]}`,
+		"duplicate":     `{"agents": [{"id": "a", "key": "k"}, {"id": "a", "key": "k2"}]}`,
+		"not json":      `agents`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name+".json")
+			writeCredentials(t, path, content)
+			_, err := NewFileStore(path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCredential_AdminAllowsEverything(t *testing.T) {
+	credential := Credential{AgentID: "ops", Key: "k", Scopes: []Scope{ScopeAdmin}}
+	assert.True(t, credential.Allows(ScopeRead))
+	assert.True(t, credential.Allows(ScopeWrite))
+	assert.True(t, credential.Allows(ScopeAdmin))
+}
@@ -0,0 +1,60 @@
+// Package collector содержит сборщики метрик агента и планировщик их опроса.
+package collector
+
+import (
+	"context"
+	"metrics/internal/repository"
+)
+
+const (
+	// RuntimeName имя сборщика метрик runtime.MemStats.
+	RuntimeName = "runtime"
+	// SystemName имя сборщика системных метрик gopsutil.
+	SystemName = "system"
+)
+
+// Collector источник метрик агента. Collect должен учитывать отмену контекста,
+// а возвращаемый срез не должен изменяться после возврата.
+type Collector interface {
+	Name() string
+	Collect(ctx context.Context) ([]repository.Metric, error)
+}
+
+type runtimeCollector struct {
+	repository *repository.MemoryRepository
+}
+
+// NewRuntime возвращает сборщик метрик runtime.MemStats.
+func NewRuntime() Collector {
+	return &runtimeCollector{repository: repository.NewMemoryRepository()}
+}
+
+func (c *runtimeCollector) Name() string {
+	return RuntimeName
+}
+
+func (c *runtimeCollector) Collect(ctx context.Context) ([]repository.Metric, error) {
+	return c.repository.GetMetrics(), nil
+}
+
+type systemCollector struct {
+	repository *repository.SystemRepository
+}
+
+// NewSystem возвращает сборщик метрик памяти и загрузки CPU.
+func NewSystem() Collector {
+	return &systemCollector{repository: repository.NewSystemRepository()}
+}
+
+func (c *systemCollector) Name() string {
+	return SystemName
+}
+
+func (c *systemCollector) Collect(ctx context.Context) ([]repository.Metric, error) {
+	return c.repository.GetMetricsContext(ctx), nil
+}
+
+// Defaults возвращает встроенные сборщики агента.
+func Defaults() []Collector {
+	return []Collector{NewRuntime(), NewSystem()}
+}
@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"metrics/internal/config"
+	"metrics/internal/repository"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Snapshot последние результаты всех сборщиков. Не изменяется после создания.
+type Snapshot struct {
+	// Время создания снимка.
+	TakenAt time.Time
+	// Метрики в порядке регистрации сборщиков.
+	Metrics []repository.Metric
+	// Число интервалов опроса с момента запуска планировщика.
+	PollCount int64
+}
+
+type job struct {
+	collector Collector
+	interval  time.Duration
+	timeout   time.Duration
+	running   atomic.Bool
+}
+
+// Scheduler опрашивает каждый сборщик со своим интервалом и таймаутом
+// и хранит последний успешный результат каждого из них.
+type Scheduler struct {
+	logger       *zap.SugaredLogger
+	results      map[string][]repository.Metric
+	jobs         []*job
+	pollInterval time.Duration
+	pollCount    atomic.Int64
+	mu           sync.RWMutex
+}
+
+// NewScheduler создаёт планировщик. Интервал и таймаут сборщика без настроек
+// равны pollInterval, отключённые сборщики не опрашиваются.
+func NewScheduler(
+	collectors []Collector,
+	settings map[string]config.CollectorConfig,
+	pollInterval time.Duration,
+	logger *zap.SugaredLogger,
+) (*Scheduler, error) {
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("poll interval must be positive, got %s", pollInterval)
+	}
+
+	s := &Scheduler{
+		logger:       logger.With("component", "collector scheduler"),
+		results:      make(map[string][]repository.Metric, len(collectors)),
+		pollInterval: pollInterval,
+	}
+
+	known := make(map[string]struct{}, len(collectors))
+	for _, c := range collectors {
+		name := c.Name()
+		if _, exists := known[name]; exists {
+			return nil, fmt.Errorf("duplicate collector %q", name)
+		}
+		known[name] = struct{}{}
+
+		cfg := settings[name]
+		if !cfg.IsEnabled() {
+			s.logger.Infow("collector disabled", "collector", name)
+			continue
+		}
+
+		interval := pollInterval
+		if cfg.Interval > 0 {
+			interval = time.Duration(cfg.Interval) * time.Second
+		}
+		timeout := interval
+		if cfg.Timeout > 0 {
+			timeout = time.Duration(cfg.Timeout) * time.Second
+		}
+		s.jobs = append(s.jobs, &job{collector: c, interval: interval, timeout: timeout})
+	}
+
+	for name := range settings {
+		if _, exists := known[name]; !exists {
+			return nil, fmt.Errorf("unknown collector %q in config", name)
+		}
+	}
+
+	return s, nil
+}
+
+// Run запускает опрос сборщиков и блокируется до отмены контекста.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			s.runJob(ctx, j)
+		}(j)
+	}
+
+	pollTicker := time.NewTicker(s.pollInterval)
+	defer pollTicker.Stop()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-pollTicker.C:
+			s.pollCount.Add(1)
+		}
+	}
+	wg.Wait()
+}
+
+// Snapshot возвращает копию последних результатов сборщиков.
+func (s *Scheduler) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	size := 0
+	for _, metrics := range s.results {
+		size += len(metrics)
+	}
+	metrics := make([]repository.Metric, 0, size)
+	for _, j := range s.jobs {
+		metrics = append(metrics, s.results[j.collector.Name()]...)
+	}
+
+	return Snapshot{
+		TakenAt:   time.Now(),
+		Metrics:   metrics,
+		PollCount: s.pollCount.Load(),
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context, j *job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.collect(ctx, j)
+		}
+	}
+}
+
+// collect опрашивает сборщик с таймаутом. Если сборщик не уложился в таймаут,
+// его результат отбрасывается, а следующий опрос пропускается до завершения текущего.
+func (s *Scheduler) collect(ctx context.Context, j *job) {
+	name := j.collector.Name()
+	if !j.running.CompareAndSwap(false, true) {
+		s.logger.Infow("collector is still running, skip", "collector", name)
+		return
+	}
+
+	collectCtx, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	type result struct {
+		err     error
+		metrics []repository.Metric
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer j.running.Store(false)
+		metrics, err := j.collector.Collect(collectCtx)
+		done <- result{metrics: metrics, err: err}
+	}()
+
+	select {
+	case <-collectCtx.Done():
+		s.logger.Infow("collector timed out", "collector", name, "timeout", j.timeout)
+	case r := <-done:
+		if r.err != nil {
+			s.logger.Infow("collector failed", "collector", name, "error", r.err)
+			return
+		}
+		s.mu.Lock()
+		s.results[name] = r.metrics
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"metrics/internal/config"
+	"metrics/internal/repository"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeCollector struct {
+	err   error
+	name  string
+	delay time.Duration
+	calls atomic.Int64
+}
+
+func (c *fakeCollector) Name() string {
+	return c.name
+}
+
+func (c *fakeCollector) Collect(ctx context.Context) ([]repository.Metric, error) {
+	call := c.calls.Add(1)
+	if c.delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.delay):
+		}
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []repository.Metric{{Name: c.name, Value: uint64(call)}}, nil
+}
+
+func newTestScheduler(t *testing.T, collectors []Collector, settings map[string]config.CollectorConfig) *Scheduler {
+	t.Helper()
+	s, err := NewScheduler(collectors, settings, 10*time.Millisecond, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	return s
+}
+
+func TestScheduler_RunCollectsAndCountsPolls(t *testing.T) {
+	first := &fakeCollector{name: "first"}
+	second := &fakeCollector{name: "second"}
+	s := newTestScheduler(t, []Collector{first, second}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(s.Snapshot().Metrics) == 2
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	snapshot := s.Snapshot()
+	assert.Equal(t, "first", snapshot.Metrics[0].Name, "порядок метрик совпадает с порядком сборщиков")
+	assert.Equal(t, "second", snapshot.Metrics[1].Name)
+	assert.Positive(t, snapshot.PollCount)
+}
+
+func TestScheduler_SnapshotIsImmutable(t *testing.T) {
+	c := &fakeCollector{name: "runtime"}
+	s := newTestScheduler(t, []Collector{c}, nil)
+	s.collect(context.Background(), s.jobs[0])
+
+	snapshot := s.Snapshot()
+	snapshot.Metrics[0].Name = "changed"
+
+	assert.Equal(t, "runtime", s.Snapshot().Metrics[0].Name)
+}
+
+func TestScheduler_DisabledCollector(t *testing.T) {
+	disabled := false
+	c := &fakeCollector{name: "system"}
+	s := newTestScheduler(t, []Collector{c}, map[string]config.CollectorConfig{
+		"system": {Enabled: &disabled},
+	})
+
+	assert.Empty(t, s.jobs)
+}
+
+func TestScheduler_Settings(t *testing.T) {
+	c := &fakeCollector{name: "system"}
+	s := newTestScheduler(t, []Collector{c, &fakeCollector{name: "runtime"}}, map[string]config.CollectorConfig{
+		"system": {Interval: 30, Timeout: 5},
+	})
+
+	require.Len(t, s.jobs, 2)
+	assert.Equal(t, 30*time.Second, s.jobs[0].interval)
+	assert.Equal(t, 5*time.Second, s.jobs[0].timeout)
+	assert.Equal(t, 10*time.Millisecond, s.jobs[1].interval, "по умолчанию используется poll interval")
+	assert.Equal(t, 10*time.Millisecond, s.jobs[1].timeout)
+}
+
+func TestScheduler_InvalidConfig(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	c := &fakeCollector{name: "runtime"}
+
+	_, err := NewScheduler([]Collector{c}, map[string]config.CollectorConfig{"gpu": {}}, time.Second, logger)
+	assert.Error(t, err, "неизвестный сборщик в конфиге")
+
+	_, err = NewScheduler([]Collector{c, c}, nil, time.Second, logger)
+	assert.Error(t, err, "повторяющееся имя сборщика")
+
+	_, err = NewScheduler([]Collector{c}, nil, 0, logger)
+	assert.Error(t, err, "нулевой интервал опроса")
+}
+
+func TestScheduler_TimeoutAndErrorKeepPreviousResult(t *testing.T) {
+	c := &fakeCollector{name: "slow"}
+	s := newTestScheduler(t, []Collector{c}, nil)
+	j := s.jobs[0]
+
+	s.collect(context.Background(), j)
+	require.Len(t, s.Snapshot().Metrics, 1)
+
+	c.delay = time.Second
+	j.timeout = 10 * time.Millisecond
+	s.collect(context.Background(), j)
+	assert.Equal(t, uint64(1), s.Snapshot().Metrics[0].Value, "результат по таймауту отбрасывается")
+
+	require.Eventually(t, func() bool { return !j.running.Load() }, time.Second, 5*time.Millisecond)
+	c.delay = 0
+	c.err = errors.New("collect failed")
+	s.collect(context.Background(), j)
+	assert.Equal(t, uint64(1), s.Snapshot().Metrics[0].Value, "при ошибке остаётся последний успешный результат")
+}
@@ -0,0 +1,32 @@
+package collector
+
+import (
+	"context"
+	"metrics/internal/repository"
+	"metrics/internal/spool"
+)
+
+// SpoolName имя сборщика метрик дисковой очереди агента.
+const SpoolName = "spool"
+
+type spoolCollector struct {
+	spool *spool.Spool
+}
+
+// NewSpool возвращает сборщик глубины дисковой очереди и числа отброшенных отчётов.
+func NewSpool(s *spool.Spool) Collector {
+	return &spoolCollector{spool: s}
+}
+
+func (c *spoolCollector) Name() string {
+	return SpoolName
+}
+
+func (c *spoolCollector) Collect(ctx context.Context) ([]repository.Metric, error) {
+	stats := c.spool.Stats()
+	return []repository.Metric{
+		{Name: "SpoolDepth", Value: uint64(stats.Depth)},
+		{Name: "SpoolBytes", Value: uint64(stats.Bytes)},
+		{Name: "SpoolDropped", Value: stats.Dropped},
+	}, nil
+}
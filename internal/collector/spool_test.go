@@ -0,0 +1,33 @@
+package collector
+
+import (
+	"context"
+	"metrics/internal/spool"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpoolCollector(t *testing.T) {
+	sp, err := spool.Open(t.TempDir(), spool.Options{SegmentBytes: 1 << 10})
+	require.NoError(t, err)
+	defer func() { _ = sp.Close() }()
+
+	require.NoError(t, sp.Append([]byte("first")))
+	require.NoError(t, sp.Append([]byte("second")))
+
+	c := NewSpool(sp)
+	assert.Equal(t, SpoolName, c.Name())
+
+	metrics, err := c.Collect(context.Background())
+	require.NoError(t, err)
+
+	values := make(map[string]uint64, len(metrics))
+	for _, metric := range metrics {
+		values[metric.Name] = metric.Value
+	}
+	assert.Equal(t, uint64(2), values["SpoolDepth"])
+	assert.Positive(t, values["SpoolBytes"])
+	assert.Equal(t, uint64(0), values["SpoolDropped"])
+}
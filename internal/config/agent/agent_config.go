@@ -32,8 +32,78 @@ const (
 	flagAgentCryptoKey        = "crypto-key"
 	envAgentCryptoKey         = "CRYPTO_KEY"
 	cryptoAgentKeyDescription = "Cryptographic encryption key"
+
+	envAgentID = "AGENT_ID"
+
+	flagSpoolDir        = "spool-dir"
+	envSpoolDir         = "SPOOL_DIR"
+	spoolDirDescription = "Directory for the on-disk queue of unsent reports (disabled if empty)"
+
+	envSpoolMaxBytes     = "SPOOL_MAX_BYTES"
+	envSpoolMaxAge       = "SPOOL_MAX_AGE"
+	defaultSpoolMaxBytes = 64 << 20
+	defaultSpoolMaxAge   = 24 * 60 * 60
+
+	flagTLSCert = "tls-cert"
+	envTLSCert  = "TLS_CERT"
+	flagTLSKey  = "tls-key"
+	envTLSKey   = "TLS_KEY"
+	flagTLSCA   = "tls-ca"
+	envTLSCA    = "TLS_CA"
+
+	tlsCertDescription = "Path to the agent TLS certificate in PEM for mutual TLS"
+	tlsKeyDescription  = "Path to the agent TLS private key in PEM"
+	tlsCADescription   = "Path to the CA certificate in PEM used to verify the server, enables HTTPS"
+
+	flagTransport        = "transport"
+	envTransport         = "TRANSPORT"
+	transportDescription = "Transport for sending metrics: http or grpc (default: http)"
+	transportHTTP        = "http"
+	transportGRPC        = "grpc"
+
+	flagGrpcAddress        = "grpc-address"
+	envGrpcAddress         = "GRPC_ADDRESS"
+	defaultGrpcAddress     = "localhost:8081"
+	grpcAddressDescription = "gRPC server address in the format host:port (default: localhost:8081)"
+
+	flagGrpcStream        = "grpc-stream"
+	envGrpcStream         = "GRPC_STREAM"
+	grpcStreamDescription = "Send reports over a single StreamMetrics stream with per-report acks"
+
+	envGrpcDialTimeout      = "GRPC_DIAL_TIMEOUT"
+	envGrpcKeepaliveTime    = "GRPC_KEEPALIVE_TIME"
+	envGrpcKeepaliveTimeout = "GRPC_KEEPALIVE_TIMEOUT"
+	envGrpcMaxMessageSize   = "GRPC_MAX_MESSAGE_SIZE"
+	envGrpcMaxAttempts      = "GRPC_MAX_ATTEMPTS"
+
+	defaultGrpcDialTimeout      = 5
+	defaultGrpcKeepaliveTime    = 30
+	defaultGrpcKeepaliveTimeout = 10
+	defaultGrpcMaxMessageSize   = 4 << 20
+	defaultGrpcMaxAttempts      = 3
+	// maxGrpcAttempts верхняя граница числа попыток, которую допускает grpc-go.
+	maxGrpcAttempts = 5
 )
 
+// agentFlags значения флагов командной строки агента.
+type agentFlags struct {
+	address        string
+	key            string
+	cryptoKey      string
+	configShort    string
+	configLong     string
+	spoolDir       string
+	tlsCert        string
+	tlsKey         string
+	tlsCA          string
+	transport      string
+	grpcAddress    string
+	reportInterval int
+	pollInterval   int
+	rateLimit      int
+	grpcStream     bool
+}
+
 func ParseAgentFlags() (*config.AgentConfig, error) {
 	addressFlag := flag.String("a", defaultAddress, addressFlagDescription)
 	reportIntervalFlag := flag.Int("r", defaultReportInterval, reportIntervalFlagDescription)
@@ -41,6 +111,13 @@ func ParseAgentFlags() (*config.AgentConfig, error) {
 	keyFlag := flag.String(flagAgentKey, "", keyDescription)
 	rateLimitFlag := flag.Int(flagRateLimit, 1, rateLimitDescription)
 	cryptoFlag := flag.String(flagAgentCryptoKey, "", cryptoAgentKeyDescription)
+	spoolDirFlag := flag.String(flagSpoolDir, "", spoolDirDescription)
+	tlsCertFlag := flag.String(flagTLSCert, "", tlsCertDescription)
+	tlsKeyFlag := flag.String(flagTLSKey, "", tlsKeyDescription)
+	tlsCAFlag := flag.String(flagTLSCA, "", tlsCADescription)
+	transportFlag := flag.String(flagTransport, "", transportDescription)
+	grpcAddressFlag := flag.String(flagGrpcAddress, "", grpcAddressDescription)
+	grpcStreamFlag := flag.Bool(flagGrpcStream, false, grpcStreamDescription)
 	configShort := flag.String("c", "", "Path to config file (short)")
 	configLong := flag.String("config", "", "Path to config file (long)")
 	flag.Parse()
@@ -50,31 +127,29 @@ func ParseAgentFlags() (*config.AgentConfig, error) {
 		return nil, fmt.Errorf("read flags: %w", err)
 	}
 
-	return processAgentFlags(
-		*addressFlag,
-		*reportIntervalFlag,
-		*pollIntervalFlag,
-		*keyFlag,
-		*rateLimitFlag,
-		*cryptoFlag,
-		*configShort,
-		*configLong,
-	)
+	return processAgentFlags(agentFlags{
+		address:        *addressFlag,
+		reportInterval: *reportIntervalFlag,
+		pollInterval:   *pollIntervalFlag,
+		key:            *keyFlag,
+		rateLimit:      *rateLimitFlag,
+		cryptoKey:      *cryptoFlag,
+		spoolDir:       *spoolDirFlag,
+		tlsCert:        *tlsCertFlag,
+		tlsKey:         *tlsKeyFlag,
+		tlsCA:          *tlsCAFlag,
+		transport:      *transportFlag,
+		grpcAddress:    *grpcAddressFlag,
+		grpcStream:     *grpcStreamFlag,
+		configShort:    *configShort,
+		configLong:     *configLong,
+	})
 }
 
-func processAgentFlags(
-	addressFlag string,
-	reportIntervalFlag,
-	pollIntervalFlag int,
-	keyFlag string,
-	rateLimitFlag int,
-	cryptoKeyFlag string,
-	configShort string,
-	configLong string,
-) (*config.AgentConfig, error) {
-	configPath := configLong
+func processAgentFlags(flags agentFlags) (*config.AgentConfig, error) {
+	configPath := flags.configLong
 	if configPath == "" {
-		configPath = configShort
+		configPath = flags.configShort
 	}
 	if configPath != "" {
 		if fromEnv, ok := os.LookupEnv("CONFIG"); ok {
@@ -89,7 +164,7 @@ func processAgentFlags(
 		}
 	}
 
-	finalAddress, err := config.GetStringValue(addressFlag, envAddress, fileCfg.Address)
+	finalAddress, err := config.GetStringValue(flags.address, envAddress, fileCfg.Address)
 	if err != nil {
 		return nil, fmt.Errorf("read flag: %w", err)
 	}
@@ -98,41 +173,170 @@ func processAgentFlags(
 	if err != nil {
 		return nil, fmt.Errorf("read flag address: %w", err)
 	}
-	address := "http://" + net.JoinHostPort(host, port)
 
-	reportInterval, err := config.GetIntValue(reportIntervalFlag, envReportInterval, fileCfg.ReportInterval)
+	reportInterval, err := config.GetIntValue(flags.reportInterval, envReportInterval, fileCfg.ReportInterval)
 	if err != nil {
 		return nil, fmt.Errorf("read flag report interval: %w", err)
 	}
 
-	poolInterval, err := config.GetIntValue(pollIntervalFlag, envPollInterval, fileCfg.PollInterval)
+	poolInterval, err := config.GetIntValue(flags.pollInterval, envPollInterval, fileCfg.PollInterval)
 	if err != nil {
 		return nil, fmt.Errorf("read flag pool interval: %w", err)
 	}
 
-	key, err := config.GetStringValue(keyFlag, envAgentKey, fileCfg.Key)
+	key, err := config.GetStringValue(flags.key, envAgentKey, fileCfg.Key)
 	if err != nil {
 		key = ""
 	}
 
-	rateLimit, err := config.GetIntValue(rateLimitFlag, envRateLimit, fileCfg.RateLimit)
+	rateLimit, err := config.GetIntValue(flags.rateLimit, envRateLimit, fileCfg.RateLimit)
 	if err != nil {
 		rateLimit = 1
 	}
 
-	cryptoKey, err := config.GetStringValue(cryptoKeyFlag, envAgentCryptoKey, fileCfg.CryptoKey)
+	cryptoKey, err := config.GetStringValue(flags.cryptoKey, envAgentCryptoKey, fileCfg.CryptoKey)
 	if err != nil {
 		cryptoKey = ""
 	}
 
+	spoolDir, err := config.GetStringValue(flags.spoolDir, envSpoolDir, fileCfg.SpoolDir)
+	if err != nil {
+		spoolDir = ""
+	}
+
+	spoolMaxBytes, err := config.GetIntValue(0, envSpoolMaxBytes, int(fileCfg.SpoolMaxBytes))
+	if err != nil {
+		spoolMaxBytes = defaultSpoolMaxBytes
+	}
+
+	spoolMaxAge, err := config.GetIntValue(0, envSpoolMaxAge, fileCfg.SpoolMaxAge)
+	if err != nil {
+		spoolMaxAge = defaultSpoolMaxAge
+	}
+
+	tlsCert, err := config.GetStringValue(flags.tlsCert, envTLSCert, fileCfg.TLSCert)
+	if err != nil {
+		tlsCert = ""
+	}
+
+	tlsKey, err := config.GetStringValue(flags.tlsKey, envTLSKey, fileCfg.TLSKey)
+	if err != nil {
+		tlsKey = ""
+	}
+
+	tlsCA, err := config.GetStringValue(flags.tlsCA, envTLSCA, fileCfg.TLSCA)
+	if err != nil {
+		tlsCA = ""
+	}
+
+	if (tlsCert == "") != (tlsKey == "") {
+		return nil, fmt.Errorf("tls certificate and key must be set together")
+	}
+
+	scheme := "http://"
+	if tlsCert != "" || tlsCA != "" {
+		scheme = "https://"
+	}
+	address := scheme + net.JoinHostPort(host, port)
+
+	agentID, err := resolveAgentID(fileCfg.AgentID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve agent id: %w", err)
+	}
+
+	transport, err := config.GetStringValue(flags.transport, envTransport, fileCfg.Transport)
+	if err != nil {
+		transport = transportHTTP
+	}
+	if transport != transportHTTP && transport != transportGRPC {
+		return nil, fmt.Errorf("unknown transport %q (expected %s or %s)", transport, transportHTTP, transportGRPC)
+	}
+
+	grpcAddress, err := config.GetStringValue(flags.grpcAddress, envGrpcAddress, fileCfg.GrpcAddress)
+	if err != nil {
+		grpcAddress = defaultGrpcAddress
+	}
+	grpcHost, grpcPort, err := config.ParseAddress(grpcAddress)
+	if err != nil {
+		return nil, fmt.Errorf("read flag grpc address: %w", err)
+	}
+
+	grpcStream, err := config.GetBoolValue(flags.grpcStream || fileCfg.GrpcStream, envGrpcStream)
+	if err != nil {
+		return nil, fmt.Errorf("read flag grpc stream: %w", err)
+	}
+
+	grpcDialTimeout, err := config.GetIntValue(0, envGrpcDialTimeout, fileCfg.GrpcDialTimeout)
+	if err != nil {
+		grpcDialTimeout = defaultGrpcDialTimeout
+	}
+
+	grpcKeepaliveTime, err := config.GetIntValue(0, envGrpcKeepaliveTime, fileCfg.GrpcKeepaliveTime)
+	if err != nil {
+		grpcKeepaliveTime = defaultGrpcKeepaliveTime
+	}
+
+	grpcKeepaliveTimeout, err := config.GetIntValue(0, envGrpcKeepaliveTimeout, fileCfg.GrpcKeepaliveTimeout)
+	if err != nil {
+		grpcKeepaliveTimeout = defaultGrpcKeepaliveTimeout
+	}
+
+	grpcMaxMessageSize, err := config.GetIntValue(0, envGrpcMaxMessageSize, fileCfg.GrpcMaxMessageSize)
+	if err != nil {
+		grpcMaxMessageSize = defaultGrpcMaxMessageSize
+	}
+
+	grpcMaxAttempts, err := config.GetIntValue(0, envGrpcMaxAttempts, fileCfg.GrpcMaxAttempts)
+	if err != nil {
+		grpcMaxAttempts = defaultGrpcMaxAttempts
+	}
+
+	if grpcDialTimeout <= 0 || grpcKeepaliveTime < 0 || grpcKeepaliveTimeout <= 0 || grpcMaxMessageSize <= 0 {
+		return nil, fmt.Errorf("grpc timeouts and max message size must be positive")
+	}
+	if grpcMaxAttempts < 1 || grpcMaxAttempts > maxGrpcAttempts {
+		return nil, fmt.Errorf("grpc max attempts must be between 1 and %d", maxGrpcAttempts)
+	}
+
 	return &config.AgentConfig{
-		Address:        address,
-		ReportInterval: reportInterval,
-		PollInterval:   poolInterval,
-		Batch:          false,
-		Key:            key,
-		RateLimit:      rateLimit,
-		CryptoKey:      cryptoKey,
-		Grpc:           false,
+		Address:              address,
+		ReportInterval:       reportInterval,
+		PollInterval:         poolInterval,
+		Batch:                false,
+		Key:                  key,
+		RateLimit:            rateLimit,
+		CryptoKey:            cryptoKey,
+		Grpc:                 transport == transportGRPC,
+		AgentID:              agentID,
+		Collectors:           fileCfg.Collectors,
+		SpoolDir:             spoolDir,
+		SpoolMaxBytes:        int64(spoolMaxBytes),
+		SpoolMaxAge:          spoolMaxAge,
+		TLSCert:              tlsCert,
+		TLSKey:               tlsKey,
+		TLSCA:                tlsCA,
+		Transport:            transport,
+		GrpcAddress:          net.JoinHostPort(grpcHost, grpcPort),
+		GrpcStream:           grpcStream,
+		GrpcDialTimeout:      grpcDialTimeout,
+		GrpcKeepaliveTime:    grpcKeepaliveTime,
+		GrpcKeepaliveTimeout: grpcKeepaliveTimeout,
+		GrpcMaxMessageSize:   grpcMaxMessageSize,
+		GrpcMaxAttempts:      grpcMaxAttempts,
 	}, nil
 }
+
+// resolveAgentID берёт идентификатор из окружения, затем из файла конфигурации, иначе имя хоста.
+func resolveAgentID(fileValue string) (string, error) {
+	if fromEnv, ok := os.LookupEnv(envAgentID); ok && fromEnv != "" {
+		return fromEnv, nil
+	}
+	if fileValue != "" {
+		return fileValue, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("get hostname: %w", err)
+	}
+	return hostname, nil
+}
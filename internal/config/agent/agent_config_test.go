@@ -4,19 +4,19 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProcessAgentFlags(t *testing.T) {
-	cfg, err := processAgentFlags(
-		"localhost:8080",
-		500,
-		600,
-		"my-secret-key",
-		10,
-		"test",
-		"",
-		"",
-	)
+	cfg, err := processAgentFlags(agentFlags{
+		address:        "localhost:8080",
+		reportInterval: 500,
+		pollInterval:   600,
+		key:            "my-secret-key",
+		rateLimit:      10,
+		cryptoKey:      "test",
+		spoolDir:       "/var/spool/agent",
+	})
 	assert.NoError(t, err)
 
 	assert.Equal(t, "http://localhost:8080", cfg.Address)
@@ -27,6 +27,35 @@ func TestProcessAgentFlags(t *testing.T) {
 	assert.Equal(t, "my-secret-key", cfg.Key)
 	assert.Equal(t, 10, cfg.RateLimit)
 	assert.Equal(t, "test", cfg.CryptoKey)
+
+	assert.Equal(t, "/var/spool/agent", cfg.SpoolDir)
+	assert.Equal(t, int64(64<<20), cfg.SpoolMaxBytes)
+	assert.Equal(t, 24*60*60, cfg.SpoolMaxAge)
+}
+
+func TestProcessAgentFlags_TLS(t *testing.T) {
+	cfg, err := processAgentFlags(agentFlags{
+		address:        "localhost:8080",
+		reportInterval: 10,
+		pollInterval:   2,
+		tlsCert:        "cert/public.cert",
+		tlsKey:         "cert/private.cert",
+		tlsCA:          "cert/public.cert",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8080", cfg.Address)
+	assert.True(t, cfg.TLSEnabled())
+	assert.Equal(t, "cert/public.cert", cfg.TLSCert)
+	assert.Equal(t, "cert/private.cert", cfg.TLSKey)
+	assert.Equal(t, "cert/public.cert", cfg.TLSCA)
+
+	_, err = processAgentFlags(agentFlags{
+		address:        "localhost:8080",
+		reportInterval: 10,
+		pollInterval:   2,
+		tlsKey:         "cert/private.cert",
+	})
+	assert.Error(t, err, "ключ без сертификата")
 }
 
 func TestParseAgentFlags(t *testing.T) {
@@ -40,4 +69,61 @@ func TestParseAgentFlags(t *testing.T) {
 	assert.Equal(t, "", cfg.Key)
 	assert.Equal(t, 1, cfg.RateLimit)
 	assert.Equal(t, "", cfg.CryptoKey)
+	assert.Equal(t, "", cfg.SpoolDir)
+}
+
+func TestProcessAgentFlags_GrpcTransport(t *testing.T) {
+	base := agentFlags{address: "localhost:8080", reportInterval: 10, pollInterval: 2}
+
+	cfg, err := processAgentFlags(base)
+	require.NoError(t, err)
+	assert.Equal(t, "http", cfg.Transport)
+	assert.False(t, cfg.Grpc)
+	assert.Equal(t, "localhost:8081", cfg.GrpcAddress)
+	assert.Equal(t, 5, cfg.GrpcDialTimeout)
+	assert.Equal(t, 30, cfg.GrpcKeepaliveTime)
+	assert.Equal(t, 10, cfg.GrpcKeepaliveTimeout)
+	assert.Equal(t, 4<<20, cfg.GrpcMaxMessageSize)
+	assert.Equal(t, 3, cfg.GrpcMaxAttempts)
+
+	flags := base
+	flags.transport = "grpc"
+	flags.grpcAddress = "metrics.local:9090"
+	cfg, err = processAgentFlags(flags)
+	require.NoError(t, err)
+	assert.True(t, cfg.Grpc)
+	assert.Equal(t, "metrics.local:9090", cfg.GrpcAddress)
+	assert.False(t, cfg.GrpcStream)
+
+	t.Setenv("GRPC_STREAM", "true")
+	cfg, err = processAgentFlags(flags)
+	require.NoError(t, err)
+	assert.True(t, cfg.GrpcStream)
+
+	t.Setenv("GRPC_ADDRESS", "10.0.0.1:7000")
+	t.Setenv("GRPC_MAX_ATTEMPTS", "1")
+	t.Setenv("GRPC_KEEPALIVE_TIME", "0")
+	cfg, err = processAgentFlags(flags)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:7000", cfg.GrpcAddress, "переменная окружения важнее флага")
+	assert.Equal(t, 1, cfg.GrpcMaxAttempts)
+	assert.Equal(t, 0, cfg.GrpcKeepaliveTime, "0 отключает keepalive")
+}
+
+func TestProcessAgentFlags_GrpcInvalid(t *testing.T) {
+	base := agentFlags{address: "localhost:8080", reportInterval: 10, pollInterval: 2}
+
+	flags := base
+	flags.transport = "udp"
+	_, err := processAgentFlags(flags)
+	assert.Error(t, err, "неизвестный транспорт")
+
+	flags = base
+	flags.grpcAddress = "localhost"
+	_, err = processAgentFlags(flags)
+	assert.Error(t, err, "адрес без порта")
+
+	t.Setenv("GRPC_MAX_ATTEMPTS", "6")
+	_, err = processAgentFlags(base)
+	assert.Error(t, err, "grpc-go не допускает больше 5 попыток")
 }
@@ -5,25 +5,102 @@ import (
 )
 
 type AgentConfig struct {
+	// Настройки сборщиков метрик по имени сборщика (runtime, system).
+	Collectors map[string]CollectorConfig `json:"collectors,omitempty"`
 	// Ключ для вычисления хеша.
 	Key string `json:"-"`
 	// Включить поддержку асимметричного шифрования
 	CryptoKey string `json:"crypto_key,omitempty"`
 	// Адрес хоста в формате 8.8.8.8:8080
 	Address string `json:"address,omitempty"`
+	// Сертификат и ключ агента в PEM для взаимной аутентификации (mTLS).
+	TLSCert string `json:"tls_cert,omitempty"`
+	TLSKey  string `json:"tls_key,omitempty"`
+	// Сертификат CA в PEM для проверки сервера. Задание любого из tls_* включает HTTPS.
+	TLSCA string `json:"tls_ca,omitempty"`
+	// Идентификатор агента для ключей идемпотентности, по умолчанию имя хоста.
+	AgentID string `json:"agent_id,omitempty"`
+	// Транспорт отправки метрик: http или grpc.
+	Transport string `json:"transport,omitempty"`
+	// Адрес gRPC-сервера в формате host:port.
+	GrpcAddress string `json:"grpc_address,omitempty"`
+	// Каталог дисковой очереди неотправленных отчётов, пустое значение отключает очередь.
+	SpoolDir string `json:"spool_dir,omitempty"`
+	// Максимальный размер дисковой очереди в байтах.
+	SpoolMaxBytes int64 `json:"spool_max_bytes,omitempty"`
+	// Максимальный возраст отчёта в дисковой очереди в секундах.
+	SpoolMaxAge int `json:"spool_max_age,omitempty"`
 	// Интервал отправки метрик.
 	ReportInterval int `json:"report_interval,omitempty"`
 	// Интервал опроса метрик.
 	PollInterval int `json:"poll_interval,omitempty"`
+	// Таймаут установки gRPC-соединения в секундах.
+	GrpcDialTimeout int `json:"grpc_dial_timeout,omitempty"`
+	// Интервал keepalive-пингов gRPC в секундах, 0 отключает пинги.
+	GrpcKeepaliveTime int `json:"grpc_keepalive_time,omitempty"`
+	// Время ожидания ответа на keepalive-пинг в секундах.
+	GrpcKeepaliveTimeout int `json:"grpc_keepalive_timeout,omitempty"`
+	// Максимальный размер gRPC-сообщения в байтах.
+	GrpcMaxMessageSize int `json:"grpc_max_message_size,omitempty"`
+	// Число попыток gRPC-вызова при недоступности сервера, 1 отключает повторы.
+	GrpcMaxAttempts int `json:"grpc_max_attempts,omitempty"`
 	// Лимит
 	RateLimit int `json:"-"`
+	// Отправлять отчёты в одном потоке StreamMetrics вместо унарных вызовов.
+	GrpcStream bool `json:"grpc_stream,omitempty"`
 	// Разрешить отправку метрик одним пакетным запросом.
 	Batch bool `json:"-"`
-	Grpc  bool `json:"-"`
+	// Отправлять метрики по gRPC, выставляется при transport=grpc.
+	Grpc bool `json:"-"`
 }
 
+// AlertsEnabled сообщает, включены ли алерты.
+func (c *ServerConfig) AlertsEnabled() bool {
+	return c.AlertRulesFile != ""
+}
+
+// TLSEnabled сообщает, должен ли агент подключаться к серверу по TLS.
+func (c *AgentConfig) TLSEnabled() bool {
+	return c.TLSCA != "" || c.TLSCert != ""
+}
+
+// CollectorConfig настройки отдельного сборщика метрик агента.
+type CollectorConfig struct {
+	// Включить сборщик, по умолчанию включён.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Интервал опроса в секундах, по умолчанию poll_interval.
+	Interval int `json:"interval,omitempty"`
+	// Таймаут одного опроса в секундах, по умолчанию равен интервалу.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// IsEnabled сообщает, включён ли сборщик. Сборщик без настроек включён.
+func (c CollectorConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// GraphiteRule правило выбора типа метрики Graphite по шаблону пути.
+type GraphiteRule struct {
+	// Шаблон пути, * и ? действуют в пределах сегмента: servers.*.requests.
+	Pattern string `json:"pattern"`
+	// Тип метрики: counter (значение — приращение) или gauge.
+	Type string `json:"type"`
+}
+
+// Политики конфликта типов метрик.
+const (
+	// TypeConflictAllow counter и gauge с одним именем хранятся как разные серии.
+	TypeConflictAllow = "allow"
+	// TypeConflictReject метрика с именем, занятым другим типом, отклоняется.
+	TypeConflictReject = "reject"
+)
+
 type ServerConfig struct {
 	TrustedNet *net.IPNet `json:"-"`
+	// Правила выбора типа метрик Graphite, первое подходящее правило определяет тип.
+	GraphiteRules []GraphiteRule `json:"graphite_rules,omitempty"`
+	// Адреса webhook, получающих смену состояния алертов.
+	AlertWebhooks []string `json:"alert_webhooks,omitempty"`
 	// CIDR
 	TrustedSubnet string `json:"trusted_subnet,omitempty"`
 	// Ключ для вычисления хеша.
@@ -36,10 +113,74 @@ type ServerConfig struct {
 	DatabaseDsn string `json:"database_dsn,omitempty"`
 	// Включить поддержку асимметричного шифрования
 	CryptoKey string `json:"crypto_key,omitempty"`
+	// Сертификат и ключ сервера в PEM, включают HTTPS и TLS для gRPC.
+	TLSCert string `json:"tls_cert,omitempty"`
+	TLSKey  string `json:"tls_key,omitempty"`
+	// Сертификат CA в PEM, при задании сервер требует клиентский сертификат (mTLS).
+	TLSCA string `json:"tls_ca,omitempty"`
+	// JSON-файл с учётными данными агентов, включает проверку агентов.
+	AuthFile string `json:"auth_file,omitempty"`
+	// Адрес gRPC-сервера в формате host:port.
+	GrpcAddress string `json:"grpc_address,omitempty"`
+	// Адрес приёма StatsD по UDP и TCP в формате host:port, пустое значение отключает приём.
+	StatsdAddress string `json:"statsd_address,omitempty"`
+	// Адрес приёма Graphite plaintext по TCP в формате host:port, пустое значение отключает приём.
+	GraphiteAddress string `json:"graphite_address,omitempty"`
+	// JSON-файл с правилами алертов, пустое значение отключает алерты.
+	AlertRulesFile string `json:"alert_rules_file,omitempty"`
+	// Файл журнала аудита административных действий, пустое значение пишет аудит в журнал сервера.
+	AuditLogFile string `json:"audit_log_file,omitempty"`
+	// Политика для имени метрики, записанного с другим типом: allow или reject.
+	MetricTypeConflict string `json:"metric_type_conflict,omitempty"`
 	// Интервал сохранения хранилища.
 	StoreInterval int `json:"store_interval,omitempty"`
+	// Интервал keepalive-пингов gRPC-сервера в секундах, 0 отключает пинги.
+	GrpcKeepaliveTime int `json:"grpc_keepalive_time,omitempty"`
+	// Время ожидания ответа на keepalive-пинг в секундах.
+	GrpcKeepaliveTimeout int `json:"grpc_keepalive_timeout,omitempty"`
+	// Максимальный размер принимаемого gRPC-сообщения в байтах.
+	GrpcMaxMessageSize int `json:"grpc_max_message_size,omitempty"`
+	// Окно агрегации StatsD в миллисекундах.
+	StatsdFlushInterval int `json:"statsd_flush_interval,omitempty"`
+	// Максимальная длина строки Graphite в байтах.
+	GraphiteMaxLineLength int `json:"graphite_max_line_length,omitempty"`
+	// Максимальное число одновременных соединений Graphite.
+	GraphiteMaxConnections int `json:"graphite_max_connections,omitempty"`
+	// Число некорректных строк, после которого соединение Graphite закрывается.
+	GraphiteMaxErrors int `json:"graphite_max_errors,omitempty"`
+	// Интервал вычисления правил алертов в секундах.
+	AlertInterval int `json:"alert_interval,omitempty"`
+	// Число повторов отправки в webhook после неудачной попытки.
+	AlertWebhookRetries int `json:"alert_webhook_retries,omitempty"`
 	// Разрешить загрузку из файла хранилища.
 	Restore bool `json:"restore,omitempty"`
+	// Учётные данные агентов в таблице agent_credentials, включает проверку агентов.
+	AuthDatabase bool `json:"auth_database,omitempty"`
 	// Разрешить отладку.
 	Debug bool `json:"-"`
 }
+
+// AuthEnabled сообщает, включена ли проверка индивидуальных учётных данных агентов.
+func (c *ServerConfig) AuthEnabled() bool {
+	return c.AuthFile != "" || c.AuthDatabase
+}
+
+// StatsdEnabled сообщает, включён ли приём метрик StatsD.
+func (c *ServerConfig) StatsdEnabled() bool {
+	return c.StatsdAddress != ""
+}
+
+// GraphiteEnabled сообщает, включён ли приём метрик Graphite.
+func (c *ServerConfig) GraphiteEnabled() bool {
+	return c.GraphiteAddress != ""
+}
+
+// RejectTypeConflicts сообщает, нужно ли отклонять метрики, имя которых уже занято другим типом.
+func (c *ServerConfig) RejectTypeConflicts() bool {
+	return c.MetricTypeConflict == TypeConflictReject
+}
+
+// TLSEnabled сообщает, настроен ли TLS на сервере.
+func (c *ServerConfig) TLSEnabled() bool {
+	return c.TLSCert != ""
+}
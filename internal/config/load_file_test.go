@@ -27,7 +27,8 @@ func TestLoadAgentConfigFromFile(t *testing.T) {
 		"report_interval": 10,
 		"poll_interval": 2,
 		"rate_limit": 100,
-		"batch": true
+		"batch": true,
+		"collectors": {"system": {"enabled": false, "interval": 30, "timeout": 5}}
 	}`
 
 	path := writeTempFile(t, jsonData)
@@ -41,6 +42,14 @@ func TestLoadAgentConfigFromFile(t *testing.T) {
 	if cfg.Address != "127.0.0.1:8080" {
 		t.Errorf("expected Address to be '127.0.0.1:8080', got %q", cfg.Address)
 	}
+
+	system := cfg.Collectors["system"]
+	if system.IsEnabled() || system.Interval != 30 || system.Timeout != 5 {
+		t.Errorf("unexpected system collector config: %+v", system)
+	}
+	if !cfg.Collectors["runtime"].IsEnabled() {
+		t.Errorf("collector without config must be enabled")
+	}
 }
 
 func TestLoadServerConfigFromFile(t *testing.T) {
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"metrics/internal/config"
 	"net"
+	"net/url"
 	"os"
+	"strings"
 )
 
 const (
@@ -55,6 +57,119 @@ const (
 	cryptoKeyDescription = "Cryptographic encryption key"
 )
 
+const (
+	flagTLSCert = "tls-cert"
+	envTLSCert  = "TLS_CERT"
+	flagTLSKey  = "tls-key"
+	envTLSKey   = "TLS_KEY"
+	flagTLSCA   = "tls-ca"
+	envTLSCA    = "TLS_CA"
+
+	tlsCertDescription = "Path to the server TLS certificate in PEM, enables HTTPS and gRPC over TLS"
+	tlsKeyDescription  = "Path to the server TLS private key in PEM"
+	tlsCADescription   = "Path to the CA certificate in PEM, requires client certificates (mTLS)"
+)
+
+const (
+	flagAuthFile     = "auth-file"
+	envAuthFile      = "AUTH_FILE"
+	flagAuthDatabase = "auth-db"
+	envAuthDatabase  = "AUTH_DATABASE"
+
+	authFileDescription     = "Path to the JSON file with per-agent credentials, enables agent authentication"
+	authDatabaseDescription = "Read per-agent credentials from the agent_credentials table, enables agent authentication"
+)
+
+const (
+	flagGrpcAddress        = "grpc-address"
+	envGrpcAddress         = "GRPC_ADDRESS"
+	defaultGrpcAddress     = "localhost:8081"
+	descriptionGrpcAddress = "gRPC server address in the format host:port (default: localhost:8081)"
+
+	envGrpcKeepaliveTime    = "GRPC_KEEPALIVE_TIME"
+	envGrpcKeepaliveTimeout = "GRPC_KEEPALIVE_TIMEOUT"
+	envGrpcMaxMessageSize   = "GRPC_MAX_MESSAGE_SIZE"
+
+	defaultGrpcKeepaliveTime    = 120
+	defaultGrpcKeepaliveTimeout = 20
+	defaultGrpcMaxMessageSize   = 4 << 20
+)
+
+const (
+	flagStatsdAddress        = "statsd-address"
+	envStatsdAddress         = "STATSD_ADDRESS"
+	descriptionStatsdAddress = "StatsD UDP and TCP listen address in the format host:port, empty disables StatsD"
+
+	envStatsdFlushInterval     = "STATSD_FLUSH_INTERVAL"
+	defaultStatsdFlushInterval = 1000
+)
+
+const (
+	flagGraphiteAddress        = "graphite-address"
+	envGraphiteAddress         = "GRAPHITE_ADDRESS"
+	descriptionGraphiteAddress = "Graphite plaintext TCP listen address in the format host:port, empty disables Graphite"
+
+	envGraphiteRules          = "GRAPHITE_RULES"
+	envGraphiteMaxLineLength  = "GRAPHITE_MAX_LINE_LENGTH"
+	envGraphiteMaxConnections = "GRAPHITE_MAX_CONNECTIONS"
+	envGraphiteMaxErrors      = "GRAPHITE_MAX_ERRORS"
+
+	defaultGraphiteMaxLineLength  = 4096
+	defaultGraphiteMaxConnections = 100
+	defaultGraphiteMaxErrors      = 100
+)
+
+const (
+	flagAlertRules        = "alert-rules"
+	envAlertRules         = "ALERT_RULES_FILE"
+	descriptionAlertRules = "JSON file with alert rules, empty disables alerting"
+
+	envAlertWebhooks       = "ALERT_WEBHOOKS"
+	envAlertInterval       = "ALERT_INTERVAL"
+	envAlertWebhookRetries = "ALERT_WEBHOOK_RETRIES"
+
+	defaultAlertInterval       = 15
+	defaultAlertWebhookRetries = 3
+)
+
+const (
+	flagMetricTypeConflict        = "metric-type-conflict"
+	envMetricTypeConflict         = "METRIC_TYPE_CONFLICT"
+	descriptionMetricTypeConflict = "Policy for a metric name written with another type: allow (separate series) or reject"
+)
+
+const (
+	flagAuditLog        = "audit-log"
+	envAuditLog         = "AUDIT_LOG_FILE"
+	descriptionAuditLog = "JSON Lines file for the audit log of admin actions, empty writes audit entries to the server log"
+)
+
+// serverFlags значения флагов командной строки сервера.
+type serverFlags struct {
+	address       string
+	storagePath   string
+	databaseDSN   string
+	key           string
+	cryptoKey     string
+	trustedSubnet string
+	tlsCert       string
+	tlsKey        string
+	tlsCA         string
+	authFile      string
+	grpcAddress   string
+	statsdAddress string
+	graphiteAddr  string
+	alertRules    string
+	typeConflict  string
+	auditLog      string
+	configShort   string
+	configLong    string
+	storeInterval int
+	restore       bool
+	enablePprof   bool
+	authDatabase  bool
+}
+
 func ParseFlags() (*config.ServerConfig, error) {
 	addressFlag := flag.String(flagHTTPAddress, defaultHTTPAddress, descriptionHTTPAddress)
 	storeIntervalFlag := flag.Int(flagStoreInterval, defaultStoreInterval, descriptionStoreInterval)
@@ -63,6 +178,17 @@ func ParseFlags() (*config.ServerConfig, error) {
 	addressDatabaseFlag := flag.String(flagDatabaseDSN, defaultDatabaseDSN, descriptionDatabaseDSN)
 	keyFlag := flag.String(flagKey, defaultKey, descriptionKey)
 	cryptoFlag := flag.String(flagCryptoKey, "", cryptoKeyDescription)
+	tlsCertFlag := flag.String(flagTLSCert, "", tlsCertDescription)
+	tlsKeyFlag := flag.String(flagTLSKey, "", tlsKeyDescription)
+	tlsCAFlag := flag.String(flagTLSCA, "", tlsCADescription)
+	authFileFlag := flag.String(flagAuthFile, "", authFileDescription)
+	authDatabaseFlag := flag.Bool(flagAuthDatabase, false, authDatabaseDescription)
+	grpcAddressFlag := flag.String(flagGrpcAddress, "", descriptionGrpcAddress)
+	statsdAddressFlag := flag.String(flagStatsdAddress, "", descriptionStatsdAddress)
+	graphiteAddressFlag := flag.String(flagGraphiteAddress, "", descriptionGraphiteAddress)
+	alertRulesFlag := flag.String(flagAlertRules, "", descriptionAlertRules)
+	typeConflictFlag := flag.String(flagMetricTypeConflict, "", descriptionMetricTypeConflict)
+	auditLogFlag := flag.String(flagAuditLog, "", descriptionAuditLog)
 	enablePprof := flag.Bool("pprof", false, "enable pprof for debugging")
 	trustedSubnet := flag.String("t", "", "CIDR")
 	configShort := flag.String("c", "", "Path to config file (short)")
@@ -74,37 +200,36 @@ func ParseFlags() (*config.ServerConfig, error) {
 		return nil, fmt.Errorf("read flag UnknownArgs: %w", err)
 	}
 
-	return processFlags(
-		*addressFlag,
-		*storeIntervalFlag,
-		*storagePathFlag,
-		*restoreFlag,
-		*addressDatabaseFlag,
-		*keyFlag,
-		*cryptoFlag,
-		*enablePprof,
-		*trustedSubnet,
-		*configShort,
-		*configLong,
-	)
+	return processFlags(serverFlags{
+		address:       *addressFlag,
+		storeInterval: *storeIntervalFlag,
+		storagePath:   *storagePathFlag,
+		restore:       *restoreFlag,
+		databaseDSN:   *addressDatabaseFlag,
+		key:           *keyFlag,
+		cryptoKey:     *cryptoFlag,
+		tlsCert:       *tlsCertFlag,
+		tlsKey:        *tlsKeyFlag,
+		tlsCA:         *tlsCAFlag,
+		authFile:      *authFileFlag,
+		authDatabase:  *authDatabaseFlag,
+		grpcAddress:   *grpcAddressFlag,
+		statsdAddress: *statsdAddressFlag,
+		graphiteAddr:  *graphiteAddressFlag,
+		alertRules:    *alertRulesFlag,
+		typeConflict:  *typeConflictFlag,
+		auditLog:      *auditLogFlag,
+		enablePprof:   *enablePprof,
+		trustedSubnet: *trustedSubnet,
+		configShort:   *configShort,
+		configLong:    *configLong,
+	})
 }
 
-func processFlags(
-	addressFlag string,
-	storeIntervalFlag int,
-	storagePathFlag string,
-	restoreFlag bool,
-	addressDatabaseFlag string,
-	keyFlag string,
-	cryptoKeyFlag string,
-	enablePprof bool,
-	trustedSubnetFlag string,
-	configShort string,
-	configLong string,
-) (*config.ServerConfig, error) {
-	configPath := configLong
+func processFlags(flags serverFlags) (*config.ServerConfig, error) {
+	configPath := flags.configLong
 	if configPath == "" {
-		configPath = configShort
+		configPath = flags.configShort
 	}
 	if configPath != "" {
 		if fromEnv, ok := os.LookupEnv("CONFIG"); ok {
@@ -119,7 +244,7 @@ func processFlags(
 		}
 	}
 
-	finalAddress, err := config.GetStringValue(addressFlag, envHTTPAddress, fileCfg.Address)
+	finalAddress, err := config.GetStringValue(flags.address, envHTTPAddress, fileCfg.Address)
 	if err != nil {
 		finalAddress = ""
 	}
@@ -130,41 +255,147 @@ func processFlags(
 	}
 	address := net.JoinHostPort(host, port)
 
-	storeInterval, err := config.GetIntValue(storeIntervalFlag, envStoreInterval, fileCfg.StoreInterval)
+	storeInterval, err := config.GetIntValue(flags.storeInterval, envStoreInterval, fileCfg.StoreInterval)
 	if err != nil {
 		return nil, fmt.Errorf("read flag report interval: %w", err)
 	}
 
-	storagePath, err := config.GetStringValue(storagePathFlag, envFileStoragePath, fileCfg.FileStoragePath)
+	storagePath, err := config.GetStringValue(flags.storagePath, envFileStoragePath, fileCfg.FileStoragePath)
 	if err != nil {
 		return nil, fmt.Errorf("read flag storage: %w", err)
 	}
 
-	restore, err := config.GetBoolValue(restoreFlag, envRestore)
+	restore, err := config.GetBoolValue(flags.restore, envRestore)
 	if err != nil {
 		return nil, fmt.Errorf("read flag restore: %w", err)
 	}
 
-	databaseDsn, err := config.GetStringValue(addressDatabaseFlag, envDatabaseDSN, fileCfg.DatabaseDsn)
+	databaseDsn, err := config.GetStringValue(flags.databaseDSN, envDatabaseDSN, fileCfg.DatabaseDsn)
 	if err != nil {
 		databaseDsn = ""
 	}
 
-	key, err := config.GetStringValue(keyFlag, envKey, fileCfg.Key)
+	key, err := config.GetStringValue(flags.key, envKey, fileCfg.Key)
 	if err != nil {
 		key = ""
 	}
 
-	cryptoKey, err := config.GetStringValue(cryptoKeyFlag, envCryptoKey, fileCfg.CryptoKey)
+	cryptoKey, err := config.GetStringValue(flags.cryptoKey, envCryptoKey, fileCfg.CryptoKey)
 	if err != nil {
 		cryptoKey = ""
 	}
 
-	trustedSubnet, err := config.GetStringValue(trustedSubnetFlag, "TRUSTED_SUBNET", fileCfg.TrustedSubnet)
+	trustedSubnet, err := config.GetStringValue(flags.trustedSubnet, "TRUSTED_SUBNET", fileCfg.TrustedSubnet)
 	if err != nil {
 		trustedSubnet = ""
 	}
 
+	tlsCert, err := config.GetStringValue(flags.tlsCert, envTLSCert, fileCfg.TLSCert)
+	if err != nil {
+		tlsCert = ""
+	}
+
+	tlsKey, err := config.GetStringValue(flags.tlsKey, envTLSKey, fileCfg.TLSKey)
+	if err != nil {
+		tlsKey = ""
+	}
+
+	tlsCA, err := config.GetStringValue(flags.tlsCA, envTLSCA, fileCfg.TLSCA)
+	if err != nil {
+		tlsCA = ""
+	}
+
+	if (tlsCert == "") != (tlsKey == "") {
+		return nil, fmt.Errorf("tls certificate and key must be set together")
+	}
+	if tlsCA != "" && tlsCert == "" {
+		return nil, fmt.Errorf("tls ca requires tls certificate and key")
+	}
+
+	authFile, err := config.GetStringValue(flags.authFile, envAuthFile, fileCfg.AuthFile)
+	if err != nil {
+		authFile = ""
+	}
+
+	authDatabase, err := config.GetBoolValue(flags.authDatabase || fileCfg.AuthDatabase, envAuthDatabase)
+	if err != nil {
+		return nil, fmt.Errorf("read flag auth database: %w", err)
+	}
+	if authFile != "" && authDatabase {
+		return nil, fmt.Errorf("auth file and auth database are mutually exclusive")
+	}
+
+	grpcAddress, err := config.GetStringValue(flags.grpcAddress, envGrpcAddress, fileCfg.GrpcAddress)
+	if err != nil {
+		grpcAddress = defaultGrpcAddress
+	}
+	grpcHost, grpcPort, err := config.ParseAddress(grpcAddress)
+	if err != nil {
+		return nil, fmt.Errorf("read flag grpc address: %w", err)
+	}
+
+	grpcKeepaliveTime, err := config.GetIntValue(0, envGrpcKeepaliveTime, fileCfg.GrpcKeepaliveTime)
+	if err != nil {
+		grpcKeepaliveTime = defaultGrpcKeepaliveTime
+	}
+
+	grpcKeepaliveTimeout, err := config.GetIntValue(0, envGrpcKeepaliveTimeout, fileCfg.GrpcKeepaliveTimeout)
+	if err != nil {
+		grpcKeepaliveTimeout = defaultGrpcKeepaliveTimeout
+	}
+
+	grpcMaxMessageSize, err := config.GetIntValue(0, envGrpcMaxMessageSize, fileCfg.GrpcMaxMessageSize)
+	if err != nil {
+		grpcMaxMessageSize = defaultGrpcMaxMessageSize
+	}
+
+	if grpcKeepaliveTime < 0 || grpcKeepaliveTimeout <= 0 || grpcMaxMessageSize <= 0 {
+		return nil, fmt.Errorf("grpc keepalive timeout and max message size must be positive")
+	}
+
+	statsdAddress, err := config.GetStringValue(flags.statsdAddress, envStatsdAddress, fileCfg.StatsdAddress)
+	if err != nil {
+		statsdAddress = ""
+	}
+	if statsdAddress != "" {
+		statsdHost, statsdPort, err := config.ParseAddress(statsdAddress)
+		if err != nil {
+			return nil, fmt.Errorf("read flag statsd address: %w", err)
+		}
+		statsdAddress = net.JoinHostPort(statsdHost, statsdPort)
+	}
+
+	statsdFlushInterval, err := config.GetIntValue(0, envStatsdFlushInterval, fileCfg.StatsdFlushInterval)
+	if err != nil {
+		statsdFlushInterval = defaultStatsdFlushInterval
+	}
+	if statsdFlushInterval <= 0 {
+		return nil, fmt.Errorf("statsd flush interval must be positive")
+	}
+
+	graphite, err := processGraphite(flags, &fileCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := processAlerts(flags, &fileCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	typeConflict, err := config.GetStringValue(flags.typeConflict, envMetricTypeConflict, fileCfg.MetricTypeConflict)
+	if err != nil {
+		typeConflict = config.TypeConflictAllow
+	}
+	if typeConflict != config.TypeConflictAllow && typeConflict != config.TypeConflictReject {
+		return nil, fmt.Errorf("metric type conflict policy %q: expected allow or reject", typeConflict)
+	}
+
+	auditLog, err := config.GetStringValue(flags.auditLog, envAuditLog, fileCfg.AuditLogFile)
+	if err != nil {
+		auditLog = ""
+	}
+
 	var trustedNet *net.IPNet
 	if trustedSubnet != "" {
 		ip, cidr, err := net.ParseCIDR(trustedSubnet)
@@ -177,15 +408,139 @@ func processFlags(
 	}
 
 	return &config.ServerConfig{
-		Address:         address,
-		StoreInterval:   storeInterval,
-		FileStoragePath: storagePath,
-		DatabaseDsn:     databaseDsn,
-		Restore:         restore,
-		Key:             key,
-		Debug:           enablePprof,
-		CryptoKey:       cryptoKey,
-		TrustedSubnet:   trustedSubnet,
-		TrustedNet:      trustedNet,
+		Address:                address,
+		StoreInterval:          storeInterval,
+		FileStoragePath:        storagePath,
+		DatabaseDsn:            databaseDsn,
+		Restore:                restore,
+		Key:                    key,
+		Debug:                  flags.enablePprof,
+		CryptoKey:              cryptoKey,
+		TrustedSubnet:          trustedSubnet,
+		TrustedNet:             trustedNet,
+		TLSCert:                tlsCert,
+		TLSKey:                 tlsKey,
+		TLSCA:                  tlsCA,
+		AuthFile:               authFile,
+		AuthDatabase:           authDatabase,
+		GrpcAddress:            net.JoinHostPort(grpcHost, grpcPort),
+		GrpcKeepaliveTime:      grpcKeepaliveTime,
+		GrpcKeepaliveTimeout:   grpcKeepaliveTimeout,
+		GrpcMaxMessageSize:     grpcMaxMessageSize,
+		StatsdAddress:          statsdAddress,
+		StatsdFlushInterval:    statsdFlushInterval,
+		GraphiteAddress:        graphite.GraphiteAddress,
+		GraphiteRules:          graphite.GraphiteRules,
+		GraphiteMaxLineLength:  graphite.GraphiteMaxLineLength,
+		GraphiteMaxConnections: graphite.GraphiteMaxConnections,
+		GraphiteMaxErrors:      graphite.GraphiteMaxErrors,
+		AlertRulesFile:         alerts.AlertRulesFile,
+		AlertWebhooks:          alerts.AlertWebhooks,
+		AlertInterval:          alerts.AlertInterval,
+		AlertWebhookRetries:    alerts.AlertWebhookRetries,
+		MetricTypeConflict:     typeConflict,
+		AuditLogFile:           auditLog,
 	}, nil
 }
+
+// processGraphite читает настройки приёма Graphite. Правила из GRAPHITE_RULES
+// в виде pattern=type,pattern=type заменяют правила из файла конфигурации.
+func processGraphite(flags serverFlags, fileCfg *config.ServerConfig) (config.ServerConfig, error) {
+	var cfg config.ServerConfig
+
+	address, err := config.GetStringValue(flags.graphiteAddr, envGraphiteAddress, fileCfg.GraphiteAddress)
+	if err != nil {
+		address = ""
+	}
+	if address != "" {
+		host, port, err := config.ParseAddress(address)
+		if err != nil {
+			return cfg, fmt.Errorf("read flag graphite address: %w", err)
+		}
+		cfg.GraphiteAddress = net.JoinHostPort(host, port)
+	}
+
+	cfg.GraphiteRules = fileCfg.GraphiteRules
+	if rules, ok := os.LookupEnv(envGraphiteRules); ok {
+		cfg.GraphiteRules, err = parseGraphiteRules(rules)
+		if err != nil {
+			return cfg, fmt.Errorf("read env %s: %w", envGraphiteRules, err)
+		}
+	}
+
+	cfg.GraphiteMaxLineLength, err = config.GetIntValue(0, envGraphiteMaxLineLength, fileCfg.GraphiteMaxLineLength)
+	if err != nil {
+		cfg.GraphiteMaxLineLength = defaultGraphiteMaxLineLength
+	}
+	cfg.GraphiteMaxConnections, err = config.GetIntValue(0, envGraphiteMaxConnections, fileCfg.GraphiteMaxConnections)
+	if err != nil {
+		cfg.GraphiteMaxConnections = defaultGraphiteMaxConnections
+	}
+	cfg.GraphiteMaxErrors, err = config.GetIntValue(0, envGraphiteMaxErrors, fileCfg.GraphiteMaxErrors)
+	if err != nil {
+		cfg.GraphiteMaxErrors = defaultGraphiteMaxErrors
+	}
+	if cfg.GraphiteMaxLineLength <= 0 || cfg.GraphiteMaxConnections <= 0 || cfg.GraphiteMaxErrors <= 0 {
+		return cfg, fmt.Errorf("graphite line length, connection and error limits must be positive")
+	}
+
+	return cfg, nil
+}
+
+// processAlerts читает настройки алертов. Адреса из ALERT_WEBHOOKS через запятую
+// заменяют адреса из файла конфигурации.
+func processAlerts(flags serverFlags, fileCfg *config.ServerConfig) (config.ServerConfig, error) {
+	var cfg config.ServerConfig
+
+	rulesFile, err := config.GetStringValue(flags.alertRules, envAlertRules, fileCfg.AlertRulesFile)
+	if err == nil {
+		cfg.AlertRulesFile = rulesFile
+	}
+
+	cfg.AlertWebhooks = fileCfg.AlertWebhooks
+	if webhooks, ok := os.LookupEnv(envAlertWebhooks); ok {
+		cfg.AlertWebhooks = nil
+		for _, webhook := range strings.Split(webhooks, ",") {
+			if webhook = strings.TrimSpace(webhook); webhook != "" {
+				cfg.AlertWebhooks = append(cfg.AlertWebhooks, webhook)
+			}
+		}
+	}
+	for _, webhook := range cfg.AlertWebhooks {
+		if u, err := url.Parse(webhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return cfg, fmt.Errorf("alert webhook %q: expected http or https URL", webhook)
+		}
+	}
+
+	cfg.AlertInterval, err = config.GetIntValue(0, envAlertInterval, fileCfg.AlertInterval)
+	if err != nil {
+		cfg.AlertInterval = defaultAlertInterval
+	}
+	if cfg.AlertInterval <= 0 {
+		return cfg, fmt.Errorf("alert interval must be positive")
+	}
+	cfg.AlertWebhookRetries, err = config.GetIntValue(0, envAlertWebhookRetries, fileCfg.AlertWebhookRetries)
+	if err != nil {
+		cfg.AlertWebhookRetries = defaultAlertWebhookRetries
+	}
+	if cfg.AlertWebhookRetries < 0 {
+		return cfg, fmt.Errorf("alert webhook retries must not be negative")
+	}
+
+	return cfg, nil
+}
+
+func parseGraphiteRules(s string) ([]config.GraphiteRule, error) {
+	var rules []config.GraphiteRule
+	for _, rule := range strings.Split(s, ",") {
+		if rule == "" {
+			continue
+		}
+		pattern, mtype, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("graphite rule %q: expected pattern=type", rule)
+		}
+		rules = append(rules, config.GraphiteRule{Pattern: pattern, Type: mtype})
+	}
+	return rules, nil
+}
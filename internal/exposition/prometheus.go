@@ -0,0 +1,315 @@
+// Package exposition формирует текстовое представление метрик для Prometheus и OpenMetrics.
+package exposition
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"metrics/internal/repository"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format формат вывода метрик.
+type Format int
+
+const (
+	// FormatText текстовый формат Prometheus 0.0.4.
+	FormatText Format = iota
+	// FormatOpenMetrics формат OpenMetrics 1.0.0.
+	FormatOpenMetrics
+)
+
+const (
+	textMediaType        = "text/plain"
+	openMetricsMediaType = "application/openmetrics-text"
+
+	counterSuffix = "_total"
+)
+
+// ContentType возвращает значение заголовка Content-Type для формата.
+func (f Format) ContentType() string {
+	if f == FormatOpenMetrics {
+		return openMetricsMediaType + "; version=1.0.0; charset=utf-8"
+	}
+	return textMediaType + "; version=0.0.4; charset=utf-8"
+}
+
+// Negotiate выбирает формат по заголовку Accept. OpenMetrics выбирается,
+// только если клиент явно предпочитает его текстовому формату.
+func Negotiate(accept string) Format {
+	openMetricsQ, textQ := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch mediaType {
+		case openMetricsMediaType:
+			openMetricsQ = math.Max(openMetricsQ, q)
+		case textMediaType, "*/*", "text/*":
+			textQ = math.Max(textQ, q)
+		}
+	}
+
+	if openMetricsQ > 0 && openMetricsQ >= textQ {
+		return FormatOpenMetrics
+	}
+	return FormatText
+}
+
+// SanitizeName приводит имя к виду [a-zA-Z_:][a-zA-Z0-9_:]*, заменяя недопустимые символы на '_'.
+func SanitizeName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	var b strings.Builder
+	b.Grow(len(name) + 1)
+	for i, r := range name {
+		valid := r == '_' || r == ':' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(i > 0 && r >= '0' && r <= '9')
+		switch {
+		case valid:
+			b.WriteRune(r)
+		case i == 0 && r >= '0' && r <= '9':
+			b.WriteByte('_')
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// Write выводит gauge, counter, histogram и summary в выбранном формате. Ключи карт — ключи серий
+// (см. repository.SeriesKey): серии с одним именем выводятся одним семейством с метками.
+// Если после очистки имя семейства уже занято семейством другого типа, к нему добавляется суффикс
+// типа (например, requests_counter рядом с gauge requests); если занято и оно, семейство не выводится.
+func Write(
+	w io.Writer,
+	gauges map[string]float64,
+	counters map[string]uint64,
+	histograms map[string]repository.Histogram,
+	summaries map[string]repository.Summary,
+	format Format,
+) error {
+	bw := bufio.NewWriter(w)
+	seen := make(map[string]struct{}, len(gauges)+len(counters)+len(histograms)+len(summaries))
+
+	for _, family := range groupFamilies(gauges, singleSample(formatFloat)) {
+		if !claimName(seen, family, "gauge") {
+			continue
+		}
+		writeFamily(bw, family, "gauge", family.name)
+	}
+
+	formatCounter := func(v uint64) string { return strconv.FormatUint(v, 10) }
+	for _, family := range groupFamilies(counters, singleSample(formatCounter)) {
+		if format == FormatOpenMetrics {
+			// В OpenMetrics имя семейства counter не содержит суффикс _total, а сэмпл — содержит.
+			family.name = strings.TrimSuffix(family.name, counterSuffix)
+		}
+		if !claimName(seen, family, "counter") {
+			continue
+		}
+		sampleName := family.name
+		if format == FormatOpenMetrics {
+			sampleName += counterSuffix
+		}
+		writeFamily(bw, family, "counter", sampleName)
+	}
+
+	for _, family := range groupFamilies(histograms, histogramSamples) {
+		if !claimName(seen, family, "histogram") {
+			continue
+		}
+		writeFamily(bw, family, "histogram", family.name)
+	}
+
+	for _, family := range groupFamilies(summaries, summarySamples) {
+		if !claimName(seen, family, "summary") {
+			continue
+		}
+		writeFamily(bw, family, "summary", family.name)
+	}
+
+	if format == FormatOpenMetrics {
+		bw.WriteString("# EOF\n")
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("write exposition: %w", err)
+	}
+	return nil
+}
+
+// claimName занимает имя семейства. Если имя уже занято, семейство переименовывается
+// в <имя>_<тип>; false означает, что занято и это имя.
+func claimName(seen map[string]struct{}, f *family, metricType string) bool {
+	for _, name := range []string{f.name, f.name + "_" + metricType} {
+		if _, exists := seen[name]; !exists {
+			seen[name] = struct{}{}
+			f.name = name
+			return true
+		}
+	}
+	return false
+}
+
+// sample строка семейства. Суффикс добавляется к имени сэмпла, например _bucket или _sum.
+type sample struct {
+	suffix string
+	labels repository.Labels
+	value  string
+}
+
+type family struct {
+	name    string
+	samples []sample
+}
+
+// singleSample возвращает функцию, выводящую серию одним сэмплом.
+func singleSample[V any](format func(V) string) func(repository.Labels, V) []sample {
+	return func(labels repository.Labels, value V) []sample {
+		return []sample{{labels: labels, value: format(value)}}
+	}
+}
+
+// histogramSamples выводит накопительные корзины с меткой le, сумму и число наблюдений.
+func histogramSamples(labels repository.Labels, h repository.Histogram) []sample {
+	samples := make([]sample, 0, len(h.Counts)+2)
+	var cumulative uint64
+	for i, count := range h.Counts {
+		cumulative += count
+		bound := math.Inf(1)
+		if i < len(h.Bounds) {
+			bound = h.Bounds[i]
+		}
+		samples = append(samples, sample{
+			suffix: "_bucket",
+			labels: withLabel(labels, "le", formatFloat(bound)),
+			value:  strconv.FormatUint(cumulative, 10),
+		})
+	}
+	return append(samples,
+		sample{suffix: "_sum", labels: labels, value: formatFloat(h.Sum)},
+		sample{suffix: "_count", labels: labels, value: strconv.FormatUint(h.Count, 10)})
+}
+
+// summarySamples выводит квантили с меткой quantile, сумму и число наблюдений.
+func summarySamples(labels repository.Labels, s repository.Summary) []sample {
+	samples := make([]sample, 0, len(s.Quantiles)+2)
+	for _, q := range s.Quantiles {
+		samples = append(samples, sample{
+			labels: withLabel(labels, "quantile", formatFloat(q.Quantile)),
+			value:  formatFloat(q.Value),
+		})
+	}
+	return append(samples,
+		sample{suffix: "_sum", labels: labels, value: formatFloat(s.Sum)},
+		sample{suffix: "_count", labels: labels, value: strconv.FormatUint(s.Count, 10)})
+}
+
+func withLabel(labels repository.Labels, name, value string) repository.Labels {
+	result := make(repository.Labels, len(labels)+1)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[name] = value
+	return result
+}
+
+// groupFamilies собирает серии в семейства по очищенному имени.
+// Семейства и серии внутри них упорядочены по имени для стабильного вывода,
+// серии, совпавшие после очистки имени, отбрасываются.
+func groupFamilies[V any](series map[string]V, samples func(repository.Labels, V) []sample) []*family {
+	byName := make(map[string]*family)
+	names := make([]string, 0)
+	seenSeries := make(map[string]struct{}, len(series))
+	for _, key := range sortedKeys(series) {
+		name, labels := repository.ParseSeriesKey(key)
+		familyName := SanitizeName(name)
+		sanitizedKey := repository.SeriesKey(familyName, labels)
+		if _, exists := seenSeries[sanitizedKey]; exists {
+			continue
+		}
+		seenSeries[sanitizedKey] = struct{}{}
+
+		f, exists := byName[familyName]
+		if !exists {
+			f = &family{name: familyName}
+			byName[familyName] = f
+			names = append(names, familyName)
+		}
+		f.samples = append(f.samples, samples(labels, series[key])...)
+	}
+
+	sort.Strings(names)
+	families := make([]*family, 0, len(names))
+	for _, name := range names {
+		families = append(families, byName[name])
+	}
+	return families
+}
+
+func writeFamily(w *bufio.Writer, f *family, metricType, sampleName string) {
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, metricType)
+	for _, s := range f.samples {
+		w.WriteString(sampleName)
+		w.WriteString(s.suffix)
+		writeLabels(w, s.labels)
+		w.WriteByte(' ')
+		w.WriteString(s.value)
+		w.WriteByte('\n')
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeLabels(w *bufio.Writer, labels repository.Labels) {
+	if len(labels) == 0 {
+		return
+	}
+	w.WriteByte('{')
+	for i, name := range sortedKeys(labels) {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(name)
+		w.WriteString(`="`)
+		w.WriteString(labelValueEscaper.Replace(labels[name]))
+		w.WriteByte('"')
+	}
+	w.WriteByte('}')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,136 @@
+package exposition
+
+import (
+	"bytes"
+	"math"
+	"metrics/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "valid", input: "HeapAlloc", expected: "HeapAlloc"},
+		{name: "dots and dashes", input: "cpu.load-1", expected: "cpu_load_1"},
+		{name: "leading digit", input: "1min", expected: "_1min"},
+		{name: "colon allowed", input: "job:rate", expected: "job:rate"},
+		{name: "unicode", input: "память", expected: "______"},
+		{name: "empty", input: "", expected: "_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SanitizeName(tt.input))
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected Format
+	}{
+		{name: "empty", accept: "", expected: FormatText},
+		{name: "text", accept: "text/plain;version=0.0.4", expected: FormatText},
+		{name: "openmetrics", accept: "application/openmetrics-text;version=1.0.0", expected: FormatOpenMetrics},
+		{
+			name:     "prometheus scraper",
+			accept:   "application/openmetrics-text;version=1.0.0;q=0.5,text/plain;version=0.0.4;q=0.4,*/*;q=0.1",
+			expected: FormatOpenMetrics,
+		},
+		{
+			name:     "text preferred",
+			accept:   "application/openmetrics-text;q=0.3,text/plain;q=0.9",
+			expected: FormatText,
+		},
+		{name: "openmetrics disabled", accept: "application/openmetrics-text;q=0", expected: FormatText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Negotiate(tt.accept))
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	gauges := map[string]float64{"Alloc": 1.5, "cpu.util": math.Inf(1)}
+	counters := map[string]uint64{"PollCount": 7}
+
+	t.Run("text format", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, Write(&buf, gauges, counters, nil, nil, FormatText))
+		assert.Equal(t, "# TYPE Alloc gauge\nAlloc 1.5\n"+
+			"# TYPE cpu_util gauge\ncpu_util +Inf\n"+
+			"# TYPE PollCount counter\nPollCount 7\n", buf.String())
+	})
+
+	t.Run("openmetrics format", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, Write(&buf, gauges, counters, nil, nil, FormatOpenMetrics))
+		assert.Equal(t, "# TYPE Alloc gauge\nAlloc 1.5\n"+
+			"# TYPE cpu_util gauge\ncpu_util +Inf\n"+
+			"# TYPE PollCount counter\nPollCount_total 7\n"+
+			"# EOF\n", buf.String())
+	})
+
+	t.Run("labeled series share one family", func(t *testing.T) {
+		var buf bytes.Buffer
+		labeled := map[string]float64{
+			`CPUutilization1{host="b"}`:    2,
+			`CPUutilization1{host="a\"1"}`: 1,
+		}
+		assert.NoError(t, Write(&buf, labeled, nil, nil, nil, FormatText))
+		assert.Equal(t, "# TYPE CPUutilization1 gauge\n"+
+			`CPUutilization1{host="a\"1"} 1`+"\n"+
+			`CPUutilization1{host="b"} 2`+"\n", buf.String())
+	})
+
+	t.Run("duplicate names after sanitizing", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, Write(&buf, map[string]float64{"a.b": 1, "a_b": 2}, nil, nil, nil, FormatText))
+		assert.Equal(t, "# TYPE a_b gauge\na_b 1\n", buf.String())
+	})
+
+	t.Run("same name with different types", func(t *testing.T) {
+		var buf bytes.Buffer
+		histograms := map[string]repository.Histogram{
+			"load": {Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1},
+		}
+		assert.NoError(t, Write(&buf, map[string]float64{"load": 1}, map[string]uint64{"load": 2},
+			histograms, nil, FormatOpenMetrics))
+		assert.Equal(t, "# TYPE load gauge\nload 1\n"+
+			"# TYPE load_counter counter\nload_counter_total 2\n"+
+			"# TYPE load_histogram histogram\n"+
+			`load_histogram_bucket{le="1"} 1`+"\n"+
+			`load_histogram_bucket{le="+Inf"} 1`+"\n"+
+			"load_histogram_sum 0.5\nload_histogram_count 1\n"+
+			"# EOF\n", buf.String())
+	})
+
+	t.Run("histogram and summary", func(t *testing.T) {
+		var buf bytes.Buffer
+		histograms := map[string]repository.Histogram{
+			`RequestDuration{host="a"}`: {Bounds: []float64{0.1, 1}, Counts: []uint64{1, 2, 3}, Sum: 9.5, Count: 6},
+		}
+		summaries := map[string]repository.Summary{
+			"GCPause": {Quantiles: []repository.Quantile{{Quantile: 0.5, Value: 0.2}}, Sum: 4, Count: 10},
+		}
+		assert.NoError(t, Write(&buf, nil, nil, histograms, summaries, FormatText))
+		assert.Equal(t, "# TYPE RequestDuration histogram\n"+
+			`RequestDuration_bucket{host="a",le="0.1"} 1`+"\n"+
+			`RequestDuration_bucket{host="a",le="1"} 3`+"\n"+
+			`RequestDuration_bucket{host="a",le="+Inf"} 6`+"\n"+
+			`RequestDuration_sum{host="a"} 9.5`+"\n"+
+			`RequestDuration_count{host="a"} 6`+"\n"+
+			"# TYPE GCPause summary\n"+
+			`GCPause{quantile="0.5"} 0.2`+"\n"+
+			"GCPause_sum 4\nGCPause_count 10\n", buf.String())
+	})
+}
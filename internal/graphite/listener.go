@@ -0,0 +1,338 @@
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// flushInterval интервал записи принятых значений в хранилище.
+	flushInterval = time.Second
+	// idleTimeout время, после которого молчащее соединение закрывается.
+	idleTimeout = 5 * time.Minute
+	// maxLoggedLine длина строки, после которой некорректная строка обрезается в журнале.
+	maxLoggedLine = 256
+	// MalformedMetric counter с числом отброшенных некорректных строк.
+	MalformedMetric = "GraphiteMalformedLines"
+	// RejectedMetric counter с числом соединений, закрытых из-за лимитов.
+	RejectedMetric = "GraphiteRejectedConnections"
+)
+
+var errNegativeCounter = errors.New("counter value must not be negative")
+
+// Limits ограничения на отправителей Graphite.
+type Limits struct {
+	// Максимальная длина строки, более длинная строка закрывает соединение.
+	MaxLineLength int
+	// Максимальное число одновременных соединений, лишние соединения сразу закрываются.
+	MaxConnections int
+	// Число некорректных строк, после которого соединение закрывается.
+	MaxErrors int
+}
+
+type pendingSample struct {
+	Sample
+	mtype string
+}
+
+// Listener принимает метрики Graphite plaintext по TCP
+// и раз в секунду передаёт принятые значения в MetricService.UpdateMultiple.
+type Listener struct {
+	service   service.MetricService
+	logger    *zap.SugaredLogger
+	listener  net.Listener
+	slots     chan struct{}
+	rules     Rules
+	pending   []pendingSample
+	limits    Limits
+	malformed atomic.Int64
+	rejected  atomic.Int64
+	// pendingMalformed и pendingRejected события с прошлого сброса.
+	pendingMalformed atomic.Int64
+	pendingRejected  atomic.Int64
+	mu               sync.Mutex
+}
+
+// Listen открывает TCP-сокет на адресе address.
+func Listen(
+	address string,
+	rules Rules,
+	limits Limits,
+	metricService service.MetricService,
+	logger *zap.SugaredLogger,
+) (*Listener, error) {
+	if limits.MaxLineLength <= 0 || limits.MaxConnections <= 0 || limits.MaxErrors <= 0 {
+		return nil, fmt.Errorf("graphite limits must be positive, got %+v", limits)
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen graphite: %w", err)
+	}
+
+	return &Listener{
+		service:  metricService,
+		logger:   logger.With("component", "graphite"),
+		listener: listener,
+		slots:    make(chan struct{}, limits.MaxConnections),
+		rules:    rules,
+		limits:   limits,
+	}, nil
+}
+
+// Addr адрес TCP-сокета.
+func (l *Listener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// Malformed число отброшенных некорректных строк с момента запуска.
+func (l *Listener) Malformed() int64 {
+	return l.malformed.Load()
+}
+
+// Rejected число соединений, закрытых из-за лимитов, с момента запуска.
+func (l *Listener) Rejected() int64 {
+	return l.rejected.Load()
+}
+
+// Run принимает соединения и блокируется до отмены контекста.
+// После отмены соединения закрываются, а принятые значения записываются в хранилище.
+func (l *Listener) Run(ctx context.Context) {
+	l.logger.Infow("Starting graphite listener", "addr", l.listener.Addr().String())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.serve(ctx)
+	}()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+			l.flush(ctx)
+		}
+	}
+
+	_ = l.listener.Close()
+	wg.Wait()
+
+	l.flush(context.WithoutCancel(ctx))
+}
+
+func (l *Listener) serve(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				l.logger.Infow("failed to accept graphite connection", "error", err)
+				continue
+			}
+			return
+		}
+
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			l.reject()
+			l.logger.Infow("graphite connection limit reached", "remote", conn.RemoteAddr().String())
+			_ = conn.Close()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-l.slots
+				wg.Done()
+			}()
+			l.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn читает строки соединения. Ошибки считаются отдельно для каждого соединения:
+// при превышении MaxErrors или слишком длинной строке соединение закрывается.
+func (l *Listener) serveConn(ctx context.Context, conn net.Conn) {
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer func() {
+		stop()
+		_ = conn.Close()
+	}()
+
+	remote := conn.RemoteAddr().String()
+	var lines, errorsCount int
+	defer func() {
+		l.logger.Infow("graphite connection closed", "remote", remote, "lines", lines, "errors", errorsCount)
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, min(l.limits.MaxLineLength, bufio.MaxScanTokenSize)), l.limits.MaxLineLength)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		if !scanner.Scan() {
+			break
+		}
+		lines++
+
+		if err := l.handleLine(scanner.Text()); err != nil {
+			errorsCount++
+			l.countMalformed()
+			l.logger.Infow("malformed graphite line", "remote", remote, "line", truncate(scanner.Text()), "error", err)
+			if errorsCount >= l.limits.MaxErrors {
+				l.reject()
+				l.logger.Infow("graphite connection error limit reached", "remote", remote)
+				return
+			}
+		}
+	}
+
+	err := scanner.Err()
+	switch {
+	case errors.Is(err, bufio.ErrTooLong):
+		errorsCount++
+		l.countMalformed()
+		l.reject()
+		l.logger.Infow("graphite line too long", "remote", remote, "limit", l.limits.MaxLineLength)
+	case err != nil && !errors.Is(err, net.ErrClosed):
+		l.logger.Infow("failed to read graphite connection", "remote", remote, "error", err)
+	}
+}
+
+func (l *Listener) handleLine(line string) error {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	// Graphite передаёт время с точностью до секунды, строки одной секунды записываются одним пакетом.
+	sample, err := ParseLine(line, time.Now().Truncate(time.Second))
+	if err != nil {
+		return err
+	}
+
+	mtype := l.rules.Type(sample.Name)
+	if mtype == typeCounter && sample.Value < 0 {
+		return errNegativeCounter
+	}
+
+	l.mu.Lock()
+	l.pending = append(l.pending, pendingSample{Sample: sample, mtype: mtype})
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *Listener) countMalformed() {
+	l.malformed.Add(1)
+	l.pendingMalformed.Add(1)
+}
+
+func (l *Listener) reject() {
+	l.rejected.Add(1)
+	l.pendingRejected.Add(1)
+}
+
+// flush записывает принятые значения пакетами с одинаковым временем в порядке возрастания времени.
+func (l *Listener) flush(ctx context.Context) {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	byTime := make(map[time.Time][]service.MetricsUpdateRequest)
+	for _, sample := range pending {
+		byTime[sample.Timestamp] = append(byTime[sample.Timestamp], sampleMetric(sample))
+	}
+	times := make([]time.Time, 0, len(byTime))
+	for at := range byTime {
+		times = append(times, at)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	for _, at := range times {
+		l.updateSamples(repository.WithSampleTime(ctx, at), byTime[at])
+	}
+
+	var own []service.MetricsUpdateRequest
+	if malformed := l.pendingMalformed.Swap(0); malformed > 0 {
+		own = append(own, service.MetricsUpdateRequest{ID: MalformedMetric, MType: typeCounter, Delta: &malformed})
+	}
+	if rejected := l.pendingRejected.Swap(0); rejected > 0 {
+		own = append(own, service.MetricsUpdateRequest{ID: RejectedMetric, MType: typeCounter, Delta: &rejected})
+	}
+	if len(own) > 0 {
+		l.update(ctx, own)
+	}
+}
+
+// updateSamples записывает значения одной секунды. Если пакет отклонён проверкой, значения записываются
+// по одному, а отклонённые считаются некорректными строками, чтобы одно значение не отбрасывало весь пакет.
+func (l *Listener) updateSamples(ctx context.Context, metrics []service.MetricsUpdateRequest) {
+	err := l.service.UpdateMultiple(ctx, metrics)
+	if !service.IsInvalidMetric(err) {
+		if err != nil {
+			l.logger.Infow("failed to flush graphite metrics", "metrics", len(metrics), "error", err)
+		}
+		return
+	}
+
+	for _, metric := range metrics {
+		err = l.service.UpdateMultiple(ctx, []service.MetricsUpdateRequest{metric})
+		switch {
+		case service.IsInvalidMetric(err):
+			l.countMalformed()
+			l.logger.Infow("graphite metric rejected", "metric", metric.ID, "error", err)
+		case err != nil:
+			l.logger.Infow("failed to flush graphite metric", "metric", metric.ID, "error", err)
+		}
+	}
+}
+
+func (l *Listener) update(ctx context.Context, metrics []service.MetricsUpdateRequest) {
+	if err := l.service.UpdateMultiple(ctx, metrics); err != nil {
+		l.logger.Infow("failed to flush graphite metrics", "metrics", len(metrics), "error", err)
+	}
+}
+
+func sampleMetric(sample pendingSample) service.MetricsUpdateRequest {
+	metric := service.MetricsUpdateRequest{ID: sample.Name, MType: sample.mtype, Labels: sample.Labels}
+	if sample.mtype == typeCounter {
+		delta := int64(math.Round(sample.Value))
+		metric.Delta = &delta
+		return metric
+	}
+	value := sample.Value
+	metric.Value = &value
+	return metric
+}
+
+func truncate(line string) string {
+	if len(line) > maxLoggedLine {
+		return line[:maxLoggedLine]
+	}
+	return line
+}
@@ -0,0 +1,152 @@
+package graphite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"metrics/internal/config"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func startListener(t *testing.T, limits Limits) (*Listener, repository.MetricStorage, func()) {
+	t.Helper()
+	memStorage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	logger := zap.NewNop().Sugar()
+	rules, err := NewRules([]config.GraphiteRule{{Pattern: "app.*.requests", Type: "counter"}})
+	require.NoError(t, err)
+
+	listener, err := Listen("127.0.0.1:0", rules, limits, service.NewMetricService(memStorage, nil, logger), logger)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.Run(ctx)
+	}()
+	return listener, memStorage, func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestListener_CountersAndGauges(t *testing.T) {
+	listener, memStorage, stop := startListener(t, Limits{MaxLineLength: 1024, MaxConnections: 4, MaxErrors: 10})
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	_, err = fmt.Fprint(conn,
+		"app.web.requests 3 1700000000\n"+
+			"app.web.requests 4 1700000010\n"+
+			"app.web.load 0.5 1700000010\n"+
+			"app.web.load 0.25 1700000000\n"+
+			"broken\n")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.Eventually(t, func() bool {
+		return listener.Malformed() == 1
+	}, time.Second, 10*time.Millisecond)
+	stop()
+
+	ctx := context.Background()
+	counter, err := memStorage.GetCounter(ctx, "app.web.requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), counter)
+
+	gauge, err := memStorage.GetGauge(ctx, "app.web.load")
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, gauge, "последним применяется значение с наибольшим временем")
+
+	malformed, err := memStorage.GetCounter(ctx, MalformedMetric)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), malformed)
+}
+
+func TestListener_RejectedSampleKeepsBatch(t *testing.T) {
+	ctx := context.Background()
+	memStorage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	logger := zap.NewNop().Sugar()
+	rules, err := NewRules([]config.GraphiteRule{{Pattern: "app.*.requests", Type: "counter"}})
+	require.NoError(t, err)
+	listener, err := Listen("127.0.0.1:0", rules, Limits{MaxLineLength: 1024, MaxConnections: 1, MaxErrors: 10},
+		service.NewMetricService(memStorage, nil, logger), logger)
+	require.NoError(t, err)
+	defer func() {
+		_ = listener.listener.Close()
+	}()
+
+	_, err = memStorage.SetCounter(ctx, "app.web.requests", repository.MaxCounter)
+	require.NoError(t, err)
+	require.NoError(t, listener.handleLine("app.web.requests 1 1700000000"))
+	require.NoError(t, listener.handleLine("app.web.load 0.5 1700000000"))
+	listener.flush(ctx)
+
+	gauge, err := memStorage.GetGauge(ctx, "app.web.load")
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, gauge, "остальные значения пакета записываются")
+
+	assert.Equal(t, int64(1), listener.Malformed())
+	malformed, err := memStorage.GetCounter(ctx, MalformedMetric)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), malformed, "отклонённое значение считается некорректной строкой")
+}
+
+func TestListener_Limits(t *testing.T) {
+	listener, _, stop := startListener(t, Limits{MaxLineLength: 64, MaxConnections: 1, MaxErrors: 2})
+	defer stop()
+
+	first, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer func() {
+		_ = first.Close()
+	}()
+	_, err = fmt.Fprint(first, "app.web.load 1\n")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(listener.slots) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	second, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	assertClosed(t, second)
+	assert.Equal(t, int64(1), listener.Rejected(), "лишнее соединение закрывается")
+
+	_, err = fmt.Fprint(first, "bad\nworse\n")
+	require.NoError(t, err)
+	assertClosed(t, first)
+	assert.Equal(t, int64(2), listener.Rejected(), "соединение закрывается после MaxErrors ошибок")
+
+	third, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	_, err = fmt.Fprint(third, strings.Repeat("a", 100)+" 1\n")
+	require.NoError(t, err)
+	assertClosed(t, third)
+	assert.Equal(t, int64(3), listener.Rejected(), "слишком длинная строка закрывает соединение")
+}
+
+// assertClosed ждёт, что сервер закроет соединение. Сервер может закрыть соединение
+// с непрочитанными данными, тогда чтение завершается сбросом вместо EOF.
+func assertClosed(t *testing.T, conn net.Conn) {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err := conn.Read(make([]byte, 1))
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatal("connection was not closed")
+	}
+	assert.Error(t, err)
+	_ = conn.Close()
+}
@@ -0,0 +1,79 @@
+package graphite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"metrics/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrMalformedLine = errors.New("malformed graphite line")
+
+// Sample разобранная строка Graphite.
+type Sample struct {
+	// Время значения.
+	Timestamp time.Time
+	// Метки из тегов Graphite (path;tag=value).
+	Labels repository.Labels
+	// Путь метрики, например servers.web-1.cpu.
+	Name string
+	// Значение метрики.
+	Value float64
+}
+
+// ParseLine разбирает строку вида path value [timestamp]. Временная метка задаётся
+// в секундах unix-времени, отсутствующая метка или -1 означают время now.
+func ParseLine(line string, now time.Time) (Sample, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return Sample{}, fmt.Errorf("%w: expected path, value and timestamp", ErrMalformedLine)
+	}
+
+	name, labels, err := parsePath(fields[0])
+	if err != nil {
+		return Sample{}, err
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return Sample{}, fmt.Errorf("%w: value %q", ErrMalformedLine, fields[1])
+	}
+
+	sample := Sample{Name: name, Labels: labels, Value: value, Timestamp: now}
+	if len(fields) == 3 && fields[2] != "-1" {
+		seconds, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || seconds < 0 {
+			return Sample{}, fmt.Errorf("%w: timestamp %q", ErrMalformedLine, fields[2])
+		}
+		sample.Timestamp = time.Unix(0, int64(seconds*float64(time.Second)))
+	}
+	return sample, nil
+}
+
+// parsePath отделяет теги от пути: servers.cpu;host=web-1;dc=eu.
+func parsePath(path string) (string, repository.Labels, error) {
+	parts := strings.Split(path, ";")
+	name := parts[0]
+	if name == "" || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") || strings.Contains(name, "..") {
+		return "", nil, fmt.Errorf("%w: path %q", ErrMalformedLine, path)
+	}
+	if len(parts) == 1 {
+		return name, nil, nil
+	}
+
+	labels := make(repository.Labels, len(parts)-1)
+	for _, tag := range parts[1:] {
+		key, value, ok := strings.Cut(tag, "=")
+		if !ok || value == "" {
+			return "", nil, fmt.Errorf("%w: tag %q", ErrMalformedLine, tag)
+		}
+		labels[key] = value
+	}
+	if err := labels.Validate(); err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrMalformedLine, err)
+	}
+	return name, labels, nil
+}
@@ -0,0 +1,71 @@
+package graphite
+
+import (
+	"metrics/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	now := time.Unix(1700000100, 0)
+	tests := []struct {
+		name string
+		line string
+		want Sample
+	}{
+		{
+			name: "with timestamp",
+			line: "servers.web-1.cpu 12.5 1700000000",
+			want: Sample{Name: "servers.web-1.cpu", Value: 12.5, Timestamp: time.Unix(1700000000, 0)},
+		},
+		{
+			name: "without timestamp",
+			line: "servers.web-1.cpu 3",
+			want: Sample{Name: "servers.web-1.cpu", Value: 3, Timestamp: now},
+		},
+		{
+			name: "timestamp -1",
+			line: "servers.web-1.cpu 3 -1",
+			want: Sample{Name: "servers.web-1.cpu", Value: 3, Timestamp: now},
+		},
+		{
+			name: "tags",
+			line: "disk.used;host=web-1;mount=root 42 1700000000",
+			want: Sample{
+				Name:      "disk.used",
+				Labels:    repository.Labels{"host": "web-1", "mount": "root"},
+				Value:     42,
+				Timestamp: time.Unix(1700000000, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLine(tt.line, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseLine_Malformed(t *testing.T) {
+	lines := []string{
+		"servers.cpu",
+		"servers.cpu 1 2 3",
+		"servers.cpu abc 1700000000",
+		"servers.cpu NaN 1700000000",
+		"servers.cpu 1 yesterday",
+		".servers.cpu 1",
+		"servers..cpu 1",
+		"servers.cpu;host 1",
+		"servers.cpu;bad-tag=1 1",
+	}
+	for _, line := range lines {
+		_, err := ParseLine(line, time.Now())
+		assert.ErrorIs(t, err, ErrMalformedLine, line)
+	}
+}
@@ -0,0 +1,49 @@
+package graphite
+
+import (
+	"fmt"
+	"metrics/internal/config"
+	"path"
+	"strings"
+)
+
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+)
+
+// Rules правила выбора типа метрики по пути. Применяется первое подходящее правило,
+// путь без подходящего правила считается gauge.
+type Rules struct {
+	rules []config.GraphiteRule
+}
+
+// NewRules проверяет правила. Шаблон — путь Graphite, в котором * и ? не выходят
+// за пределы сегмента между точками, например servers.*.requests.
+func NewRules(rules []config.GraphiteRule) (Rules, error) {
+	for _, rule := range rules {
+		if rule.Type != typeCounter && rule.Type != typeGauge {
+			return Rules{}, fmt.Errorf("graphite rule %q: unknown type %q", rule.Pattern, rule.Type)
+		}
+		if _, err := path.Match(segments(rule.Pattern), ""); err != nil {
+			return Rules{}, fmt.Errorf("graphite rule %q: %w", rule.Pattern, err)
+		}
+	}
+	return Rules{rules: rules}, nil
+}
+
+// Type возвращает тип метрики для пути name: counter или gauge.
+func (r Rules) Type(name string) string {
+	name = segments(name)
+	for _, rule := range r.rules {
+		if matched, _ := path.Match(segments(rule.Pattern), name); matched {
+			return rule.Type
+		}
+	}
+	return typeGauge
+}
+
+// segments заменяет точки на '/', чтобы шаблоны path.Match сопоставлялись посегментно.
+func segments(name string) string {
+	return strings.ReplaceAll(name, ".", "/")
+}
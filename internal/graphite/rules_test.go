@@ -0,0 +1,33 @@
+package graphite
+
+import (
+	"metrics/internal/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Type(t *testing.T) {
+	rules, err := NewRules([]config.GraphiteRule{
+		{Pattern: "servers.*.requests", Type: "counter"},
+		{Pattern: "servers.web-1.*", Type: "gauge"},
+		{Pattern: "*.errors", Type: "counter"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "counter", rules.Type("servers.web-1.requests"))
+	assert.Equal(t, "gauge", rules.Type("servers.web-1.cpu"))
+	assert.Equal(t, "gauge", rules.Type("servers.web-1.requests.total"), "* не выходит за пределы сегмента")
+	assert.Equal(t, "counter", rules.Type("app.errors"))
+	assert.Equal(t, "gauge", rules.Type("app.db.errors"))
+	assert.Equal(t, "gauge", Rules{}.Type("app.errors"), "без правил метрики считаются gauge")
+}
+
+func TestNewRules_Invalid(t *testing.T) {
+	_, err := NewRules([]config.GraphiteRule{{Pattern: "servers.*", Type: "histogram"}})
+	assert.Error(t, err)
+
+	_, err = NewRules([]config.GraphiteRule{{Pattern: "servers.[", Type: "gauge"}})
+	assert.Error(t, err)
+}
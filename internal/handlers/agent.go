@@ -2,13 +2,21 @@ package handlers
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"metrics/internal/collector"
 	"metrics/internal/config"
 	"metrics/internal/repository"
 	"metrics/internal/service"
+	"metrics/internal/spool"
 	"os"
 	"os/signal"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -22,33 +30,52 @@ const (
 
 const typeMetricName = "gauge"
 
+const (
+	spoolSegmentBytes    = 1 << 20
+	spoolMinSegments     = 4
+	shutdownDrainTimeout = 5 * time.Second
+)
+
+// hostLabel метка, которую агент добавляет ко всем метрикам, чтобы серии разных хостов не смешивались.
+const hostLabel = "host"
+
 type MetricsPayload struct {
+	// BatchID уникален для каждого отчёта и не меняется при повторной отправке.
+	BatchID   string
 	Metrics   []repository.Metric
 	PollCount int64
 }
 
 type AgentHandler struct {
-	configs          *config.AgentConfig
-	memoryRepository *repository.MemoryRepository
-	systemRepository *repository.SystemRepository
-	agentService     service.MetricSender
-	logger           *zap.SugaredLogger
-	sendQueue        chan MetricsPayload
+	configs      *config.AgentConfig
+	agentService service.MetricSender
+	logger       *zap.SugaredLogger
+	sendQueue    chan MetricsPayload
+	labels       repository.Labels
+	collectors   []collector.Collector
+	// Приращения PollCount из отчётов, удалённых из дисковой очереди.
+	droppedPollCount atomic.Int64
 }
 
 func NewAgentHandler(
 	configs *config.AgentConfig,
-	memoryRepository *repository.MemoryRepository,
-	systemRepository *repository.SystemRepository,
+	collectors []collector.Collector,
 	metricService service.MetricSender,
 	logger *zap.SugaredLogger,
 ) *AgentHandler {
+	labels := repository.Labels{}
+	if hostname, err := os.Hostname(); err == nil {
+		labels[hostLabel] = hostname
+	} else {
+		logger.Infow("unable to detect hostname", "error", err)
+	}
+
 	return &AgentHandler{
-		configs:          configs,
-		memoryRepository: memoryRepository,
-		systemRepository: systemRepository,
-		agentService:     metricService,
-		logger:           logger,
+		configs:      configs,
+		collectors:   collectors,
+		agentService: metricService,
+		logger:       logger,
+		labels:       labels,
 	}
 }
 
@@ -56,112 +83,251 @@ func (h *AgentHandler) Handle() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	collectors := h.collectors
+	var sp *spool.Spool
+	if h.configs.SpoolDir != "" {
+		var err error
+		sp, err = h.openSpool()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := sp.Close(); err != nil {
+				h.logger.Infow("failed to close spool", "error", err)
+			}
+		}()
+		collectors = append(slices.Clone(collectors), collector.NewSpool(sp))
+	}
+
+	scheduler, err := collector.NewScheduler(
+		collectors,
+		h.configs.Collectors,
+		time.Duration(h.configs.PollInterval)*time.Second,
+		h.logger,
+	)
+	if err != nil {
+		return fmt.Errorf("create collector scheduler: %w", err)
+	}
+
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
-	pollTicker := time.NewTicker(time.Duration(h.configs.PollInterval) * time.Second)
 	reportTicker := time.NewTicker(time.Duration(h.configs.ReportInterval) * time.Second)
-	defer pollTicker.Stop()
 	defer reportTicker.Stop()
 
-	h.sendQueue = make(chan MetricsPayload, h.configs.RateLimit)
-
 	var wg sync.WaitGroup
-	for range make([]struct{}, h.configs.RateLimit) {
+	wake := make(chan struct{}, 1)
+	if sp != nil {
+		// Очередь на диске отправляется одним потоком, чтобы сохранить порядок отчётов.
 		wg.Add(1)
-		go h.worker(&wg)
+		go func() {
+			defer wg.Done()
+			h.spoolSender(ctx, sp, wake)
+		}()
+	} else {
+		h.sendQueue = make(chan MetricsPayload, h.configs.RateLimit)
+		for range make([]struct{}, h.configs.RateLimit) {
+			wg.Add(1)
+			go h.worker(&wg)
+		}
 	}
 
-	var runtimeMetrics []repository.Metric
-	var systemMetrics []repository.Metric
-	var counter int64 = 0
-
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-pollTicker.C:
-				runtimeMetrics = h.memoryRepository.GetMetrics()
-				counter++
-			}
+	enqueue := func(payload MetricsPayload) {
+		if sp == nil {
+			h.sendQueue <- payload
+			return
 		}
-	}()
+		if err := h.spoolPayload(sp, payload); err != nil {
+			h.logger.Infow("failed to spool metrics", "error", err)
+			return
+		}
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
 
+	schedulerDone := make(chan struct{})
 	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-pollTicker.C:
-				systemMetrics = h.systemRepository.GetMetrics()
-			}
-		}
+		defer close(schedulerDone)
+		scheduler.Run(ctx)
 	}()
 
+	var reported int64
 loop:
 	for {
 		select {
 		case <-ctx.Done():
 			break loop
 		case <-reportTicker.C:
-			h.sendQueue <- MetricsPayload{
-				Metrics:   append(runtimeMetrics, systemMetrics...),
-				PollCount: counter,
-			}
+			enqueue(h.payload(scheduler.Snapshot(), &reported))
 		case sig := <-sigCh:
 			h.logger.Infof("Received signal: %s, shutting down...", sig)
 			cancel()
 			break loop
 		}
 	}
-	h.sendQueue <- MetricsPayload{
-		Metrics:   append(runtimeMetrics, systemMetrics...),
-		PollCount: counter,
-	}
+	<-schedulerDone
+	enqueue(h.payload(scheduler.Snapshot(), &reported))
 
-	close(h.sendQueue)
+	if sp == nil {
+		close(h.sendQueue)
+	}
 	wg.Wait()
 
+	if sp != nil {
+		drainCtx, drainCancel := context.WithTimeout(context.Background(), shutdownDrainTimeout)
+		defer drainCancel()
+		if err := h.drainSpool(drainCtx, sp); err != nil {
+			h.logger.Infow("Unsent metrics left in spool", "error", err, "depth", sp.Stats().Depth)
+		}
+	}
+
 	h.logger.Info("Agent gracefully shut down")
 	return nil
 }
 
+// payload формирует отчёт из снимка. PollCount отправляется как приращение
+// с предыдущего отчёта, потому что сервер суммирует значения counter.
+// Приращения отчётов, удалённых из дисковой очереди, добавляются к следующему отчёту.
+func (h *AgentHandler) payload(snapshot collector.Snapshot, reported *int64) MetricsPayload {
+	pollCount := snapshot.PollCount - *reported + h.droppedPollCount.Swap(0)
+	*reported = snapshot.PollCount
+
+	return MetricsPayload{
+		BatchID:   newBatchID(),
+		Metrics:   snapshot.Metrics,
+		PollCount: pollCount,
+	}
+}
+
 func (h *AgentHandler) worker(wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for payload := range h.sendQueue {
-		ctx := context.Background()
-		pollCount := payload.PollCount
-		metrics := payload.Metrics
+		if err := h.send(context.Background(), payload); err != nil {
+			h.logger.Infow("Failed to send metrics", "batch_id", payload.BatchID, "error", err)
+		}
+	}
+}
 
-		var err error
-		if h.configs.Batch {
-			err = h.sendBatch(ctx, metrics, pollCount)
-		} else {
-			err = h.sendAPI(ctx, metrics, pollCount)
+func (h *AgentHandler) send(ctx context.Context, payload MetricsPayload) error {
+	idempotencyKey := h.idempotencyKey(payload.BatchID)
+	if h.configs.Batch {
+		return h.sendBatch(ctx, payload.Metrics, payload.PollCount, idempotencyKey)
+	}
+	return h.sendAPI(ctx, payload.Metrics, payload.PollCount, idempotencyKey)
+}
+
+func (h *AgentHandler) openSpool() (*spool.Spool, error) {
+	segmentBytes := int64(spoolSegmentBytes)
+	if maxBytes := h.configs.SpoolMaxBytes; maxBytes > 0 && maxBytes < segmentBytes*spoolMinSegments {
+		segmentBytes = maxBytes / spoolMinSegments
+	}
+
+	sp, err := spool.Open(h.configs.SpoolDir, spool.Options{
+		MaxBytes:     h.configs.SpoolMaxBytes,
+		MaxAge:       time.Duration(h.configs.SpoolMaxAge) * time.Second,
+		SegmentBytes: segmentBytes,
+		OnDrop:       h.onSpoolDrop,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open spool: %w", err)
+	}
+	return sp, nil
+}
+
+func (h *AgentHandler) spoolPayload(sp *spool.Spool, payload MetricsPayload) error {
+	record, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	if err := sp.Append(record); err != nil {
+		return fmt.Errorf("append to spool: %w", err)
+	}
+	return nil
+}
+
+// onSpoolDrop сохраняет приращение PollCount удалённого отчёта, чтобы счётчик не терялся.
+// Значения gauge не переносятся: следующий отчёт всё равно содержит более свежие.
+func (h *AgentHandler) onSpoolDrop(record []byte) {
+	var payload MetricsPayload
+	if err := json.Unmarshal(record, &payload); err != nil {
+		h.logger.Infow("failed to decode dropped spool record", "error", err)
+		return
+	}
+	h.droppedPollCount.Add(payload.PollCount)
+	h.logger.Infow("Spooled metrics dropped", "batch_id", payload.BatchID, "poll_count", payload.PollCount)
+}
+
+func (h *AgentHandler) spoolSender(ctx context.Context, sp *spool.Spool, wake <-chan struct{}) {
+	retryTicker := time.NewTicker(time.Duration(h.configs.ReportInterval) * time.Second)
+	defer retryTicker.Stop()
+
+	for {
+		if err := h.drainSpool(ctx, sp); err != nil {
+			h.logger.Infow("Failed to send spooled metrics", "error", err, "depth", sp.Stats().Depth)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-retryTicker.C:
 		}
+	}
+}
+
+// drainSpool отправляет отчёты из очереди по порядку и останавливается на первой ошибке.
+// Отклонённые сервером отчёты удаляются, чтобы не блокировать очередь.
+func (h *AgentHandler) drainSpool(ctx context.Context, sp *spool.Spool) error {
+	for ctx.Err() == nil {
+		record, pos, ok, err := sp.Peek()
 		if err != nil {
-			fmt.Printf("Failed to send metrics: %v\n", err)
+			return fmt.Errorf("read spool: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+
+		var payload MetricsPayload
+		if err := json.Unmarshal(record, &payload); err != nil {
+			h.logger.Infow("Skip corrupted spool record", "error", err)
+		} else if err := h.send(ctx, payload); err != nil {
+			if !errors.Is(err, service.ErrRejected) {
+				return err
+			}
+			h.logger.Infow("Spooled metrics rejected by server", "batch_id", payload.BatchID, "error", err)
+		}
+
+		if err := sp.Ack(pos); err != nil {
+			return fmt.Errorf("ack spool record: %w", err)
 		}
 	}
+	return nil
 }
 
-func (h *AgentHandler) sendBatch(ctx context.Context, metrics []repository.Metric, counter int64) error {
-	metricsRequests := service.AgentMetricsUpdateRequests{}
+func (h *AgentHandler) sendBatch(
+	ctx context.Context,
+	metrics []repository.Metric,
+	counter int64,
+	idempotencyKey string,
+) error {
+	metricsRequests := service.AgentMetricsUpdateRequests{IdempotencyKey: idempotencyKey}
 	metric := service.AgentMetricsUpdateRequest{
-		Delta: &counter,
-		ID:    nameCounter,
-		MType: typeCounter,
+		Delta:  &counter,
+		ID:     nameCounter,
+		MType:  typeCounter,
+		Labels: h.seriesLabels(nil),
 	}
 	metricsRequests.Metrics = append(metricsRequests.Metrics, metric)
 
 	for _, metric := range metrics {
 		valueFloat := float64(metric.Value)
 		metric := service.AgentMetricsUpdateRequest{
-			Value: &valueFloat,
-			ID:    metric.Name,
-			MType: typeMetricName,
+			Value:  &valueFloat,
+			ID:     metric.Name,
+			MType:  typeMetricName,
+			Labels: h.seriesLabels(metric.Labels),
 		}
 		metricsRequests.Metrics = append(metricsRequests.Metrics, metric)
 	}
@@ -174,11 +340,18 @@ func (h *AgentHandler) sendBatch(ctx context.Context, metrics []repository.Metri
 	return nil
 }
 
-func (h *AgentHandler) sendAPI(ctx context.Context, metrics []repository.Metric, counter int64) error {
+func (h *AgentHandler) sendAPI(
+	ctx context.Context,
+	metrics []repository.Metric,
+	counter int64,
+	idempotencyKey string,
+) error {
 	metricCounterRequest := service.AgentMetricsCounterRequest{
-		Delta: &counter,
-		ID:    nameCounter,
-		MType: typeCounter,
+		Delta:          &counter,
+		ID:             nameCounter,
+		MType:          typeCounter,
+		Labels:         h.seriesLabels(nil),
+		IdempotencyKey: idempotencyKey,
 	}
 
 	err := h.agentService.SendIncrement(ctx, metricCounterRequest)
@@ -191,9 +364,10 @@ func (h *AgentHandler) sendAPI(ctx context.Context, metrics []repository.Metric,
 		valueFloat := float64(metric.Value)
 
 		MetricGaugeUpdateRequest := service.AgentMetricsGaugeUpdateRequest{
-			Value: &valueFloat,
-			ID:    metric.Name,
-			MType: typeMetricName,
+			Value:  &valueFloat,
+			ID:     metric.Name,
+			MType:  typeMetricName,
+			Labels: h.seriesLabels(metric.Labels),
 		}
 
 		err = h.agentService.SendMetric(ctx, MetricGaugeUpdateRequest)
@@ -204,3 +378,34 @@ func (h *AgentHandler) sendAPI(ctx context.Context, metrics []repository.Metric,
 
 	return nil
 }
+
+// seriesLabels объединяет общие метки агента с метками метрики, метки метрики приоритетнее.
+func (h *AgentHandler) seriesLabels(metricLabels repository.Labels) map[string]string {
+	if len(h.labels) == 0 && len(metricLabels) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(h.labels)+len(metricLabels))
+	for name, value := range h.labels {
+		labels[name] = value
+	}
+	for name, value := range metricLabels {
+		labels[name] = value
+	}
+	return labels
+}
+
+// idempotencyKey связывает пакет с агентом, чтобы ключи разных агентов не пересекались.
+func (h *AgentHandler) idempotencyKey(batchID string) string {
+	if batchID == "" {
+		return ""
+	}
+	return h.configs.AgentID + ":" + batchID
+}
+
+func newBatchID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"metrics/internal/collector"
 	"metrics/internal/config"
 	repository2 "metrics/internal/repository"
 	"metrics/internal/service"
@@ -13,6 +15,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTestClient() *service.Client {
@@ -37,13 +40,12 @@ func TestSendAPI_Success(t *testing.T) {
 	agentService := service.NewHTTPMetricSender(client.RestyClient)
 	h := NewAgentHandler(
 		&config.AgentConfig{},
-		repository2.NewMemoryRepository(),
-		repository2.NewSystemRepository(),
+		collector.Defaults(),
 		agentService,
 		client.Logger,
 	)
 	ctx := context.Background()
-	err := h.sendAPI(ctx, []repository2.Metric{{Name: "metric1", Value: 10}}, 5)
+	err := h.sendAPI(ctx, []repository2.Metric{{Name: "metric1", Value: 10}}, 5, "")
 	assert.NoError(t, err)
 }
 
@@ -57,8 +59,7 @@ func TestSendBatch_Success(t *testing.T) {
 	agentService := service.NewHTTPMetricSender(client.RestyClient)
 	h := NewAgentHandler(
 		&config.AgentConfig{},
-		repository2.NewMemoryRepository(),
-		repository2.NewSystemRepository(),
+		collector.Defaults(),
 		agentService,
 		client.Logger,
 	)
@@ -69,6 +70,125 @@ func TestSendBatch_Success(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	err := h.sendBatch(ctx, metrics, 5)
+	err := h.sendBatch(ctx, metrics, 5, "")
 	assert.NoError(t, err)
 }
+
+func TestSendBatch_IdempotencyKey(t *testing.T) {
+	client := setupTestClient()
+	defer httpmock.DeactivateAndReset()
+
+	var gotKey string
+	var gotBody []map[string]any
+	httpmock.RegisterResponder(http.MethodPost, "/updates", func(req *http.Request) (*http.Response, error) {
+		gotKey = req.Header.Get("Idempotency-Key")
+		if err := json.NewDecoder(req.Body).Decode(&gotBody); err != nil {
+			return httpmock.NewStringResponse(http.StatusBadRequest, ""), nil
+		}
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	agentService := service.NewHTTPMetricSender(client.RestyClient)
+	h := NewAgentHandler(
+		&config.AgentConfig{AgentID: "agent-1"},
+		collector.Defaults(),
+		agentService,
+		client.Logger,
+	)
+
+	ctx := context.Background()
+	err := h.sendBatch(ctx, []repository2.Metric{{Name: "metric1", Value: 10}}, 5, h.idempotencyKey("batch-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "agent-1:batch-1", gotKey)
+	assert.Len(t, gotBody, 2)
+	for _, metric := range gotBody {
+		assert.Contains(t, metric["labels"], "host", "агент должен добавлять метку host")
+	}
+}
+
+func TestNewBatchID(t *testing.T) {
+	first := newBatchID()
+	second := newBatchID()
+	assert.Len(t, first, 32)
+	assert.NotEqual(t, first, second)
+}
+
+func TestPayload_PollCountDelta(t *testing.T) {
+	h := NewAgentHandler(&config.AgentConfig{}, nil, nil, zap.NewNop().Sugar())
+
+	var reported int64
+	first := h.payload(collector.Snapshot{PollCount: 5}, &reported)
+	second := h.payload(collector.Snapshot{PollCount: 8}, &reported)
+
+	assert.Equal(t, int64(5), first.PollCount)
+	assert.Equal(t, int64(3), second.PollCount, "отправляется приращение с прошлого отчёта")
+	assert.NotEqual(t, first.BatchID, second.BatchID)
+}
+
+func TestDrainSpool_ReplaysInOrderAfterFailure(t *testing.T) {
+	client := setupTestClient()
+	defer httpmock.DeactivateAndReset()
+
+	failing := true
+	var gotKeys []string
+	httpmock.RegisterResponder(http.MethodPost, "/updates", func(req *http.Request) (*http.Response, error) {
+		if failing {
+			return httpmock.NewStringResponse(http.StatusServiceUnavailable, ""), nil
+		}
+		gotKeys = append(gotKeys, req.Header.Get("Idempotency-Key"))
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	h := NewAgentHandler(
+		&config.AgentConfig{AgentID: "agent-1", Batch: true, SpoolDir: t.TempDir(), SpoolMaxBytes: 1 << 20},
+		nil,
+		service.NewHTTPMetricSender(client.RestyClient),
+		client.Logger,
+	)
+	sp, err := h.openSpool()
+	require.NoError(t, err)
+	defer func() { _ = sp.Close() }()
+
+	for _, batchID := range []string{"b1", "b2", "b3"} {
+		require.NoError(t, h.spoolPayload(sp, MetricsPayload{BatchID: batchID, PollCount: 1}))
+	}
+
+	ctx := context.Background()
+	assert.Error(t, h.drainSpool(ctx, sp))
+	assert.Equal(t, 3, sp.Stats().Depth, "неотправленные отчёты остаются в очереди")
+
+	failing = false
+	require.NoError(t, h.drainSpool(ctx, sp))
+	assert.Equal(t, []string{"agent-1:b1", "agent-1:b2", "agent-1:b3"}, gotKeys)
+	assert.Equal(t, 0, sp.Stats().Depth)
+}
+
+func TestDrainSpool_DropsRejected(t *testing.T) {
+	client := setupTestClient()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodPost, "/updates", httpmock.NewStringResponder(http.StatusBadRequest, ""))
+
+	h := NewAgentHandler(
+		&config.AgentConfig{Batch: true, SpoolDir: t.TempDir()},
+		nil,
+		service.NewHTTPMetricSender(client.RestyClient),
+		client.Logger,
+	)
+	sp, err := h.openSpool()
+	require.NoError(t, err)
+	defer func() { _ = sp.Close() }()
+
+	require.NoError(t, h.spoolPayload(sp, MetricsPayload{BatchID: "b1"}))
+	require.NoError(t, h.drainSpool(context.Background(), sp))
+	assert.Equal(t, 0, sp.Stats().Depth, "отклонённый сервером отчёт не блокирует очередь")
+}
+
+func TestOnSpoolDrop_CarriesPollCount(t *testing.T) {
+	h := NewAgentHandler(&config.AgentConfig{}, nil, nil, zap.NewNop().Sugar())
+	h.onSpoolDrop([]byte(`{"PollCount":4}`))
+
+	var reported int64
+	payload := h.payload(collector.Snapshot{PollCount: 2}, &reported)
+	assert.Equal(t, int64(6), payload.PollCount, "приращение удалённого отчёта переносится в следующий")
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"metrics/internal/auth"
+	"metrics/internal/service"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// AdminHandler обработчики администрирования сервера.
+type AdminHandler struct {
+	credentials auth.Store
+	metrics     service.AdminService
+	logger      *zap.SugaredLogger
+}
+
+func NewAdminHandler(
+	credentials auth.Store,
+	metrics service.AdminService,
+	logger *zap.SugaredLogger,
+) *AdminHandler {
+	return &AdminHandler{
+		credentials: credentials,
+		metrics:     metrics,
+		logger:      logger,
+	}
+}
+
+// MetricRenameRequest тело запроса переименования метрики.
+type MetricRenameRequest struct {
+	// Новое имя метрики, метки серии сохраняются.
+	NewID string `json:"new_id"`
+}
+
+// RevokeAgentHandler .
+// @Summary Отзыв учётных данных агента
+// @Description Отзывает учётные данные агента без перезапуска сервера. Требуется право admin
+// @Tags Admin
+// @Param agentID path string true "Идентификатор агента"
+// @Param X-Agent-ID header string true "Идентификатор администратора"
+// @Param HashSHA256 header string true "Подпись тела запроса ключом администратора"
+// @Success 204 "Учётные данные отозваны"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Agent not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/agents/{agentID}/revoke [post].
+func (h *AdminHandler) RevokeAgentHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api RevokeAgentHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		agentID := chi.URLParam(request, "agentID")
+
+		err := h.credentials.Revoke(request.Context(), agentID)
+		if errors.Is(err, auth.ErrUnknownAgent) {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			handlerLogger.Infow("error revoke credentials", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var admin string
+		if credential, ok := auth.CredentialFromContext(request.Context()); ok {
+			admin = credential.AgentID
+		}
+		handlerLogger.Infow("Agent credentials revoked", "agent_id", agentID, "by", admin)
+		response.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeleteMetricHandler .
+// @Summary Удаление метрики
+// @Description Удаляет серию вместе с историей и записывает действие в журнал аудита. Требуется право admin
+// @Tags Admin
+// @Param metricType path string true "Тип метрики: counter, gauge, histogram или summary"
+// @Param metricName path string true "Имя метрики"
+// @Param label query []string false "Метка серии в виде name:value, можно повторять" collectionFormat(multi)
+// @Param X-Agent-ID header string true "Идентификатор администратора"
+// @Param HashSHA256 header string true "Подпись тела запроса ключом администратора"
+// @Success 204 "Метрика удалена"
+// @Failure 400 {string} string "Некорректный запрос"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Metric not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/metrics/{metricType}/{metricName} [delete].
+func (h *AdminHandler) DeleteMetricHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api DeleteMetricHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		metric, err := parseAdminMetric(request)
+		if err != nil {
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.writeAdminResult(response, handlerLogger, h.metrics.Delete(request.Context(), metric))
+	}
+}
+
+// ResetCounterHandler .
+// @Summary Обнуление counter
+// @Description Обнуляет counter, записывает нулевое значение в историю и действие в журнал аудита. Требуется право admin
+// @Tags Admin
+// @Param metricType path string true "Тип метрики, только counter"
+// @Param metricName path string true "Имя метрики"
+// @Param label query []string false "Метка серии в виде name:value, можно повторять" collectionFormat(multi)
+// @Param X-Agent-ID header string true "Идентификатор администратора"
+// @Param HashSHA256 header string true "Подпись тела запроса ключом администратора"
+// @Success 204 "Counter обнулён"
+// @Failure 400 {string} string "Некорректный запрос"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Metric not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/metrics/{metricType}/{metricName}/reset [post].
+func (h *AdminHandler) ResetCounterHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api ResetCounterHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		metric, err := parseAdminMetric(request)
+		if err != nil {
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.writeAdminResult(response, handlerLogger, h.metrics.ResetCounter(request.Context(), metric))
+	}
+}
+
+// RenameMetricHandler .
+// @Summary Переименование метрики
+// @Description Переносит серию вместе с историей под новое имя и записывает действие в журнал аудита. Требуется право admin
+// @Tags Admin
+// @Accept json
+// @Param metricType path string true "Тип метрики: counter, gauge, histogram или summary"
+// @Param metricName path string true "Имя метрики"
+// @Param label query []string false "Метка серии в виде name:value, можно повторять" collectionFormat(multi)
+// @Param request body MetricRenameRequest true "Новое имя метрики"
+// @Param X-Agent-ID header string true "Идентификатор администратора"
+// @Param HashSHA256 header string true "Подпись тела запроса ключом администратора"
+// @Success 204 "Метрика переименована"
+// @Failure 400 {string} string "Некорректный запрос"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Metric not found"
+// @Failure 409 {string} string "Metric with the new name already exists"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/metrics/{metricType}/{metricName}/rename [post].
+func (h *AdminHandler) RenameMetricHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api RenameMetricHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		metric, err := parseAdminMetric(request)
+		if err != nil {
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var body MetricRenameRequest
+		if err = json.NewDecoder(request.Body).Decode(&body); err != nil {
+			http.Error(response, "invalid json body", http.StatusBadRequest)
+			return
+		}
+
+		err = h.metrics.Rename(request.Context(), service.MetricsRenameRequest{
+			ID:     metric.ID,
+			MType:  metric.MType,
+			Labels: metric.Labels,
+			NewID:  body.NewID,
+		})
+		h.writeAdminResult(response, handlerLogger, err)
+	}
+}
+
+// writeAdminResult отвечает 204 или кодом, соответствующим ошибке сервиса.
+func (h *AdminHandler) writeAdminResult(response http.ResponseWriter, logger *zap.SugaredLogger, err error) {
+	switch {
+	case err == nil:
+		response.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, service.ErrInvalidAdminRequest):
+		http.Error(response, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrMetricNotFound):
+		http.Error(response, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrMetricExists):
+		http.Error(response, err.Error(), http.StatusConflict)
+	default:
+		logger.Infow("error in service", nameError, err)
+		response.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func parseAdminMetric(request *http.Request) (service.MetricsGetRequest, error) {
+	labels, err := parseLabels(request.URL.Query()["label"])
+	if err != nil {
+		return service.MetricsGetRequest{}, err
+	}
+	return service.MetricsGetRequest{
+		ID:     chi.URLParam(request, "metricName"),
+		MType:  chi.URLParam(request, "metricType"),
+		Labels: labels,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"metrics/internal/audit"
+	"metrics/internal/auth"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type revokeRecorder struct {
+	known   map[string]bool
+	revoked []string
+}
+
+func (s *revokeRecorder) Lookup(ctx context.Context, agentID string) (auth.Credential, error) {
+	return auth.Credential{}, auth.ErrUnknownAgent
+}
+
+func (s *revokeRecorder) Revoke(ctx context.Context, agentID string) error {
+	if !s.known[agentID] {
+		return auth.ErrUnknownAgent
+	}
+	s.revoked = append(s.revoked, agentID)
+	return nil
+}
+
+func TestRevokeAgentHandler(t *testing.T) {
+	store := &revokeRecorder{known: map[string]bool{"agent-1": true}}
+	router := chi.NewRouter()
+	router.Post("/admin/agents/{agentID}/revoke", NewAdminHandler(store, nil, zap.NewNop().Sugar()).RevokeAgentHandler())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/agents/agent-1/revoke", http.NoBody))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, []string{"agent-1"}, store.revoked)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/agents/ghost/revoke", http.NoBody))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+type auditRecorder struct {
+	entries []audit.Entry
+}
+
+func (r *auditRecorder) Record(ctx context.Context, entry audit.Entry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *auditRecorder) Close() error {
+	return nil
+}
+
+func TestAdminMetricHandlers(t *testing.T) {
+	ctx := context.Background()
+	memStorage, _ := repository.NewMemStorage()
+	require.NoError(t, memStorage.UpdateCounterAndGauges(ctx,
+		map[string]uint64{"PollCount": 5, "Requests": 1},
+		map[string]float64{repository.SeriesKey("CPUutilization8", map[string]string{"host": "web-8"}): 12.5}))
+	auditLog := &auditRecorder{}
+	adminService := service.NewAdminService(memStorage, nil, auditLog, zap.NewNop().Sugar())
+	handler := NewAdminHandler(nil, adminService, zap.NewNop().Sugar())
+
+	router := chi.NewRouter()
+	router.Route("/admin/metrics/{metricType}/{metricName}", func(r chi.Router) {
+		r.Delete("/", handler.DeleteMetricHandler())
+		r.Post("/reset", handler.ResetCounterHandler())
+		r.Post("/rename", handler.RenameMetricHandler())
+	})
+
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		body         string
+		expectedCode int
+	}{
+		{"Delete labeled gauge", http.MethodDelete, "/admin/metrics/gauge/CPUutilization8?label=host:web-8", "", http.StatusNoContent},
+		{"Delete missing gauge", http.MethodDelete, "/admin/metrics/gauge/CPUutilization8?label=host:web-8", "", http.StatusNotFound},
+		{"Delete with invalid label", http.MethodDelete, "/admin/metrics/gauge/CPUutilization8?label=host", "", http.StatusBadRequest},
+		{"Reset counter", http.MethodPost, "/admin/metrics/counter/PollCount/reset", "", http.StatusNoContent},
+		{"Reset gauge", http.MethodPost, "/admin/metrics/gauge/PollCount/reset", "", http.StatusBadRequest},
+		{"Rename counter", http.MethodPost, "/admin/metrics/counter/PollCount/rename", `{"new_id": "Polls"}`, http.StatusNoContent},
+		{"Rename to existing", http.MethodPost, "/admin/metrics/counter/Requests/rename", `{"new_id": "Polls"}`, http.StatusConflict},
+		{"Rename without body", http.MethodPost, "/admin/metrics/counter/Requests/rename", "", http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.expectedCode, rr.Code, rr.Body.String())
+		})
+	}
+
+	counters, err := memStorage.Counters(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"Polls": 0, "Requests": 1}, counters)
+	assert.Len(t, auditLog.entries, 6, "запросы, дошедшие до сервиса, попадают в аудит")
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"metrics/internal/alerting"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// AlertSource источник текущего состояния алертов.
+type AlertSource interface {
+	Alerts() []alerting.Alert
+}
+
+// AlertsHandler обработчики алертов.
+type AlertsHandler struct {
+	alerts AlertSource
+	logger *zap.SugaredLogger
+}
+
+func NewAlertsHandler(
+	alerts AlertSource,
+	logger *zap.SugaredLogger,
+) *AlertsHandler {
+	return &AlertsHandler{
+		alerts: alerts,
+		logger: logger,
+	}
+}
+
+// ListHandler возвращает состояние правил алертов.
+// @Summary Состояние алертов
+// @Description Возвращает все правила алертов с состоянием inactive, pending, firing или resolved, упорядоченные по имени
+// @Tags Alerts
+// @Produce json
+// @Success 200 {array} alerting.Alert
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /alerts [get].
+func (h *AlertsHandler) ListHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api AlertsListHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("Content-Type", "application/json")
+
+		resp, err := json.Marshal(h.alerts.Alerts())
+		if err != nil {
+			handlerLogger.Infow("error marshal json", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if _, err = response.Write(resp); err != nil {
+			handlerLogger.Infow("error write response", nameError, err)
+		}
+	}
+}
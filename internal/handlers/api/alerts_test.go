@@ -0,0 +1,37 @@
+package api
+
+import (
+	"metrics/internal/alerting"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type staticAlerts []alerting.Alert
+
+func (a staticAlerts) Alerts() []alerting.Alert {
+	return a
+}
+
+func TestAlertsListHandler(t *testing.T) {
+	value := 100.0
+	alerts := staticAlerts{
+		{Name: "LowMemory", Expr: "FreeMemory < 500MB for 2m", State: alerting.StatePending, Value: &value},
+		{Name: "NoPolls", Expr: "rate(PollCount) == 0 for 1m", State: alerting.StateInactive},
+	}
+	handler := NewAlertsHandler(alerts, zap.NewNop().Sugar()).ListHandler()
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/alerts", http.NoBody))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[
+		{"name":"LowMemory","expr":"FreeMemory < 500MB for 2m","state":"pending","value":100},
+		{"name":"NoPolls","expr":"rate(PollCount) == 0 for 1m","state":"inactive"}
+	]`, rr.Body.String())
+}
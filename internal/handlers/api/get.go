@@ -34,7 +34,9 @@ func (h *Handler) GetHandler() http.HandlerFunc {
 			return
 		}
 
-		if metricGetRequest.MType != "counter" && metricGetRequest.MType != "gauge" {
+		switch metricGetRequest.MType {
+		case service.MetricTypeCounter, service.MetricTypeGauge, service.MetricTypeHistogram, service.MetricTypeSummary:
+		default:
 			response.WriteHeader(http.StatusBadRequest)
 
 			return
@@ -83,7 +83,7 @@ func TestGetHandler(t *testing.T) {
 			tc.setupStorage(memStorage)
 
 			r := chi.NewRouter()
-			metricService := service.NewMetricService(memStorage, sugar)
+			metricService := service.NewMetricService(memStorage, nil, sugar)
 			apiHandler := NewHandler(metricService, sugar)
 			r.Post("/get", apiHandler.GetHandler())
 			srv := httptest.NewServer(r)
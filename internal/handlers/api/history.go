@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HistoryHandler .
+// @Summary История метрики
+// @Description Возвращает значения метрики за интервал времени
+// @Tags Json
+// @Produce json
+// @Param metricType path string true "Тип метрики (counter или gauge)"
+// @Param metricName path string true "Имя метрики"
+// @Param from query string false "Начало интервала (RFC3339 или unix-время в секундах)"
+// @Param to query string false "Конец интервала (RFC3339 или unix-время в секундах), по умолчанию текущее время"
+// @Param step query string false "Шаг прореживания (например 30s или число секунд)"
+// @Param label query []string false "Метка серии в виде name:value, можно повторять" collectionFormat(multi)
+// @Success 200 {object} service.MetricsHistoryResponse
+// @Failure 400 {string} string "Некорректный запрос"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /history/{metricType}/{metricName} [get].
+func (h *Handler) HistoryHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api HistoryHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		response.Header().Set("Content-Type", "application/json")
+
+		historyRequest, err := parseHistoryRequest(request)
+		if err != nil {
+			handlerLogger.Infow("Invalid history request", nameError, err)
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		result, err := h.metricService.History(ctx, historyRequest)
+		if err != nil {
+			if errors.Is(err, service.ErrMetricNotFound) {
+				response.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			handlerLogger.Infow("error in service", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		resp, err := json.Marshal(result)
+		if err != nil {
+			handlerLogger.Infow("error marshal json", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_, err = response.Write(resp)
+		if err != nil {
+			handlerLogger.Infow("error write response", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func parseHistoryRequest(request *http.Request) (service.MetricsHistoryRequest, error) {
+	query := request.URL.Query()
+	historyRequest := service.MetricsHistoryRequest{
+		ID:    chi.URLParam(request, "metricName"),
+		MType: chi.URLParam(request, "metricType"),
+	}
+
+	var err error
+	if historyRequest.From, err = parseTime(query.Get("from")); err != nil {
+		return historyRequest, fmt.Errorf("invalid from: %w", err)
+	}
+	if historyRequest.To, err = parseTime(query.Get("to")); err != nil {
+		return historyRequest, fmt.Errorf("invalid to: %w", err)
+	}
+	if !historyRequest.To.IsZero() && historyRequest.From.After(historyRequest.To) {
+		return historyRequest, errors.New("from is after to")
+	}
+	if historyRequest.Step, err = parseStep(query.Get("step")); err != nil {
+		return historyRequest, fmt.Errorf("invalid step: %w", err)
+	}
+	if historyRequest.Labels, err = parseLabels(query["label"]); err != nil {
+		return historyRequest, fmt.Errorf("invalid label: %w", err)
+	}
+
+	return historyRequest, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse time %q: %w", value, err)
+	}
+	return parsed, nil
+}
+
+func parseStep(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	var step time.Duration
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		step = time.Duration(seconds) * time.Second
+	} else if step, err = time.ParseDuration(value); err != nil {
+		return 0, fmt.Errorf("parse step %q: %w", value, err)
+	}
+	if step < 0 {
+		return 0, errors.New("step must not be negative")
+	}
+	return step, nil
+}
+
+// parseLabels разбирает метки вида name:value.
+func parseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(values))
+	for _, value := range values {
+		name, labelValue, ok := strings.Cut(value, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("label %q must be name:value", value)
+		}
+		labels[name] = labelValue
+	}
+	if err := repository.Labels(labels).Validate(); err != nil {
+		return nil, fmt.Errorf("validate labels: %w", err)
+	}
+	return labels, nil
+}
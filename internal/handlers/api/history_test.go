@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	repository2 "metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryHandler(t *testing.T) {
+	ctx := context.Background()
+	sugar := zap.NewNop().Sugar()
+	memStorage, _ := repository2.NewMemStorage()
+	_, _ = memStorage.SetGauge(ctx, "HeapAlloc", 10)
+	_, _ = memStorage.SetGauge(ctx, "HeapAlloc", 20)
+	_, _ = memStorage.SetGauge(ctx, repository2.SeriesKey("HeapAlloc", repository2.Labels{"host": "web-1"}), 30)
+
+	r := chi.NewRouter()
+	metricService := service.NewMetricService(memStorage, nil, sugar)
+	apiHandler := NewHandler(metricService, sugar)
+	r.Get("/history/{metricType}/{metricName}", apiHandler.HistoryHandler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	testCases := []struct {
+		name           string
+		path           string
+		expectedCode   int
+		expectedPoints int
+	}{
+		{name: "All samples", path: "/history/gauge/HeapAlloc", expectedCode: http.StatusOK, expectedPoints: 2},
+		{name: "Downsampled", path: "/history/gauge/HeapAlloc?from=0&step=1h", expectedCode: http.StatusOK, expectedPoints: 1},
+		{name: "Unknown metric", path: "/history/gauge/Unknown", expectedCode: http.StatusOK, expectedPoints: 0},
+		{name: "Invalid type", path: "/history/invalid/HeapAlloc", expectedCode: http.StatusBadRequest},
+		{name: "Invalid from", path: "/history/gauge/HeapAlloc?from=yesterday", expectedCode: http.StatusBadRequest},
+		{name: "From after to", path: "/history/gauge/HeapAlloc?from=200&to=100", expectedCode: http.StatusBadRequest},
+		{name: "Negative step", path: "/history/gauge/HeapAlloc?step=-5", expectedCode: http.StatusBadRequest},
+		{name: "Labeled series", path: "/history/gauge/HeapAlloc?label=host:web-1", expectedCode: http.StatusOK, expectedPoints: 1},
+		{name: "Invalid label", path: "/history/gauge/HeapAlloc?label=host", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := resty.New().R().Get(srv.URL + tc.path)
+			assert.NoError(t, err, "Error making HTTP request")
+			assert.Equal(t, tc.expectedCode, resp.StatusCode(), "Unexpected status code")
+
+			if tc.expectedCode == http.StatusOK {
+				var history service.MetricsHistoryResponse
+				assert.NoError(t, json.Unmarshal(resp.Body(), &history))
+				assert.Len(t, history.Points, tc.expectedPoints)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ListHandler возвращает все метрики в JSON.
+// @Summary Список метрик в JSON
+// @Description Возвращает текущие значения всех серий, упорядоченные по имени, типу и меткам
+// @Tags Json
+// @Produce json
+// @Success 200 {array} service.MetricsResponse
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/metrics [get].
+func (h *Handler) ListHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api ListHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("Content-Type", "application/json")
+
+		resp, err := json.Marshal(h.metricService.GetMetrics(request.Context()).Metrics())
+		if err != nil {
+			handlerLogger.Infow("error marshal json", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if _, err = response.Write(resp); err != nil {
+			handlerLogger.Infow("error write response", nameError, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	repository2 "metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestListHandler(t *testing.T) {
+	ctx := context.Background()
+	sugar := zap.NewNop().Sugar()
+	memStorage, _ := repository2.NewMemStorage()
+	_, err := memStorage.SetGauge(ctx, repository2.SeriesKey("Alloc", repository2.Labels{"host": "web-2"}), 2.5)
+	require.NoError(t, err)
+	_, err = memStorage.SetGauge(ctx, repository2.SeriesKey("Alloc", repository2.Labels{"host": "web-1"}), 1.5)
+	require.NoError(t, err)
+	_, err = memStorage.SetCounter(ctx, "PollCount", 7)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/api/metrics", NewHandler(service.NewMetricService(memStorage, nil, sugar), sugar).ListHandler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := resty.New().R().Get(srv.URL + "/api/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[
+		{"id":"Alloc","type":"gauge","value":1.5,"labels":{"host":"web-1"}},
+		{"id":"Alloc","type":"gauge","value":2.5,"labels":{"host":"web-2"}},
+		{"id":"PollCount","type":"counter","delta":7}
+	]`, string(resp.Body()))
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"metrics/internal/otlp"
+	"metrics/internal/service"
+	"mime"
+	"net/http"
+
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+)
+
+var errUnsupportedContentType = errors.New("unsupported content type")
+
+// OTLPMetricsHandler принимает метрики OpenTelemetry по OTLP/HTTP.
+// @Summary Приём метрик OTLP
+// @Description Принимает ExportMetricsServiceRequest в protobuf или JSON и отвечает ExportMetricsServiceResponse в том же формате.
+// @Description Sum сохраняется как counter, Gauge — как gauge, гистограммы и summary отклоняются в partial_success.
+// @Tags OTLP
+// @Accept json
+// @Accept application/x-protobuf
+// @Produce json
+// @Produce application/x-protobuf
+// @Success 200 {string} string "ExportMetricsServiceResponse"
+// @Failure 400 {string} string "Некорректный запрос или метрики отклонены проверкой"
+// @Failure 415 {string} string "Неподдерживаемый формат"
+// @Failure 503 {string} string "Хранилище недоступно, запрос можно повторить"
+// @Router /v1/metrics [post].
+func (h *Handler) OTLPMetricsHandler(receiver *otlp.Receiver) http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api OTLPMetricsHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		contentType, err := otlpContentType(request.Header.Get("Content-Type"))
+		if err != nil {
+			handlerLogger.Infow("Unsupported content type", nameError, err)
+			response.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			handlerLogger.Infow("Failed to read body", nameError, err)
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var exportRequest collectormetricspb.ExportMetricsServiceRequest
+		if contentType == contentTypeJSON {
+			err = protojson.Unmarshal(body, &exportRequest)
+		} else {
+			err = proto.Unmarshal(body, &exportRequest)
+		}
+		if err != nil {
+			handlerLogger.Infow("Invalid OTLP request", nameError, err)
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		exportResponse, err := receiver.Export(request.Context(), &exportRequest)
+		if service.IsInvalidMetric(err) {
+			handlerLogger.Infow("metrics rejected", nameError, err)
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			handlerLogger.Infow("error in service", nameError, err)
+			response.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if partial := exportResponse.GetPartialSuccess(); partial != nil {
+			handlerLogger.Infow("OTLP data points rejected",
+				"rejected", partial.GetRejectedDataPoints(),
+				nameError, partial.GetErrorMessage(),
+			)
+		}
+
+		var resp []byte
+		if contentType == contentTypeJSON {
+			resp, err = protojson.Marshal(exportResponse)
+		} else {
+			resp, err = proto.Marshal(exportResponse)
+		}
+		if err != nil {
+			handlerLogger.Infow("error marshal response", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response.Header().Set("Content-Type", contentType)
+		response.WriteHeader(http.StatusOK)
+		if _, err = response.Write(resp); err != nil {
+			handlerLogger.Infow("error write response", nameError, err)
+		}
+	}
+}
+
+func otlpContentType(header string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return "", errUnsupportedContentType
+	}
+	switch mediaType {
+	case contentTypeProtobuf, contentTypeJSON:
+		return mediaType, nil
+	default:
+		return "", errUnsupportedContentType
+	}
+}
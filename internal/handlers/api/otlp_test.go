@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"metrics/internal/otlp"
+	repository2 "metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPMetricsHandler(t *testing.T) {
+	ctx := context.Background()
+	sugar := zap.NewNop().Sugar()
+	memStorage, _ := repository2.NewMemStorage()
+
+	metricService := service.NewMetricService(memStorage, nil, sugar)
+	r := chi.NewRouter()
+	r.Post("/v1/metrics", NewHandler(metricService, sugar).OTLPMetricsHandler(otlp.NewReceiver(metricService)))
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	body, err := proto.Marshal(&collectormetricspb.ExportMetricsServiceRequest{ResourceMetrics: []*metricspb.ResourceMetrics{{
+		ScopeMetrics: []*metricspb.ScopeMetrics{{Metrics: []*metricspb.Metric{{
+			Name: "temperature",
+			Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{{
+				Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 21.5},
+			}}}},
+		}}}},
+	}}})
+	require.NoError(t, err)
+
+	resp, err := resty.New().R().SetHeader("Content-Type", "application/x-protobuf").SetBody(body).Post(srv.URL + "/v1/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "application/x-protobuf", resp.Header().Get("Content-Type"))
+	var exportResponse collectormetricspb.ExportMetricsServiceResponse
+	require.NoError(t, proto.Unmarshal(resp.Body(), &exportResponse))
+
+	value, err := memStorage.GetGauge(ctx, "temperature")
+	require.NoError(t, err)
+	assert.Equal(t, 21.5, value)
+
+	jsonBody := `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{"name":"latency","histogram":{"dataPoints":[{"count":"2"}]}}]}]}]}`
+	resp, err = resty.New().R().SetHeader("Content-Type", "application/json").SetBody(jsonBody).Post(srv.URL + "/v1/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.JSONEq(t,
+		`{"partialSuccess":{"rejectedDataPoints":"1","errorMessage":"histogram \"latency\" is not supported"}}`,
+		string(resp.Body()))
+
+	invalidBody := `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{"name":"bad{name}","gauge":{"dataPoints":[{"asDouble":1}]}}]}]}]}`
+	resp, err = resty.New().R().SetHeader("Content-Type", "application/json").SetBody(invalidBody).Post(srv.URL + "/v1/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode(), "ошибка проверки метрик не повторяется")
+
+	resp, err = resty.New().R().SetHeader("Content-Type", "text/plain").SetBody("x").Post(srv.URL + "/v1/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode())
+
+	resp, err = resty.New().R().SetHeader("Content-Type", "application/json").SetBody("{").Post(srv.URL + "/v1/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+}
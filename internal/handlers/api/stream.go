@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"metrics/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamWriteTimeout время записи события, после которого клиент считается медленным и отключается.
+	streamWriteTimeout = 10 * time.Second
+	// streamKeepAlive интервал пустых сообщений SSE и ping WebSocket для прокси и проверки клиента.
+	streamKeepAlive = 15 * time.Second
+	// wsReadLimit максимальный размер сообщения от клиента WebSocket, сообщения клиента не используются.
+	wsReadLimit = 512
+)
+
+var upgrader = websocket.Upgrader{}
+
+// StreamHandler отправляет изменения метрик как Server-Sent Events.
+// @Summary Поток изменений метрик (SSE)
+// @Description Отправляет каждое принятое обновление метрики событием metric с текущим значением в JSON.
+// @Description Клиент, не успевающий читать события, получает событие error и отключается.
+// @Tags Stream
+// @Produce text/event-stream
+// @Param name query string false "Шаблон имени метрики (*, ?, [...])"
+// @Param type query string false "Тип метрики (counter, gauge, histogram или summary)"
+// @Success 200 {object} service.MetricEvent
+// @Failure 400 {string} string "Некорректный фильтр"
+// @Failure 503 {string} string "Поток недоступен"
+// @Router /stream [get].
+func (h *Handler) StreamHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api StreamHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		sub, ok := h.subscribe(response, request)
+		if !ok {
+			return
+		}
+		defer sub.Close()
+
+		controller := http.NewResponseController(response)
+		response.Header().Set("Content-Type", "text/event-stream")
+		response.Header().Set("Cache-Control", "no-cache")
+		response.Header().Set("X-Accel-Buffering", "no")
+		response.WriteHeader(http.StatusOK)
+
+		send := func(message string) error {
+			// Сервер может не поддерживать дедлайны записи, тогда запись ограничена только буфером подписки.
+			if err := controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil &&
+				!errors.Is(err, http.ErrNotSupported) {
+				return fmt.Errorf("set write deadline: %w", err)
+			}
+			if _, err := fmt.Fprint(response, message); err != nil {
+				return fmt.Errorf("write event: %w", err)
+			}
+			if err := controller.Flush(); err != nil {
+				return fmt.Errorf("flush event: %w", err)
+			}
+			return nil
+		}
+		if err := send(": connected\n\n"); err != nil {
+			handlerLogger.Infow("stream client disconnected", nameError, err)
+			return
+		}
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			var err error
+			select {
+			case <-request.Context().Done():
+				return
+			case <-keepAlive.C:
+				err = send(": ping\n\n")
+			case event, ok := <-sub.Events():
+				if !ok {
+					handlerLogger.Infow("stream subscriber disconnected", nameError, sub.Err())
+					if errors.Is(sub.Err(), service.ErrSlowSubscriber) {
+						_ = send(fmt.Sprintf("event: error\ndata: %s\n\n", sub.Err()))
+					}
+					return
+				}
+				var data []byte
+				data, err = json.Marshal(event)
+				if err != nil {
+					handlerLogger.Infow("error marshal json", nameError, err)
+					return
+				}
+				err = send(fmt.Sprintf("event: metric\ndata: %s\n\n", data))
+			}
+			if err != nil {
+				handlerLogger.Infow("stream client disconnected", nameError, err)
+				return
+			}
+		}
+	}
+}
+
+// WebSocketHandler отправляет изменения метрик сообщениями WebSocket.
+// @Summary Поток изменений метрик (WebSocket)
+// @Description Отправляет каждое принятое обновление метрики текстовым сообщением с текущим значением в JSON.
+// @Description Клиент, не успевающий читать события, отключается с кодом закрытия 1013.
+// @Tags Stream
+// @Param name query string false "Шаблон имени метрики (*, ?, [...])"
+// @Param type query string false "Тип метрики (counter, gauge, histogram или summary)"
+// @Success 101 {object} service.MetricEvent
+// @Failure 400 {string} string "Некорректный фильтр"
+// @Failure 503 {string} string "Поток недоступен"
+// @Router /ws [get].
+func (h *Handler) WebSocketHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api WebSocketHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		sub, ok := h.subscribe(response, request)
+		if !ok {
+			return
+		}
+		defer sub.Close()
+
+		conn, err := upgrader.Upgrade(response, request, nil)
+		if err != nil {
+			handlerLogger.Infow("websocket upgrade failed", nameError, err)
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		// Сообщения клиента не используются: чтение нужно для обработки pong и закрытия соединения.
+		closed := make(chan struct{})
+		conn.SetReadLimit(wsReadLimit)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * streamKeepAlive))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * streamKeepAlive))
+		})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-request.Context().Done():
+				return
+			case <-keepAlive.C:
+				err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+			case event, ok := <-sub.Events():
+				if !ok {
+					handlerLogger.Infow("websocket subscriber disconnected", nameError, sub.Err())
+					code := websocket.CloseGoingAway
+					if errors.Is(sub.Err(), service.ErrSlowSubscriber) {
+						code = websocket.CloseTryAgainLater
+					}
+					message := websocket.FormatCloseMessage(code, sub.Err().Error())
+					_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(streamWriteTimeout))
+					return
+				}
+				_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+				err = conn.WriteJSON(event)
+			}
+			if err != nil {
+				handlerLogger.Infow("websocket client disconnected", nameError, err)
+				return
+			}
+		}
+	}
+}
+
+// subscribe подписывает на изменения метрик по фильтрам name и type запроса.
+// При ошибке ответ уже записан.
+func (h *Handler) subscribe(response http.ResponseWriter, request *http.Request) (*service.Subscription, bool) {
+	query := request.URL.Query()
+	sub, err := h.metricService.Subscribe(service.WatchFilter{
+		Pattern: query.Get("name"),
+		MType:   query.Get("type"),
+	})
+	if err != nil {
+		h.logger.Infow("failed to subscribe", nameError, err)
+		if errors.Is(err, service.ErrInvalidFilter) {
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		http.Error(response, err.Error(), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return sub, true
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	repository2 "metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newStreamServer(t *testing.T) (*httptest.Server, service.MetricService, *service.Hub) {
+	t.Helper()
+	sugar := zap.NewNop().Sugar()
+	memStorage, err := repository2.NewMemStorage()
+	require.NoError(t, err)
+
+	hub := service.NewHub(16)
+	metricService := service.NewMetricService(memStorage, hub, sugar)
+	handler := NewHandler(metricService, sugar)
+	r := chi.NewRouter()
+	r.Get("/stream", handler.StreamHandler())
+	r.Get("/ws", handler.WebSocketHandler())
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, metricService, hub
+}
+
+func updateGauges(t *testing.T, metricService service.MetricService, names ...string) {
+	t.Helper()
+	metrics := make([]service.MetricsUpdateRequest, 0, len(names))
+	for _, name := range names {
+		value := 1.5
+		metrics = append(metrics, service.MetricsUpdateRequest{ID: name, MType: "gauge", Value: &value})
+	}
+	require.NoError(t, metricService.UpdateMultiple(context.Background(), metrics))
+}
+
+func TestStreamHandler(t *testing.T) {
+	srv, metricService, hub := newStreamServer(t)
+
+	resp, err := http.Get(srv.URL + "/stream?name=Heap*&type=gauge")
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": connected\n", line, "подписка создана до первого сообщения")
+
+	updateGauges(t, metricService, "Alloc", "HeapAlloc")
+
+	var lines []string
+	for len(lines) < 2 {
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, "event: metric", lines[0])
+	var event service.MetricEvent
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event))
+	assert.Equal(t, "HeapAlloc", event.ID, "события вне фильтра не отправляются")
+	assert.Equal(t, 1.5, *event.Value)
+
+	hub.Close()
+	_, err = reader.ReadString('\n')
+	for err == nil {
+		_, err = reader.ReadString('\n')
+	}
+	assert.Error(t, err, "поток завершается при закрытии хаба")
+
+	badResp, err := http.Get(srv.URL + "/stream?name=%5BHeap")
+	require.NoError(t, err)
+	_ = badResp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, badResp.StatusCode)
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	srv, metricService, hub := newStreamServer(t)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?type=gauge"
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	updateGauges(t, metricService, "Alloc")
+
+	var event service.MetricEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, "Alloc", event.ID)
+	assert.Equal(t, "gauge", event.MType)
+
+	hub.Close()
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "получено %v", err)
+
+	_, resp, err = websocket.DefaultDialer.Dial(url+"&name=%5B", nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	_ = resp.Body.Close()
+}
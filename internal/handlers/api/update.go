@@ -13,6 +13,7 @@ import (
 // @Accept  json
 // @Produce  json
 // @Param request body service.MetricsUpdateRequest true "Metrics Update Request"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом не применяется"
 // @Success 200 {object} string "Response with success status"
 // @Failure 400 {string} string "Invalid request"
 // @Failure 500 {string} string "Internal server error"
@@ -57,6 +57,11 @@ func TestUpdateHandler(t *testing.T) {
 			requestBody:  `{"id": "Unknown", "type": "unknown"}`,
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name:         "Negative Counter Delta",
+			requestBody:  `{"id": "PollCount", "type": "counter", "delta": -1}`,
+			expectedCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range successCases {
@@ -116,7 +121,7 @@ func setupHandler() (*chi.Mux, *Handler, repository2.MetricStorage) {
 	sugar := logger.Sugar()
 	memStorage, _ := repository2.NewMemStorage()
 	r := chi.NewRouter()
-	metricService := service.NewMetricService(memStorage, sugar)
+	metricService := service.NewMetricService(memStorage, nil, sugar)
 	apiHandler := NewHandler(metricService, sugar)
 
 	return r, apiHandler, memStorage
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"metrics/internal/lineprotocol"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var errNegativeCounter = errors.New("negative integer field cannot be a counter")
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// WriteHandler принимает метрики в формате InfluxDB line protocol.
+// @Summary Запись метрик InfluxDB
+// @Description Принимает точки InfluxDB line protocol (совместимо с /write v1 и /api/v2/write).
+// @Description Целые поля сохраняются как counter, дробные и логические — как gauge с именем measurement_field.
+// @Description Теги становятся метками серии, строковые поля пропускаются.
+// @Tags Influx
+// @Accept plain
+// @Param precision query string false "Точность временных меток: ns, us, ms, s (v1 также n, u, m, h)"
+// @Param request body string true "Строки line protocol"
+// @Success 204 {string} string "Точки записаны"
+// @Failure 400 {string} string "Некорректный запрос или метрики отклонены проверкой, ничего не записано"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /write [post].
+func (h *Handler) WriteHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "api WriteHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		precision, err := lineprotocol.ParsePrecision(request.URL.Query().Get("precision"))
+		if err != nil {
+			handlerLogger.Infow("Invalid precision", nameError, err)
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			handlerLogger.Infow("Failed to read body", nameError, err)
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		points, err := lineprotocol.Parse(body, precision, time.Now())
+		if err != nil {
+			handlerLogger.Infow("Invalid line protocol", nameError, err)
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		batches, err := pointBatches(points)
+		if err != nil {
+			handlerLogger.Infow("Invalid line protocol", nameError, err)
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Пакеты записываются по одному, поэтому все точки проверяются до записи первого пакета.
+		var metrics []service.MetricsUpdateRequest
+		for _, batch := range batches {
+			metrics = append(metrics, batch.metrics...)
+		}
+		err = h.metricService.Validate(ctx, metrics)
+		if service.IsInvalidMetric(err) {
+			handlerLogger.Infow("metrics rejected", nameError, err)
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			handlerLogger.Infow("error in service", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		for _, batch := range batches {
+			err = h.metricService.UpdateMultiple(repository.WithSampleTime(ctx, batch.at), batch.metrics)
+			if service.IsInvalidMetric(err) {
+				handlerLogger.Infow("metrics rejected", nameError, err)
+				http.Error(response, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				handlerLogger.Infow("error in service", nameError, err)
+				response.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		response.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type pointBatch struct {
+	at      time.Time
+	metrics []service.MetricsUpdateRequest
+}
+
+// pointBatches группирует обновления по времени точек, пакеты упорядочены по времени,
+// поэтому последним применяется самое новое значение gauge.
+func pointBatches(points []lineprotocol.Point) ([]pointBatch, error) {
+	byTime := make(map[time.Time]*pointBatch)
+	for _, point := range points {
+		at := point.Timestamp
+		batch, ok := byTime[at]
+		if !ok {
+			batch = &pointBatch{at: at}
+			byTime[at] = batch
+		}
+
+		labels := pointLabels(point.Tags)
+		for _, field := range point.Fields {
+			metric, ok, err := fieldMetric(point.Measurement+"_"+field.Key, field, labels)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", point.Measurement, field.Key, err)
+			}
+			if ok {
+				batch.metrics = append(batch.metrics, metric)
+			}
+		}
+	}
+
+	batches := make([]pointBatch, 0, len(byTime))
+	for _, batch := range byTime {
+		if len(batch.metrics) > 0 {
+			batches = append(batches, *batch)
+		}
+	}
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].at.Before(batches[j].at)
+	})
+	return batches, nil
+}
+
+// fieldMetric преобразует поле в обновление метрики. Строковые поля пропускаются.
+func fieldMetric(
+	id string,
+	field lineprotocol.Field,
+	labels map[string]string,
+) (service.MetricsUpdateRequest, bool, error) {
+	metric := service.MetricsUpdateRequest{ID: id, Labels: labels}
+	switch field.Kind {
+	case lineprotocol.FieldInteger:
+		if field.Integer < 0 {
+			return metric, false, errNegativeCounter
+		}
+		delta := field.Integer
+		metric.MType, metric.Delta = "counter", &delta
+	case lineprotocol.FieldUnsigned:
+		delta := int64(field.Unsigned)
+		if delta < 0 {
+			return metric, false, fmt.Errorf("unsigned field %d overflows counter", field.Unsigned)
+		}
+		metric.MType, metric.Delta = "counter", &delta
+	case lineprotocol.FieldFloat:
+		value := field.Float
+		metric.MType, metric.Value = "gauge", &value
+	case lineprotocol.FieldBool:
+		value := 0.0
+		if field.Bool {
+			value = 1
+		}
+		metric.MType, metric.Value = "gauge", &value
+	default:
+		return metric, false, nil
+	}
+	return metric, true, nil
+}
+
+// pointLabels переводит теги в метки, заменяя недопустимые в имени метки символы на '_'.
+func pointLabels(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(tags))
+	for key, value := range tags {
+		name := invalidLabelChars.ReplaceAllString(key, "_")
+		if name[0] >= '0' && name[0] <= '9' {
+			name = "_" + name
+		}
+		labels[name] = value
+	}
+	return labels
+}
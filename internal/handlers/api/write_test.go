@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	repository2 "metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWriteHandler(t *testing.T) {
+	ctx := context.Background()
+	sugar := zap.NewNop().Sugar()
+	memStorage, _ := repository2.NewMemStorage()
+
+	r := chi.NewRouter()
+	apiHandler := NewHandler(service.NewMetricService(memStorage, nil, sugar), sugar)
+	r.Post("/write", apiHandler.WriteHandler())
+	r.Post("/api/v2/write", apiHandler.WriteHandler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	body := "cpu,host=web-1,cpu-id=0 usage=12.5 1700000010\n" +
+		"cpu,host=web-1,cpu-id=0 usage=20 1700000020\n" +
+		"net,host=web-1 packets=5i,up=true,name=\"eth0\" 1700000010\n" +
+		"net,host=web-1 packets=7i 1700000020\n"
+	resp, err := resty.New().R().SetBody(body).Post(srv.URL + "/write?precision=s")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+	cpuKey := repository2.SeriesKey("cpu_usage", repository2.Labels{"host": "web-1", "cpu_id": "0"})
+	gauge, err := memStorage.GetGauge(ctx, cpuKey)
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, gauge, "последним применяется самое новое значение")
+
+	netLabels := repository2.Labels{"host": "web-1"}
+	counter, err := memStorage.GetCounter(ctx, repository2.SeriesKey("net_packets", netLabels))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12), counter)
+
+	up, err := memStorage.GetGauge(ctx, repository2.SeriesKey("net_up", netLabels))
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, up)
+
+	_, err = memStorage.GetGauge(ctx, repository2.SeriesKey("net_name", netLabels))
+	assert.Error(t, err, "строковые поля пропускаются")
+
+	history, err := memStorage.GaugeHistory(ctx, cpuKey, time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.True(t, history[0].Timestamp.Equal(time.Unix(1700000010, 0)))
+	assert.True(t, history[1].Timestamp.Equal(time.Unix(1700000020, 0)))
+
+	resp, err = resty.New().R().SetBody("mem free=1.5").Post(srv.URL + "/api/v2/write?org=o&bucket=b&precision=ns")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+	testCases := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "Invalid precision", path: "/write?precision=d", body: "cpu usage=1"},
+		{name: "Invalid line", path: "/write", body: "cpu usage"},
+		{name: "Negative counter", path: "/api/v2/write", body: "cpu errors=-1i"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := resty.New().R().SetBody(tc.body).Post(srv.URL + tc.path)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+		})
+	}
+}
+
+func TestWriteHandler_RejectsBeforeWriting(t *testing.T) {
+	ctx := context.Background()
+	sugar := zap.NewNop().Sugar()
+	memStorage, _ := repository2.NewMemStorage()
+	storage := repository2.NewTypeGuardStorage(memStorage)
+
+	r := chi.NewRouter()
+	r.Post("/write", NewHandler(service.NewMetricService(storage, nil, sugar), sugar).WriteHandler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	_, err := storage.SetCounter(ctx, "net_packets", repository2.MaxCounter-1)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{name: "Type conflict in later batch", body: "cpu usage=1 1700000010\ncpu usage=5i 1700000020\n"},
+		{name: "Counter overflow in later batch", body: "cpu usage=1 1700000010\nnet packets=2i 1700000020\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := resty.New().R().SetBody(tc.body).Post(srv.URL + "/write?precision=s")
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+
+			_, err = memStorage.GetGauge(ctx, "cpu_usage")
+			assert.Error(t, err, "ни один пакет не записан")
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	pbModel "metrics/internal/proto/v1/model"
+	"metrics/internal/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeleteMetric удаляет серию вместе с историей.
+func (s *MetricServer) DeleteMetric(
+	ctx context.Context,
+	req *pbModel.DeleteMetricRequest,
+) (*pbModel.AdminResponse, error) {
+	if s.adminService == nil {
+		return nil, errAdminDisabled
+	}
+	err := s.adminService.Delete(ctx, service.MetricsGetRequest{
+		ID:     req.GetId(),
+		MType:  req.GetType(),
+		Labels: req.GetLabels(),
+	})
+	return s.adminResult(err)
+}
+
+// ResetCounter обнуляет counter.
+func (s *MetricServer) ResetCounter(
+	ctx context.Context,
+	req *pbModel.ResetCounterRequest,
+) (*pbModel.AdminResponse, error) {
+	if s.adminService == nil {
+		return nil, errAdminDisabled
+	}
+	err := s.adminService.ResetCounter(ctx, service.MetricsGetRequest{
+		ID:     req.GetId(),
+		MType:  service.MetricTypeCounter,
+		Labels: req.GetLabels(),
+	})
+	return s.adminResult(err)
+}
+
+// RenameMetric переносит серию вместе с историей под новое имя.
+func (s *MetricServer) RenameMetric(
+	ctx context.Context,
+	req *pbModel.RenameMetricRequest,
+) (*pbModel.AdminResponse, error) {
+	if s.adminService == nil {
+		return nil, errAdminDisabled
+	}
+	err := s.adminService.Rename(ctx, service.MetricsRenameRequest{
+		ID:     req.GetId(),
+		MType:  req.GetType(),
+		Labels: req.GetLabels(),
+		NewID:  req.GetNewId(),
+	})
+	return s.adminResult(err)
+}
+
+// errAdminDisabled административные методы доступны только при проверке агентов по учётным данным,
+// как и маршруты /admin HTTP API.
+var errAdminDisabled = status.Error(codes.Unimplemented, "admin API requires agent credentials")
+
+// adminResult сопоставляет ошибку сервиса с кодом gRPC.
+func (s *MetricServer) adminResult(err error) (*pbModel.AdminResponse, error) {
+	switch {
+	case err == nil:
+		return &pbModel.AdminResponse{}, nil
+	case errors.Is(err, service.ErrInvalidAdminRequest):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrMetricNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrMetricExists):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	default:
+		s.logger.Infow("service error", "error", err)
+		return nil, status.Error(codes.Internal, "admin action failed")
+	}
+}
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"context"
+	pbModel "metrics/internal/proto/v1/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMetricServer_AdminMethods(t *testing.T) {
+	client, memStorage := startServer(t)
+	ctx := context.Background()
+
+	_, err := memStorage.SetCounter(ctx, `Requests{host="web-1"}`, 5)
+	require.NoError(t, err)
+	_, err = memStorage.SetGauge(ctx, "Alloc", 1)
+	require.NoError(t, err)
+	_, err = memStorage.SetGauge(ctx, "Heap", 2)
+	require.NoError(t, err)
+
+	_, err = client.ResetCounter(ctx, &pbModel.ResetCounterRequest{
+		Id:     proto.String("Requests"),
+		Labels: map[string]string{"host": "web-1"},
+	})
+	require.NoError(t, err)
+	metric, err := client.GetMetric(ctx, &pbModel.GetMetricRequest{
+		Id:     proto.String("Requests"),
+		Type:   proto.String("counter"),
+		Labels: map[string]string{"host": "web-1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), metric.GetDelta())
+
+	_, err = client.RenameMetric(ctx, &pbModel.RenameMetricRequest{
+		Id: proto.String("Alloc"), Type: proto.String("gauge"), NewId: proto.String("Heap"),
+	})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.RenameMetric(ctx, &pbModel.RenameMetricRequest{
+		Id: proto.String("Alloc"), Type: proto.String("gauge"), NewId: proto.String("AllocBytes"),
+	})
+	require.NoError(t, err)
+	_, err = client.GetMetric(ctx, &pbModel.GetMetricRequest{Id: proto.String("AllocBytes"), Type: proto.String("gauge")})
+	require.NoError(t, err)
+
+	_, err = client.DeleteMetric(ctx, &pbModel.DeleteMetricRequest{Id: proto.String("Heap"), Type: proto.String("gauge")})
+	require.NoError(t, err)
+	_, err = client.DeleteMetric(ctx, &pbModel.DeleteMetricRequest{Id: proto.String("Heap"), Type: proto.String("gauge")})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.DeleteMetric(ctx, &pbModel.DeleteMetricRequest{Id: proto.String("Heap"), Type: proto.String("timer")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestMetricServer_AdminDisabled(t *testing.T) {
+	server := NewServer(nil, nil, zap.NewNop().Sugar())
+	_, err := server.DeleteMetric(context.Background(), &pbModel.DeleteMetricRequest{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
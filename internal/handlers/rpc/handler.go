@@ -2,41 +2,45 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	pb "metrics/internal/proto/v1"
 	pbModel "metrics/internal/proto/v1/model"
+	"metrics/internal/repository"
 	"metrics/internal/service"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type MetricServer struct {
 	pb.UnimplementedMetricsServer
 	metricService service.MetricService
+	adminService  service.AdminService
 	logger        *zap.SugaredLogger
 }
 
-func NewServer(svc service.MetricService, logger *zap.SugaredLogger) *MetricServer {
+// NewServer создаёт обработчик gRPC. Расшифровка и проверка подписи запросов
+// выполняются перехватчиками (см. пакет interceptor). Без admin административные методы
+// отвечают Unimplemented.
+func NewServer(svc service.MetricService, admin service.AdminService, logger *zap.SugaredLogger) *MetricServer {
 	return &MetricServer{
 		metricService: svc,
+		adminService:  admin,
 		logger:        logger.With("component", "rpc MetricServer"),
 	}
 }
 
 func (s *MetricServer) SendMetrics(ctx context.Context, req *pbModel.MetricsRequest) (*pbModel.MetricsResponse, error) {
-	metrics := make([]service.MetricsUpdateRequest, 0, len(req.GetMetrics()))
-	for _, m := range req.GetMetrics() {
-		metrics = append(metrics, service.MetricsUpdateRequest{
-			Delta: ptr(m.GetDelta()),
-			Value: ptr(m.GetValue()),
-			ID:    m.GetId(),
-			MType: m.GetType(),
-		})
-	}
-
-	err := s.metricService.UpdateMultiple(ctx, metrics)
+	ctx = service.WithIdempotencyKey(ctx, req.GetIdempotencyKey())
+	err := s.metricService.UpdateMultiple(ctx, toUpdateRequests(req))
 	if err != nil {
 		s.logger.Infow("service error", "error", err)
+		if service.IsInvalidMetric(err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, fmt.Errorf("error update metrics: %w", err)
 	}
 
@@ -45,6 +49,154 @@ func (s *MetricServer) SendMetrics(ctx context.Context, req *pbModel.MetricsRequ
 	}, nil
 }
 
+// Статусы подтверждений StreamMetrics.
+const (
+	ackStatusOK       = "ok"
+	ackStatusRejected = "rejected"
+	ackStatusError    = "error"
+)
+
+// StreamMetrics применяет отчёты из потока по порядку и подтверждает каждый отдельно.
+// Ошибка отчёта не закрывает поток: агент решает по статусу подтверждения, повторять ли отчёт.
+func (s *MetricServer) StreamMetrics(stream pb.Metrics_StreamMetricsServer) error {
+	var sequence uint64
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("receive metrics: %w", err)
+		}
+		sequence++
+
+		ack := &pbModel.MetricsAck{
+			Sequence:       ptr(sequence),
+			IdempotencyKey: ptr(req.GetIdempotencyKey()),
+			Status:         ptr(ackStatusOK),
+		}
+		ctx := service.WithIdempotencyKey(stream.Context(), req.GetIdempotencyKey())
+		if err = s.metricService.UpdateMultiple(ctx, toUpdateRequests(req)); err != nil {
+			s.logger.Infow("service error", "sequence", sequence, "error", err)
+			ack.Status = ptr(ackStatusError)
+			if service.IsInvalidMetric(err) {
+				ack.Status = ptr(ackStatusRejected)
+			}
+			ack.Error = ptr(err.Error())
+		}
+
+		if err = stream.Send(ack); err != nil {
+			return fmt.Errorf("send ack: %w", err)
+		}
+	}
+}
+
+// Watch отправляет изменения метрик, пока клиент не отменит вызов.
+// Подписчик, не успевающий читать события, отключается с кодом ResourceExhausted,
+// при остановке сервера вызов завершается с кодом Unavailable.
+func (s *MetricServer) Watch(req *pbModel.WatchRequest, stream pb.Metrics_WatchServer) error {
+	sub, err := s.metricService.Subscribe(service.WatchFilter{
+		Prefix: req.GetPrefix(),
+		MType:  req.GetType(),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFilter) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				s.logger.Infow("watcher disconnected", "error", sub.Err())
+				if errors.Is(sub.Err(), service.ErrHubClosed) {
+					return status.Error(codes.Unavailable, "server is shutting down")
+				}
+				return status.Error(codes.ResourceExhausted, "watcher is too slow")
+			}
+			if err = stream.Send(toMetricEvent(event)); err != nil {
+				return fmt.Errorf("send event: %w", err)
+			}
+		}
+	}
+}
+
+// toUpdateRequests преобразует метрики запроса в запросы обновления сервиса.
+func toUpdateRequests(req *pbModel.MetricsRequest) []service.MetricsUpdateRequest {
+	metrics := make([]service.MetricsUpdateRequest, 0, len(req.GetMetrics()))
+	for _, m := range req.GetMetrics() {
+		// Поля delta и value имеют явное присутствие: незаданное поле не обновляет серию другого типа.
+		metrics = append(metrics, service.MetricsUpdateRequest{
+			Delta:        m.Delta,
+			Value:        m.Value,
+			ID:           m.GetId(),
+			MType:        m.GetType(),
+			Labels:       m.GetLabels(),
+			Histogram:    fromPbHistogram(m.GetHistogram()),
+			Summary:      fromPbSummary(m.GetSummary()),
+			Observations: m.GetObservations(),
+			Buckets:      m.GetBuckets(),
+		})
+	}
+	return metrics
+}
+
+func toMetricEvent(event service.MetricEvent) *pbModel.MetricEvent {
+	return &pbModel.MetricEvent{
+		Metric: &pbModel.Metric{
+			Id:        ptr(event.ID),
+			Type:      ptr(event.MType),
+			Delta:     event.Delta,
+			Value:     event.Value,
+			Labels:    event.Labels,
+			Histogram: toPbHistogram(event.Histogram),
+			Summary:   toPbSummary(event.Summary),
+		},
+		TimestampUnixNano: ptr(event.Timestamp.UnixNano()),
+	}
+}
+
+func fromPbHistogram(h *pbModel.Histogram) *repository.Histogram {
+	if h == nil {
+		return nil
+	}
+	return &repository.Histogram{Bounds: h.GetBounds(), Counts: h.GetCounts(), Sum: h.GetSum(), Count: h.GetCount()}
+}
+
+func toPbHistogram(h *repository.Histogram) *pbModel.Histogram {
+	if h == nil {
+		return nil
+	}
+	return &pbModel.Histogram{Bounds: h.Bounds, Counts: h.Counts, Sum: ptr(h.Sum), Count: ptr(h.Count)}
+}
+
+func fromPbSummary(s *pbModel.Summary) *repository.Summary {
+	if s == nil {
+		return nil
+	}
+	quantiles := make([]repository.Quantile, 0, len(s.GetQuantiles()))
+	for _, q := range s.GetQuantiles() {
+		quantiles = append(quantiles, repository.Quantile{Quantile: q.GetQuantile(), Value: q.GetValue()})
+	}
+	return &repository.Summary{Quantiles: quantiles, Sum: s.GetSum(), Count: s.GetCount()}
+}
+
+func toPbSummary(s *repository.Summary) *pbModel.Summary {
+	if s == nil {
+		return nil
+	}
+	quantiles := make([]*pbModel.Quantile, 0, len(s.Quantiles))
+	for _, q := range s.Quantiles {
+		quantiles = append(quantiles, &pbModel.Quantile{Quantile: ptr(q.Quantile), Value: ptr(q.Value)})
+	}
+	return &pbModel.Summary{Quantiles: quantiles, Sum: ptr(s.Sum), Count: ptr(s.Count)}
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
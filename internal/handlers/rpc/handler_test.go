@@ -0,0 +1,200 @@
+package rpc
+
+import (
+	"context"
+	"metrics/internal/audit"
+	pb "metrics/internal/proto/v1"
+	pbModel "metrics/internal/proto/v1/model"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func startServer(t *testing.T) (pb.MetricsClient, repository.MetricStorage) {
+	t.Helper()
+	memStorage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	logger := zap.NewNop().Sugar()
+	hub := service.NewHub(16)
+	metricService := service.NewMetricService(memStorage, hub, logger)
+	adminService := service.NewAdminService(memStorage, hub, audit.NewLoggerLog(logger), logger)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	pb.RegisterMetricsServer(grpcServer, NewServer(metricService, adminService, logger))
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return pb.NewMetricsClient(conn), memStorage
+}
+
+func gaugeRequest(id string, value float64, labels map[string]string) *pbModel.MetricsRequest {
+	return &pbModel.MetricsRequest{Metrics: []*pbModel.Metric{{
+		Id:     proto.String(id),
+		Type:   proto.String("gauge"),
+		Value:  proto.Float64(value),
+		Labels: labels,
+	}}}
+}
+
+func TestMetricServer_SendMetricsKeepsFieldPresence(t *testing.T) {
+	client, memStorage := startServer(t)
+	ctx := context.Background()
+
+	_, err := client.SendMetrics(ctx, gaugeRequest("Alloc", 1.5, nil))
+	require.NoError(t, err)
+
+	gauge, err := memStorage.GetGauge(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, gauge)
+	_, err = memStorage.GetCounter(ctx, "Alloc")
+	assert.Error(t, err, "gauge не создаёт counter с тем же именем")
+}
+
+func TestMetricServer_SendMetricsRejectsNegativeDelta(t *testing.T) {
+	client, memStorage := startServer(t)
+	ctx := context.Background()
+
+	_, err := client.SendMetrics(ctx, &pbModel.MetricsRequest{Metrics: []*pbModel.Metric{{
+		Id:    proto.String("PollCount"),
+		Type:  proto.String("counter"),
+		Delta: proto.Int64(-1),
+	}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = memStorage.GetCounter(ctx, "PollCount")
+	assert.Error(t, err)
+}
+
+func TestMetricServer_StreamMetrics(t *testing.T) {
+	client, memStorage := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamMetrics(ctx)
+	require.NoError(t, err)
+
+	first := gaugeRequest("Alloc", 1, nil)
+	first.IdempotencyKey = proto.String("agent:1")
+	require.NoError(t, stream.Send(first))
+	ack, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), ack.GetSequence())
+	assert.Equal(t, "agent:1", ack.GetIdempotencyKey())
+	assert.Equal(t, "ok", ack.GetStatus())
+
+	require.NoError(t, stream.Send(gaugeRequest("Alloc", 2, map[string]string{"bad-label": "x"})))
+	ack, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), ack.GetSequence())
+	assert.Equal(t, "rejected", ack.GetStatus())
+	assert.NotEmpty(t, ack.GetError())
+
+	require.NoError(t, stream.Send(gaugeRequest("Alloc", 3, nil)))
+	ack, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "ok", ack.GetStatus(), "поток продолжает работу после отклонения")
+
+	require.NoError(t, stream.CloseSend())
+	gauge, err := memStorage.GetGauge(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, gauge)
+}
+
+func TestMetricServer_Watch(t *testing.T) {
+	client, _ := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch, err := client.Watch(ctx, &pbModel.WatchRequest{Prefix: proto.String("Heap"), Type: proto.String("gauge")})
+	require.NoError(t, err)
+
+	// Подписка оформляется асинхронно, поэтому обновления повторяются до первого события.
+	events := make(chan *pbModel.MetricEvent, 1)
+	go func() {
+		event, recvErr := watch.Recv()
+		if recvErr == nil {
+			events <- event
+		}
+	}()
+
+	var event *pbModel.MetricEvent
+	require.Eventually(t, func() bool {
+		_, err = client.SendMetrics(ctx, gaugeRequest("Alloc", 1, nil))
+		require.NoError(t, err)
+		_, err = client.SendMetrics(ctx, gaugeRequest("HeapAlloc", 2, map[string]string{"host": "web-1"}))
+		require.NoError(t, err)
+		select {
+		case event = <-events:
+			return true
+		default:
+			return false
+		}
+	}, 3*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, "HeapAlloc", event.GetMetric().GetId())
+	assert.Equal(t, 2.0, event.GetMetric().GetValue())
+	assert.Equal(t, map[string]string{"host": "web-1"}, event.GetMetric().GetLabels())
+	assert.Positive(t, event.GetTimestampUnixNano())
+}
+
+func TestMetricServer_SendDistributions(t *testing.T) {
+	client, _ := startServer(t)
+	ctx := context.Background()
+
+	_, err := client.SendMetrics(ctx, &pbModel.MetricsRequest{Metrics: []*pbModel.Metric{
+		{
+			Id:           proto.String("RequestDuration"),
+			Type:         proto.String("histogram"),
+			Buckets:      []float64{0.1, 1},
+			Observations: []float64{0.05, 0.5},
+		},
+		{
+			Id:   proto.String("GCPause"),
+			Type: proto.String("summary"),
+			Summary: &pbModel.Summary{
+				Quantiles: []*pbModel.Quantile{{Quantile: proto.Float64(0.5), Value: proto.Float64(0.2)}},
+				Sum:       proto.Float64(4),
+				Count:     proto.Uint64(10),
+			},
+		},
+	}})
+	require.NoError(t, err)
+
+	histogram, err := client.GetMetric(ctx, &pbModel.GetMetricRequest{
+		Id:   proto.String("RequestDuration"),
+		Type: proto.String("histogram"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 1, 0}, histogram.GetHistogram().GetCounts())
+	assert.Equal(t, uint64(2), histogram.GetHistogram().GetCount())
+
+	summary, err := client.GetMetric(ctx, &pbModel.GetMetricRequest{Id: proto.String("GCPause"), Type: proto.String("summary")})
+	require.NoError(t, err)
+	assert.Equal(t, 0.2, summary.GetSummary().GetQuantiles()[0].GetValue())
+	assert.Equal(t, uint64(10), summary.GetSummary().GetCount())
+
+	list, err := client.ListMetrics(ctx, &pbModel.ListMetricsRequest{Type: proto.String("histogram")})
+	require.NoError(t, err)
+	require.Len(t, list.GetMetrics(), 1)
+	assert.Equal(t, "RequestDuration", list.GetMetrics()[0].GetId())
+}
@@ -0,0 +1,168 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	pbModel "metrics/internal/proto/v1/model"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+func knownMetricType(mtype string) bool {
+	switch mtype {
+	case service.MetricTypeCounter, service.MetricTypeGauge, service.MetricTypeHistogram, service.MetricTypeSummary:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetMetric возвращает текущее значение серии или NotFound.
+func (s *MetricServer) GetMetric(ctx context.Context, req *pbModel.GetMetricRequest) (*pbModel.Metric, error) {
+	if !knownMetricType(req.GetType()) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown metric type %q", req.GetType())
+	}
+
+	metric, err := s.metricService.Get(ctx, service.MetricsGetRequest{
+		ID:     req.GetId(),
+		MType:  req.GetType(),
+		Labels: req.GetLabels(),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrMetricNotFound) {
+			return nil, status.Error(codes.NotFound, "metric not found")
+		}
+		s.logger.Infow("service error", "error", err)
+		return nil, status.Error(codes.Internal, "failed to get metric")
+	}
+
+	return &pbModel.Metric{
+		Id:        ptr(metric.ID),
+		Type:      ptr(metric.MType),
+		Delta:     metric.Delta,
+		Value:     metric.Value,
+		Labels:    metric.Labels,
+		Histogram: toPbHistogram(metric.Histogram),
+		Summary:   toPbSummary(metric.Summary),
+	}, nil
+}
+
+// ListMetrics возвращает страницу метрик, упорядоченных по типу и ключу серии.
+// Токен страницы — позиция последней отданной серии, поэтому новые серии не сдвигают страницы.
+// Если хранилище недоступно, вызов завершается с кодом Unavailable, а не пустой страницей.
+func (s *MetricServer) ListMetrics(
+	ctx context.Context,
+	req *pbModel.ListMetricsRequest,
+) (*pbModel.ListMetricsResponse, error) {
+	if req.GetType() != "" && !knownMetricType(req.GetType()) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown metric type %q", req.GetType())
+	}
+	pageSize := int(req.GetPageSize())
+	if pageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page size must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	after, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+
+	data, err := s.metricService.ListMetrics(ctx)
+	if err != nil {
+		s.logger.Infow("service error", "error", err)
+		return nil, status.Error(codes.Unavailable, "failed to list metrics")
+	}
+	entries := listEntries(data, req.GetNamePrefix(), req.GetType())
+	start := sort.Search(len(entries), func(i int) bool {
+		return entries[i].position > after
+	})
+	end := min(start+pageSize, len(entries))
+
+	response := &pbModel.ListMetricsResponse{
+		Metrics: make([]*pbModel.Metric, 0, end-start),
+	}
+	for _, entry := range entries[start:end] {
+		response.Metrics = append(response.Metrics, entry.metric)
+	}
+	if end < len(entries) {
+		response.NextPageToken = ptr(encodePageToken(entries[end-1].position))
+	}
+	return response, nil
+}
+
+// listEntry метрика с позицией для постраничной выдачи.
+type listEntry struct {
+	metric   *pbModel.Metric
+	position string
+}
+
+func listEntries(data service.MetricsData, namePrefix, mtype string) []listEntry {
+	entries := make([]listEntry, 0, len(data.Counters)+len(data.Gauges)+len(data.Histograms)+len(data.Summaries))
+	add := func(mtype, key string, metric *pbModel.Metric) {
+		name, labels := repository.ParseSeriesKey(key)
+		if !strings.HasPrefix(name, namePrefix) {
+			return
+		}
+		metric.Id = ptr(name)
+		metric.Type = ptr(mtype)
+		metric.Labels = labels
+		entries = append(entries, listEntry{metric: metric, position: mtype + "\x00" + key})
+	}
+
+	if mtype == "" || mtype == service.MetricTypeCounter {
+		for key, value := range data.Counters {
+			add(service.MetricTypeCounter, key, &pbModel.Metric{Delta: ptr(int64(value))})
+		}
+	}
+	if mtype == "" || mtype == service.MetricTypeGauge {
+		for key, value := range data.Gauges {
+			add(service.MetricTypeGauge, key, &pbModel.Metric{Value: ptr(value)})
+		}
+	}
+	if mtype == "" || mtype == service.MetricTypeHistogram {
+		for key, value := range data.Histograms {
+			add(service.MetricTypeHistogram, key, &pbModel.Metric{Histogram: toPbHistogram(&value)})
+		}
+	}
+	if mtype == "" || mtype == service.MetricTypeSummary {
+		for key, value := range data.Summaries {
+			add(service.MetricTypeSummary, key, &pbModel.Metric{Summary: toPbSummary(&value)})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].position < entries[j].position
+	})
+	return entries
+}
+
+func encodePageToken(position string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	position, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("decode page token: %w", err)
+	}
+	return string(position), nil
+}
@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	pbModel "metrics/internal/proto/v1/model"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMetricServer_GetMetric(t *testing.T) {
+	client, memStorage := startServer(t)
+	ctx := context.Background()
+
+	_, err := memStorage.SetCounter(ctx, `PollCount{host="web-1"}`, 7)
+	require.NoError(t, err)
+
+	metric, err := client.GetMetric(ctx, &pbModel.GetMetricRequest{
+		Id:     proto.String("PollCount"),
+		Type:   proto.String("counter"),
+		Labels: map[string]string{"host": "web-1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), metric.GetDelta())
+	assert.Nil(t, metric.Value)
+
+	_, err = client.GetMetric(ctx, &pbModel.GetMetricRequest{Id: proto.String("PollCount"), Type: proto.String("counter")})
+	assert.Equal(t, codes.NotFound, status.Code(err), "серия без меток не найдена")
+
+	_, err = client.GetMetric(ctx, &pbModel.GetMetricRequest{Id: proto.String("PollCount"), Type: proto.String("timer")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestMetricServer_ListMetrics(t *testing.T) {
+	client, memStorage := startServer(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := memStorage.SetGauge(ctx, fmt.Sprintf("Heap%d", i), float64(i))
+		require.NoError(t, err)
+	}
+	_, err := memStorage.SetGauge(ctx, `Alloc{host="web-1"}`, 1)
+	require.NoError(t, err)
+	_, err = memStorage.SetCounter(ctx, "HeapCount", 3)
+	require.NoError(t, err)
+
+	var names []string
+	token := ""
+	pages := 0
+	for {
+		resp, listErr := client.ListMetrics(ctx, &pbModel.ListMetricsRequest{
+			NamePrefix: proto.String("Heap"),
+			Type:       proto.String("gauge"),
+			PageSize:   proto.Int32(2),
+			PageToken:  proto.String(token),
+		})
+		require.NoError(t, listErr)
+		pages++
+		for _, metric := range resp.GetMetrics() {
+			assert.Equal(t, "gauge", metric.GetType())
+			names = append(names, metric.GetId())
+		}
+		token = resp.GetNextPageToken()
+		if token == "" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"Heap0", "Heap1", "Heap2", "Heap3", "Heap4"}, names)
+	assert.Equal(t, 3, pages)
+
+	resp, err := client.ListMetrics(ctx, &pbModel.ListMetricsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetMetrics(), 7)
+	assert.Equal(t, "HeapCount", resp.GetMetrics()[0].GetId(), "counter идут перед gauge")
+	assert.Equal(t, "Alloc", resp.GetMetrics()[1].GetId())
+	assert.Equal(t, map[string]string{"host": "web-1"}, resp.GetMetrics()[1].GetLabels())
+	assert.Empty(t, resp.GetNextPageToken())
+
+	_, err = client.ListMetrics(ctx, &pbModel.ListMetricsRequest{PageToken: proto.String("%%%")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// unavailableStorage хранилище, чтение gauge из которого завершается ошибкой.
+type unavailableStorage struct {
+	repository.MetricStorage
+}
+
+func (unavailableStorage) Gauges(ctx context.Context) (map[string]float64, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestMetricServer_ListMetricsStorageUnavailable(t *testing.T) {
+	memStorage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	logger := zap.NewNop().Sugar()
+	server := NewServer(service.NewMetricService(unavailableStorage{memStorage}, nil, logger), nil, logger)
+
+	_, err = server.ListMetrics(context.Background(), &pbModel.ListMetricsRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err), "ошибка хранилища не выдаётся за пустой список")
+}
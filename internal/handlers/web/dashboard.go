@@ -0,0 +1,205 @@
+package web
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// dashboardFS шаблоны страниц и статические файлы панели, внешние CDN не используются.
+//
+//go:embed dashboard
+var dashboardFS embed.FS
+
+var dashboardTemplates = template.Must(template.ParseFS(dashboardFS, "dashboard/*.html"))
+
+// dashboardMetric строка таблицы панели и заголовок страницы метрики.
+type dashboardMetric struct {
+	// Имя метрики.
+	Name string
+	// Тип метрики: counter, gauge, histogram или summary.
+	Type string
+	// Значение для вывода.
+	Value string
+	// Ключ строки для обновлений из потока: тип, имя и метки.
+	Key string
+	// Ссылка на страницу метрики.
+	URL string
+	// Метки серии в JSON для запросов истории.
+	LabelsJSON string
+	// Метки в виде name=value, упорядоченные по имени.
+	Labels []string
+}
+
+func newDashboardMetric(metric service.MetricsResponse) (dashboardMetric, error) {
+	names := make([]string, 0, len(metric.Labels))
+	for name := range metric.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	labels := make([]string, 0, len(names))
+	query := url.Values{}
+	for _, name := range names {
+		labels = append(labels, name+"="+metric.Labels[name])
+		query.Add("label", name+":"+metric.Labels[name])
+	}
+
+	labelsJSON := []byte("{}")
+	if len(metric.Labels) > 0 {
+		var err error
+		if labelsJSON, err = json.Marshal(metric.Labels); err != nil {
+			return dashboardMetric{}, fmt.Errorf("marshal labels: %w", err)
+		}
+	}
+
+	link := "/dashboard/" + url.PathEscape(metric.MType) + "/" + url.PathEscape(metric.ID)
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+
+	return dashboardMetric{
+		Name:       metric.ID,
+		Type:       metric.MType,
+		Value:      formatValue(metric),
+		Key:        metric.MType + " " + metric.ID + " " + strings.Join(labels, ","),
+		URL:        link,
+		LabelsJSON: string(labelsJSON),
+		Labels:     labels,
+	}, nil
+}
+
+func formatValue(metric service.MetricsResponse) string {
+	switch {
+	case metric.Delta != nil:
+		return strconv.FormatInt(*metric.Delta, 10)
+	case metric.Value != nil:
+		return strconv.FormatFloat(*metric.Value, 'f', -1, 64)
+	case metric.Histogram != nil:
+		return formatDistribution(metric.Histogram.Count, metric.Histogram.Sum, nil)
+	case metric.Summary != nil:
+		return formatDistribution(metric.Summary.Count, metric.Summary.Sum, metric.Summary.Quantiles)
+	default:
+		return ""
+	}
+}
+
+// formatDistribution выводит число и сумму наблюдений и квантили в виде count=3 sum=1.5 q0.5=0.4.
+func formatDistribution(count uint64, sum float64, quantiles []repository.Quantile) string {
+	parts := make([]string, 0, len(quantiles)+2)
+	parts = append(parts,
+		"count="+strconv.FormatUint(count, 10),
+		"sum="+strconv.FormatFloat(sum, 'f', -1, 64))
+	for _, q := range quantiles {
+		parts = append(parts,
+			"q"+strconv.FormatFloat(q.Quantile, 'f', -1, 64)+"="+strconv.FormatFloat(q.Value, 'f', -1, 64))
+	}
+	return strings.Join(parts, " ")
+}
+
+// MetricPageHandler .
+// @Summary Страница метрики
+// @Description Показывает значение, метки и график истории одной серии
+// @Tags Info
+// @Produce  text/html
+// @Param metricType path string true "Тип метрики (counter, gauge, histogram или summary)"
+// @Param metricName path string true "Имя метрики"
+// @Param label query []string false "Метка серии в виде name:value, можно повторять" collectionFormat(multi)
+// @Success 200 {string} string "HTML страница метрики"
+// @Failure 400 {string} string "Неверный запрос"
+// @Failure 404 {string} string "Метрика не найдена"
+// @Failure 500 {string} string "Внутренняя ошибка сервера"
+// @Router /dashboard/{metricType}/{metricName} [get].
+func (h *Handler) MetricPageHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "web MetricPageHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		labels, err := parseLabels(request.URL.Query()["label"])
+		if err != nil {
+			handlerLogger.Infow("invalid labels", nameError, err)
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		metric, err := h.metricService.Get(request.Context(), service.MetricsGetRequest{
+			ID:     chi.URLParam(request, "metricName"),
+			MType:  chi.URLParam(request, "metricType"),
+			Labels: labels,
+		})
+		if err != nil {
+			if errors.Is(err, service.ErrMetricNotFound) {
+				response.WriteHeader(http.StatusNotFound)
+				return
+			}
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		page, err := newDashboardMetric(*metric)
+		if err != nil {
+			handlerLogger.Infow("error render metric", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		h.render(response, "metric.html", page)
+	}
+}
+
+// AssetsHandler отдаёт стили и скрипты панели из встроенных файлов. Список файлов каталога не отдаётся.
+func (h *Handler) AssetsHandler() http.Handler {
+	assets, err := fs.Sub(dashboardFS, "dashboard/assets")
+	if err != nil {
+		panic(fmt.Sprintf("dashboard assets: %v", err))
+	}
+	files := http.StripPrefix("/assets/", http.FileServer(http.FS(assets)))
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "/") {
+			http.NotFound(response, request)
+			return
+		}
+		files.ServeHTTP(response, request)
+	})
+}
+
+// render выполняет шаблон в буфер, чтобы при ошибке ответить кодом 500, а не обрезанной страницей.
+func (h *Handler) render(response http.ResponseWriter, name string, data any) {
+	var buf bytes.Buffer
+	if err := dashboardTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		h.logger.Infow("error render template", "template", name, nameError, err)
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := buf.WriteTo(response); err != nil {
+		h.logger.Infow("error write response", "template", name, nameError, err)
+	}
+}
+
+// parseLabels разбирает метки вида name:value.
+func parseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(values))
+	for _, value := range values {
+		name, labelValue, ok := strings.Cut(value, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("label %q must be name:value", value)
+		}
+		labels[name] = labelValue
+	}
+	return labels, nil
+}
@@ -0,0 +1,426 @@
+// Панель метрик: сортировка, поиск и фильтр таблицы, графики истории
+// и обновление значений через /stream (SSE) или опрос /api/metrics.
+(function () {
+  "use strict";
+
+  var POLL_INTERVAL = 10000;
+  var SPARK_POINTS = 60;
+  var SPARK_RANGE = 3600;
+
+  var status = document.getElementById("status");
+
+  function setStatus(text, live) {
+    status.textContent = text;
+    status.classList.toggle("live", live);
+  }
+
+  // seriesKey повторяет ключ строки, который формирует сервер: тип, имя и метки name=value по имени.
+  function seriesKey(type, name, labels) {
+    var pairs = Object.keys(labels || {}).sort().map(function (label) {
+      return label + "=" + labels[label];
+    });
+    return type + " " + name + " " + pairs.join(",");
+  }
+
+  // formatValue повторяет вывод сервера: для histogram и summary — count, sum и квантили.
+  function formatValue(metric) {
+    var distribution = metric.histogram || metric.summary;
+    if (!distribution) {
+      return String(metric.type === "counter" ? metric.delta : metric.value);
+    }
+    var parts = ["count=" + distribution.count, "sum=" + distribution.sum];
+    (distribution.quantiles || []).forEach(function (q) {
+      parts.push("q" + q.quantile + "=" + q.value);
+    });
+    return parts.join(" ");
+  }
+
+  // sparkValue значение для мини-графика: для распределений — число наблюдений.
+  function sparkValue(metric) {
+    var distribution = metric.histogram || metric.summary;
+    if (distribution) {
+      return distribution.count;
+    }
+    return metric.type === "counter" ? metric.delta : metric.value;
+  }
+
+  function pointValue(point) {
+    return point.value !== undefined ? point.value : point.delta;
+  }
+
+  function historyURL(type, name, labels, seconds) {
+    var from = Math.floor(Date.now() / 1000) - seconds;
+    var step = Math.max(1, Math.floor(seconds / SPARK_POINTS));
+    var params = ["from=" + from, "step=" + step];
+    Object.keys(labels).sort().forEach(function (label) {
+      params.push("label=" + encodeURIComponent(label + ":" + labels[label]));
+    });
+    return "/history/" + encodeURIComponent(type) + "/" + encodeURIComponent(name) + "?" + params.join("&");
+  }
+
+  function fetchHistory(type, name, labels, seconds) {
+    return fetch(historyURL(type, name, labels, seconds)).then(function (response) {
+      if (!response.ok) {
+        throw new Error("history " + response.status);
+      }
+      return response.json();
+    }).then(function (history) {
+      return history.points || [];
+    });
+  }
+
+  var SVG = "http://www.w3.org/2000/svg";
+
+  function svgElement(name, attributes) {
+    var element = document.createElementNS(SVG, name);
+    Object.keys(attributes).forEach(function (key) {
+      element.setAttribute(key, attributes[key]);
+    });
+    return element;
+  }
+
+  // drawLine рисует значения в SVG размером width×height с отступом pad.
+  function drawLine(values, width, height, pad) {
+    var svg = svgElement("svg", {width: width, height: height, viewBox: "0 0 " + width + " " + height});
+    var min = Math.min.apply(null, values);
+    var max = Math.max.apply(null, values);
+    var span = max - min || 1;
+    var points = values.map(function (value, i) {
+      var x = pad + (values.length === 1 ? 0 : i * (width - 2 * pad) / (values.length - 1));
+      var y = height - pad - (value - min) * (height - 2 * pad) / span;
+      return x.toFixed(1) + "," + y.toFixed(1);
+    });
+    svg.appendChild(svgElement("polyline", {points: points.join(" ")}));
+    return {svg: svg, min: min, max: max};
+  }
+
+  // Подписка на изменения: SSE, а если поток недоступен — опрос JSON.
+  function subscribe(query, onMetric, poll) {
+    if (!window.EventSource) {
+      startPolling(poll);
+      return;
+    }
+    var opened = false;
+    var source = new EventSource("/stream" + query);
+    source.onopen = function () {
+      opened = true;
+      setStatus("онлайн", true);
+    };
+    source.addEventListener("metric", function (event) {
+      onMetric(JSON.parse(event.data));
+    });
+    source.onerror = function () {
+      if (source.readyState === EventSource.CLOSED || !opened) {
+        source.close();
+        startPolling(poll);
+        return;
+      }
+      setStatus("переподключение…", false);
+    };
+  }
+
+  function startPolling(poll) {
+    setStatus("обновление каждые " + POLL_INTERVAL / 1000 + " с", false);
+    window.setInterval(poll, POLL_INTERVAL);
+  }
+
+  function initDashboard() {
+    var table = document.getElementById("metrics");
+    var tbody = table.tBodies[0];
+    var search = document.getElementById("search");
+    var typeFilter = document.getElementById("type-filter");
+    var count = document.getElementById("count");
+    var empty = document.getElementById("empty");
+    var sortKey = "name";
+    var sortDir = 1;
+    // Значения графиков по ключу строки.
+    var sparks = {};
+
+    function rows() {
+      return Array.prototype.slice.call(tbody.rows);
+    }
+
+    function rowLabels(row) {
+      return JSON.parse(row.dataset.labels || "{}");
+    }
+
+    function sortValue(row, key) {
+      switch (key) {
+        case "value":
+          return parseFloat(row.querySelector(".value").textContent.replace(/^count=/, ""));
+        case "labels":
+          return row.cells[2].textContent;
+        default:
+          return row.dataset[key];
+      }
+    }
+
+    function applySort() {
+      var sorted = rows().sort(function (a, b) {
+        var x = sortValue(a, sortKey);
+        var y = sortValue(b, sortKey);
+        if (x < y) {
+          return -sortDir;
+        }
+        if (x > y) {
+          return sortDir;
+        }
+        return a.dataset.key < b.dataset.key ? -1 : 1;
+      });
+      sorted.forEach(function (row) {
+        tbody.appendChild(row);
+      });
+    }
+
+    function applyFilter() {
+      var text = search.value.trim().toLowerCase();
+      var type = typeFilter.value;
+      var visible = 0;
+      rows().forEach(function (row) {
+        var haystack = (row.dataset.name + " " + row.cells[2].textContent).toLowerCase();
+        var show = (!type || row.dataset.type === type) && haystack.indexOf(text) !== -1;
+        row.hidden = !show;
+        if (show) {
+          visible++;
+        }
+      });
+      count.textContent = visible + " из " + tbody.rows.length;
+      empty.hidden = tbody.rows.length > 0;
+    }
+
+    function drawSpark(row) {
+      var values = sparks[row.dataset.key];
+      var cell = row.querySelector(".spark");
+      cell.textContent = "";
+      if (values && values.length > 1) {
+        cell.appendChild(drawLine(values, 120, 24, 2).svg);
+      }
+    }
+
+    function loadSpark(row) {
+      fetchHistory(row.dataset.type, row.dataset.name, rowLabels(row), SPARK_RANGE).then(function (points) {
+        sparks[row.dataset.key] = points.map(pointValue).slice(-SPARK_POINTS);
+        drawSpark(row);
+      }).catch(function () {
+        // История может быть недоступна для серии, тогда график не рисуется.
+      });
+    }
+
+    function addRow(metric, key) {
+      var row = tbody.insertRow();
+      var pairs = Object.keys(metric.labels || {}).sort();
+      var link = "/dashboard/" + encodeURIComponent(metric.type) + "/" + encodeURIComponent(metric.id);
+      if (pairs.length) {
+        link += "?" + pairs.map(function (label) {
+          return "label=" + encodeURIComponent(label + ":" + metric.labels[label]);
+        }).join("&");
+      }
+
+      row.dataset.key = key;
+      row.dataset.name = metric.id;
+      row.dataset.type = metric.type;
+      row.dataset.labels = JSON.stringify(metric.labels || {});
+
+      var anchor = document.createElement("a");
+      anchor.href = link;
+      anchor.textContent = metric.id;
+      row.insertCell().appendChild(anchor);
+
+      var type = document.createElement("span");
+      type.className = "type type-" + metric.type;
+      type.textContent = metric.type;
+      row.insertCell().appendChild(type);
+
+      var labelsCell = row.insertCell();
+      pairs.forEach(function (label) {
+        var span = document.createElement("span");
+        span.className = "label";
+        span.textContent = label + "=" + metric.labels[label];
+        labelsCell.appendChild(span);
+      });
+
+      row.insertCell().className = "num value";
+      row.insertCell().className = "spark";
+      return row;
+    }
+
+    function update(metric) {
+      var key = seriesKey(metric.type, metric.id, metric.labels);
+      var row = rows().filter(function (candidate) {
+        return candidate.dataset.key === key;
+      })[0];
+      if (!row) {
+        row = addRow(metric, key);
+        applySort();
+        applyFilter();
+      }
+
+      var cell = row.querySelector(".value");
+      cell.textContent = formatValue(metric);
+      cell.classList.remove("updated");
+      void cell.offsetWidth;
+      cell.classList.add("updated");
+
+      var values = sparks[key] || (sparks[key] = []);
+      values.push(sparkValue(metric));
+      if (values.length > SPARK_POINTS) {
+        values.shift();
+      }
+      drawSpark(row);
+      if (sortKey === "value") {
+        applySort();
+      }
+    }
+
+    function poll() {
+      fetch("/api/metrics").then(function (response) {
+        if (!response.ok) {
+          throw new Error("metrics " + response.status);
+        }
+        return response.json();
+      }).then(function (metrics) {
+        metrics.forEach(update);
+        setStatus("обновлено " + new Date().toLocaleTimeString(), false);
+      }).catch(function () {
+        setStatus("сервер недоступен", false);
+      });
+    }
+
+    Array.prototype.forEach.call(table.tHead.querySelectorAll("th[data-sort]"), function (th) {
+      th.addEventListener("click", function () {
+        var key = th.dataset.sort;
+        sortDir = key === sortKey ? -sortDir : 1;
+        sortKey = key;
+        Array.prototype.forEach.call(table.tHead.querySelectorAll("th"), function (other) {
+          other.removeAttribute("aria-sort");
+        });
+        th.setAttribute("aria-sort", sortDir > 0 ? "ascending" : "descending");
+        applySort();
+      });
+    });
+    search.addEventListener("input", applyFilter);
+    typeFilter.addEventListener("change", applyFilter);
+
+    applyFilter();
+    rows().forEach(loadSpark);
+    subscribe("", update, poll);
+  }
+
+  // globEscape экранирует символы шаблона, чтобы фильтр потока совпадал только с именем метрики.
+  function globEscape(name) {
+    return name.replace(/[\\*?[]/g, "\\$&");
+  }
+
+  function initMetric(root) {
+    var name = root.dataset.name;
+    var type = root.dataset.type;
+    var labels = JSON.parse(root.dataset.labels || "{}");
+    var key = root.dataset.key;
+    var valueCell = document.getElementById("value");
+    var chart = document.getElementById("chart");
+    var noHistory = document.getElementById("no-history");
+    var pointsBody = document.getElementById("points").tBodies[0];
+    var range = 3600;
+    var points = [];
+
+    function render() {
+      chart.textContent = "";
+      pointsBody.textContent = "";
+      noHistory.hidden = points.length > 0;
+      if (!points.length) {
+        return;
+      }
+
+      var width = Math.max(320, Math.min(chart.clientWidth || 800, 1000));
+      var line = drawLine(points.map(pointValue), width, 200, 24);
+      line.svg.insertBefore(svgElement("line", {x1: 24, y1: 24, x2: width - 24, y2: 24}), line.svg.firstChild);
+      line.svg.insertBefore(svgElement("line", {x1: 24, y1: 176, x2: width - 24, y2: 176}), line.svg.firstChild);
+      var maxLabel = svgElement("text", {x: 24, y: 16});
+      maxLabel.textContent = String(line.max);
+      var minLabel = svgElement("text", {x: 24, y: 194});
+      minLabel.textContent = String(line.min);
+      line.svg.appendChild(maxLabel);
+      line.svg.appendChild(minLabel);
+      chart.appendChild(line.svg);
+
+      points.slice(-20).reverse().forEach(function (point) {
+        var row = pointsBody.insertRow();
+        row.insertCell().textContent = new Date(point.timestamp).toLocaleString();
+        var value = row.insertCell();
+        value.className = "num";
+        value.textContent = String(pointValue(point));
+      });
+    }
+
+    function load() {
+      fetchHistory(type, name, labels, range).then(function (loaded) {
+        points = loaded;
+        render();
+      }).catch(function () {
+        points = [];
+        render();
+      });
+    }
+
+    function setValue(metric) {
+      var text = formatValue(metric);
+      if (valueCell.textContent === text) {
+        return;
+      }
+      valueCell.textContent = text;
+      valueCell.classList.remove("updated");
+      void valueCell.offsetWidth;
+      valueCell.classList.add("updated");
+    }
+
+    function update(metric) {
+      if (seriesKey(metric.type, metric.id, metric.labels) !== key) {
+        return;
+      }
+      setValue(metric);
+      points.push({timestamp: metric.timestamp, value: metric.value, delta: metric.delta});
+      render();
+    }
+
+    function poll() {
+      fetch("/api/metrics").then(function (response) {
+        if (!response.ok) {
+          throw new Error("metrics " + response.status);
+        }
+        return response.json();
+      }).then(function (metrics) {
+        metrics.forEach(function (metric) {
+          if (seriesKey(metric.type, metric.id, metric.labels) === key) {
+            setValue(metric);
+          }
+        });
+        setStatus("обновлено " + new Date().toLocaleTimeString(), false);
+        load();
+      }).catch(function () {
+        setStatus("сервер недоступен", false);
+      });
+    }
+
+    Array.prototype.forEach.call(document.querySelectorAll("#ranges button"), function (button) {
+      button.addEventListener("click", function () {
+        range = parseInt(button.dataset.range, 10);
+        Array.prototype.forEach.call(document.querySelectorAll("#ranges button"), function (other) {
+          other.classList.toggle("active", other === button);
+        });
+        load();
+      });
+    });
+
+    load();
+    subscribe("?name=" + encodeURIComponent(globEscape(name)) + "&type=" + encodeURIComponent(type), update, poll);
+  }
+
+  var dashboard = document.getElementById("dashboard");
+  if (dashboard) {
+    initDashboard();
+  }
+  var metric = document.getElementById("metric");
+  if (metric) {
+    initMetric(metric);
+  }
+})();
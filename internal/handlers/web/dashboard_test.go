@@ -0,0 +1,121 @@
+package web
+
+import (
+	"context"
+	"io/fs"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newDashboardServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ctx := context.Background()
+	sugar := zap.NewNop().Sugar()
+	memStorage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	_, err = memStorage.SetGauge(ctx, repository.SeriesKey("Alloc", repository.Labels{"host": "web-1"}), 1.5)
+	require.NoError(t, err)
+	_, err = memStorage.SetGauge(ctx, "<b>Heap</b>", 2)
+	require.NoError(t, err)
+	_, err = memStorage.SetCounter(ctx, "PollCount", 7)
+	require.NoError(t, err)
+
+	webHandler := NewHandler(service.NewMetricService(memStorage, nil, sugar), sugar)
+	r := chi.NewRouter()
+	r.Get("/", webHandler.ListHandler())
+	r.Get("/dashboard/{metricType}/{metricName}", webHandler.MetricPageHandler())
+	r.Handle("/assets/*", webHandler.AssetsHandler())
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestListHandler_Dashboard(t *testing.T) {
+	srv := newDashboardServer(t)
+
+	resp, err := resty.New().R().Get(srv.URL + "/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	body := string(resp.Body())
+
+	assert.Contains(t, body, `data-key="gauge Alloc host=web-1"`)
+	assert.Contains(t, body, `href="/dashboard/gauge/Alloc?label=host%3Aweb-1"`)
+	assert.Contains(t, body, `<span class="label">host=web-1</span>`)
+	assert.Less(t, strings.Index(body, `data-name="Alloc"`), strings.Index(body, `data-name="PollCount"`),
+		"строки упорядочены по имени")
+	assert.NotContains(t, body, "<b>Heap</b>", "имена метрик экранируются")
+	assert.Contains(t, body, `<script src="/assets/dashboard.js">`)
+}
+
+func TestMetricPageHandler(t *testing.T) {
+	srv := newDashboardServer(t)
+	client := resty.New()
+
+	resp, err := client.R().Get(srv.URL + "/dashboard/gauge/Alloc?label=host:web-1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header().Get("Content-Type"))
+	assert.Contains(t, string(resp.Body()), `<dd id="value" class="value big">1.5</dd>`)
+
+	resp, err = client.R().Get(srv.URL + "/dashboard/gauge/Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode(), "серия без меток не существует")
+
+	resp, err = client.R().Get(srv.URL + "/dashboard/gauge/Alloc?label=host")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+}
+
+func TestAssetsHandler(t *testing.T) {
+	srv := newDashboardServer(t)
+
+	resp, err := resty.New().R().Get(srv.URL + "/assets/dashboard.js")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Contains(t, resp.Header().Get("Content-Type"), "javascript")
+
+	resp, err = resty.New().R().Get(srv.URL + "/assets/index.html")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode(), "шаблоны не отдаются как файлы")
+
+	resp, err = resty.New().R().Get(srv.URL + "/assets/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode(), "список файлов не отдаётся")
+}
+
+func TestDashboard_NoExternalResources(t *testing.T) {
+	err := fs.WalkDir(dashboardFS, "dashboard", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(dashboardFS, path)
+		require.NoError(t, err)
+		for _, marker := range []string{`src="http`, `href="http`, "url(http", "@import", "fetch(\"http"} {
+			assert.NotContains(t, string(data), marker, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestFormatValue_Distributions(t *testing.T) {
+	histogram := repository.Histogram{Bounds: []float64{1}, Counts: []uint64{2, 1}, Sum: 3.5, Count: 3}
+	summary := repository.Summary{
+		Quantiles: []repository.Quantile{{Quantile: 0.5, Value: 0.2}, {Quantile: 0.99, Value: 1.5}},
+		Sum:       4,
+		Count:     10,
+	}
+
+	assert.Equal(t, "count=3 sum=3.5", formatValue(service.MetricsResponse{Histogram: &histogram}))
+	assert.Equal(t, "count=10 sum=4 q0.5=0.2 q0.99=1.5", formatValue(service.MetricsResponse{Summary: &summary}))
+}
@@ -1,7 +1,11 @@
 package web
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"metrics/internal/repository"
 	"metrics/internal/service"
 	"net/http"
 	"strconv"
@@ -11,12 +15,15 @@ import (
 
 // GetHandler .
 // @Summary Получение значения метрики
-// @Description Возвращает значение метрики по ее типу (counter или gauge) в формате текста
+// @Description Возвращает значение метрики по ее типу в формате текста. Для histogram и summary
+// @Description возвращается JSON с корзинами или квантилями, суммой и числом наблюдений.
+// @Description Без меток, если серии без меток нет, возвращается единственная серия с этим именем
 // @Tags Text
 // @Accept  text/plain
-// @Produce  text/plain
-// @Param metricType path string true "Тип метрики (counter или gauge)"
+// @Produce  text/plain,json
+// @Param metricType path string true "Тип метрики (counter, gauge, histogram или summary)"
 // @Param metricName path string true "Имя метрики"
+// @Param label query []string false "Метка серии в виде name:value, можно повторять" collectionFormat(multi)
 // @Success 200 {string} string "Метрика возвращена успешно"
 // @Failure 400 {string} string "Неверный запрос"
 // @Failure 404 {string} string "Метрика не найдена"
@@ -28,17 +35,29 @@ func (h *Handler) GetHandler() http.HandlerFunc {
 		ctx := request.Context()
 		response.Header().Set("Content-Type", "text/plain; charset=utf-8")
 
-		var metricGetRequest service.MetricsGetRequest
-
 		metricNameRequest := chi.URLParam(request, "metricName")
 		metricTypeRequest := chi.URLParam(request, "metricType")
+		labels, err := parseLabels(request.URL.Query()["label"])
+		if err != nil {
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-		metricGetRequest = service.MetricsGetRequest{
-			ID:    metricNameRequest,
-			MType: metricTypeRequest,
+		metricGetRequest := service.MetricsGetRequest{
+			ID:     metricNameRequest,
+			MType:  metricTypeRequest,
+			Labels: labels,
 		}
 
 		result, err := h.metricService.Get(ctx, metricGetRequest)
+		if errors.Is(err, service.ErrMetricNotFound) && len(labels) == 0 {
+			// Агент добавляет к метрикам метку host, поэтому запрос только по имени
+			// находит серию, если она единственная с этим именем.
+			if onlyLabels, ok := h.onlySeries(ctx, metricTypeRequest, metricNameRequest); ok {
+				metricGetRequest.Labels = onlyLabels
+				result, err = h.metricService.Get(ctx, metricGetRequest)
+			}
+		}
 		if err != nil {
 			if errors.Is(err, service.ErrMetricNotFound) {
 				response.WriteHeader(http.StatusNotFound)
@@ -49,13 +68,15 @@ func (h *Handler) GetHandler() http.HandlerFunc {
 			return
 		}
 
-		if metricTypeRequest == "counter" {
+		switch metricTypeRequest {
+		case service.MetricTypeCounter:
 			_, err = response.Write([]byte(strconv.Itoa(int(*result.Delta))))
-		}
-		if metricTypeRequest == "gauge" {
-			gaugeValue := result.Value
-			result := strconv.FormatFloat(*gaugeValue, 'g', -1, 64)
-			_, err = response.Write([]byte(result))
+		case service.MetricTypeGauge:
+			_, err = response.Write([]byte(strconv.FormatFloat(*result.Value, 'g', -1, 64)))
+		case service.MetricTypeHistogram:
+			err = writeDistribution(response, result.Histogram)
+		case service.MetricTypeSummary:
+			err = writeDistribution(response, result.Summary)
 		}
 
 		if err != nil {
@@ -65,3 +86,46 @@ func (h *Handler) GetHandler() http.HandlerFunc {
 		}
 	}
 }
+
+// onlySeries возвращает метки серии, если она единственная серия типа mtype с именем name.
+func (h *Handler) onlySeries(ctx context.Context, mtype, name string) (map[string]string, bool) {
+	data := h.metricService.GetMetrics(ctx)
+	var keys []string
+	switch mtype {
+	case service.MetricTypeCounter:
+		keys = seriesNamed(data.Counters, name)
+	case service.MetricTypeGauge:
+		keys = seriesNamed(data.Gauges, name)
+	case service.MetricTypeHistogram:
+		keys = seriesNamed(data.Histograms, name)
+	case service.MetricTypeSummary:
+		keys = seriesNamed(data.Summaries, name)
+	}
+	if len(keys) != 1 {
+		return nil, false
+	}
+	_, labels := repository.ParseSeriesKey(keys[0])
+	return labels, true
+}
+
+// seriesNamed возвращает ключи серий с именем name.
+func seriesNamed[V any](values map[string]V, name string) []string {
+	var keys []string
+	for key := range values {
+		if seriesName, _ := repository.ParseSeriesKey(key); seriesName == name {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// writeDistribution выводит гистограмму или summary в JSON.
+func writeDistribution(response http.ResponseWriter, value any) error {
+	resp, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal distribution: %w", err)
+	}
+	response.Header().Set("Content-Type", "application/json")
+	_, err = response.Write(resp)
+	return err
+}
@@ -41,7 +41,7 @@ func TestGetCounterHandler(t *testing.T) {
 				return
 			}
 			r := chi.NewRouter()
-			metricService := service.NewMetricService(memStorage, sugar)
+			metricService := service.NewMetricService(memStorage, nil, sugar)
 			webHandler := NewHandler(metricService, sugar)
 			r.Get("/value/{metricType}/{metricName}", webHandler.GetHandler())
 			srv := httptest.NewServer(r)
@@ -84,7 +84,7 @@ func TestGetCounterFailsHandler(t *testing.T) {
 		return
 	}
 	r := chi.NewRouter()
-	metricService := service.NewMetricService(memStorage, sugar)
+	metricService := service.NewMetricService(memStorage, nil, sugar)
 	webHandler := NewHandler(metricService, sugar)
 	r.Get("/value/{metricType}/{metricName}", webHandler.GetHandler())
 	srv := httptest.NewServer(r)
@@ -136,7 +136,7 @@ func TestGetGaugeHandler(t *testing.T) {
 				return
 			}
 			r := chi.NewRouter()
-			metricService := service.NewMetricService(memStorage, sugar)
+			metricService := service.NewMetricService(memStorage, nil, sugar)
 			webHandler := NewHandler(metricService, sugar)
 			r.Get("/value/{metricType}/{metricName}", webHandler.GetHandler())
 			srv := httptest.NewServer(r)
@@ -185,7 +185,7 @@ func TestGetGaugeFailHandler(t *testing.T) {
 				return
 			}
 			r := chi.NewRouter()
-			metricService := service.NewMetricService(memStorage, sugar)
+			metricService := service.NewMetricService(memStorage, nil, sugar)
 			webHandler := NewHandler(metricService, sugar)
 			r.Get("/value/{metricType}/{metricName}", webHandler.GetHandler())
 			srv := httptest.NewServer(r)
@@ -203,3 +203,77 @@ func TestGetGaugeFailHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGetHistogramHandler(t *testing.T) {
+	sugar := zap.NewNop().Sugar()
+	memStorage, _ := repository.NewMemStorage()
+	metricService := service.NewMetricService(memStorage, nil, sugar)
+	webHandler := NewHandler(metricService, sugar)
+	r := chi.NewRouter()
+	r.Post("/update/{metricType}/{metricName}/{metricValue}", webHandler.UpdateHandler())
+	r.Get("/value/{metricType}/{metricName}", webHandler.GetHandler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client := resty.New()
+	for _, observation := range []string{"0.2", "3"} {
+		resp, err := client.R().Post(srv.URL + "/update/histogram/RequestDuration/" + observation)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+	}
+
+	resp, err := client.R().Post(srv.URL + "/update/summary/GCPause/1")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode(), "summary не обновляется одним значением")
+
+	resp, err = client.R().Get(srv.URL + "/value/histogram/RequestDuration")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"bounds":[0.005,0.01,0.025,0.05,0.1,0.25,0.5,1,2.5,5,10],
+		"counts":[0,0,0,0,0,1,0,0,0,1,0,0],
+		"sum":3.2,
+		"count":2
+	}`, string(resp.Body()))
+}
+
+func TestGetLabeledSeriesHandler(t *testing.T) {
+	sugar := zap.NewNop().Sugar()
+	ctx := context.Background()
+	memStorage, _ := repository.NewMemStorage()
+	_, err := memStorage.SetGauge(ctx, `Alloc{host="web-1"}`, 1)
+	assert.NoError(t, err)
+	_, err = memStorage.SetGauge(ctx, `Heap{host="web-1"}`, 2)
+	assert.NoError(t, err)
+	_, err = memStorage.SetGauge(ctx, `Heap{host="web-2"}`, 3)
+	assert.NoError(t, err)
+
+	r := chi.NewRouter()
+	webHandler := NewHandler(service.NewMetricService(memStorage, nil, sugar), sugar)
+	r.Get("/value/{metricType}/{metricName}", webHandler.GetHandler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	testCases := []struct {
+		name         string
+		path         string
+		expectedBody string
+		expectedCode int
+	}{
+		{name: "single series without labels", path: "/value/gauge/Alloc", expectedBody: "1", expectedCode: http.StatusOK},
+		{name: "labels in query", path: "/value/gauge/Heap?label=host:web-2", expectedBody: "3", expectedCode: http.StatusOK},
+		{name: "ambiguous name", path: "/value/gauge/Heap", expectedCode: http.StatusNotFound},
+		{name: "invalid label", path: "/value/gauge/Heap?label=host", expectedCode: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := resty.New().R().Get(srv.URL + tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedCode, resp.StatusCode())
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, string(resp.Body()))
+			}
+		})
+	}
+}
@@ -15,7 +15,7 @@ func TestHealthHandler_Failure(t *testing.T) {
 	logger := zap.NewNop()
 	sugar := logger.Sugar()
 	memStorage, _ := repository.NewMemStorage()
-	metricService := service.NewMetricService(memStorage, sugar)
+	metricService := service.NewMetricService(memStorage, nil, sugar)
 	webHandler := NewHandler(metricService, sugar)
 	healthHandler := webHandler.HealthHandler("mock_dsn")
 
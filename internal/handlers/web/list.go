@@ -1,13 +1,13 @@
 package web
 
 import (
-	"html/template"
 	"net/http"
 )
 
 // ListHandler .
-// @Summary Список метрик
-// @Description Генерирует HTML-страницу с перечнем метрик (gauge и counter)
+// @Summary Панель метрик
+// @Description Генерирует HTML-страницу с таблицей метрик (gauge, counter, histogram и summary): сортировка, поиск, фильтр по типу,
+// @Description графики истории и обновление значений через /stream или /api/metrics
 // @Tags Info
 // @Produce  text/html
 // @Success 200 {string} string "HTML страница с метриками"
@@ -16,39 +16,18 @@ import (
 func (h *Handler) ListHandler() http.HandlerFunc {
 	handlerLogger := h.logger.With(nameLogger, "web ListHandler")
 	return func(response http.ResponseWriter, request *http.Request) {
-		ctx := request.Context()
-		response.Header().Set("Content-Type", "text/html; charset=utf-8")
+		metrics := h.metricService.GetMetrics(request.Context()).Metrics()
 
-		data := h.metricService.GetMetrics(ctx)
-
-		tmpl, err := template.New("metrics").Parse(`
-			<html>
-				<head><title>Metrics List</title></head>
-				<body>
-					<h1>Metrics</h1>
-					<ul>
-						{{range $name, $value := .Gauges}}
-							<li>{{$name}} (gauge): {{$value}}</li>
-						{{end}}
-						{{range $name, $value := .Counters}}
-							<li>{{$name}} (counter): {{$value}}</li>
-						{{end}}
-					</ul>
-				</body>
-			</html>
-		`)
-
-		if err != nil {
-			handlerLogger.Infow("error parse metrics", "error", err)
-			response.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		err = tmpl.Execute(response, data)
-		if err != nil {
-			handlerLogger.Infow("error parse metrics", "error", err)
-			response.WriteHeader(http.StatusInternalServerError)
-			return
+		rows := make([]dashboardMetric, 0, len(metrics))
+		for _, metric := range metrics {
+			row, err := newDashboardMetric(metric)
+			if err != nil {
+				handlerLogger.Infow("error render metrics", nameError, err)
+				response.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			rows = append(rows, row)
 		}
+		h.render(response, "index.html", rows)
 	}
 }
@@ -51,7 +51,7 @@ func TestListGaugeHandler(t *testing.T) {
 				return
 			}
 			r := chi.NewRouter()
-			metricService := service.NewMetricService(memStorage, sugar)
+			metricService := service.NewMetricService(memStorage, nil, sugar)
 			webHandler := NewHandler(metricService, sugar)
 			r.Get("/", webHandler.ListHandler())
 			srv := httptest.NewServer(r)
@@ -0,0 +1,31 @@
+package web
+
+import (
+	"metrics/internal/exposition"
+	"net/http"
+)
+
+// PrometheusHandler .
+// @Summary Метрики в формате Prometheus
+// @Description Возвращает все метрики в текстовом формате Prometheus или OpenMetrics (по заголовку Accept)
+// @Tags Info
+// @Produce plain
+// @Success 200 {string} string "Метрики в формате Prometheus"
+// @Failure 500 {string} string "Внутренняя ошибка сервера"
+// @Router /metrics [get].
+func (h *Handler) PrometheusHandler() http.HandlerFunc {
+	handlerLogger := h.logger.With(nameLogger, "web PrometheusHandler")
+	return func(response http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		format := exposition.Negotiate(request.Header.Get("Accept"))
+		response.Header().Set("Content-Type", format.ContentType())
+
+		data := h.metricService.GetMetrics(ctx)
+		err := exposition.Write(response, data.Gauges, data.Counters, data.Histograms, data.Summaries, format)
+		if err != nil {
+			handlerLogger.Infow("error write metrics", nameError, err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
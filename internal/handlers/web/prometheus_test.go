@@ -0,0 +1,64 @@
+package web
+
+import (
+	"context"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	ctx := context.Background()
+	sugar := zap.NewNop().Sugar()
+
+	memStorage, _ := repository.NewMemStorage()
+	_, err := memStorage.SetGauge(ctx, "Alloc", 12.5)
+	assert.NoError(t, err)
+	_, err = memStorage.SetCounter(ctx, "PollCount", 3)
+	assert.NoError(t, err)
+
+	r := chi.NewRouter()
+	webHandler := NewHandler(service.NewMetricService(memStorage, nil, sugar), sugar)
+	r.Get("/metrics", webHandler.PrometheusHandler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	testCases := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "text",
+			accept:              "text/plain",
+			expectedContentType: "text/plain; version=0.0.4; charset=utf-8",
+			expectedBody:        "# TYPE Alloc gauge\nAlloc 12.5\n# TYPE PollCount counter\nPollCount 3\n",
+		},
+		{
+			name:                "openmetrics",
+			accept:              "application/openmetrics-text; version=1.0.0",
+			expectedContentType: "application/openmetrics-text; version=1.0.0; charset=utf-8",
+			expectedBody:        "# TYPE Alloc gauge\nAlloc 12.5\n# TYPE PollCount counter\nPollCount_total 3\n# EOF\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := resty.New().R().
+				SetHeader("Accept", tc.accept).
+				Get(srv.URL + "/metrics")
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode())
+			assert.Equal(t, tc.expectedContentType, resp.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedBody, string(resp.Body()))
+		})
+	}
+}
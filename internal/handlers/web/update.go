@@ -10,11 +10,12 @@ import (
 
 // UpdateHandler .
 // @Summary Обновление значения метрики
-// @Description Обновляет значение метрики по её типу (counter или gauge) на основе переданных параметров
+// @Description Обновляет значение метрики по её типу (counter или gauge) на основе переданных параметров.
+// @Description Для histogram значение — одно наблюдение, раскладываемое по корзинам по умолчанию
 // @Tags Text
 // @Accept  text/plain
 // @Produce  text/plain
-// @Param metricType path string true "Тип метрики (counter или gauge)"
+// @Param metricType path string true "Тип метрики (counter, gauge или histogram)"
 // @Param metricName path string true "Имя метрики"
 // @Param metricValue path string true "Новое значение метрики"
 // @Success 200 {string} string "Метрика успешно обновлена"
@@ -57,6 +58,18 @@ func (h *Handler) UpdateHandler() http.HandlerFunc {
 				Value: &metricValue,
 			}
 		}
+		if metricTypeRequest == service.MetricTypeHistogram {
+			observation, err := strconv.ParseFloat(metricValueRequest, 64)
+			if err != nil {
+				response.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			metricUpdateRequest = service.MetricsUpdateRequest{
+				ID:           metricNameRequest,
+				MType:        metricTypeRequest,
+				Observations: []float64{observation},
+			}
+		}
 		_, err := h.metricService.Update(ctx, metricUpdateRequest)
 		if err != nil {
 			handlerLogger.Infow("error in service", "error", err)
@@ -36,7 +36,7 @@ func TestUpdateHandler(t *testing.T) {
 
 			memStorage, _ := repository.NewMemStorage()
 			r := chi.NewRouter()
-			metricService := service.NewMetricService(memStorage, sugar)
+			metricService := service.NewMetricService(memStorage, nil, sugar)
 			webHandler := NewHandler(metricService, sugar)
 			r.Post("/update/{metricType}/{metricName}/{metricValue}", webHandler.UpdateHandler())
 			srv := httptest.NewServer(r)
@@ -0,0 +1,299 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"metrics/internal/auth"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ScopePublic право для методов, доступных без проверки агента (например, health).
+const ScopePublic auth.Scope = ""
+
+// streamSignatureMaxAge допустимое расхождение времени подписи открытия потока с часами сервера.
+const streamSignatureMaxAge = 5 * time.Minute
+
+// Authenticator проверяет подписи вызовов так же, как HTTP-сервер.
+// С хранилищем учётных данных агент обязан передать x-agent-id и подпись своим ключом,
+// а права проверяются по таблице методов; методы вне таблицы требуют права admin.
+// Без хранилища используется общий ключ: вызов без подписи отклоняется, публичные методы не проверяются.
+type Authenticator struct {
+	credentials auth.Store
+	logger      *zap.SugaredLogger
+	scopes      map[string]auth.Scope
+	nonces      *nonceCache
+	sharedKey   string
+}
+
+func NewAuthenticator(
+	logger *zap.SugaredLogger,
+	sharedKey string,
+	credentials auth.Store,
+	scopes map[string]auth.Scope,
+) *Authenticator {
+	return &Authenticator{
+		credentials: credentials,
+		logger:      logger.With("interceptor", "Authenticator"),
+		scopes:      scopes,
+		nonces:      newNonceCache(2 * streamSignatureMaxAge),
+		sharedKey:   sharedKey,
+	}
+}
+
+// Unary проверяет подпись запроса — детерминированной сериализации сообщения (см. Sign).
+// В режиме общего ключа ответ подписывается в заголовке hashsha256.
+func (a *Authenticator) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		message, ok := req.(proto.Message)
+		if !ok {
+			return nil, status.Error(codes.Internal, "unexpected request type")
+		}
+		sign := func(key string) (string, error) {
+			return Sign(message, key)
+		}
+
+		if a.credentials != nil {
+			authCtx, err := a.authorize(ctx, info.FullMethod, sign)
+			if err != nil {
+				return nil, err
+			}
+			return handler(authCtx, req)
+		}
+
+		if err := a.checkSharedKey(ctx, info.FullMethod, sign); err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		if err != nil || a.sharedKey == "" {
+			return resp, err
+		}
+		if signErr := a.signResponse(ctx, resp); signErr != nil {
+			a.logger.Infow("failed to sign response", "method", info.FullMethod, "error", signErr)
+		}
+		return resp, nil
+	}
+}
+
+// Stream проверяет подпись открытия потока (см. SignStream): время подписи не старше
+// streamSignatureMaxAge, а nonce не использовался, поэтому перехваченную подпись нельзя повторить.
+// Входящие сообщения с полем signature проверяются по отдельности (см. SignStreamMessage),
+// иначе сообщения можно было бы подменить в уже открытом потоке.
+func (a *Authenticator) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		if a.public(info.FullMethod) || (a.credentials == nil && a.sharedKey == "") {
+			return handler(srv, ss)
+		}
+
+		timestamp := metadataValue(ctx, MetadataTimestamp)
+		nonce := metadataValue(ctx, MetadataNonce)
+		if err := a.checkFresh(info.FullMethod, timestamp, nonce); err != nil {
+			return err
+		}
+		sign := func(key string) (string, error) {
+			return SignStream(info.FullMethod, timestamp, nonce, key), nil
+		}
+
+		key := a.sharedKey
+		if a.credentials != nil {
+			authCtx, err := a.authorize(ctx, info.FullMethod, sign)
+			if err != nil {
+				return err
+			}
+			ctx = authCtx
+			if credential, ok := auth.CredentialFromContext(ctx); ok {
+				key = credential.Key
+			}
+		} else if err := a.checkSharedKey(ctx, info.FullMethod, sign); err != nil {
+			return err
+		}
+
+		// Nonce запоминается только после проверки подписи, иначе чужой запрос занял бы его.
+		if !a.nonces.add(nonce, time.Now()) {
+			a.logger.Infow("stream signature replayed", "method", info.FullMethod)
+			return status.Error(codes.Unauthenticated, "stream signature already used")
+		}
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			ctx:          ctx,
+			recv:         a.verifyMessages(info.FullMethod, nonce, key),
+		})
+	}
+}
+
+// signedMessage сообщение потока, которое агент подписывает по отдельности.
+type signedMessage interface {
+	proto.Message
+	GetSignature() string
+}
+
+// verifyMessages возвращает проверку подписей входящих сообщений потока. Номер сообщения растёт
+// с каждым полученным сообщением, RecvMsg одного потока не вызывается параллельно.
+func (a *Authenticator) verifyMessages(method, nonce, key string) func(m any) error {
+	var seq uint64
+	return func(m any) error {
+		message, ok := m.(signedMessage)
+		if !ok {
+			return nil
+		}
+		seq++
+		if message.GetSignature() == "" {
+			a.logger.Infow("missing stream message signature", "method", method, "seq", seq)
+			return status.Error(codes.Unauthenticated, "missing message signature")
+		}
+		sign := func(key string) (string, error) {
+			return SignStreamMessage(message, nonce, seq, key)
+		}
+		if !a.verify(method, sign, key, message.GetSignature()) {
+			a.logger.Infow("stream message signature mismatch", "method", method, "seq", seq)
+			return status.Error(codes.Unauthenticated, "invalid message signature")
+		}
+		return nil
+	}
+}
+
+// checkFresh проверяет, что время подписи открытия потока близко к часам сервера и nonce передан.
+func (a *Authenticator) checkFresh(method, timestamp, nonce string) error {
+	if timestamp == "" || nonce == "" {
+		a.logger.Infow("missing stream timestamp or nonce", "method", method)
+		return status.Error(codes.Unauthenticated, "missing stream timestamp or nonce")
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "invalid stream timestamp")
+	}
+	age := time.Since(time.Unix(seconds, 0))
+	if age > streamSignatureMaxAge || age < -streamSignatureMaxAge {
+		a.logger.Infow("stale stream signature", "method", method, "age", age)
+		return status.Error(codes.Unauthenticated, "stale stream signature")
+	}
+	return nil
+}
+
+// public сообщает, что метод доступен без проверки агента.
+func (a *Authenticator) public(method string) bool {
+	scope, ok := a.scopes[method]
+	return ok && scope == ScopePublic
+}
+
+func (a *Authenticator) authorize(
+	ctx context.Context,
+	method string,
+	sign func(key string) (string, error),
+) (context.Context, error) {
+	scope, ok := a.scopes[method]
+	if !ok {
+		scope = auth.ScopeAdmin
+	}
+	if scope == ScopePublic {
+		return ctx, nil
+	}
+
+	agentID := metadataValue(ctx, MetadataAgentID)
+	providedHash := metadataValue(ctx, MetadataHash)
+	if agentID == "" || providedHash == "" {
+		a.logger.Infow("missing agent id or signature", "method", method, "agent_id", agentID)
+		return nil, status.Error(codes.Unauthenticated, "missing agent id or signature")
+	}
+
+	credential, err := a.credentials.Lookup(ctx, agentID)
+	if err != nil {
+		if !errors.Is(err, auth.ErrUnknownAgent) && !errors.Is(err, auth.ErrRevoked) {
+			a.logger.Infow("error lookup credentials", "agent_id", agentID, "error", err)
+			return nil, status.Error(codes.Internal, "failed to check credentials")
+		}
+		a.logger.Infow("agent rejected", "agent_id", agentID, "error", err)
+		return nil, status.Error(codes.Unauthenticated, "agent rejected")
+	}
+
+	if !a.verify(method, sign, credential.Key, providedHash) {
+		a.logger.Infow("hash mismatch", "agent_id", agentID, "method", method)
+		return nil, status.Error(codes.Unauthenticated, "invalid signature")
+	}
+
+	if !credential.Allows(scope) {
+		a.logger.Infow("scope denied", "agent_id", agentID, "scope", scope, "method", method)
+		return nil, status.Error(codes.PermissionDenied, "forbidden")
+	}
+
+	return auth.WithCredential(ctx, credential), nil
+}
+
+func (a *Authenticator) checkSharedKey(ctx context.Context, method string, sign func(key string) (string, error)) error {
+	if a.sharedKey == "" || a.public(method) {
+		return nil
+	}
+	providedHash := metadataValue(ctx, MetadataHash)
+	if providedHash == "" {
+		a.logger.Infow("missing signature", "method", method)
+		return status.Error(codes.Unauthenticated, "missing signature")
+	}
+	if !a.verify(method, sign, a.sharedKey, providedHash) {
+		a.logger.Infow("hash mismatch", "method", method)
+		return status.Error(codes.InvalidArgument, "invalid signature")
+	}
+	return nil
+}
+
+func (a *Authenticator) verify(method string, sign func(key string) (string, error), key, providedHash string) bool {
+	expected, err := sign(key)
+	if err != nil {
+		a.logger.Infow("failed to sign request", "method", method, "error", err)
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(providedHash))
+}
+
+func (a *Authenticator) signResponse(ctx context.Context, resp any) error {
+	message, ok := resp.(proto.Message)
+	if !ok {
+		return errors.New("unexpected response type")
+	}
+	hash, err := Sign(message, a.sharedKey)
+	if err != nil {
+		return err
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(MetadataHash, hash)); err != nil {
+		return fmt.Errorf("set header: %w", err)
+	}
+	return nil
+}
+
+// nonceCache запоминает nonce подписей открытия потока на время, пока подпись считается свежей.
+type nonceCache struct {
+	seen map[string]time.Time
+	ttl  time.Duration
+	mu   sync.Mutex
+}
+
+func newNonceCache(ttl time.Duration) *nonceCache {
+	return &nonceCache{seen: make(map[string]time.Time), ttl: ttl}
+}
+
+// add запоминает nonce и возвращает false, если он уже использовался.
+func (c *nonceCache) add(nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for seen, at := range c.seen {
+		if now.Sub(at) > c.ttl {
+			delete(c.seen, seen)
+		}
+	}
+	if _, ok := c.seen[nonce]; ok {
+		return false
+	}
+	c.seen[nonce] = now
+	return true
+}
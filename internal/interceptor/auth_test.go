@@ -0,0 +1,301 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"metrics/internal/auth"
+	pbModel "metrics/internal/proto/v1/model"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const sendMethod = "/metrics.go.grpc.v1.Metrics/SendMetrics"
+
+type stubCredentialStore map[string]auth.Credential
+
+func (s stubCredentialStore) Lookup(ctx context.Context, agentID string) (auth.Credential, error) {
+	credential, ok := s[agentID]
+	if !ok {
+		return auth.Credential{}, auth.ErrUnknownAgent
+	}
+	if credential.Revoked {
+		return auth.Credential{}, auth.ErrRevoked
+	}
+	return credential, nil
+}
+
+func (s stubCredentialStore) Revoke(ctx context.Context, agentID string) error {
+	return nil
+}
+
+func signedContext(t *testing.T, req proto.Message, agentID, key string) context.Context {
+	t.Helper()
+	pairs := []string{}
+	if agentID != "" {
+		pairs = append(pairs, MetadataAgentID, agentID)
+	}
+	if key != "" {
+		hash, err := Sign(req, key)
+		require.NoError(t, err)
+		pairs = append(pairs, MetadataHash, hash)
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func TestAuthenticator_UnaryPerAgent(t *testing.T) {
+	store := stubCredentialStore{
+		"writer":  {AgentID: "writer", Key: "writer-key", Scopes: []auth.Scope{auth.ScopeWrite}},
+		"reader":  {AgentID: "reader", Key: "reader-key", Scopes: []auth.Scope{auth.ScopeRead}},
+		"revoked": {AgentID: "revoked", Key: "old-key", Scopes: []auth.Scope{auth.ScopeWrite}, Revoked: true},
+	}
+	authenticator := NewAuthenticator(zap.NewNop().Sugar(), "", store, map[string]auth.Scope{
+		sendMethod: auth.ScopeWrite,
+	})
+	unary := authenticator.Unary()
+
+	req := &pbModel.MetricsRequest{IdempotencyKey: proto.String("writer:1")}
+
+	tests := []struct {
+		ctx    context.Context
+		name   string
+		method string
+		code   codes.Code
+	}{
+		{name: "valid agent", ctx: signedContext(t, req, "writer", "writer-key"), method: sendMethod, code: codes.OK},
+		{name: "no metadata", ctx: context.Background(), method: sendMethod, code: codes.Unauthenticated},
+		{name: "no signature", ctx: signedContext(t, req, "writer", ""), method: sendMethod, code: codes.Unauthenticated},
+		{name: "unknown agent", ctx: signedContext(t, req, "ghost", "writer-key"), method: sendMethod, code: codes.Unauthenticated},
+		{name: "revoked agent", ctx: signedContext(t, req, "revoked", "old-key"), method: sendMethod, code: codes.Unauthenticated},
+		{name: "wrong key", ctx: signedContext(t, req, "writer", "other"), method: sendMethod, code: codes.Unauthenticated},
+		{name: "missing scope", ctx: signedContext(t, req, "reader", "reader-key"), method: sendMethod, code: codes.PermissionDenied},
+		{name: "unlisted method requires admin", ctx: signedContext(t, req, "writer", "writer-key"), method: "/metrics.go.grpc.v1.Metrics/Unknown", code: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAgent string
+			handler := func(ctx context.Context, req any) (any, error) {
+				credential, _ := auth.CredentialFromContext(ctx)
+				gotAgent = credential.AgentID
+				return &pbModel.MetricsResponse{}, nil
+			}
+
+			_, err := unary(tt.ctx, req, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.code == codes.OK {
+				assert.Equal(t, "writer", gotAgent, "учётные данные передаются обработчику")
+			}
+		})
+	}
+}
+
+func TestAuthenticator_PublicMethod(t *testing.T) {
+	authenticator := NewAuthenticator(zap.NewNop().Sugar(), "", stubCredentialStore{}, map[string]auth.Scope{
+		"/grpc.health.v1.Health/Check": ScopePublic,
+	})
+
+	called := false
+	handler := func(ctx context.Context, req any) (any, error) {
+		called = true
+		return &pbModel.MetricsResponse{}, nil
+	}
+	_, err := authenticator.Unary()(context.Background(), &pbModel.MetricsRequest{},
+		&grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	require.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestAuthenticator_UnarySharedKey(t *testing.T) {
+	authenticator := NewAuthenticator(zap.NewNop().Sugar(), "shared", nil, nil)
+	unary := authenticator.Unary()
+	info := &grpc.UnaryServerInfo{FullMethod: sendMethod}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return &pbModel.MetricsResponse{}, nil
+	}
+	req := &pbModel.MetricsRequest{IdempotencyKey: proto.String("agent:1")}
+
+	_, err := unary(signedContext(t, req, "", "wrong"), req, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "неверная подпись отклоняется")
+
+	_, err = unary(context.Background(), req, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "запрос без подписи отклоняется")
+
+	_, err = unary(signedContext(t, req, "", "shared"), req, info, handler)
+	assert.NoError(t, err)
+}
+
+func TestAuthenticator_StreamPerAgent(t *testing.T) {
+	store := stubCredentialStore{
+		"reader": {AgentID: "reader", Key: "reader-key", Scopes: []auth.Scope{auth.ScopeRead}},
+	}
+	const method = "/metrics.go.grpc.v1.Metrics/Watch"
+	stream := NewAuthenticator(zap.NewNop().Sugar(), "", store, map[string]auth.Scope{
+		method: auth.ScopeRead,
+	}).Stream()
+	info := &grpc.StreamServerInfo{FullMethod: method}
+	handler := func(srv any, ss grpc.ServerStream) error {
+		return nil
+	}
+
+	streamContext := func(pairs ...string) context.Context {
+		return metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs(append([]string{MetadataAgentID, "reader"}, pairs...)...))
+	}
+
+	signed, err := StreamMetadata(method, "reader-key")
+	require.NoError(t, err)
+	ctx := streamContext(signed...)
+	require.NoError(t, stream(nil, &fakeServerStream{ctx: ctx}, info, handler))
+
+	err = stream(nil, &fakeServerStream{ctx: ctx}, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "повтор подписи отклоняется")
+
+	other, err := StreamMetadata("/metrics.go.grpc.v1.Metrics/Other", "reader-key")
+	require.NoError(t, err)
+	err = stream(nil, &fakeServerStream{ctx: streamContext(other...)}, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "подпись другого метода не подходит")
+
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	ctx = streamContext(
+		MetadataTimestamp, stale,
+		MetadataNonce, "n1",
+		MetadataHash, SignStream(method, stale, "n1", "reader-key"),
+	)
+	err = stream(nil, &fakeServerStream{ctx: ctx}, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "устаревшая подпись отклоняется")
+}
+
+func TestAuthenticator_StreamSharedKey(t *testing.T) {
+	const method = "/metrics.go.grpc.v1.Metrics/StreamMetrics"
+	stream := NewAuthenticator(zap.NewNop().Sugar(), "shared", nil, nil).Stream()
+	info := &grpc.StreamServerInfo{FullMethod: method}
+	handler := func(srv any, ss grpc.ServerStream) error {
+		return nil
+	}
+
+	err := stream(nil, &fakeServerStream{ctx: context.Background()}, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "открытие без подписи отклоняется")
+
+	signed, err := StreamMetadata(method, "shared")
+	require.NoError(t, err)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(signed...))
+	assert.NoError(t, stream(nil, &fakeServerStream{ctx: ctx}, info, handler))
+}
+
+func TestAuthenticator_StreamMessageSignatures(t *testing.T) {
+	const method = "/metrics.go.grpc.v1.Metrics/StreamMetrics"
+	stream := NewAuthenticator(zap.NewNop().Sugar(), "shared", nil, nil).Stream()
+	info := &grpc.StreamServerInfo{FullMethod: method}
+
+	signedMessage := func(t *testing.T, nonce string, seq uint64, key, idempotencyKey string) *pbModel.MetricsRequest {
+		t.Helper()
+		req := &pbModel.MetricsRequest{IdempotencyKey: proto.String(idempotencyKey)}
+		signature, err := SignStreamMessage(req, nonce, seq, key)
+		require.NoError(t, err)
+		req.Signature = proto.String(signature)
+		return req
+	}
+	receiveAll := func(srv any, ss grpc.ServerStream) error {
+		for {
+			if err := ss.RecvMsg(&pbModel.MetricsRequest{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	tests := []struct {
+		messages func(nonce string) []*pbModel.MetricsRequest
+		name     string
+		code     codes.Code
+	}{
+		{
+			name: "signed in order",
+			messages: func(nonce string) []*pbModel.MetricsRequest {
+				return []*pbModel.MetricsRequest{
+					signedMessage(t, nonce, 1, "shared", "a:1"),
+					signedMessage(t, nonce, 2, "shared", "a:2"),
+				}
+			},
+			code: codes.OK,
+		},
+		{
+			name: "unsigned message",
+			messages: func(nonce string) []*pbModel.MetricsRequest {
+				return []*pbModel.MetricsRequest{{IdempotencyKey: proto.String("a:1")}}
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "replayed message",
+			messages: func(nonce string) []*pbModel.MetricsRequest {
+				first := signedMessage(t, nonce, 1, "shared", "a:1")
+				return []*pbModel.MetricsRequest{first, first}
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "message from another stream",
+			messages: func(nonce string) []*pbModel.MetricsRequest {
+				return []*pbModel.MetricsRequest{signedMessage(t, "other-nonce", 1, "shared", "a:1")}
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "tampered message",
+			messages: func(nonce string) []*pbModel.MetricsRequest {
+				req := signedMessage(t, nonce, 1, "shared", "a:1")
+				req.IdempotencyKey = proto.String("a:2")
+				return []*pbModel.MetricsRequest{req}
+			},
+			code: codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := StreamMetadata(method, "shared")
+			require.NoError(t, err)
+			md := metadata.Pairs(signed...)
+			ss := &fakeServerStream{
+				ctx:      metadata.NewIncomingContext(context.Background(), md),
+				messages: tt.messages(md.Get(MetadataNonce)[0]),
+			}
+
+			err = stream(nil, ss, info, receiveAll)
+			if tt.code == codes.OK {
+				assert.ErrorIs(t, err, io.EOF, "все сообщения приняты")
+				return
+			}
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	messages []*pbModel.MetricsRequest
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeServerStream) RecvMsg(m any) error {
+	if len(s.messages) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(proto.Message), s.messages[0])
+	s.messages = s.messages[1:]
+	return nil
+}
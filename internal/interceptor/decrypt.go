@@ -0,0 +1,76 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"metrics/internal/security"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// sealed сообщение, которое может быть передано в конверте (поле envelope).
+type sealed interface {
+	proto.Message
+	GetEnvelope() []byte
+}
+
+// UnaryDecrypt извлекает запрос из конверта. Если у сервера задан закрытый ключ,
+// незашифрованные запросы с полем envelope отклоняются. Без ключа перехватчик выключен.
+func UnaryDecrypt(logger *zap.SugaredLogger, privateKey *rsa.PrivateKey) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := openEnvelope(privateKey, req); err != nil {
+			logger.Infow("failed to decrypt request", "method", info.FullMethod, "error", err)
+			return nil, status.Error(codes.InvalidArgument, "failed to decrypt request")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamDecrypt извлекает из конвертов все входящие сообщения потока.
+func StreamDecrypt(logger *zap.SugaredLogger, privateKey *rsa.PrivateKey) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if privateKey == nil {
+			return handler(srv, ss)
+		}
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			recv: func(m any) error {
+				if err := openEnvelope(privateKey, m); err != nil {
+					logger.Infow("failed to decrypt message", "method", info.FullMethod, "error", err)
+					return status.Error(codes.InvalidArgument, "failed to decrypt message")
+				}
+				return nil
+			},
+		})
+	}
+}
+
+// openEnvelope заменяет содержимое сообщения расшифрованным содержимым конверта.
+func openEnvelope(privateKey *rsa.PrivateKey, msg any) error {
+	if privateKey == nil {
+		return nil
+	}
+	message, ok := msg.(sealed)
+	if !ok {
+		return nil
+	}
+	if len(message.GetEnvelope()) == 0 {
+		return security.ErrMalformedEnvelope
+	}
+
+	data, err := security.DecryptEnvelope(privateKey, message.GetEnvelope())
+	if err != nil {
+		return fmt.Errorf("decrypt envelope: %w", err)
+	}
+
+	proto.Reset(message)
+	if err := proto.Unmarshal(data, message); err != nil {
+		return fmt.Errorf("unmarshal envelope payload: %w", err)
+	}
+	return nil
+}
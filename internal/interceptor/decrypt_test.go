@@ -0,0 +1,52 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	pbModel "metrics/internal/proto/v1/model"
+	"metrics/internal/security"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUnaryDecrypt(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	plain := &pbModel.MetricsRequest{IdempotencyKey: proto.String("agent:1")}
+	data, err := proto.Marshal(plain)
+	require.NoError(t, err)
+	envelope, err := security.EncryptEnvelope(data, &privateKey.PublicKey)
+	require.NoError(t, err)
+
+	interceptor := UnaryDecrypt(zap.NewNop().Sugar(), privateKey)
+	info := &grpc.UnaryServerInfo{FullMethod: sendMethod}
+
+	var got *pbModel.MetricsRequest
+	handler := func(ctx context.Context, req any) (any, error) {
+		got, _ = req.(*pbModel.MetricsRequest)
+		return &pbModel.MetricsResponse{}, nil
+	}
+
+	_, err = interceptor(context.Background(), &pbModel.MetricsRequest{Envelope: envelope}, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "agent:1", got.GetIdempotencyKey())
+	assert.Empty(t, got.GetEnvelope())
+
+	_, err = interceptor(context.Background(), plain, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "открытый запрос отклоняется при заданном ключе")
+
+	_, err = interceptor(context.Background(), &pbModel.MetricsRequest{Envelope: []byte("garbage")}, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = UnaryDecrypt(zap.NewNop().Sugar(), nil)(context.Background(), plain, info, handler)
+	assert.NoError(t, err, "без ключа перехватчик выключен")
+}
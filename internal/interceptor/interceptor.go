@@ -0,0 +1,113 @@
+// Package interceptor содержит перехватчики gRPC-сервера, повторяющие цепочку HTTP middleware:
+// журналирование, проверку доверенной подсети, расшифровку и проверку подписи запросов.
+package interceptor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"metrics/internal/security"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Ключи метаданных gRPC, аналоги HTTP-заголовков агента.
+const (
+	MetadataHash      = "hashsha256"
+	MetadataAgentID   = "x-agent-id"
+	MetadataRealIP    = "x-real-ip"
+	MetadataTimestamp = "x-timestamp"
+	MetadataNonce     = "x-nonce"
+)
+
+// signatureField поле сообщения потока с его подписью (см. SignStreamMessage).
+const signatureField = "signature"
+
+// Sign вычисляет подпись сообщения: base64(hmac(sha256)) от детерминированной сериализации.
+// Агент подписывает запрос до шифрования, сервер проверяет подпись после расшифровки.
+func Sign(msg proto.Message, key string) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("marshal message: %w", err)
+	}
+	return security.HMACSHA256Base64(data, []byte(key)), nil
+}
+
+// SignStream вычисляет подпись открытия потока. Агент подтверждает владение ключом подписью имени
+// метода, времени открытия и одноразового значения: сервер отклоняет устаревшие подписи и повтор nonce.
+func SignStream(fullMethod, timestamp, nonce, key string) string {
+	return security.HMACSHA256Base64([]byte(fullMethod+"\n"+timestamp+"\n"+nonce), []byte(key))
+}
+
+// SignStreamMessage вычисляет подпись сообщения потока: HMAC от nonce открытия, номера сообщения
+// (с единицы) и детерминированной сериализации сообщения без поля signature. Номер не даёт
+// переставить, повторить или пропустить сообщение, nonce — перенести его в другой поток.
+func SignStreamMessage(msg proto.Message, nonce string, seq uint64, key string) (string, error) {
+	unsigned := proto.Clone(msg)
+	if field := unsigned.ProtoReflect().Descriptor().Fields().ByName(signatureField); field != nil {
+		unsigned.ProtoReflect().Clear(field)
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(unsigned)
+	if err != nil {
+		return "", fmt.Errorf("marshal message: %w", err)
+	}
+	prefix := nonce + "\n" + strconv.FormatUint(seq, 10) + "\n"
+	return security.HMACSHA256Base64(append([]byte(prefix), data...), []byte(key)), nil
+}
+
+// StreamMetadata возвращает метаданные подписанного открытия потока: время, nonce и подпись.
+func StreamMetadata(fullMethod, key string) ([]string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := hex.EncodeToString(buf)
+	return []string{
+		MetadataTimestamp, timestamp,
+		MetadataNonce, nonce,
+		MetadataHash, SignStream(fullMethod, timestamp, nonce, key),
+	}, nil
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// serverStream подменяет контекст и обработку входящих сообщений потока.
+type serverStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	recv func(m any) error
+}
+
+func (s *serverStream) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return s.ServerStream.Context()
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	// Ошибки потока, в том числе io.EOF, передаются без изменений.
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.recv != nil {
+		return s.recv(m)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryLogging журналирует метод, код ответа и длительность каждого вызова.
+func UnaryLogging(logger *zap.SugaredLogger) grpc.UnaryServerInterceptor {
+	handlerLogger := logger.With("interceptor", "UnaryLogging")
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(handlerLogger, ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamLogging журналирует метод, код завершения и длительность каждого потока.
+func StreamLogging(logger *zap.SugaredLogger) grpc.StreamServerInterceptor {
+	handlerLogger := logger.With("interceptor", "StreamLogging")
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(handlerLogger, ss.Context(), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(logger *zap.SugaredLogger, ctx context.Context, method string, start time.Time, err error) {
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	logger.Infow(
+		"grpc call",
+		"method", method,
+		"code", status.Code(err).String(),
+		"peer", addr,
+		"duration", time.Since(start),
+	)
+}
@@ -0,0 +1,67 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryTrustedSubnet пропускает только вызовы из доверенной подсети. Без подсети проверка выключена.
+func UnaryTrustedSubnet(logger *zap.SugaredLogger, trustedSubnet *net.IPNet) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := checkSubnet(ctx, logger, trustedSubnet); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamTrustedSubnet пропускает только потоки из доверенной подсети. Без подсети проверка выключена.
+func StreamTrustedSubnet(logger *zap.SugaredLogger, trustedSubnet *net.IPNet) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkSubnet(ss.Context(), logger, trustedSubnet); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// checkSubnet берёт адрес клиента из метаданных x-real-ip, как HTTP-сервер из X-Real-IP,
+// а при их отсутствии — адрес соединения.
+func checkSubnet(ctx context.Context, logger *zap.SugaredLogger, trustedSubnet *net.IPNet) error {
+	if trustedSubnet == nil {
+		return nil
+	}
+
+	ip := clientIP(ctx)
+	if ip == nil {
+		logger.Infow("unable to detect client ip")
+		return status.Error(codes.PermissionDenied, "forbidden")
+	}
+	if !trustedSubnet.Contains(ip) {
+		logger.Infow("unauthorized IP", "ip", ip.String())
+		return status.Error(codes.PermissionDenied, "forbidden")
+	}
+	return nil
+}
+
+func clientIP(ctx context.Context) net.IP {
+	if realIP := metadataValue(ctx, MetadataRealIP); realIP != "" {
+		return net.ParseIP(realIP)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
@@ -0,0 +1,56 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryTrustedSubnet(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	require.NoError(t, err)
+
+	withPeer := func(addr string) context.Context {
+		tcpAddr, resolveErr := net.ResolveTCPAddr("tcp", addr)
+		require.NoError(t, resolveErr)
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: tcpAddr})
+	}
+
+	tests := []struct {
+		ctx    context.Context
+		subnet *net.IPNet
+		name   string
+		code   codes.Code
+	}{
+		{name: "disabled", ctx: context.Background(), subnet: nil, code: codes.OK},
+		{name: "peer in subnet", ctx: withPeer("192.168.1.10:5000"), subnet: subnet, code: codes.OK},
+		{name: "peer outside subnet", ctx: withPeer("10.0.0.1:5000"), subnet: subnet, code: codes.PermissionDenied},
+		{
+			name:   "real ip has priority",
+			ctx:    metadata.NewIncomingContext(withPeer("10.0.0.1:5000"), metadata.Pairs(MetadataRealIP, "192.168.1.20")),
+			subnet: subnet,
+			code:   codes.OK,
+		},
+		{name: "unknown client", ctx: context.Background(), subnet: subnet, code: codes.PermissionDenied},
+	}
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return struct{}{}, nil
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := UnaryTrustedSubnet(zap.NewNop().Sugar(), tt.subnet)
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: sendMethod}, handler)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Package lineprotocol разбирает строки InfluxDB line protocol:
+//
+//	measurement[,tag=value...] field=value[,field=value...] [timestamp]
+package lineprotocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLine = errors.New("invalid line protocol")
+
+// FieldKind тип значения поля.
+type FieldKind int
+
+const (
+	FieldFloat FieldKind = iota
+	FieldInteger
+	FieldUnsigned
+	FieldBool
+	FieldString
+)
+
+// Field поле точки. Заполнено значение, соответствующее Kind.
+type Field struct {
+	Key      string
+	String   string
+	Float    float64
+	Integer  int64
+	Unsigned uint64
+	Kind     FieldKind
+	Bool     bool
+}
+
+// Point точка line protocol.
+type Point struct {
+	Timestamp   time.Time
+	Tags        map[string]string
+	Measurement string
+	Fields      []Field
+}
+
+// ParsePrecision разбирает точность временных меток в формате InfluxDB v1 (n, u, ms, s, m, h)
+// и v2 (ns, us, ms, s). Пустая строка означает наносекунды.
+func ParsePrecision(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "n", "ns":
+		return time.Nanosecond, nil
+	case "u", "us", "µ":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	default:
+		return 0, fmt.Errorf("unknown precision %q", precision)
+	}
+}
+
+// Parse разбирает тело запроса записи. Временные метки задаются в единицах precision,
+// точки без метки получают время now. Пустые строки и комментарии пропускаются.
+func Parse(data []byte, precision time.Duration, now time.Time) ([]Point, error) {
+	var points []Point
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		text := strings.TrimSpace(string(line))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		point, err := parseLine(text, precision, now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func parseLine(line string, precision time.Duration, now time.Time) (Point, error) {
+	// Кавычки в имени и тегах не ограничивают строки, поэтому они отделяются без учёта кавычек.
+	seriesEnd := indexUnescaped(line, ' ')
+	if seriesEnd < 0 {
+		return Point{}, fmt.Errorf("%w: missing fields", ErrInvalidLine)
+	}
+	sections := append([]string{line[:seriesEnd]}, splitUnescaped(line[seriesEnd+1:], ' ', true)...)
+	if len(sections) > 3 {
+		return Point{}, fmt.Errorf("%w: expected measurement, fields and optional timestamp", ErrInvalidLine)
+	}
+
+	point := Point{Timestamp: now}
+	series := splitUnescaped(sections[0], ',', false)
+	point.Measurement = unescape(series[0])
+	if point.Measurement == "" {
+		return Point{}, fmt.Errorf("%w: missing measurement", ErrInvalidLine)
+	}
+
+	for _, tag := range series[1:] {
+		key, value, err := splitPair(tag)
+		if err != nil {
+			return Point{}, err
+		}
+		if point.Tags == nil {
+			point.Tags = make(map[string]string)
+		}
+		point.Tags[key] = value
+	}
+
+	for _, rawField := range splitUnescaped(sections[1], ',', true) {
+		key, rawValue, err := splitRawPair(rawField)
+		if err != nil {
+			return Point{}, err
+		}
+		field, err := parseFieldValue(rawValue)
+		if err != nil {
+			return Point{}, fmt.Errorf("field %q: %w", key, err)
+		}
+		field.Key = key
+		point.Fields = append(point.Fields, field)
+	}
+
+	if len(sections) == 3 {
+		ts, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return Point{}, fmt.Errorf("%w: timestamp %q", ErrInvalidLine, sections[2])
+		}
+		point.Timestamp = time.Unix(0, ts*int64(precision))
+	}
+
+	return point, nil
+}
+
+func parseFieldValue(raw string) (Field, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		if len(raw) < 2 || !strings.HasSuffix(raw, `"`) {
+			return Field{}, fmt.Errorf("%w: unterminated string", ErrInvalidLine)
+		}
+		value := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(raw[1 : len(raw)-1])
+		return Field{Kind: FieldString, String: value}, nil
+	case strings.HasSuffix(raw, "i"):
+		value, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return Field{}, fmt.Errorf("%w: integer %q", ErrInvalidLine, raw)
+		}
+		return Field{Kind: FieldInteger, Integer: value}, nil
+	case strings.HasSuffix(raw, "u"):
+		value, err := strconv.ParseUint(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return Field{}, fmt.Errorf("%w: unsigned %q", ErrInvalidLine, raw)
+		}
+		return Field{Kind: FieldUnsigned, Unsigned: value}, nil
+	}
+
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return Field{Kind: FieldBool, Bool: true}, nil
+	case "f", "F", "false", "False", "FALSE":
+		return Field{Kind: FieldBool}, nil
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return Field{}, fmt.Errorf("%w: float %q", ErrInvalidLine, raw)
+	}
+	return Field{Kind: FieldFloat, Float: value}, nil
+}
+
+// splitPair разбирает key=value тега, ключ и значение раскрываются.
+func splitPair(s string) (string, string, error) {
+	key, value, err := splitRawPair(s)
+	if err != nil {
+		return "", "", err
+	}
+	value = unescape(value)
+	if value == "" {
+		return "", "", fmt.Errorf("%w: empty tag value %q", ErrInvalidLine, key)
+	}
+	return key, value, nil
+}
+
+// splitRawPair делит key=value по первому неэкранированному '=', раскрывает только ключ.
+func splitRawPair(s string) (string, string, error) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '=':
+			key := unescape(s[:i])
+			if key == "" || i == len(s)-1 {
+				return "", "", fmt.Errorf("%w: pair %q", ErrInvalidLine, s)
+			}
+			return key, s[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("%w: pair %q", ErrInvalidLine, s)
+}
+
+// indexUnescaped возвращает позицию первого неэкранированного sep или -1.
+func indexUnescaped(s string, sep byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitUnescaped делит строку по sep, пропуская экранированные символы
+// и, если quoted, разделители внутри строк в двойных кавычках.
+func splitUnescaped(s string, sep byte, quoted bool) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quoted && s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+var unescaper = strings.NewReplacer(`\,`, ",", `\=`, "=", `\ `, " ", `\\`, `\`)
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return unescaper.Replace(s)
+}
@@ -0,0 +1,83 @@
+package lineprotocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	data := []byte(`# comment
+cpu,host=web-1,region=eu\ west usage_idle=97.5,usage_user=1.25 1700000010000000000
+
+mem,host=web-1 used=1024i,free=7u,ok=t,note="a \"quoted\", value" 
+disk\,fs path=1
+`)
+
+	points, err := Parse(data, time.Nanosecond, now)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+
+	assert.Equal(t, "cpu", points[0].Measurement)
+	assert.Equal(t, map[string]string{"host": "web-1", "region": "eu west"}, points[0].Tags)
+	assert.Equal(t, []Field{
+		{Key: "usage_idle", Kind: FieldFloat, Float: 97.5},
+		{Key: "usage_user", Kind: FieldFloat, Float: 1.25},
+	}, points[0].Fields)
+	assert.True(t, points[0].Timestamp.Equal(time.Unix(1700000010, 0)))
+
+	assert.Equal(t, []Field{
+		{Key: "used", Kind: FieldInteger, Integer: 1024},
+		{Key: "free", Kind: FieldUnsigned, Unsigned: 7},
+		{Key: "ok", Kind: FieldBool, Bool: true},
+		{Key: "note", Kind: FieldString, String: `a "quoted", value`},
+	}, points[1].Fields)
+	assert.Equal(t, now, points[1].Timestamp, "точка без метки получает текущее время")
+
+	assert.Equal(t, "disk,fs", points[2].Measurement)
+}
+
+func TestParse_Precision(t *testing.T) {
+	for precision, want := range map[string]time.Time{
+		"":   time.Unix(0, 1700000000),
+		"ms": time.Unix(1700000000/1000, 0),
+		"s":  time.Unix(1700000000, 0),
+		"u":  time.Unix(1700, 0),
+		"us": time.Unix(1700, 0),
+	} {
+		unit, err := ParsePrecision(precision)
+		require.NoError(t, err, precision)
+		points, err := Parse([]byte("cpu value=1 1700000000"), unit, time.Now())
+		require.NoError(t, err, precision)
+		assert.True(t, want.Equal(points[0].Timestamp), precision)
+	}
+
+	_, err := ParsePrecision("d")
+	assert.Error(t, err)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	lines := []string{
+		"cpu",
+		"cpu ",
+		",host=a value=1",
+		"cpu,host value=1",
+		"cpu,host= value=1",
+		"cpu value",
+		"cpu value=abc",
+		"cpu value=NaN",
+		"cpu value=+Inf",
+		"cpu value=-inf",
+		"cpu value=1x",
+		`cpu value="open`,
+		"cpu value=1 yesterday",
+		"cpu value=1 1 2",
+	}
+	for _, line := range lines {
+		_, err := Parse([]byte(line), time.Nanosecond, time.Now())
+		assert.ErrorIs(t, err, ErrInvalidLine, line)
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"metrics/internal/auth"
+	"metrics/internal/security"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// AuthorizeMiddleware проверяет агента по заголовку X-Agent-ID, подпись HashSHA256 тела запроса
+// его собственным ключом и наличие права scope. В отличие от CheckHashMiddleware, запрос
+// без подписи отклоняется. Без хранилища учётных данных проверка выключена.
+func AuthorizeMiddleware(
+	logger *zap.SugaredLogger,
+	store auth.Store,
+	scope auth.Scope,
+) func(next http.Handler) http.Handler {
+	return authorizeMiddleware(logger, store, scope, false)
+}
+
+// BrowserAuthorizeMiddleware как AuthorizeMiddleware, но принимает и HTTP Basic: имя — идентификатор
+// агента, пароль — его ключ. Браузер не умеет подписывать запросы, а учётные данные Basic сам
+// передаёт в fetch, EventSource и WebSocket той же страницы. Ключ уходит открытым текстом,
+// поэтому дашборд с проверкой агентов нужно открывать по HTTPS.
+func BrowserAuthorizeMiddleware(
+	logger *zap.SugaredLogger,
+	store auth.Store,
+	scope auth.Scope,
+) func(next http.Handler) http.Handler {
+	return authorizeMiddleware(logger, store, scope, true)
+}
+
+func authorizeMiddleware(
+	logger *zap.SugaredLogger,
+	store auth.Store,
+	scope auth.Scope,
+	allowBasic bool,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if store == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			agentID := r.Header.Get(auth.AgentIDHeader)
+			providedHash := r.Header.Get("HashSHA256")
+			basicID, password, basic := r.BasicAuth()
+			basic = basic && allowBasic && providedHash == ""
+			if basic {
+				agentID = basicID
+			}
+			if agentID == "" || (providedHash == "" && !basic) {
+				logger.Infow("missing agent id or signature", "uri", r.RequestURI, "agent_id", agentID)
+				unauthorized(w, allowBasic)
+				return
+			}
+
+			credential, err := store.Lookup(r.Context(), agentID)
+			if err != nil {
+				if !errors.Is(err, auth.ErrUnknownAgent) && !errors.Is(err, auth.ErrRevoked) {
+					logger.Infow("error lookup credentials", "agent_id", agentID, "error", err)
+					http.Error(w, "", http.StatusInternalServerError)
+					return
+				}
+				logger.Infow("agent rejected", "agent_id", agentID, "error", err)
+				unauthorized(w, allowBasic)
+				return
+			}
+
+			if basic {
+				if subtle.ConstantTimeCompare([]byte(password), []byte(credential.Key)) != 1 {
+					logger.Infow("password mismatch", "agent_id", agentID, "method", r.Method, "uri", r.RequestURI)
+					unauthorized(w, allowBasic)
+					return
+				}
+			} else {
+				bodyBytes, err := io.ReadAll(r.Body)
+				if err != nil {
+					logger.Infoln("error read body")
+					http.Error(w, "", http.StatusInternalServerError)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+				if !security.CheckHMACSHA256Base64(bodyBytes, []byte(credential.Key), providedHash) {
+					logger.Infow("hash mismatch", "agent_id", agentID, "method", r.Method, "uri", r.RequestURI)
+					unauthorized(w, allowBasic)
+					return
+				}
+			}
+
+			if !credential.Allows(scope) {
+				logger.Infow("scope denied", "agent_id", agentID, "scope", scope, "uri", r.RequestURI)
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithCredential(r.Context(), credential)))
+		})
+	}
+}
+
+// unauthorized отвечает 401. Если принимается Basic, заголовок WWW-Authenticate
+// заставляет браузер запросить идентификатор агента и ключ.
+func unauthorized(w http.ResponseWriter, allowBasic bool) {
+	if allowBasic {
+		w.Header().Set("WWW-Authenticate", `Basic realm="metrics", charset="UTF-8"`)
+	}
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"metrics/internal/auth"
+	"metrics/internal/security"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type stubCredentialStore map[string]auth.Credential
+
+func (s stubCredentialStore) Lookup(ctx context.Context, agentID string) (auth.Credential, error) {
+	credential, ok := s[agentID]
+	if !ok {
+		return auth.Credential{}, auth.ErrUnknownAgent
+	}
+	if credential.Revoked {
+		return auth.Credential{}, auth.ErrRevoked
+	}
+	return credential, nil
+}
+
+func (s stubCredentialStore) Revoke(ctx context.Context, agentID string) error {
+	return nil
+}
+
+func TestAuthorizeMiddleware(t *testing.T) {
+	store := stubCredentialStore{
+		"writer":  {AgentID: "writer", Key: "writer-key", Scopes: []auth.Scope{auth.ScopeWrite}},
+		"reader":  {AgentID: "reader", Key: "reader-key", Scopes: []auth.Scope{auth.ScopeRead}},
+		"revoked": {AgentID: "revoked", Key: "old-key", Scopes: []auth.Scope{auth.ScopeWrite}, Revoked: true},
+	}
+	body := `[{"id":"Alloc","type":"gauge","value":1}]`
+
+	var gotAgent string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credential, _ := auth.CredentialFromContext(r.Context())
+		gotAgent = credential.AgentID
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := AuthorizeMiddleware(zap.NewNop().Sugar(), store, auth.ScopeWrite)(next)
+
+	tests := []struct {
+		name    string
+		agentID string
+		hash    string
+		want    int
+	}{
+		{name: "valid", agentID: "writer", hash: security.HMACSHA256Base64([]byte(body), []byte("writer-key")), want: http.StatusOK},
+		{name: "missing signature", agentID: "writer", want: http.StatusUnauthorized},
+		{name: "missing agent id", hash: security.HMACSHA256Base64([]byte(body), []byte("writer-key")), want: http.StatusUnauthorized},
+		{name: "signed with another key", agentID: "writer", hash: security.HMACSHA256Base64([]byte(body), []byte("reader-key")), want: http.StatusUnauthorized},
+		{name: "unknown agent", agentID: "ghost", hash: "x", want: http.StatusUnauthorized},
+		{name: "revoked", agentID: "revoked", hash: security.HMACSHA256Base64([]byte(body), []byte("old-key")), want: http.StatusUnauthorized},
+		{name: "no scope", agentID: "reader", hash: security.HMACSHA256Base64([]byte(body), []byte("reader-key")), want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAgent = ""
+			req := httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewBufferString(body))
+			if tt.agentID != "" {
+				req.Header.Set(auth.AgentIDHeader, tt.agentID)
+			}
+			if tt.hash != "" {
+				req.Header.Set("HashSHA256", tt.hash)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+			if tt.want == http.StatusOK {
+				assert.Equal(t, tt.agentID, gotAgent)
+			}
+		})
+	}
+}
+
+func TestAuthorizeMiddleware_Disabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := AuthorizeMiddleware(zap.NewNop().Sugar(), nil, auth.ScopeWrite)(next)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/updates/", http.NoBody))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestBrowserAuthorizeMiddleware(t *testing.T) {
+	store := stubCredentialStore{
+		"reader": {AgentID: "reader", Key: "reader-key", Scopes: []auth.Scope{auth.ScopeRead}},
+		"writer": {AgentID: "writer", Key: "writer-key", Scopes: []auth.Scope{auth.ScopeWrite}},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	browser := BrowserAuthorizeMiddleware(zap.NewNop().Sugar(), store, auth.ScopeRead)(next)
+	agent := AuthorizeMiddleware(zap.NewNop().Sugar(), store, auth.ScopeRead)(next)
+
+	tests := []struct {
+		handler   http.Handler
+		name      string
+		user      string
+		password  string
+		want      int
+		challenge bool
+	}{
+		{name: "basic credentials", handler: browser, user: "reader", password: "reader-key", want: http.StatusOK},
+		{name: "no credentials", handler: browser, want: http.StatusUnauthorized, challenge: true},
+		{name: "wrong password", handler: browser, user: "reader", password: "writer-key", want: http.StatusUnauthorized, challenge: true},
+		{name: "no scope", handler: browser, user: "writer", password: "writer-key", want: http.StatusForbidden},
+		{name: "basic not accepted for agents", handler: agent, user: "reader", password: "reader-key", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/metrics", http.NoBody)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			rr := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+			assert.Equal(t, tt.challenge, rr.Header().Get("WWW-Authenticate") != "")
+		})
+	}
+
+	t.Run("signed request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/metrics", http.NoBody)
+		req.Header.Set(auth.AgentIDHeader, "reader")
+		req.Header.Set("HashSHA256", security.HMACSHA256Base64(nil, []byte("reader-key")))
+		rr := httptest.NewRecorder()
+
+		browser.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code, "агенты по-прежнему подписывают запросы")
+	})
+}
@@ -29,12 +29,27 @@ func (g gzipResponseWriter) Write(b []byte) (int, error) {
 	return n, nil
 }
 
+// Flush отправляет сжатые данные клиенту, чтобы потоковые ответы (SSE) не задерживались в буфере gzip.
+func (g gzipResponseWriter) Flush() {
+	if gz, ok := g.Writer.(*gzip.Writer); ok {
+		_ = gz.Flush()
+	}
+	_ = http.NewResponseController(g.ResponseWriter).Flush()
+}
+
+// Unwrap возвращает исходный ResponseWriter для http.ResponseController.
+func (g gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
 func ResponseCompressionMiddleware(logger *zap.SugaredLogger) func(next http.Handler) http.Handler {
 	handlerLogger := logger.With("middleware", "ResponseCompressionMiddleware")
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			contentEncoding := r.Header.Get(acceptEncodingHeader)
-			if !strings.Contains(contentEncoding, gzipEncoding) {
+			// Соединение WebSocket передаётся обработчику целиком и не сжимается.
+			upgrade := strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+			if !strings.Contains(contentEncoding, gzipEncoding) || upgrade {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -71,3 +71,36 @@ func TestResponseCompressionMiddleware(t *testing.T) {
 		assert.Equal(t, expectedBody, string(body))
 	})
 }
+
+func TestResponseCompressionMiddleware_Streaming(t *testing.T) {
+	middleware := ResponseCompressionMiddleware(zap.NewNop().Sugar())
+
+	t.Run("Flush sends compressed data", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("event"))
+			assert.NoError(t, http.NewResponseController(w).Flush())
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.Header.Set(acceptEncodingHeader, gzipEncoding)
+		rec := httptest.NewRecorder()
+
+		middleware(handler).ServeHTTP(rec, req)
+
+		assert.True(t, rec.Flushed)
+	})
+
+	t.Run("WebSocket upgrade is not compressed", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := w.(*httptest.ResponseRecorder)
+			assert.True(t, ok, "обработчик получает исходный ResponseWriter")
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.Header.Set(acceptEncodingHeader, gzipEncoding)
+		req.Header.Set("Upgrade", "websocket")
+		rec := httptest.NewRecorder()
+
+		middleware(handler).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get(contentEncodingHeader))
+	})
+}
@@ -25,7 +25,7 @@ func DecryptMiddleware(privateKey *rsa.PrivateKey) func(http.Handler) http.Handl
 				_ = Body.Close()
 			}(r.Body)
 
-			decryptedData, err := security.DecryptRSA(privateKey, encryptedData)
+			decryptedData, err := security.Decrypt(privateKey, encryptedData)
 			if err != nil {
 				http.Error(w, "failed to decrypt request body", http.StatusBadRequest)
 				return
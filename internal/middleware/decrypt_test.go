@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"metrics/internal/security"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecryptMiddleware(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	expectedBody := bytes.Repeat([]byte(`{"id":"Alloc","type":"gauge","value":1}`), 100)
+	envelope, err := security.EncryptEnvelope(expectedBody, &privateKey.PublicKey)
+	require.NoError(t, err)
+
+	var actualBody []byte
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actualBody, _ = io.ReadAll(r.Body)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/updates", bytes.NewReader(envelope))
+	rec := httptest.NewRecorder()
+	DecryptMiddleware(privateKey)(handler).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, expectedBody, actualBody)
+}
+
+func TestDecryptMiddleware_InvalidPayload(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler must not be called")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/updates", bytes.NewReader([]byte(`{"id":"Alloc"}`)))
+	rec := httptest.NewRecorder()
+	DecryptMiddleware(privateKey)(handler).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
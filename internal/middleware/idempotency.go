@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"metrics/internal/service"
+	"net/http"
+)
+
+// IdempotencyKeyHeader заголовок, в котором агент передаёт ключ идемпотентности.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+func IdempotencyKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(service.WithIdempotencyKey(r.Context(), key)))
+	})
+}
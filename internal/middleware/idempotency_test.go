@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"metrics/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKeyMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		expectedKey string
+		expectedOk  bool
+	}{
+		{
+			name:        "Key provided",
+			header:      "agent-1:batch-1",
+			expectedKey: "agent-1:batch-1",
+			expectedOk:  true,
+		},
+		{
+			name:        "No key",
+			header:      "",
+			expectedKey: "",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotKey string
+			var gotOk bool
+			handler := IdempotencyKeyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotKey, gotOk = service.IdempotencyKeyFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/updates", http.NoBody)
+			if tt.header != "" {
+				req.Header.Set(IdempotencyKeyHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.expectedKey, gotKey)
+			assert.Equal(t, tt.expectedOk, gotOk)
+		})
+	}
+}
@@ -0,0 +1,375 @@
+// Package otlp преобразует метрики OpenTelemetry (OTLP) в обновления MetricService.
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// sumExpiry время, после которого сумма серии без новых точек забывается.
+const sumExpiry = time.Hour
+
+// Receiver принимает экспорт OTLP. Sum преобразуется в counter, Gauge — в gauge,
+// атрибуты ресурса и точки становятся метками серии. Монотонные cumulative-суммы
+// переводятся в приращения по последнему принятому значению серии.
+// Гистограммы, summary и точки без значения отклоняются и возвращаются в partial_success.
+// Суммы серий без точек дольше expiry забываются, чтобы ушедшие источники не занимали память.
+type Receiver struct {
+	service service.MetricService
+	// sums накопленные значения сумм по сериям, изменяются только после записи пакета с точкой.
+	sums map[string]sumState
+	// started cumulative-серии, начатые позже, учитываются с нуля. Это время создания получателя,
+	// а после вытеснения сумм — последнее обновление вытесненной серии: вернувшаяся серия с прежним
+	// началом задаёт точку отсчёта, а не учитывается повторно.
+	started time.Time
+	expiry  time.Duration
+	// mu сериализует экспорт, чтобы приращения одной серии не считались дважды.
+	mu sync.Mutex
+}
+
+type sumState struct {
+	start time.Time
+	// updated время экспорта, в котором сумма последний раз изменилась.
+	updated time.Time
+	total   float64
+}
+
+// NewReceiver создаёт получатель OTLP.
+func NewReceiver(metricService service.MetricService) *Receiver {
+	return &Receiver{
+		service: metricService,
+		sums:    make(map[string]sumState),
+		started: time.Now(),
+		expiry:  sumExpiry,
+	}
+}
+
+// export результат разбора запроса до записи в хранилище.
+type export struct {
+	byTime map[time.Time][]service.MetricsUpdateRequest
+	// sums накопленные значения сумм с учётом всех точек запроса.
+	sums map[string]sumState
+	// sumsAt значения сумм после точек с данным временем, сохраняются после записи пакета этого времени.
+	sumsAt   map[time.Time]map[string]sumState
+	now      time.Time
+	rejected int64
+	reason   string
+}
+
+// Export записывает метрики запроса. Точки с одинаковым временем записываются одним пакетом
+// в порядке возрастания времени, время точки сохраняется в истории метрики. Если пакет не записан,
+// суммы остаются такими, какими их оставили уже записанные пакеты.
+func (r *Receiver) Export(
+	ctx context.Context,
+	req *collectormetricspb.ExportMetricsServiceRequest,
+) (*collectormetricspb.ExportMetricsServiceResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.expire(now)
+	e := &export{
+		byTime: make(map[time.Time][]service.MetricsUpdateRequest),
+		sums:   make(map[string]sumState),
+		sumsAt: make(map[time.Time]map[string]sumState),
+		now:    now,
+	}
+	for _, resourceMetrics := range req.GetResourceMetrics() {
+		resourceLabels := attributesLabels(nil, resourceMetrics.GetResource().GetAttributes())
+		for _, scopeMetrics := range resourceMetrics.GetScopeMetrics() {
+			for _, metric := range scopeMetrics.GetMetrics() {
+				r.convertMetric(ctx, e, metric, resourceLabels, now)
+			}
+		}
+	}
+
+	for _, at := range e.times() {
+		if metrics, ok := e.byTime[at]; ok {
+			if err := r.service.UpdateMultiple(repository.WithSampleTime(ctx, at), metrics); err != nil {
+				return nil, fmt.Errorf("failed to write otlp metrics: %w", err)
+			}
+		}
+		for key, state := range e.sumsAt[at] {
+			r.sums[key] = state
+		}
+	}
+
+	response := &collectormetricspb.ExportMetricsServiceResponse{}
+	if e.rejected > 0 {
+		response.PartialSuccess = &collectormetricspb.ExportMetricsPartialSuccess{
+			RejectedDataPoints: e.rejected,
+			ErrorMessage:       e.reason,
+		}
+	}
+	return response, nil
+}
+
+// expire забывает суммы серий, не обновлявшиеся дольше expiry.
+func (r *Receiver) expire(now time.Time) {
+	for key, state := range r.sums {
+		if now.Sub(state.updated) <= r.expiry {
+			continue
+		}
+		delete(r.sums, key)
+		if state.updated.After(r.started) {
+			r.started = state.updated
+		}
+	}
+}
+
+func (r *Receiver) convertMetric(
+	ctx context.Context,
+	e *export,
+	metric *metricspb.Metric,
+	resourceLabels map[string]string,
+	now time.Time,
+) {
+	name := metric.GetName()
+	switch data := metric.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		for _, point := range data.Gauge.GetDataPoints() {
+			value, err := pointValue(point)
+			if err != nil {
+				e.reject(1, fmt.Sprintf("gauge %q: %s", name, err))
+				continue
+			}
+			e.add(pointTime(point.GetTimeUnixNano(), now), gaugeUpdate(name, attributesLabels(resourceLabels, point.GetAttributes()), value))
+		}
+	case *metricspb.Metric_Sum:
+		cumulative := data.Sum.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+		for _, point := range data.Sum.GetDataPoints() {
+			value, err := pointValue(point)
+			if err != nil {
+				e.reject(1, fmt.Sprintf("sum %q: %s", name, err))
+				continue
+			}
+			labels := attributesLabels(resourceLabels, point.GetAttributes())
+			at := pointTime(point.GetTimeUnixNano(), now)
+			if !data.Sum.GetIsMonotonic() {
+				e.add(at, gaugeUpdate(name, labels, r.upDownValue(ctx, e, at, name, labels, value, cumulative)))
+				continue
+			}
+			delta, err := r.monotonicDelta(e, at, name, labels, value, cumulative, pointTime(point.GetStartTimeUnixNano(), time.Time{}))
+			if err != nil {
+				e.reject(1, fmt.Sprintf("sum %q: %s", name, err))
+				continue
+			}
+			if delta > 0 {
+				e.add(at, service.MetricsUpdateRequest{ID: name, MType: "counter", Delta: &delta, Labels: labels})
+			}
+		}
+	case *metricspb.Metric_Histogram:
+		e.reject(int64(len(data.Histogram.GetDataPoints())), fmt.Sprintf("histogram %q is not supported", name))
+	case *metricspb.Metric_ExponentialHistogram:
+		e.reject(int64(len(data.ExponentialHistogram.GetDataPoints())), fmt.Sprintf("exponential histogram %q is not supported", name))
+	case *metricspb.Metric_Summary:
+		e.reject(int64(len(data.Summary.GetDataPoints())), fmt.Sprintf("summary %q is not supported", name))
+	default:
+		e.reject(0, fmt.Sprintf("metric %q has no data", name))
+	}
+}
+
+// monotonicDelta возвращает целое приращение монотонной суммы. Накопленное значение серии
+// округляется, поэтому дробные приращения не теряются между запросами. Новая cumulative-серия,
+// начатая до запуска сервера, или серия без времени начала задаёт точку отсчёта без приращения.
+func (r *Receiver) monotonicDelta(
+	e *export,
+	at time.Time,
+	name string,
+	labels map[string]string,
+	value float64,
+	cumulative bool,
+	start time.Time,
+) (int64, error) {
+	if value < 0 {
+		return 0, fmt.Errorf("negative value %v", value)
+	}
+
+	key := "counter/" + repository.SeriesKey(name, labels)
+	prev, seen := e.sums[key]
+	if !seen {
+		prev, seen = r.sums[key]
+	}
+
+	next := sumState{start: start}
+	switch {
+	case !cumulative:
+		next.total = prev.total + value
+	case seen && start.Equal(prev.start) && value >= prev.total:
+		next.total = value
+	case seen || start.After(r.started):
+		// Серия перезапущена или начата после запуска сервера: значение целиком — приращение.
+		prev.total, next.total = 0, value
+	default:
+		e.setSum(at, key, sumState{start: start, total: value})
+		return 0, nil
+	}
+	e.setSum(at, key, next)
+
+	return int64(math.Round(next.total) - math.Round(prev.total)), nil
+}
+
+// upDownValue возвращает значение немонотонной суммы: cumulative — как есть,
+// delta — накопленный итог серии. Итог забытой или ещё не виденной серии начинается
+// с сохранённого значения gauge.
+func (r *Receiver) upDownValue(
+	ctx context.Context,
+	e *export,
+	at time.Time,
+	name string,
+	labels map[string]string,
+	value float64,
+	cumulative bool,
+) float64 {
+	if cumulative {
+		return value
+	}
+	key := "gauge/" + repository.SeriesKey(name, labels)
+	prev, seen := e.sums[key]
+	if !seen {
+		prev, seen = r.sums[key]
+	}
+	if !seen {
+		prev.total = r.storedGauge(ctx, name, labels)
+	}
+	prev.total += value
+	e.setSum(at, key, prev)
+	return prev.total
+}
+
+// storedGauge возвращает сохранённое значение gauge, 0 для новой серии.
+func (r *Receiver) storedGauge(ctx context.Context, name string, labels map[string]string) float64 {
+	response, err := r.service.Get(ctx, service.MetricsGetRequest{ID: name, MType: "gauge", Labels: labels})
+	if err != nil || response.Value == nil {
+		return 0
+	}
+	return *response.Value
+}
+
+func (e *export) add(at time.Time, metric service.MetricsUpdateRequest) {
+	e.byTime[at] = append(e.byTime[at], metric)
+}
+
+// setSum запоминает значение суммы после точки со временем at.
+func (e *export) setSum(at time.Time, key string, state sumState) {
+	state.updated = e.now
+	e.sums[key] = state
+	if e.sumsAt[at] == nil {
+		e.sumsAt[at] = make(map[string]sumState)
+	}
+	e.sumsAt[at][key] = state
+}
+
+// times возвращает время пакетов и точек, изменивших суммы, в порядке возрастания.
+func (e *export) times() []time.Time {
+	times := make([]time.Time, 0, len(e.byTime))
+	for at := range e.byTime {
+		times = append(times, at)
+	}
+	for at := range e.sumsAt {
+		if _, ok := e.byTime[at]; !ok {
+			times = append(times, at)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	return times
+}
+
+func (e *export) reject(points int64, reason string) {
+	e.rejected += points
+	if e.reason == "" {
+		e.reason = reason
+	}
+}
+
+func gaugeUpdate(name string, labels map[string]string, value float64) service.MetricsUpdateRequest {
+	return service.MetricsUpdateRequest{ID: name, MType: "gauge", Value: &value, Labels: labels}
+}
+
+// pointValue возвращает значение точки или причину, по которой точка отклоняется:
+// флаг отсутствия значения, NaN, бесконечность или пустое значение.
+func pointValue(point *metricspb.NumberDataPoint) (float64, error) {
+	if point.GetFlags()&uint32(metricspb.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK) != 0 {
+		return 0, errors.New("no recorded value")
+	}
+	switch value := point.GetValue().(type) {
+	case *metricspb.NumberDataPoint_AsDouble:
+		if math.IsNaN(value.AsDouble) || math.IsInf(value.AsDouble, 0) {
+			return 0, fmt.Errorf("non-finite value %v", value.AsDouble)
+		}
+		return value.AsDouble, nil
+	case *metricspb.NumberDataPoint_AsInt:
+		return float64(value.AsInt), nil
+	default:
+		return 0, errors.New("no value")
+	}
+}
+
+func pointTime(unixNano uint64, fallback time.Time) time.Time {
+	if unixNano == 0 {
+		return fallback
+	}
+	return time.Unix(0, int64(unixNano))
+}
+
+// attributesLabels добавляет атрибуты к меткам base. Недопустимые символы в именах
+// атрибутов (например точки в service.name) заменяются на '_'.
+func attributesLabels(base map[string]string, attributes []*commonpb.KeyValue) map[string]string {
+	if len(base) == 0 && len(attributes) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(base)+len(attributes))
+	for key, value := range base {
+		labels[key] = value
+	}
+	for _, attribute := range attributes {
+		name := invalidLabelChars.ReplaceAllString(attribute.GetKey(), "_")
+		if name == "" {
+			continue
+		}
+		if name[0] >= '0' && name[0] <= '9' {
+			name = "_" + name
+		}
+		labels[name] = anyValueString(attribute.GetValue())
+	}
+	return labels
+}
+
+func anyValueString(value *commonpb.AnyValue) string {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+	case nil:
+		return ""
+	default:
+		data, err := protojson.Marshal(value)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,233 @@
+package otlp
+
+import (
+	"context"
+	"math"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/zap"
+)
+
+func newTestReceiver(t *testing.T) (*Receiver, repository.MetricStorage) {
+	t.Helper()
+	memStorage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	return NewReceiver(service.NewMetricService(memStorage, nil, zap.NewNop().Sugar())), memStorage
+}
+
+func stringAttr(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func request(metrics ...*metricspb.Metric) *collectormetricspb.ExportMetricsServiceRequest {
+	return &collectormetricspb.ExportMetricsServiceRequest{ResourceMetrics: []*metricspb.ResourceMetrics{{
+		Resource:     &resourcepb.Resource{Attributes: []*commonpb.KeyValue{stringAttr("service.name", "checkout")}},
+		ScopeMetrics: []*metricspb.ScopeMetrics{{Metrics: metrics}},
+	}}}
+}
+
+func sum(name string, temporality metricspb.AggregationTemporality, monotonic bool, start time.Time, values ...int64) *metricspb.Metric {
+	points := make([]*metricspb.NumberDataPoint, 0, len(values))
+	for _, value := range values {
+		points = append(points, &metricspb.NumberDataPoint{
+			StartTimeUnixNano: uint64(start.UnixNano()),
+			Value:             &metricspb.NumberDataPoint_AsInt{AsInt: value},
+		})
+	}
+	return &metricspb.Metric{Name: name, Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+		AggregationTemporality: temporality,
+		IsMonotonic:            monotonic,
+		DataPoints:             points,
+	}}}
+}
+
+var serviceLabels = repository.Labels{"service_name": "checkout"}
+
+func TestReceiver_GaugeAndDeltaSum(t *testing.T) {
+	receiver, memStorage := newTestReceiver(t)
+	ctx := context.Background()
+	at := time.Unix(1700000000, 0)
+
+	gauge := &metricspb.Metric{Name: "queue.size", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+		DataPoints: []*metricspb.NumberDataPoint{{
+			TimeUnixNano: uint64(at.UnixNano()),
+			Attributes:   []*commonpb.KeyValue{stringAttr("queue", "orders")},
+			Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: 4.5},
+		}},
+	}}}
+	delta := sum("requests", metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, true, time.Time{}, 3, 2)
+
+	response, err := receiver.Export(ctx, request(gauge, delta))
+	require.NoError(t, err)
+	assert.Nil(t, response.GetPartialSuccess())
+
+	gaugeKey := repository.SeriesKey("queue.size", repository.Labels{"service_name": "checkout", "queue": "orders"})
+	value, err := memStorage.GetGauge(ctx, gaugeKey)
+	require.NoError(t, err)
+	assert.Equal(t, 4.5, value)
+
+	history, err := memStorage.GaugeHistory(ctx, gaugeKey, time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.True(t, at.Equal(history[0].Timestamp), "время точки сохраняется в истории")
+
+	counter, err := memStorage.GetCounter(ctx, repository.SeriesKey("requests", serviceLabels))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), counter)
+}
+
+func TestReceiver_CumulativeSum(t *testing.T) {
+	receiver, memStorage := newTestReceiver(t)
+	ctx := context.Background()
+	cumulative := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	key := repository.SeriesKey("requests", serviceLabels)
+	counter := func() uint64 {
+		value, err := memStorage.GetCounter(ctx, key)
+		require.NoError(t, err)
+		return value
+	}
+
+	before := receiver.started.Add(-time.Hour)
+	_, err := receiver.Export(ctx, request(sum("requests", cumulative, true, before, 100)))
+	require.NoError(t, err)
+	_, err = memStorage.GetCounter(ctx, key)
+	assert.Error(t, err, "серия, начатая до запуска, задаёт точку отсчёта")
+
+	_, err = receiver.Export(ctx, request(sum("requests", cumulative, true, before, 130)))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(30), counter())
+
+	restarted := receiver.started.Add(time.Minute)
+	_, err = receiver.Export(ctx, request(sum("requests", cumulative, true, restarted, 7)))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(37), counter(), "после перезапуска серии значение целиком — приращение")
+
+	_, err = receiver.Export(ctx, request(sum("requests", cumulative, true, restarted, 7)))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(37), counter())
+}
+
+func TestReceiver_UpDownSumAndHistogram(t *testing.T) {
+	receiver, memStorage := newTestReceiver(t)
+	ctx := context.Background()
+
+	upDown := sum("connections", metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, false, time.Time{}, 5, -2)
+	histogram := &metricspb.Metric{Name: "latency", Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+		DataPoints: []*metricspb.HistogramDataPoint{{Count: 3}, {Count: 4}},
+	}}}
+
+	response, err := receiver.Export(ctx, request(upDown, histogram))
+	require.NoError(t, err)
+	require.NotNil(t, response.GetPartialSuccess())
+	assert.Equal(t, int64(2), response.GetPartialSuccess().GetRejectedDataPoints())
+	assert.Contains(t, response.GetPartialSuccess().GetErrorMessage(), "latency")
+
+	value, err := memStorage.GetGauge(ctx, repository.SeriesKey("connections", serviceLabels))
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, value)
+}
+
+func TestReceiver_FailedBatchKeepsWrittenSums(t *testing.T) {
+	receiver, memStorage := newTestReceiver(t)
+	ctx := context.Background()
+	delta := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	first, second := time.Unix(1700000000, 0), time.Unix(1700000010, 0)
+
+	point := func(at time.Time, value int64) *metricspb.NumberDataPoint {
+		return &metricspb.NumberDataPoint{TimeUnixNano: uint64(at.UnixNano()), Value: &metricspb.NumberDataPoint_AsInt{AsInt: value}}
+	}
+	upDown := sum("connections", delta, false, time.Time{})
+	upDown.GetSum().DataPoints = []*metricspb.NumberDataPoint{point(first, 3), point(second, 2)}
+	invalid := &metricspb.Metric{Name: `bad{name}`, Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+		DataPoints: []*metricspb.NumberDataPoint{point(second, 1)},
+	}}}
+
+	_, err := receiver.Export(ctx, request(upDown, invalid))
+	require.ErrorIs(t, err, repository.ErrInvalidMetricName)
+
+	key := repository.SeriesKey("connections", serviceLabels)
+	value, err := memStorage.GetGauge(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, value, "первый пакет записан")
+
+	_, err = receiver.Export(ctx, request(sum("connections", delta, false, time.Time{}, 1)))
+	require.NoError(t, err)
+	value, err = memStorage.GetGauge(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, value, "итог учитывает только записанные точки")
+}
+
+func TestReceiver_RejectsPointsWithoutValue(t *testing.T) {
+	receiver, memStorage := newTestReceiver(t)
+	ctx := context.Background()
+
+	gauge := &metricspb.Metric{Name: "queue.size", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+		DataPoints: []*metricspb.NumberDataPoint{
+			{Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: math.NaN()}},
+			{Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: math.Inf(1)}},
+			{Flags: uint32(metricspb.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK)},
+			{Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 2}},
+		},
+	}}}
+	delta := sum("requests", metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, true, time.Time{}, 3)
+	delta.GetSum().DataPoints = append(delta.GetSum().DataPoints, &metricspb.NumberDataPoint{})
+
+	response, err := receiver.Export(ctx, request(gauge, delta))
+	require.NoError(t, err)
+	require.NotNil(t, response.GetPartialSuccess())
+	assert.Equal(t, int64(4), response.GetPartialSuccess().GetRejectedDataPoints())
+	assert.Contains(t, response.GetPartialSuccess().GetErrorMessage(), "queue.size")
+
+	value, err := memStorage.GetGauge(ctx, repository.SeriesKey("queue.size", serviceLabels))
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+	counter, err := memStorage.GetCounter(ctx, repository.SeriesKey("requests", serviceLabels))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), counter)
+}
+
+func TestReceiver_ExpiresIdleSums(t *testing.T) {
+	receiver, memStorage := newTestReceiver(t)
+	ctx := context.Background()
+	cumulative := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	delta := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	receiver.started = time.Now().Add(-time.Hour)
+	started := time.Now().Add(-time.Minute)
+
+	_, err := receiver.Export(ctx, request(
+		sum("requests", cumulative, true, started, 10),
+		sum("connections", delta, false, time.Time{}, 5),
+	))
+	require.NoError(t, err)
+	require.Len(t, receiver.sums, 2)
+
+	receiver.expiry = 0
+	_, err = receiver.Export(ctx, request())
+	require.NoError(t, err)
+	assert.Empty(t, receiver.sums, "суммы без новых точек забываются")
+	receiver.expiry = sumExpiry
+
+	_, err = receiver.Export(ctx, request(
+		sum("requests", cumulative, true, started, 15),
+		sum("connections", delta, false, time.Time{}, -2),
+	))
+	require.NoError(t, err)
+	_, err = receiver.Export(ctx, request(sum("requests", cumulative, true, started, 18)))
+	require.NoError(t, err)
+
+	counter, err := memStorage.GetCounter(ctx, repository.SeriesKey("requests", serviceLabels))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(13), counter, "вернувшаяся серия задаёт точку отсчёта, а не учитывается повторно")
+	gauge, err := memStorage.GetGauge(ctx, repository.SeriesKey("connections", serviceLabels))
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, gauge, "итог delta-суммы продолжается с сохранённого значения")
+}
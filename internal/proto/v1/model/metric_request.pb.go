@@ -23,8 +23,14 @@ const (
 )
 
 type MetricsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Metrics       []*Metric              `protobuf:"bytes,1,rep,name=metrics" json:"metrics,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Metrics []*Metric              `protobuf:"bytes,1,rep,name=metrics" json:"metrics,omitempty"`
+	// Зашифрованный конверт с сериализованным MetricsRequest, заменяет metrics.
+	Envelope []byte `protobuf:"bytes,2,opt,name=envelope" json:"envelope,omitempty"`
+	// Ключ идемпотентности пакета: повторный запрос с тем же ключом не применяется.
+	IdempotencyKey *string `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey" json:"idempotency_key,omitempty"`
+	// Подпись сообщения потока StreamMetrics: HMAC от nonce открытия, номера сообщения и содержимого.
+	Signature     *string `protobuf:"bytes,4,opt,name=signature" json:"signature,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -66,12 +72,43 @@ func (x *MetricsRequest) GetMetrics() []*Metric {
 	return nil
 }
 
+func (x *MetricsRequest) GetEnvelope() []byte {
+	if x != nil {
+		return x.Envelope
+	}
+	return nil
+}
+
+func (x *MetricsRequest) GetIdempotencyKey() string {
+	if x != nil && x.IdempotencyKey != nil {
+		return *x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *MetricsRequest) GetSignature() string {
+	if x != nil && x.Signature != nil {
+		return *x.Signature
+	}
+	return ""
+}
+
 type Metric struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            *string                `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
-	Type          *string                `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
-	Delta         *int64                 `protobuf:"varint,3,opt,name=delta" json:"delta,omitempty"`
-	Value         *float64               `protobuf:"fixed64,4,opt,name=value" json:"value,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    *string                `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Type  *string                `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	Delta *int64                 `protobuf:"varint,3,opt,name=delta" json:"delta,omitempty"`
+	Value *float64               `protobuf:"fixed64,4,opt,name=value" json:"value,omitempty"`
+	// Метки серии, например host.
+	Labels map[string]string `protobuf:"bytes,5,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Готовые корзины для типа histogram, прибавляются к серии.
+	Histogram *Histogram `protobuf:"bytes,6,opt,name=histogram" json:"histogram,omitempty"`
+	// Квантили для типа summary, заменяют значение серии.
+	Summary *Summary `protobuf:"bytes,7,opt,name=summary" json:"summary,omitempty"`
+	// Наблюдения для типа histogram, раскладываются по корзинам buckets.
+	Observations []float64 `protobuf:"fixed64,8,rep,packed,name=observations" json:"observations,omitempty"`
+	// Границы корзин для observations, по умолчанию границы сервера.
+	Buckets       []float64 `protobuf:"fixed64,9,rep,packed,name=buckets" json:"buckets,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -134,18 +171,258 @@ func (x *Metric) GetValue() float64 {
 	return 0
 }
 
+func (x *Metric) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Metric) GetHistogram() *Histogram {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+func (x *Metric) GetSummary() *Summary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *Metric) GetObservations() []float64 {
+	if x != nil {
+		return x.Observations
+	}
+	return nil
+}
+
+func (x *Metric) GetBuckets() []float64 {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+type Histogram struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Верхние границы корзин по возрастанию без +Inf.
+	Bounds []float64 `protobuf:"fixed64,1,rep,packed,name=bounds" json:"bounds,omitempty"`
+	// Число наблюдений в каждой корзине (не накопительное), на одно больше числа границ.
+	Counts        []uint64 `protobuf:"varint,2,rep,packed,name=counts" json:"counts,omitempty"`
+	Sum           *float64 `protobuf:"fixed64,3,opt,name=sum" json:"sum,omitempty"`
+	Count         *uint64  `protobuf:"varint,4,opt,name=count" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Histogram) Reset() {
+	*x = Histogram{}
+	mi := &file_model_metric_request_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Histogram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Histogram) ProtoMessage() {}
+
+func (x *Histogram) ProtoReflect() protoreflect.Message {
+	mi := &file_model_metric_request_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Histogram.ProtoReflect.Descriptor instead.
+func (*Histogram) Descriptor() ([]byte, []int) {
+	return file_model_metric_request_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Histogram) GetBounds() []float64 {
+	if x != nil {
+		return x.Bounds
+	}
+	return nil
+}
+
+func (x *Histogram) GetCounts() []uint64 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+func (x *Histogram) GetSum() float64 {
+	if x != nil && x.Sum != nil {
+		return *x.Sum
+	}
+	return 0
+}
+
+func (x *Histogram) GetCount() uint64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+type Summary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quantiles     []*Quantile            `protobuf:"bytes,1,rep,name=quantiles" json:"quantiles,omitempty"`
+	Sum           *float64               `protobuf:"fixed64,2,opt,name=sum" json:"sum,omitempty"`
+	Count         *uint64                `protobuf:"varint,3,opt,name=count" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Summary) Reset() {
+	*x = Summary{}
+	mi := &file_model_metric_request_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Summary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Summary) ProtoMessage() {}
+
+func (x *Summary) ProtoReflect() protoreflect.Message {
+	mi := &file_model_metric_request_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Summary.ProtoReflect.Descriptor instead.
+func (*Summary) Descriptor() ([]byte, []int) {
+	return file_model_metric_request_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Summary) GetQuantiles() []*Quantile {
+	if x != nil {
+		return x.Quantiles
+	}
+	return nil
+}
+
+func (x *Summary) GetSum() float64 {
+	if x != nil && x.Sum != nil {
+		return *x.Sum
+	}
+	return 0
+}
+
+func (x *Summary) GetCount() uint64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+type Quantile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quantile      *float64               `protobuf:"fixed64,1,opt,name=quantile" json:"quantile,omitempty"`
+	Value         *float64               `protobuf:"fixed64,2,opt,name=value" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quantile) Reset() {
+	*x = Quantile{}
+	mi := &file_model_metric_request_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quantile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quantile) ProtoMessage() {}
+
+func (x *Quantile) ProtoReflect() protoreflect.Message {
+	mi := &file_model_metric_request_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quantile.ProtoReflect.Descriptor instead.
+func (*Quantile) Descriptor() ([]byte, []int) {
+	return file_model_metric_request_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Quantile) GetQuantile() float64 {
+	if x != nil && x.Quantile != nil {
+		return *x.Quantile
+	}
+	return 0
+}
+
+func (x *Quantile) GetValue() float64 {
+	if x != nil && x.Value != nil {
+		return *x.Value
+	}
+	return 0
+}
+
 var File_model_metric_request_proto protoreflect.FileDescriptor
 
 const file_model_metric_request_proto_rawDesc = "" +
 	"\n" +
-	"\x1amodel/metric_request.proto\x12\x18metrics.go.grpc.v1.model\"L\n" +
+	"\x1amodel/metric_request.proto\x12\x18metrics.go.grpc.v1.model\"\xaf\x01\n" +
 	"\x0eMetricsRequest\x12:\n" +
-	"\ametrics\x18\x01 \x03(\v2 .metrics.go.grpc.v1.model.MetricR\ametrics\"X\n" +
+	"\ametrics\x18\x01 \x03(\v2 .metrics.go.grpc.v1.model.MetricR\ametrics\x12\x1a\n" +
+	"\benvelope\x18\x02 \x01(\fR\benvelope\x12'\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\tR\tsignature\"\x97\x03\n" +
 	"\x06Metric\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04type\x18\x02 \x01(\tR\x04type\x12\x14\n" +
 	"\x05delta\x18\x03 \x01(\x03R\x05delta\x12\x14\n" +
-	"\x05value\x18\x04 \x01(\x01R\x05valueB!Z\x1fmetrics/internal/proto/v1/modelb\beditionsp\xe8\a"
+	"\x05value\x18\x04 \x01(\x01R\x05value\x12D\n" +
+	"\x06labels\x18\x05 \x03(\v2,.metrics.go.grpc.v1.model.Metric.LabelsEntryR\x06labels\x12A\n" +
+	"\thistogram\x18\x06 \x01(\v2#.metrics.go.grpc.v1.model.HistogramR\thistogram\x12;\n" +
+	"\asummary\x18\a \x01(\v2!.metrics.go.grpc.v1.model.SummaryR\asummary\x12\"\n" +
+	"\fobservations\x18\b \x03(\x01R\fobservations\x12\x18\n" +
+	"\abuckets\x18\t \x03(\x01R\abuckets\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"c\n" +
+	"\tHistogram\x12\x16\n" +
+	"\x06bounds\x18\x01 \x03(\x01R\x06bounds\x12\x16\n" +
+	"\x06counts\x18\x02 \x03(\x04R\x06counts\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x04R\x05count\"s\n" +
+	"\aSummary\x12@\n" +
+	"\tquantiles\x18\x01 \x03(\v2\".metrics.go.grpc.v1.model.QuantileR\tquantiles\x12\x10\n" +
+	"\x03sum\x18\x02 \x01(\x01R\x03sum\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x04R\x05count\"<\n" +
+	"\bQuantile\x12\x1a\n" +
+	"\bquantile\x18\x01 \x01(\x01R\bquantile\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05valueB!Z\x1fmetrics/internal/proto/v1/modelb\beditionsp\xe8\a"
 
 var (
 	file_model_metric_request_proto_rawDescOnce sync.Once
@@ -159,18 +436,26 @@ func file_model_metric_request_proto_rawDescGZIP() []byte {
 	return file_model_metric_request_proto_rawDescData
 }
 
-var file_model_metric_request_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_model_metric_request_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_model_metric_request_proto_goTypes = []any{
 	(*MetricsRequest)(nil), // 0: metrics.go.grpc.v1.model.MetricsRequest
 	(*Metric)(nil),         // 1: metrics.go.grpc.v1.model.Metric
+	(*Histogram)(nil),      // 2: metrics.go.grpc.v1.model.Histogram
+	(*Summary)(nil),        // 3: metrics.go.grpc.v1.model.Summary
+	(*Quantile)(nil),       // 4: metrics.go.grpc.v1.model.Quantile
+	nil,                    // 5: metrics.go.grpc.v1.model.Metric.LabelsEntry
 }
 var file_model_metric_request_proto_depIdxs = []int32{
 	1, // 0: metrics.go.grpc.v1.model.MetricsRequest.metrics:type_name -> metrics.go.grpc.v1.model.Metric
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	5, // 1: metrics.go.grpc.v1.model.Metric.labels:type_name -> metrics.go.grpc.v1.model.Metric.LabelsEntry
+	2, // 2: metrics.go.grpc.v1.model.Metric.histogram:type_name -> metrics.go.grpc.v1.model.Histogram
+	3, // 3: metrics.go.grpc.v1.model.Metric.summary:type_name -> metrics.go.grpc.v1.model.Summary
+	4, // 4: metrics.go.grpc.v1.model.Summary.quantiles:type_name -> metrics.go.grpc.v1.model.Quantile
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_model_metric_request_proto_init() }
@@ -184,7 +469,7 @@ func file_model_metric_request_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_model_metric_request_proto_rawDesc), len(file_model_metric_request_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
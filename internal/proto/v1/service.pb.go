@@ -26,22 +26,54 @@ var File_service_proto protoreflect.FileDescriptor
 
 const file_service_proto_rawDesc = "" +
 	"\n" +
-	"\rservice.proto\x12\x12metrics.go.grpc.v1\x1a\x1amodel/metric_request.proto\x1a\x1bmodel/metric_response.proto2m\n" +
+	"\rservice.proto\x12\x12metrics.go.grpc.v1\x1a\x1amodel/metric_request.proto\x1a\x1bmodel/metric_response.proto\x1a\x19model/metric_stream.proto\x1a\x18model/metric_query.proto\x1a\x18model/metric_admin.proto2\xab\x06\n" +
 	"\aMetrics\x12b\n" +
-	"\vSendMetrics\x12(.metrics.go.grpc.v1.model.MetricsRequest\x1a).metrics.go.grpc.v1.model.MetricsResponseB\x1bZ\x19metrics/internal/proto/v1b\beditionsp\xe8\a"
+	"\vSendMetrics\x12(.metrics.go.grpc.v1.model.MetricsRequest\x1a).metrics.go.grpc.v1.model.MetricsResponse\x12c\n" +
+	"\rStreamMetrics\x12(.metrics.go.grpc.v1.model.MetricsRequest\x1a$.metrics.go.grpc.v1.model.MetricsAck(\x010\x01\x12X\n" +
+	"\x05Watch\x12&.metrics.go.grpc.v1.model.WatchRequest\x1a%.metrics.go.grpc.v1.model.MetricEvent0\x01\x12Y\n" +
+	"\tGetMetric\x12*.metrics.go.grpc.v1.model.GetMetricRequest\x1a .metrics.go.grpc.v1.model.Metric\x12j\n" +
+	"\vListMetrics\x12,.metrics.go.grpc.v1.model.ListMetricsRequest\x1a-.metrics.go.grpc.v1.model.ListMetricsResponse\x12f\n" +
+	"\fDeleteMetric\x12-.metrics.go.grpc.v1.model.DeleteMetricRequest\x1a'.metrics.go.grpc.v1.model.AdminResponse\x12f\n" +
+	"\fResetCounter\x12-.metrics.go.grpc.v1.model.ResetCounterRequest\x1a'.metrics.go.grpc.v1.model.AdminResponse\x12f\n" +
+	"\fRenameMetric\x12-.metrics.go.grpc.v1.model.RenameMetricRequest\x1a'.metrics.go.grpc.v1.model.AdminResponseB\x1bZ\x19metrics/internal/proto/v1b\beditionsp\xe8\a"
 
 var file_service_proto_goTypes = []any{
-	(*model.MetricsRequest)(nil),  // 0: metrics.go.grpc.v1.model.MetricsRequest
-	(*model.MetricsResponse)(nil), // 1: metrics.go.grpc.v1.model.MetricsResponse
+	(*model.MetricsRequest)(nil),      // 0: metrics.go.grpc.v1.model.MetricsRequest
+	(*model.WatchRequest)(nil),        // 1: metrics.go.grpc.v1.model.WatchRequest
+	(*model.GetMetricRequest)(nil),    // 2: metrics.go.grpc.v1.model.GetMetricRequest
+	(*model.ListMetricsRequest)(nil),  // 3: metrics.go.grpc.v1.model.ListMetricsRequest
+	(*model.DeleteMetricRequest)(nil), // 4: metrics.go.grpc.v1.model.DeleteMetricRequest
+	(*model.ResetCounterRequest)(nil), // 5: metrics.go.grpc.v1.model.ResetCounterRequest
+	(*model.RenameMetricRequest)(nil), // 6: metrics.go.grpc.v1.model.RenameMetricRequest
+	(*model.MetricsResponse)(nil),     // 7: metrics.go.grpc.v1.model.MetricsResponse
+	(*model.MetricsAck)(nil),          // 8: metrics.go.grpc.v1.model.MetricsAck
+	(*model.MetricEvent)(nil),         // 9: metrics.go.grpc.v1.model.MetricEvent
+	(*model.Metric)(nil),              // 10: metrics.go.grpc.v1.model.Metric
+	(*model.ListMetricsResponse)(nil), // 11: metrics.go.grpc.v1.model.ListMetricsResponse
+	(*model.AdminResponse)(nil),       // 12: metrics.go.grpc.v1.model.AdminResponse
 }
 var file_service_proto_depIdxs = []int32{
-	0, // 0: metrics.go.grpc.v1.Metrics.SendMetrics:input_type -> metrics.go.grpc.v1.model.MetricsRequest
-	1, // 1: metrics.go.grpc.v1.Metrics.SendMetrics:output_type -> metrics.go.grpc.v1.model.MetricsResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: metrics.go.grpc.v1.Metrics.SendMetrics:input_type -> metrics.go.grpc.v1.model.MetricsRequest
+	0,  // 1: metrics.go.grpc.v1.Metrics.StreamMetrics:input_type -> metrics.go.grpc.v1.model.MetricsRequest
+	1,  // 2: metrics.go.grpc.v1.Metrics.Watch:input_type -> metrics.go.grpc.v1.model.WatchRequest
+	2,  // 3: metrics.go.grpc.v1.Metrics.GetMetric:input_type -> metrics.go.grpc.v1.model.GetMetricRequest
+	3,  // 4: metrics.go.grpc.v1.Metrics.ListMetrics:input_type -> metrics.go.grpc.v1.model.ListMetricsRequest
+	4,  // 5: metrics.go.grpc.v1.Metrics.DeleteMetric:input_type -> metrics.go.grpc.v1.model.DeleteMetricRequest
+	5,  // 6: metrics.go.grpc.v1.Metrics.ResetCounter:input_type -> metrics.go.grpc.v1.model.ResetCounterRequest
+	6,  // 7: metrics.go.grpc.v1.Metrics.RenameMetric:input_type -> metrics.go.grpc.v1.model.RenameMetricRequest
+	7,  // 8: metrics.go.grpc.v1.Metrics.SendMetrics:output_type -> metrics.go.grpc.v1.model.MetricsResponse
+	8,  // 9: metrics.go.grpc.v1.Metrics.StreamMetrics:output_type -> metrics.go.grpc.v1.model.MetricsAck
+	9,  // 10: metrics.go.grpc.v1.Metrics.Watch:output_type -> metrics.go.grpc.v1.model.MetricEvent
+	10, // 11: metrics.go.grpc.v1.Metrics.GetMetric:output_type -> metrics.go.grpc.v1.model.Metric
+	11, // 12: metrics.go.grpc.v1.Metrics.ListMetrics:output_type -> metrics.go.grpc.v1.model.ListMetricsResponse
+	12, // 13: metrics.go.grpc.v1.Metrics.DeleteMetric:output_type -> metrics.go.grpc.v1.model.AdminResponse
+	12, // 14: metrics.go.grpc.v1.Metrics.ResetCounter:output_type -> metrics.go.grpc.v1.model.AdminResponse
+	12, // 15: metrics.go.grpc.v1.Metrics.RenameMetric:output_type -> metrics.go.grpc.v1.model.AdminResponse
+	8,  // [8:16] is the sub-list for method output_type
+	0,  // [0:8] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
 }
 
 func init() { file_service_proto_init() }
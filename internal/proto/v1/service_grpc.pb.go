@@ -21,7 +21,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Metrics_SendMetrics_FullMethodName = "/metrics.go.grpc.v1.Metrics/SendMetrics"
+	Metrics_SendMetrics_FullMethodName   = "/metrics.go.grpc.v1.Metrics/SendMetrics"
+	Metrics_StreamMetrics_FullMethodName = "/metrics.go.grpc.v1.Metrics/StreamMetrics"
+	Metrics_Watch_FullMethodName         = "/metrics.go.grpc.v1.Metrics/Watch"
+	Metrics_GetMetric_FullMethodName     = "/metrics.go.grpc.v1.Metrics/GetMetric"
+	Metrics_ListMetrics_FullMethodName   = "/metrics.go.grpc.v1.Metrics/ListMetrics"
+	Metrics_DeleteMetric_FullMethodName  = "/metrics.go.grpc.v1.Metrics/DeleteMetric"
+	Metrics_ResetCounter_FullMethodName  = "/metrics.go.grpc.v1.Metrics/ResetCounter"
+	Metrics_RenameMetric_FullMethodName  = "/metrics.go.grpc.v1.Metrics/RenameMetric"
 )
 
 // MetricsClient is the client API for Metrics service.
@@ -29,6 +36,20 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type MetricsClient interface {
 	SendMetrics(ctx context.Context, in *model.MetricsRequest, opts ...grpc.CallOption) (*model.MetricsResponse, error)
+	// StreamMetrics принимает отчёты агента в одном долгоживущем потоке и подтверждает каждый отдельно.
+	StreamMetrics(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[model.MetricsRequest, model.MetricsAck], error)
+	// Watch отправляет подписчику изменения метрик, подходящих под фильтр.
+	Watch(ctx context.Context, in *model.WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[model.MetricEvent], error)
+	// GetMetric возвращает текущее значение серии.
+	GetMetric(ctx context.Context, in *model.GetMetricRequest, opts ...grpc.CallOption) (*model.Metric, error)
+	// ListMetrics возвращает метрики постранично, упорядоченные по типу и ключу серии.
+	ListMetrics(ctx context.Context, in *model.ListMetricsRequest, opts ...grpc.CallOption) (*model.ListMetricsResponse, error)
+	// DeleteMetric удаляет серию вместе с историей.
+	DeleteMetric(ctx context.Context, in *model.DeleteMetricRequest, opts ...grpc.CallOption) (*model.AdminResponse, error)
+	// ResetCounter обнуляет counter.
+	ResetCounter(ctx context.Context, in *model.ResetCounterRequest, opts ...grpc.CallOption) (*model.AdminResponse, error)
+	// RenameMetric переносит серию вместе с историей под новое имя.
+	RenameMetric(ctx context.Context, in *model.RenameMetricRequest, opts ...grpc.CallOption) (*model.AdminResponse, error)
 }
 
 type metricsClient struct {
@@ -49,11 +70,107 @@ func (c *metricsClient) SendMetrics(ctx context.Context, in *model.MetricsReques
 	return out, nil
 }
 
+func (c *metricsClient) StreamMetrics(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[model.MetricsRequest, model.MetricsAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Metrics_ServiceDesc.Streams[0], Metrics_StreamMetrics_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[model.MetricsRequest, model.MetricsAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Metrics_StreamMetricsClient = grpc.BidiStreamingClient[model.MetricsRequest, model.MetricsAck]
+
+func (c *metricsClient) Watch(ctx context.Context, in *model.WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[model.MetricEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Metrics_ServiceDesc.Streams[1], Metrics_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[model.WatchRequest, model.MetricEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Metrics_WatchClient = grpc.ServerStreamingClient[model.MetricEvent]
+
+func (c *metricsClient) GetMetric(ctx context.Context, in *model.GetMetricRequest, opts ...grpc.CallOption) (*model.Metric, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(model.Metric)
+	err := c.cc.Invoke(ctx, Metrics_GetMetric_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsClient) ListMetrics(ctx context.Context, in *model.ListMetricsRequest, opts ...grpc.CallOption) (*model.ListMetricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(model.ListMetricsResponse)
+	err := c.cc.Invoke(ctx, Metrics_ListMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsClient) DeleteMetric(ctx context.Context, in *model.DeleteMetricRequest, opts ...grpc.CallOption) (*model.AdminResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(model.AdminResponse)
+	err := c.cc.Invoke(ctx, Metrics_DeleteMetric_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsClient) ResetCounter(ctx context.Context, in *model.ResetCounterRequest, opts ...grpc.CallOption) (*model.AdminResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(model.AdminResponse)
+	err := c.cc.Invoke(ctx, Metrics_ResetCounter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsClient) RenameMetric(ctx context.Context, in *model.RenameMetricRequest, opts ...grpc.CallOption) (*model.AdminResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(model.AdminResponse)
+	err := c.cc.Invoke(ctx, Metrics_RenameMetric_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetricsServer is the server API for Metrics service.
 // All implementations must embed UnimplementedMetricsServer
 // for forward compatibility.
 type MetricsServer interface {
 	SendMetrics(context.Context, *model.MetricsRequest) (*model.MetricsResponse, error)
+	// StreamMetrics принимает отчёты агента в одном долгоживущем потоке и подтверждает каждый отдельно.
+	StreamMetrics(grpc.BidiStreamingServer[model.MetricsRequest, model.MetricsAck]) error
+	// Watch отправляет подписчику изменения метрик, подходящих под фильтр.
+	Watch(*model.WatchRequest, grpc.ServerStreamingServer[model.MetricEvent]) error
+	// GetMetric возвращает текущее значение серии.
+	GetMetric(context.Context, *model.GetMetricRequest) (*model.Metric, error)
+	// ListMetrics возвращает метрики постранично, упорядоченные по типу и ключу серии.
+	ListMetrics(context.Context, *model.ListMetricsRequest) (*model.ListMetricsResponse, error)
+	// DeleteMetric удаляет серию вместе с историей.
+	DeleteMetric(context.Context, *model.DeleteMetricRequest) (*model.AdminResponse, error)
+	// ResetCounter обнуляет counter.
+	ResetCounter(context.Context, *model.ResetCounterRequest) (*model.AdminResponse, error)
+	// RenameMetric переносит серию вместе с историей под новое имя.
+	RenameMetric(context.Context, *model.RenameMetricRequest) (*model.AdminResponse, error)
 	mustEmbedUnimplementedMetricsServer()
 }
 
@@ -67,6 +184,27 @@ type UnimplementedMetricsServer struct{}
 func (UnimplementedMetricsServer) SendMetrics(context.Context, *model.MetricsRequest) (*model.MetricsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendMetrics not implemented")
 }
+func (UnimplementedMetricsServer) StreamMetrics(grpc.BidiStreamingServer[model.MetricsRequest, model.MetricsAck]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamMetrics not implemented")
+}
+func (UnimplementedMetricsServer) Watch(*model.WatchRequest, grpc.ServerStreamingServer[model.MetricEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedMetricsServer) GetMetric(context.Context, *model.GetMetricRequest) (*model.Metric, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetric not implemented")
+}
+func (UnimplementedMetricsServer) ListMetrics(context.Context, *model.ListMetricsRequest) (*model.ListMetricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMetrics not implemented")
+}
+func (UnimplementedMetricsServer) DeleteMetric(context.Context, *model.DeleteMetricRequest) (*model.AdminResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMetric not implemented")
+}
+func (UnimplementedMetricsServer) ResetCounter(context.Context, *model.ResetCounterRequest) (*model.AdminResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetCounter not implemented")
+}
+func (UnimplementedMetricsServer) RenameMetric(context.Context, *model.RenameMetricRequest) (*model.AdminResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameMetric not implemented")
+}
 func (UnimplementedMetricsServer) mustEmbedUnimplementedMetricsServer() {}
 func (UnimplementedMetricsServer) testEmbeddedByValue()                 {}
 
@@ -106,6 +244,114 @@ func _Metrics_SendMetrics_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Metrics_StreamMetrics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MetricsServer).StreamMetrics(&grpc.GenericServerStream[model.MetricsRequest, model.MetricsAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Metrics_StreamMetricsServer = grpc.BidiStreamingServer[model.MetricsRequest, model.MetricsAck]
+
+func _Metrics_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(model.WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MetricsServer).Watch(m, &grpc.GenericServerStream[model.WatchRequest, model.MetricEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Metrics_WatchServer = grpc.ServerStreamingServer[model.MetricEvent]
+
+func _Metrics_GetMetric_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(model.GetMetricRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServer).GetMetric(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Metrics_GetMetric_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServer).GetMetric(ctx, req.(*model.GetMetricRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Metrics_ListMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(model.ListMetricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServer).ListMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Metrics_ListMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServer).ListMetrics(ctx, req.(*model.ListMetricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Metrics_DeleteMetric_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(model.DeleteMetricRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServer).DeleteMetric(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Metrics_DeleteMetric_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServer).DeleteMetric(ctx, req.(*model.DeleteMetricRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Metrics_ResetCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(model.ResetCounterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServer).ResetCounter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Metrics_ResetCounter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServer).ResetCounter(ctx, req.(*model.ResetCounterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Metrics_RenameMetric_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(model.RenameMetricRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServer).RenameMetric(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Metrics_RenameMetric_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServer).RenameMetric(ctx, req.(*model.RenameMetricRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Metrics_ServiceDesc is the grpc.ServiceDesc for Metrics service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -117,7 +363,39 @@ var Metrics_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SendMetrics",
 			Handler:    _Metrics_SendMetrics_Handler,
 		},
+		{
+			MethodName: "GetMetric",
+			Handler:    _Metrics_GetMetric_Handler,
+		},
+		{
+			MethodName: "ListMetrics",
+			Handler:    _Metrics_ListMetrics_Handler,
+		},
+		{
+			MethodName: "DeleteMetric",
+			Handler:    _Metrics_DeleteMetric_Handler,
+		},
+		{
+			MethodName: "ResetCounter",
+			Handler:    _Metrics_ResetCounter_Handler,
+		},
+		{
+			MethodName: "RenameMetric",
+			Handler:    _Metrics_RenameMetric_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamMetrics",
+			Handler:       _Metrics_StreamMetrics_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Metrics_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "service.proto",
 }
@@ -2,8 +2,10 @@ package repository
 
 // Metric хранит информацию о метриках.
 type Metric struct {
-	Name  string
-	Value uint64
+	// Метки серии, дополняются меткой host при отправке.
+	Labels Labels
+	Name   string
+	Value  uint64
 }
 
 type AgentMetricsRepository interface {
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/shirou/gopsutil/cpu"
@@ -15,10 +16,15 @@ type SystemRepository struct {
 }
 
 func (r *SystemRepository) GetMetrics() []Metric {
+	return r.GetMetricsContext(context.Background())
+}
+
+// GetMetricsContext собирает метрики системы, прерывая опрос при отмене контекста.
+func (r *SystemRepository) GetMetricsContext(ctx context.Context) []Metric {
 	const percent = 100
 	var metrics []Metric
 
-	virtualMemory, err := mem.VirtualMemory()
+	virtualMemory, err := mem.VirtualMemoryWithContext(ctx)
 	if err == nil {
 		metrics = append(metrics,
 			Metric{Name: "TotalMemory", Value: virtualMemory.Total},
@@ -26,7 +32,7 @@ func (r *SystemRepository) GetMetrics() []Metric {
 		)
 	}
 
-	if cpuUsages, err := cpu.Percent(0, true); err == nil {
+	if cpuUsages, err := cpu.PercentWithContext(ctx, 0, true); err == nil {
 		for i, usage := range cpuUsages {
 			metrics = append(metrics, Metric{
 				Name:  "CPUutilization" + strconv.Itoa(i+1),
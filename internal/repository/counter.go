@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MaxCounter наибольшее значение counter. Counter хранится как uint64, но передаётся в JSON,
+// protobuf и колонке PostgreSQL BIGINT как знаковое 64-битное число.
+const MaxCounter = math.MaxInt64
+
+// ErrCounterOverflow значение counter после прибавления превысило бы MaxCounter.
+var ErrCounterOverflow = errors.New("counter overflow")
+
+// AddCounter прибавляет delta к значению counter или возвращает ErrCounterOverflow.
+func AddCounter(value, delta uint64) (uint64, error) {
+	if delta > MaxCounter || value > MaxCounter-delta {
+		return value, fmt.Errorf("%w: %d + %d exceeds %d", ErrCounterOverflow, value, delta, uint64(MaxCounter))
+	}
+	return value + delta, nil
+}
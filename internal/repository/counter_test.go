@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddCounter(t *testing.T) {
+	value, err := AddCounter(40, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), value)
+
+	value, err = AddCounter(MaxCounter-1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(MaxCounter), value)
+
+	_, err = AddCounter(MaxCounter, 1)
+	assert.ErrorIs(t, err, ErrCounterOverflow)
+
+	_, err = AddCounter(0, MaxCounter+1)
+	assert.ErrorIs(t, err, ErrCounterOverflow)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"metrics/internal/auth"
+	"metrics/internal/config"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CredentialRepository учётные данные агентов в таблице agent_credentials.
+// Данные читаются на каждый запрос, поэтому отзыв действует сразу на всех экземплярах сервера.
+type CredentialRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCredentialRepository открывает пул соединений для учётных данных. Миграции не запускаются:
+// с DSN метрики всегда хранятся в PostgreSQL, и таблицу уже создало хранилище метрик.
+// Пул закрывается методом Close.
+func NewCredentialRepository(ctx context.Context, dsn string) (*CredentialRepository, error) {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a connection pool: %w", err)
+	}
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &CredentialRepository{pool: pool}, nil
+}
+
+// Close закрывает пул соединений.
+func (r *CredentialRepository) Close() {
+	r.pool.Close()
+}
+
+func (r *CredentialRepository) Lookup(ctx context.Context, agentID string) (auth.Credential, error) {
+	query := `SELECT key, scopes, revoked_at IS NOT NULL FROM agent_credentials WHERE agent_id = $1`
+
+	var scopes []string
+	credential := auth.Credential{AgentID: agentID}
+	err := r.pool.QueryRow(ctx, query, agentID).Scan(&credential.Key, &scopes, &credential.Revoked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.Credential{}, auth.ErrUnknownAgent
+	}
+	if err != nil {
+		return auth.Credential{}, fmt.Errorf("error getting credentials of '%s': %w", agentID, err)
+	}
+	if credential.Revoked {
+		return auth.Credential{}, auth.ErrRevoked
+	}
+
+	for _, scope := range scopes {
+		credential.Scopes = append(credential.Scopes, auth.Scope(scope))
+	}
+	if err := credential.Validate(); err != nil {
+		return auth.Credential{}, fmt.Errorf("error credentials of '%s': %w", agentID, err)
+	}
+	return credential, nil
+}
+
+func (r *CredentialRepository) Revoke(ctx context.Context, agentID string) error {
+	query := `UPDATE agent_credentials SET revoked_at = COALESCE(revoked_at, now()) WHERE agent_id = $1`
+	tag, err := r.pool.Exec(ctx, query, agentID)
+	if err != nil {
+		return fmt.Errorf("error revoking credentials of '%s': %w", agentID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUnknownAgent
+	}
+	return nil
+}
+
+// NewCredentialStore возвращает хранилище учётных данных агентов по настройкам сервера
+// или nil, если проверка агентов выключена. Функцию закрытия нужно вызвать при остановке сервера.
+func NewCredentialStore(ctx context.Context, cfg *config.ServerConfig) (auth.Store, func(), error) {
+	noop := func() {}
+	switch {
+	case cfg.AuthFile != "" && cfg.AuthDatabase:
+		return nil, noop, errors.New("auth file and auth database are mutually exclusive")
+	case cfg.AuthFile != "":
+		store, err := auth.NewFileStore(cfg.AuthFile)
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to load credentials: %w", err)
+		}
+		return store, noop, nil
+	case cfg.AuthDatabase:
+		if cfg.DatabaseDsn == "" {
+			return nil, noop, errors.New("auth database requires database dsn")
+		}
+		store, err := NewCredentialRepository(ctx, cfg.DatabaseDsn)
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to create CredentialRepository: %w", err)
+		}
+		return store, store.Close, nil
+	default:
+		return nil, noop, nil
+	}
+}
@@ -2,15 +2,62 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"metrics/internal/config"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Запросы обновляют текущее значение и одновременно пишут сэмпл в историю.
+const (
+	upsertGaugeQuery = `
+		WITH upserted AS (
+			INSERT INTO metrics (name, labels, value, mtype)
+			VALUES ($1, $2::jsonb, $3, 'gauge')
+			ON CONFLICT (name, mtype, labels) DO UPDATE SET value = EXCLUDED.value
+			RETURNING name, labels, value
+		)
+		INSERT INTO metric_samples (name, labels, mtype, value, created_at)
+		SELECT name, labels, 'gauge', value, $4::timestamptz FROM upserted
+		RETURNING value
+	`
+	upsertCounterQuery = `
+		WITH upserted AS (
+			INSERT INTO metrics (name, labels, delta, mtype)
+			VALUES ($1, $2::jsonb, $3, 'counter')
+			ON CONFLICT (name, mtype, labels) DO UPDATE SET delta = metrics.delta + EXCLUDED.delta
+			RETURNING name, labels, delta
+		)
+		INSERT INTO metric_samples (name, labels, mtype, delta, created_at)
+		SELECT name, labels, 'counter', delta, $4::timestamptz FROM upserted
+		RETURNING delta
+	`
+	// Гистограмма и summary хранятся в колонке distribution в JSON.
+	insertHistogramQuery = `
+		INSERT INTO metrics (name, labels, mtype, distribution)
+		VALUES ($1, $2::jsonb, 'histogram', $3::jsonb)
+		ON CONFLICT (name, mtype, labels) DO NOTHING
+	`
+	upsertSummaryQuery = `
+		INSERT INTO metrics (name, labels, mtype, distribution)
+		VALUES ($1, $2::jsonb, 'summary', $3::jsonb)
+		ON CONFLICT (name, mtype, labels) DO UPDATE SET distribution = EXCLUDED.distribution
+	`
+)
+
+// Коды ошибок PostgreSQL: переполнение BIGINT и нарушение уникальности.
+const (
+	numericValueOutOfRange = "22003"
+	uniqueViolation        = "23505"
+)
+
 type DBRepository struct {
 	pool   *pgxpool.Pool
 	cfg    *config.ServerConfig
@@ -34,14 +81,13 @@ func NewDBRepository(
 }
 
 func (r *DBRepository) SetGauge(ctx context.Context, name string, value float64) (float64, error) {
-	query := `
-		INSERT INTO metrics (name, value, mtype)
-		VALUES ($1, $2, 'gauge')
-		ON CONFLICT (name) DO UPDATE SET value = $2, mtype = 'gauge'
-		RETURNING value
-	`
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return 0, err
+	}
+
 	var newValue float64
-	err := r.pool.QueryRow(ctx, query, name, value).Scan(&newValue)
+	err = r.pool.QueryRow(ctx, upsertGaugeQuery, metricName, labels, value, sampleTime(ctx)).Scan(&newValue)
 	if err != nil {
 		return 0, fmt.Errorf("error setting gauge '%s': %w", name, err)
 	}
@@ -50,9 +96,14 @@ func (r *DBRepository) SetGauge(ctx context.Context, name string, value float64)
 }
 
 func (r *DBRepository) GetGauge(ctx context.Context, name string) (float64, error) {
-	query := `SELECT value FROM metrics WHERE name = $1 AND mtype = 'gauge'`
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `SELECT value FROM metrics WHERE name = $1 AND labels = $2::jsonb AND mtype = 'gauge'`
 	var value float64
-	err := r.pool.QueryRow(ctx, query, name).Scan(&value)
+	err = r.pool.QueryRow(ctx, query, metricName, labels).Scan(&value)
 	if err != nil {
 		return 0, fmt.Errorf("error getting gauge '%s': %w", name, err)
 	}
@@ -60,25 +111,32 @@ func (r *DBRepository) GetGauge(ctx context.Context, name string) (float64, erro
 }
 
 func (r *DBRepository) SetCounter(ctx context.Context, name string, value uint64) (uint64, error) {
-	query := `
-		INSERT INTO metrics (name, delta, mtype)
-		VALUES ($1, $2, 'counter')
-		ON CONFLICT (name) DO UPDATE SET delta = metrics.delta + $2, mtype = 'counter'
-		RETURNING delta
-	`
+	if _, err := AddCounter(0, value); err != nil {
+		return 0, fmt.Errorf("error setting counter '%s': %w", name, err)
+	}
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return 0, err
+	}
+
 	var newValue uint64
-	err := r.pool.QueryRow(ctx, query, name, value).Scan(&newValue)
+	err = r.pool.QueryRow(ctx, upsertCounterQuery, metricName, labels, value, sampleTime(ctx)).Scan(&newValue)
 	if err != nil {
-		return 0, fmt.Errorf("error setting gauge '%s': %w", name, err)
+		return 0, fmt.Errorf("error setting counter '%s': %w", name, counterError(err))
 	}
 
 	return newValue, nil
 }
 
 func (r *DBRepository) GetCounter(ctx context.Context, name string) (uint64, error) {
-	query := `SELECT delta FROM metrics WHERE name = $1 AND mtype = 'counter'`
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `SELECT delta FROM metrics WHERE name = $1 AND labels = $2::jsonb AND mtype = 'counter'`
 	var value uint64
-	err := r.pool.QueryRow(ctx, query, name).Scan(&value)
+	err = r.pool.QueryRow(ctx, query, metricName, labels).Scan(&value)
 	if err != nil {
 		return 0, fmt.Errorf("error getting counter '%s': %w", name, err)
 	}
@@ -86,7 +144,7 @@ func (r *DBRepository) GetCounter(ctx context.Context, name string) (uint64, err
 }
 
 func (r *DBRepository) Gauges(ctx context.Context) (map[string]float64, error) {
-	query := `SELECT name, value FROM metrics WHERE mtype = 'gauge'`
+	query := `SELECT name, labels, value FROM metrics WHERE mtype = 'gauge'`
 	rows, err := r.pool.Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("error get Gauges: %w", err)
@@ -96,17 +154,18 @@ func (r *DBRepository) Gauges(ctx context.Context) (map[string]float64, error) {
 	gauges := make(map[string]float64)
 	for rows.Next() {
 		var name string
+		var labels Labels
 		var value float64
-		if err := rows.Scan(&name, &value); err != nil {
+		if err := rows.Scan(&name, &labels, &value); err != nil {
 			continue
 		}
-		gauges[name] = value
+		gauges[SeriesKey(name, labels)] = value
 	}
 	return gauges, nil
 }
 
 func (r *DBRepository) Counters(ctx context.Context) (map[string]uint64, error) {
-	query := `SELECT name, delta FROM metrics WHERE mtype = 'counter'`
+	query := `SELECT name, labels, delta FROM metrics WHERE mtype = 'counter'`
 	rows, err := r.pool.Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("error get counters: %w", err)
@@ -116,12 +175,13 @@ func (r *DBRepository) Counters(ctx context.Context) (map[string]uint64, error)
 	counters := make(map[string]uint64)
 	for rows.Next() {
 		var name string
+		var labels Labels
 		var delta float64
-		if err := rows.Scan(&name, &delta); err != nil {
+		if err := rows.Scan(&name, &labels, &delta); err != nil {
 			fmt.Printf("error scanning row: %v\n", err)
 			continue
 		}
-		counters[name] = uint64(delta)
+		counters[SeriesKey(name, labels)] = uint64(delta)
 	}
 	return counters, nil
 }
@@ -131,32 +191,474 @@ func (r *DBRepository) UpdateCounterAndGauges(
 	counters map[string]uint64,
 	gauges map[string]float64,
 ) error {
-	batch := new(pgx.Batch)
+	batch, err := upsertBatch(counters, gauges, sampleTime(ctx))
+	if err != nil {
+		return err
+	}
+
+	// Пакет выполняется одной неявной транзакцией: при переполнении counter не применяется ничего.
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("error send batch: %w", counterError(err))
+	}
+	return nil
+}
+
+// ApplyOnce записывает ключ, counter, gauge, гистограммы и summary одной транзакцией: если гистограмма
+// не совпадает с серией по границам или запись не удалась, ключ не сохраняется и повтор применяется заново.
+func (r *DBRepository) ApplyOnce(
+	ctx context.Context,
+	key string,
+	counters map[string]uint64,
+	gauges map[string]float64,
+	distributions Distributions,
+) (bool, error) {
+	for name, histogram := range distributions.Histograms {
+		if err := histogram.Validate(); err != nil {
+			return false, fmt.Errorf("histogram '%s': %w", name, err)
+		}
+	}
+	for name, summary := range distributions.Summaries {
+		if err := summary.Validate(); err != nil {
+			return false, fmt.Errorf("summary '%s': %w", name, err)
+		}
+	}
+
+	applied := false
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, time.Now().Add(-idempotencyTTL))
+		if err != nil {
+			return fmt.Errorf("error delete expired idempotency keys: %w", err)
+		}
+
+		tag, err := tx.Exec(ctx, `INSERT INTO idempotency_keys (key) VALUES ($1) ON CONFLICT DO NOTHING`, key)
+		if err != nil {
+			return fmt.Errorf("error save idempotency key '%s': %w", key, err)
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		batch, err := upsertBatch(counters, gauges, sampleTime(ctx))
+		if err != nil {
+			return err
+		}
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return fmt.Errorf("error send batch: %w", counterError(err))
+		}
+		for name, histogram := range distributions.Histograms {
+			if _, err := addHistogram(ctx, tx, name, histogram); err != nil {
+				return fmt.Errorf("error setting histogram '%s': %w", name, err)
+			}
+		}
+		for name, summary := range distributions.Summaries {
+			if err := setSummary(ctx, tx, name, summary); err != nil {
+				return err
+			}
+		}
+
+		applied = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("error apply once '%s': %w", key, err)
+	}
+
+	return applied, nil
+}
+
+func (r *DBRepository) GaugeHistory(
+	ctx context.Context,
+	name string,
+	from, to time.Time,
+) ([]GaugeSample, error) {
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT created_at, value FROM metric_samples
+		WHERE name = $1 AND labels = $2::jsonb AND mtype = 'gauge' AND created_at BETWEEN $3 AND $4
+		ORDER BY created_at, id
+	`
+	rows, err := r.pool.Query(ctx, query, metricName, labels, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error get gauge history '%s': %w", name, err)
+	}
+	defer rows.Close()
+
+	samples := make([]GaugeSample, 0)
+	for rows.Next() {
+		var sample GaugeSample
+		if err := rows.Scan(&sample.Timestamp, &sample.Value); err != nil {
+			return nil, fmt.Errorf("error scanning gauge sample: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading gauge history '%s': %w", name, err)
+	}
+	return samples, nil
+}
+
+func (r *DBRepository) CounterHistory(
+	ctx context.Context,
+	name string,
+	from, to time.Time,
+) ([]CounterSample, error) {
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT created_at, delta FROM metric_samples
+		WHERE name = $1 AND labels = $2::jsonb AND mtype = 'counter' AND created_at BETWEEN $3 AND $4
+		ORDER BY created_at, id
+	`
+	rows, err := r.pool.Query(ctx, query, metricName, labels, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error get counter history '%s': %w", name, err)
+	}
+	defer rows.Close()
+
+	samples := make([]CounterSample, 0)
+	for rows.Next() {
+		var sample CounterSample
+		if err := rows.Scan(&sample.Timestamp, &sample.Value); err != nil {
+			return nil, fmt.Errorf("error scanning counter sample: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading counter history '%s': %w", name, err)
+	}
+	return samples, nil
+}
+
+// SetHistogram прибавляет наблюдения в транзакции: строка серии блокируется до записи суммы.
+func (r *DBRepository) SetHistogram(ctx context.Context, name string, value Histogram) (Histogram, error) {
+	if err := value.Validate(); err != nil {
+		return Histogram{}, err
+	}
+
+	var result Histogram
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		result, err = addHistogram(ctx, tx, name, value)
+		return err
+	})
+	if err != nil {
+		return Histogram{}, fmt.Errorf("error setting histogram '%s': %w", name, err)
+	}
+	return result, nil
+}
+
+// addHistogram прибавляет проверенную гистограмму к серии в транзакции tx.
+func addHistogram(ctx context.Context, tx pgx.Tx, name string, value Histogram) (Histogram, error) {
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return Histogram{}, err
+	}
+	empty, err := json.Marshal(NewHistogram(value.Bounds))
+	if err != nil {
+		return Histogram{}, fmt.Errorf("error encode histogram '%s': %w", name, err)
+	}
+
+	if _, err = tx.Exec(ctx, insertHistogramQuery, metricName, labels, string(empty)); err != nil {
+		return Histogram{}, fmt.Errorf("error insert histogram: %w", err)
+	}
+
+	query := `
+		SELECT distribution FROM metrics
+		WHERE name = $1 AND labels = $2::jsonb AND mtype = 'histogram'
+		FOR UPDATE
+	`
+	var current Histogram
+	if err = scanDistribution(tx.QueryRow(ctx, query, metricName, labels), &current); err != nil {
+		return Histogram{}, err
+	}
+	result, err := current.Add(value)
+	if err != nil {
+		return Histogram{}, err
+	}
 
-	upsertCounterQuery := `
-		INSERT INTO metrics (name, delta, mtype)
-		VALUES ($1, $2, 'counter')
-		ON CONFLICT (name) DO UPDATE SET delta = metrics.delta + EXCLUDED.delta, mtype = 'counter'`
-	for counterName, counterValue := range counters {
-		batch.Queue(upsertCounterQuery, counterName, counterValue)
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return Histogram{}, fmt.Errorf("error encode histogram: %w", err)
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE metrics SET distribution = $3::jsonb WHERE name = $1 AND labels = $2::jsonb AND mtype = 'histogram'`,
+		metricName, labels, string(encoded))
+	if err != nil {
+		return Histogram{}, fmt.Errorf("error update histogram: %w", err)
 	}
+	return result, nil
+}
 
-	upsertGaugesQuery := `
-		INSERT INTO metrics (name, value, mtype)
-		VALUES ($1, $2, 'gauge')
-		ON CONFLICT (name) DO UPDATE SET value = $2, mtype = 'gauge'`
-	for gaugeName, gaugeValue := range gauges {
-		batch.Queue(upsertGaugesQuery, gaugeName, gaugeValue)
+func (r *DBRepository) GetHistogram(ctx context.Context, name string) (Histogram, error) {
+	var value Histogram
+	if err := r.getDistribution(ctx, name, "histogram", &value); err != nil {
+		return Histogram{}, err
+	}
+	return value, nil
+}
+
+func (r *DBRepository) Histograms(ctx context.Context) (map[string]Histogram, error) {
+	histograms := make(map[string]Histogram)
+	err := r.listDistributions(ctx, "histogram", func(key string, data []byte) error {
+		var value Histogram
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		histograms[key] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return histograms, nil
+}
+
+func (r *DBRepository) SetSummary(ctx context.Context, name string, value Summary) (Summary, error) {
+	if err := value.Validate(); err != nil {
+		return Summary{}, err
+	}
+	if err := setSummary(ctx, r.pool, name, value); err != nil {
+		return Summary{}, err
 	}
+	return value, nil
+}
+
+// executor пул соединений или транзакция.
+type executor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// setSummary заменяет значение summary серии.
+func setSummary(ctx context.Context, db executor, name string, value Summary) error {
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error encode summary '%s': %w", name, err)
+	}
+
+	if _, err = db.Exec(ctx, upsertSummaryQuery, metricName, labels, string(encoded)); err != nil {
+		return fmt.Errorf("error setting summary '%s': %w", name, err)
+	}
+	return nil
+}
+
+func (r *DBRepository) GetSummary(ctx context.Context, name string) (Summary, error) {
+	var value Summary
+	if err := r.getDistribution(ctx, name, "summary", &value); err != nil {
+		return Summary{}, err
+	}
+	return value, nil
+}
+
+func (r *DBRepository) Summaries(ctx context.Context) (map[string]Summary, error) {
+	summaries := make(map[string]Summary)
+	err := r.listDistributions(ctx, "summary", func(key string, data []byte) error {
+		var value Summary
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		summaries[key] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+func (r *DBRepository) getDistribution(ctx context.Context, name, mtype string, value any) error {
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT distribution FROM metrics WHERE name = $1 AND labels = $2::jsonb AND mtype = $3`
+	if err := scanDistribution(r.pool.QueryRow(ctx, query, metricName, labels, mtype), value); err != nil {
+		return fmt.Errorf("error getting %s '%s': %w", mtype, name, err)
+	}
+	return nil
+}
+
+func (r *DBRepository) listDistributions(ctx context.Context, mtype string, add func(key string, data []byte) error) error {
+	query := `SELECT name, labels, distribution FROM metrics WHERE mtype = $1 AND distribution IS NOT NULL`
+	rows, err := r.pool.Query(ctx, query, mtype)
+	if err != nil {
+		return fmt.Errorf("error get %s metrics: %w", mtype, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var labels Labels
+		var data []byte
+		if err := rows.Scan(&name, &labels, &data); err != nil {
+			return fmt.Errorf("error scanning %s row: %w", mtype, err)
+		}
+		if err := add(SeriesKey(name, labels), data); err != nil {
+			return fmt.Errorf("error decode %s '%s': %w", mtype, name, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error reading %s metrics: %w", mtype, err)
+	}
+	return nil
+}
 
-	results := r.pool.SendBatch(ctx, batch)
-	defer func(results pgx.BatchResults) {
-		err := results.Close()
+func scanDistribution(row pgx.Row, value any) error {
+	var data []byte
+	if err := row.Scan(&data); err != nil {
+		return fmt.Errorf("error scanning distribution: %w", err)
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("error decode distribution: %w", err)
+	}
+	return nil
+}
+
+func (r *DBRepository) Delete(ctx context.Context, mtype MetricType, name string) error {
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return err
+	}
+
+	err = pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx,
+			`DELETE FROM metrics WHERE name = $1 AND labels = $2::jsonb AND mtype = $3`,
+			metricName, labels, string(mtype))
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrSeriesNotFound
+		}
+		_, err = tx.Exec(ctx,
+			`DELETE FROM metric_samples WHERE name = $1 AND labels = $2::jsonb AND mtype = $3`,
+			metricName, labels, string(mtype))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting %s '%s': %w", mtype, name, err)
+	}
+	return nil
+}
+
+func (r *DBRepository) ResetCounter(ctx context.Context, name string) error {
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		WITH reset AS (
+			UPDATE metrics SET delta = 0
+			WHERE name = $1 AND labels = $2::jsonb AND mtype = 'counter'
+			RETURNING name, labels, delta
+		)
+		INSERT INTO metric_samples (name, labels, mtype, delta, created_at)
+		SELECT name, labels, 'counter', delta, $3::timestamptz FROM reset
+	`
+	tag, err := r.pool.Exec(ctx, query, metricName, labels, sampleTime(ctx))
+	if err != nil {
+		return fmt.Errorf("error resetting counter '%s': %w", name, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("error resetting counter '%s': %w", name, ErrSeriesNotFound)
+	}
+	return nil
+}
+
+func (r *DBRepository) Rename(ctx context.Context, mtype MetricType, name, newName string) error {
+	metricName, labels, err := seriesArgs(name)
+	if err != nil {
+		return err
+	}
+	newMetricName, newLabels, err := seriesArgs(newName)
+	if err != nil {
+		return err
+	}
+
+	err = pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE metrics SET name = $4, labels = $5::jsonb
+			WHERE name = $1 AND labels = $2::jsonb AND mtype = $3`,
+			metricName, labels, string(mtype), newMetricName, newLabels)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrSeriesExists
+		}
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrSeriesNotFound
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE metric_samples SET name = $4, labels = $5::jsonb
+			WHERE name = $1 AND labels = $2::jsonb AND mtype = $3`,
+			metricName, labels, string(mtype), newMetricName, newLabels)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error renaming %s '%s' to '%s': %w", mtype, name, newName, err)
+	}
+	return nil
+}
+
+// counterError сообщает о переполнении BIGINT в колонке delta как ErrCounterOverflow.
+func counterError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == numericValueOutOfRange {
+		return fmt.Errorf("%w: %s", ErrCounterOverflow, pgErr.Message)
+	}
+	return err
+}
+
+// seriesArgs раскладывает ключ серии на имя и метки в виде JSON для колонки labels.
+func seriesArgs(key string) (string, string, error) {
+	name, labels := ParseSeriesKey(key)
+	encoded, err := json.Marshal(labels)
+	if err != nil {
+		return "", "", fmt.Errorf("error encode labels of '%s': %w", key, err)
+	}
+	return name, string(encoded), nil
+}
+
+func upsertBatch(counters map[string]uint64, gauges map[string]float64, at time.Time) (*pgx.Batch, error) {
+	batch := new(pgx.Batch)
+	for counterKey, counterValue := range counters {
+		if _, err := AddCounter(0, counterValue); err != nil {
+			return nil, fmt.Errorf("counter '%s': %w", counterKey, err)
+		}
+		name, labels, err := seriesArgs(counterKey)
+		if err != nil {
+			return nil, err
+		}
+		batch.Queue(upsertCounterQuery, name, labels, counterValue, at)
+	}
+	for gaugeKey, gaugeValue := range gauges {
+		name, labels, err := seriesArgs(gaugeKey)
 		if err != nil {
-			r.logger.Infoln("Error send batch", err)
+			return nil, err
 		}
-	}(results)
+		batch.Queue(upsertGaugeQuery, name, labels, gaugeValue, at)
+	}
+	return batch, nil
+}
 
+func (r *DBRepository) Ping(ctx context.Context) error {
+	if err := r.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("ping database: %w", err)
+	}
 	return nil
 }
 
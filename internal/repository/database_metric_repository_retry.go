@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"metrics/internal/config"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -113,5 +114,132 @@ func (r *RetryDBRepository) UpdateCounterAndGauges(
 	})
 }
 
+func (r *RetryDBRepository) ApplyOnce(
+	ctx context.Context,
+	key string,
+	counters map[string]uint64,
+	gauges map[string]float64,
+	distributions Distributions,
+) (bool, error) {
+	var applied bool
+	err := retry(ctx, func() error {
+		var err error
+		applied, err = r.storage.ApplyOnce(ctx, key, counters, gauges, distributions)
+		return err
+	})
+	return applied, err
+}
+
+func (r *RetryDBRepository) GaugeHistory(
+	ctx context.Context,
+	name string,
+	from, to time.Time,
+) ([]GaugeSample, error) {
+	var result []GaugeSample
+	err := retry(ctx, func() error {
+		var err error
+		result, err = r.storage.GaugeHistory(ctx, name, from, to)
+		return err
+	})
+	return result, err
+}
+
+func (r *RetryDBRepository) CounterHistory(
+	ctx context.Context,
+	name string,
+	from, to time.Time,
+) ([]CounterSample, error) {
+	var result []CounterSample
+	err := retry(ctx, func() error {
+		var err error
+		result, err = r.storage.CounterHistory(ctx, name, from, to)
+		return err
+	})
+	return result, err
+}
+
+func (r *RetryDBRepository) SetHistogram(ctx context.Context, name string, value Histogram) (Histogram, error) {
+	var result Histogram
+	err := retry(ctx, func() error {
+		var err error
+		result, err = r.storage.SetHistogram(ctx, name, value)
+		return err
+	})
+	return result, err
+}
+
+func (r *RetryDBRepository) GetHistogram(ctx context.Context, name string) (Histogram, error) {
+	var result Histogram
+	err := retry(ctx, func() error {
+		var err error
+		result, err = r.storage.GetHistogram(ctx, name)
+		return err
+	})
+	return result, err
+}
+
+func (r *RetryDBRepository) Histograms(ctx context.Context) (map[string]Histogram, error) {
+	var result map[string]Histogram
+	err := retry(ctx, func() error {
+		var err error
+		result, err = r.storage.Histograms(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (r *RetryDBRepository) SetSummary(ctx context.Context, name string, value Summary) (Summary, error) {
+	var result Summary
+	err := retry(ctx, func() error {
+		var err error
+		result, err = r.storage.SetSummary(ctx, name, value)
+		return err
+	})
+	return result, err
+}
+
+func (r *RetryDBRepository) GetSummary(ctx context.Context, name string) (Summary, error) {
+	var result Summary
+	err := retry(ctx, func() error {
+		var err error
+		result, err = r.storage.GetSummary(ctx, name)
+		return err
+	})
+	return result, err
+}
+
+func (r *RetryDBRepository) Summaries(ctx context.Context) (map[string]Summary, error) {
+	var result map[string]Summary
+	err := retry(ctx, func() error {
+		var err error
+		result, err = r.storage.Summaries(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (r *RetryDBRepository) Delete(ctx context.Context, mtype MetricType, name string) error {
+	return retry(ctx, func() error {
+		return r.storage.Delete(ctx, mtype, name)
+	})
+}
+
+func (r *RetryDBRepository) ResetCounter(ctx context.Context, name string) error {
+	return retry(ctx, func() error {
+		return r.storage.ResetCounter(ctx, name)
+	})
+}
+
+func (r *RetryDBRepository) Rename(ctx context.Context, mtype MetricType, name, newName string) error {
+	return retry(ctx, func() error {
+		return r.storage.Rename(ctx, mtype, name, newName)
+	})
+}
+
+// Ping не повторяет проверку: готовность отражает текущее состояние базы.
+func (r *RetryDBRepository) Ping(ctx context.Context) error {
+	return r.storage.Ping(ctx)
+}
+
 func (r *RetryDBRepository) Shutdown(ctx context.Context) {
 }
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// ErrInvalidDistribution гистограмма или summary заданы неверно, например границы корзин
+// не совпадают с границами серии.
+var ErrInvalidDistribution = errors.New("invalid distribution")
+
+// Distributions гистограммы и summary пакета: гистограммы прибавляются к сериям, summary заменяют значения.
+type Distributions struct {
+	Histograms map[string]Histogram
+	Summaries  map[string]Summary
+}
+
+// Histogram распределение наблюдений по корзинам.
+type Histogram struct {
+	// Верхние границы корзин по возрастанию, корзина +Inf не указывается.
+	Bounds []float64 `json:"bounds"`
+	// Число наблюдений в каждой корзине (не накопительное), на одно больше числа границ.
+	Counts []uint64 `json:"counts"`
+	// Сумма наблюдений.
+	Sum float64 `json:"sum"`
+	// Число наблюдений.
+	Count uint64 `json:"count"`
+}
+
+// NewHistogram создаёт пустую гистограмму с границами bounds.
+func NewHistogram(bounds []float64) Histogram {
+	return Histogram{Bounds: slices.Clone(bounds), Counts: make([]uint64, len(bounds)+1)}
+}
+
+// Observe добавляет наблюдение в корзину с наименьшей границей не меньше value.
+func (h *Histogram) Observe(value float64) {
+	h.Counts[sort.SearchFloat64s(h.Bounds, value)]++
+	h.Sum += value
+	h.Count++
+}
+
+// Validate проверяет, что границы конечны и возрастают, а число наблюдений совпадает с суммой корзин.
+func (h Histogram) Validate() error {
+	if err := validateBounds(h.Bounds); err != nil {
+		return err
+	}
+	if len(h.Counts) != len(h.Bounds)+1 {
+		return fmt.Errorf("%w: histogram has %d bounds and %d counts, expected %d counts",
+			ErrInvalidDistribution, len(h.Bounds), len(h.Counts), len(h.Bounds)+1)
+	}
+	var count uint64
+	for _, c := range h.Counts {
+		count += c
+	}
+	if count != h.Count {
+		return fmt.Errorf("%w: histogram count %d does not match bucket counts %d", ErrInvalidDistribution, h.Count, count)
+	}
+	if math.IsNaN(h.Sum) || math.IsInf(h.Sum, 0) {
+		return fmt.Errorf("%w: histogram sum must be finite", ErrInvalidDistribution)
+	}
+	return nil
+}
+
+// Add прибавляет к гистограмме наблюдения other с теми же границами корзин.
+func (h Histogram) Add(other Histogram) (Histogram, error) {
+	if !slices.Equal(h.Bounds, other.Bounds) {
+		return h, fmt.Errorf("%w: histogram bounds %v do not match series bounds %v",
+			ErrInvalidDistribution, other.Bounds, h.Bounds)
+	}
+	result := h.Clone()
+	for i, c := range other.Counts {
+		result.Counts[i] += c
+	}
+	result.Sum += other.Sum
+	result.Count += other.Count
+	return result, nil
+}
+
+// Clone копирует гистограмму вместе с корзинами.
+func (h Histogram) Clone() Histogram {
+	h.Bounds = slices.Clone(h.Bounds)
+	h.Counts = slices.Clone(h.Counts)
+	return h
+}
+
+// Quantile значение квантиля summary.
+type Quantile struct {
+	// Квантиль от 0 до 1, например 0.99.
+	Quantile float64 `json:"quantile"`
+	// Значение квантиля.
+	Value float64 `json:"value"`
+}
+
+// Summary квантили, вычисленные источником наблюдений, с суммой и числом наблюдений.
+// Новое значение заменяет предыдущее.
+type Summary struct {
+	// Квантили по возрастанию.
+	Quantiles []Quantile `json:"quantiles"`
+	// Сумма наблюдений.
+	Sum float64 `json:"sum"`
+	// Число наблюдений.
+	Count uint64 `json:"count"`
+}
+
+// Validate проверяет, что квантили лежат в [0, 1] и возрастают.
+func (s Summary) Validate() error {
+	for i, q := range s.Quantiles {
+		if math.IsNaN(q.Quantile) || q.Quantile < 0 || q.Quantile > 1 {
+			return fmt.Errorf("%w: quantile %v is out of [0, 1]", ErrInvalidDistribution, q.Quantile)
+		}
+		if i > 0 && q.Quantile <= s.Quantiles[i-1].Quantile {
+			return fmt.Errorf("%w: quantiles must be in ascending order", ErrInvalidDistribution)
+		}
+	}
+	if math.IsNaN(s.Sum) || math.IsInf(s.Sum, 0) {
+		return fmt.Errorf("%w: summary sum must be finite", ErrInvalidDistribution)
+	}
+	return nil
+}
+
+// Clone копирует summary вместе с квантилями.
+func (s Summary) Clone() Summary {
+	s.Quantiles = slices.Clone(s.Quantiles)
+	return s
+}
+
+func validateBounds(bounds []float64) error {
+	for i, bound := range bounds {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) {
+			return fmt.Errorf("%w: histogram bound %v must be finite", ErrInvalidDistribution, bound)
+		}
+		if i > 0 && bound <= bounds[i-1] {
+			return fmt.Errorf("%w: histogram bounds must be in ascending order", ErrInvalidDistribution)
+		}
+	}
+	return nil
+}
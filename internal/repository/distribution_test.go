@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogram_Observe(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 0.5, 1})
+	for _, v := range []float64{0.05, 0.1, 0.3, 2} {
+		h.Observe(v)
+	}
+
+	assert.Equal(t, []uint64{2, 1, 0, 1}, h.Counts, "граница входит в свою корзину")
+	assert.Equal(t, uint64(4), h.Count)
+	assert.InDelta(t, 2.45, h.Sum, 1e-9)
+	assert.NoError(t, h.Validate())
+}
+
+func TestHistogram_Add(t *testing.T) {
+	h := Histogram{Bounds: []float64{1, 2}, Counts: []uint64{1, 2, 3}, Sum: 10, Count: 6}
+	sum, err := h.Add(Histogram{Bounds: []float64{1, 2}, Counts: []uint64{1, 0, 1}, Sum: 4, Count: 2})
+	require.NoError(t, err)
+	assert.Equal(t, Histogram{Bounds: []float64{1, 2}, Counts: []uint64{2, 2, 4}, Sum: 14, Count: 8}, sum)
+	assert.Equal(t, []uint64{1, 2, 3}, h.Counts, "исходная гистограмма не меняется")
+
+	_, err = h.Add(NewHistogram([]float64{1, 5}))
+	assert.ErrorIs(t, err, ErrInvalidDistribution)
+}
+
+func TestHistogram_Validate(t *testing.T) {
+	tests := map[string]Histogram{
+		"границы не по возрастанию": {Bounds: []float64{2, 1}, Counts: []uint64{0, 0, 0}},
+		"бесконечная граница":       {Bounds: []float64{math.Inf(1)}, Counts: []uint64{0, 0}},
+		"лишняя корзина":            {Bounds: []float64{1}, Counts: []uint64{0, 0, 0}},
+		"число не совпадает":        {Bounds: []float64{1}, Counts: []uint64{1, 1}, Count: 3},
+		"сумма NaN":                 {Bounds: []float64{1}, Counts: []uint64{0, 0}, Sum: math.NaN()},
+	}
+	for name, h := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, h.Validate(), ErrInvalidDistribution)
+		})
+	}
+}
+
+func TestSummary_Validate(t *testing.T) {
+	valid := Summary{Quantiles: []Quantile{{Quantile: 0.5, Value: 1}, {Quantile: 0.99, Value: 3}}, Sum: 10, Count: 5}
+	assert.NoError(t, valid.Validate())
+
+	outOfRange := Summary{Quantiles: []Quantile{{Quantile: 1.5}}}
+	assert.ErrorIs(t, outOfRange.Validate(), ErrInvalidDistribution)
+
+	unordered := Summary{Quantiles: []Quantile{{Quantile: 0.9}, {Quantile: 0.5}}}
+	assert.ErrorIs(t, unordered.Validate(), ErrInvalidDistribution)
+}
@@ -3,18 +3,30 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"metrics/internal/config"
 	"os"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// walCompactBytes размер журнала, после которого при синхронной записи делается снимок.
+const walCompactBytes = 4 << 20
+
+// FileStorageWrapper хранит метрики в памяти и сохраняет изменения в журнал упреждающей
+// записи (файл с суффиксом .wal), который периодически сворачивается в снимок FileStoragePath.
+// При StoreInterval = 0 каждая запись в журнал синхронизируется с диском, а снимок делается
+// по мере роста журнала; при StoreInterval = N снимок делается каждые N секунд.
 type FileStorageWrapper struct {
-	storage MetricStorage
+	storage *MemStorage
 	cfg     *config.ServerConfig
 	logger  *zap.SugaredLogger
+	wal     *writeAheadLog
+	// mu упорядочивает записи в журнал и снимки.
+	mu sync.Mutex
 }
 
 func NewFileStorageWrapper(
@@ -23,27 +35,36 @@ func NewFileStorageWrapper(
 	logger *zap.SugaredLogger,
 ) (*FileStorageWrapper, error) {
 	handlerLogger := logger.With("file", "NewFileStorageWrapper")
-	memRepo, _ := NewMemStorage()
+	memRepo := newMemStorage()
+
+	wal, err := openWAL(walPath(cfg.FileStoragePath), cfg.StoreInterval <= 0)
+	if err != nil {
+		return nil, &RetriableError{Err: err}
+	}
 
 	fileStorage := &FileStorageWrapper{
 		storage: memRepo,
 		cfg:     cfg,
 		logger:  handlerLogger,
+		wal:     wal,
 	}
 	if fileStorage.cfg.Restore {
 		handlerLogger.Info("Restore is enabled, loading from file...")
 		if err := fileStorage.loadFromFile(ctx); err != nil {
+			_ = wal.close()
 			return nil, &RetriableError{Err: err}
 		}
 		handlerLogger.Info("Successfully loaded metrics from file.")
+	} else if err := fileStorage.saveToFile(ctx); err != nil {
+		// Без восстановления прежние снимок и журнал заменяются пустыми.
+		_ = wal.close()
+		return nil, &RetriableError{Err: err}
 	}
 
 	if fileStorage.isEnableAutoSave() {
 		go func() {
 			handlerLogger.Info("autoSave enabled")
-			if err := fileStorage.autoSave(ctx); err != nil {
-				handlerLogger.Info("Error during autoSave", "error", err)
-			}
+			fileStorage.autoSave(ctx)
 		}()
 	}
 
@@ -52,11 +73,14 @@ func NewFileStorageWrapper(
 }
 
 func (fw *FileStorageWrapper) SetGauge(ctx context.Context, name string, value float64) (float64, error) {
-	value, _ = fw.storage.SetGauge(ctx, name, value)
-	if fw.isEnableAutoSave() {
-		if err := fw.saveToFile(ctx); err != nil {
-			return 0, fmt.Errorf("error set gauge: %w", err)
-		}
+	record := walRecord{Gauges: map[string]float64{name: value}, At: sampleTime(ctx)}
+	err := fw.commit(ctx, record, func() error {
+		var err error
+		value, err = fw.storage.SetGauge(ctx, name, value)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error set gauge: %w", err)
 	}
 	return value, nil
 }
@@ -70,13 +94,15 @@ func (fw *FileStorageWrapper) GetGauge(ctx context.Context, name string) (float6
 }
 
 func (fw *FileStorageWrapper) SetCounter(ctx context.Context, name string, value uint64) (uint64, error) {
-	value, _ = fw.storage.SetCounter(ctx, name, value)
-	if fw.isEnableAutoSave() {
-		if err := fw.saveToFile(ctx); err != nil {
-			return 0, fmt.Errorf("error set counter: %w", err)
-		}
+	record := walRecord{Counters: map[string]uint64{name: value}, At: sampleTime(ctx)}
+	err := fw.commit(ctx, record, func() error {
+		var err error
+		value, err = fw.storage.SetCounter(ctx, name, value)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error set counter: %w", err)
 	}
-
 	return value, nil
 }
 
@@ -104,41 +130,91 @@ func (fw *FileStorageWrapper) Counters(ctx context.Context) (map[string]uint64,
 	return counters, nil
 }
 
-func (fw *FileStorageWrapper) saveToFile(ctx context.Context) error {
-	gauges, err := fw.storage.Gauges(ctx)
+func (fw *FileStorageWrapper) GaugeHistory(
+	ctx context.Context,
+	name string,
+	from, to time.Time,
+) ([]GaugeSample, error) {
+	samples, err := fw.storage.GaugeHistory(ctx, name, from, to)
 	if err != nil {
-		return fmt.Errorf("failed to get gauges: %w", err)
+		return nil, fmt.Errorf("failed to get gauge history '%s': %w", name, err)
 	}
+	return samples, nil
+}
 
-	counters, err := fw.storage.Counters(ctx)
+func (fw *FileStorageWrapper) CounterHistory(
+	ctx context.Context,
+	name string,
+	from, to time.Time,
+) ([]CounterSample, error) {
+	samples, err := fw.storage.CounterHistory(ctx, name, from, to)
 	if err != nil {
-		return fmt.Errorf("failed to get counters: %w", err)
+		return nil, fmt.Errorf("failed to get counter history '%s': %w", name, err)
+	}
+	return samples, nil
+}
+
+// SetHistogram проверяет гистограмму до записи в журнал, чтобы журнал воспроизводился без ошибок.
+func (fw *FileStorageWrapper) SetHistogram(ctx context.Context, name string, value Histogram) (Histogram, error) {
+	record := walRecord{Histograms: map[string]Histogram{name: value}, At: sampleTime(ctx)}
+	err := fw.commit(ctx, record, func() error {
+		var err error
+		value, err = fw.storage.SetHistogram(ctx, name, value)
+		return err
+	})
+	if err != nil {
+		return Histogram{}, fmt.Errorf("error set histogram: %w", err)
 	}
+	return value, nil
+}
 
-	data := struct {
-		Gauges   map[string]float64 `json:"gauges"`
-		Counters map[string]uint64  `json:"counters"`
-	}{
-		Gauges:   gauges,
-		Counters: counters,
+func (fw *FileStorageWrapper) GetHistogram(ctx context.Context, name string) (Histogram, error) {
+	value, err := fw.storage.GetHistogram(ctx, name)
+	if err != nil {
+		return Histogram{}, fmt.Errorf("failed to get histogram '%s': %w", name, err)
 	}
+	return value, nil
+}
 
-	file, err := os.Create(fw.cfg.FileStoragePath)
+func (fw *FileStorageWrapper) Histograms(ctx context.Context) (map[string]Histogram, error) {
+	histograms, err := fw.storage.Histograms(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return nil, fmt.Errorf("failed to get histograms from storage: %w", err)
 	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			err = fmt.Errorf("error closing file %s: %w", fw.cfg.FileStoragePath, closeErr)
-		}
-	}()
+	return histograms, nil
+}
 
-	encoder := json.NewEncoder(file)
-	if err := encoder.Encode(data); err != nil {
-		return fmt.Errorf("failed to encode data to file: %w", err)
+func (fw *FileStorageWrapper) SetSummary(ctx context.Context, name string, value Summary) (Summary, error) {
+	if err := value.Validate(); err != nil {
+		return Summary{}, err
 	}
 
-	return nil
+	record := walRecord{Summaries: map[string]Summary{name: value}, At: sampleTime(ctx)}
+	err := fw.commit(ctx, record, func() error {
+		var err error
+		value, err = fw.storage.SetSummary(ctx, name, value)
+		return err
+	})
+	if err != nil {
+		return Summary{}, fmt.Errorf("error set summary: %w", err)
+	}
+	return value, nil
+}
+
+func (fw *FileStorageWrapper) GetSummary(ctx context.Context, name string) (Summary, error) {
+	value, err := fw.storage.GetSummary(ctx, name)
+	if err != nil {
+		return Summary{}, fmt.Errorf("failed to get summary '%s': %w", name, err)
+	}
+	return value, nil
+}
+
+func (fw *FileStorageWrapper) Summaries(ctx context.Context) (map[string]Summary, error) {
+	summaries, err := fw.storage.Summaries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get summaries from storage: %w", err)
+	}
+	return summaries, nil
 }
 
 func (fw *FileStorageWrapper) UpdateCounterAndGauges(
@@ -146,26 +222,174 @@ func (fw *FileStorageWrapper) UpdateCounterAndGauges(
 	counters map[string]uint64,
 	gauges map[string]float64,
 ) error {
-	for counterName, counterValue := range counters {
-		_, err := fw.storage.SetCounter(ctx, counterName, counterValue)
-		if err != nil {
-			return fmt.Errorf("error set counter: %w", err)
-		}
+	record := walRecord{Counters: counters, Gauges: gauges, At: sampleTime(ctx)}
+	err := fw.commit(ctx, record, func() error {
+		return fw.storage.UpdateCounterAndGauges(ctx, counters, gauges)
+	})
+	if err != nil {
+		return fmt.Errorf("error update metrics: %w", err)
 	}
+	return nil
+}
 
-	for gaugeName, gaugeValue := range gauges {
-		_, err := fw.storage.SetGauge(ctx, gaugeName, gaugeValue)
-		if err != nil {
-			return fmt.Errorf("error set gauge: %w", err)
-		}
+func (fw *FileStorageWrapper) ApplyOnce(
+	ctx context.Context,
+	key string,
+	counters map[string]uint64,
+	gauges map[string]float64,
+	distributions Distributions,
+) (bool, error) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	// Ключ проверяется до записи в журнал, чтобы повтор не попал в журнал дважды, а запоминается
+	// только после записи: повтор запроса, который не удалось записать, применяется заново.
+	if fw.storage.isApplied(key) {
+		return false, nil
+	}
+	record := walRecord{
+		Key:        key,
+		Counters:   counters,
+		Gauges:     gauges,
+		Histograms: distributions.Histograms,
+		Summaries:  distributions.Summaries,
+		At:         sampleTime(ctx),
+	}
+	err := fw.commitLocked(ctx, record, func() error {
+		_, err := fw.storage.ApplyOnce(ctx, key, counters, gauges, distributions)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("error apply once: %w", err)
+	}
+	return true, nil
+}
+
+func (fw *FileStorageWrapper) Delete(ctx context.Context, mtype MetricType, name string) error {
+	if err := fw.commitAdmin(ctx, walAdmin{Op: walDelete, Type: mtype, Name: name}); err != nil {
+		return fmt.Errorf("error delete %s: %w", mtype, err)
+	}
+	return nil
+}
+
+func (fw *FileStorageWrapper) ResetCounter(ctx context.Context, name string) error {
+	if err := fw.commitAdmin(ctx, walAdmin{Op: walResetCounter, Type: MetricCounter, Name: name}); err != nil {
+		return fmt.Errorf("error reset counter: %w", err)
+	}
+	return nil
+}
+
+func (fw *FileStorageWrapper) Rename(ctx context.Context, mtype MetricType, name, newName string) error {
+	op := walAdmin{Op: walRename, Type: mtype, Name: name, NewName: newName}
+	if err := fw.commitAdmin(ctx, op); err != nil {
+		return fmt.Errorf("error rename %s: %w", mtype, err)
+	}
+	return nil
+}
+
+// commitAdmin проверяет операцию по памяти, записывает её в журнал и затем применяет.
+// Отклонённая операция не попадает в журнал, а при ошибке журнала память не меняется.
+func (fw *FileStorageWrapper) commitAdmin(ctx context.Context, op walAdmin) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if err := op.check(fw.storage); err != nil {
+		return err
+	}
+	if err := fw.appendLocked(ctx, walRecord{Admin: &op, At: sampleTime(ctx)}); err != nil {
+		return err
+	}
+	return op.apply(ctx, fw.storage)
+}
+
+// commit записывает изменение в журнал и затем применяет его к памяти.
+func (fw *FileStorageWrapper) commit(ctx context.Context, record walRecord, apply func() error) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	return fw.commitLocked(ctx, record, apply)
+}
+
+// commitLocked вызывается под fw.mu. Переполнение counter, гистограммы и summary проверяются до записи,
+// чтобы отклонённое изменение не попало в журнал.
+func (fw *FileStorageWrapper) commitLocked(ctx context.Context, record walRecord, apply func() error) error {
+	if err := fw.storage.check(record.Counters, record.distributions()); err != nil {
+		return err
+	}
+	if err := fw.appendLocked(ctx, record); err != nil {
+		return err
+	}
+	return apply()
+}
+
+func (fw *FileStorageWrapper) appendLocked(ctx context.Context, record walRecord) error {
+	if fw.wal == nil {
+		return fmt.Errorf("file storage %s is closed", fw.cfg.FileStoragePath)
+	}
+	if err := fw.wal.append(record); err != nil {
+		return fmt.Errorf("error write wal: %w", err)
 	}
 
-	if fw.isEnableAutoSave() {
-		if err := fw.saveToFile(ctx); err != nil {
-			return fmt.Errorf("error save fail: %w", err)
+	if !fw.isEnableAutoSave() && fw.wal.size >= walCompactBytes {
+		// Запись уже на диске, поэтому ошибка снимка не влияет на результат операции.
+		if err := fw.snapshotLocked(ctx); err != nil {
+			fw.logger.Infow("Error compacting wal", "error", err)
 		}
 	}
+	return nil
+}
+
+// saveToFile записывает снимок текущих значений и очищает журнал.
+func (fw *FileStorageWrapper) saveToFile(ctx context.Context) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	return fw.snapshotLocked(ctx)
+}
+
+func (fw *FileStorageWrapper) snapshotLocked(ctx context.Context) error {
+	if fw.wal == nil {
+		return nil
+	}
+
+	gauges, err := fw.storage.Gauges(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get gauges: %w", err)
+	}
 
+	counters, err := fw.storage.Counters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get counters: %w", err)
+	}
+
+	histograms, err := fw.storage.Histograms(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get histograms: %w", err)
+	}
+
+	summaries, err := fw.storage.Summaries(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get summaries: %w", err)
+	}
+
+	data, err := json.Marshal(fileSnapshot{
+		Gauges:     gauges,
+		Counters:   counters,
+		Histograms: histograms,
+		Summaries:  summaries,
+		Seq:        fw.wal.seq,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode data: %w", err)
+	}
+	if err := writeFileAtomic(fw.cfg.FileStoragePath, data); err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", fw.cfg.FileStoragePath, err)
+	}
+
+	// Если процесс упадёт до очистки журнала, записи с номером до Seq пропустятся при загрузке.
+	if err := fw.wal.reset(); err != nil {
+		return fmt.Errorf("failed to reset wal: %w", err)
+	}
 	return nil
 }
 
@@ -173,60 +397,92 @@ func (fw *FileStorageWrapper) isEnableAutoSave() bool {
 	return fw.cfg.StoreInterval > 0
 }
 
+// loadFromFile восстанавливает значения из снимка и дописанного после него хвоста журнала.
 func (fw *FileStorageWrapper) loadFromFile(ctx context.Context) error {
-	info, err := os.Stat(fw.cfg.FileStoragePath)
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	snapshot, err := fw.readSnapshot()
 	if err != nil {
-		if os.IsNotExist(err) {
-			fw.logger.Info("loadFromFile: File does not exist")
-			return nil
-		}
-		return fmt.Errorf("error stat file %s: %w", fw.cfg.FileStoragePath, err)
+		return err
 	}
 
-	if info.Size() == 0 {
-		fw.logger.Warn("loadFromFile: File is empty, skipping restore")
-		return nil
+	if err = fw.storage.UpdateCounterAndGauges(ctx, snapshot.Counters, snapshot.Gauges); err != nil {
+		return fmt.Errorf("error restore snapshot: %w", err)
+	}
+	if err = fw.restoreDistributions(ctx, snapshot.Histograms, snapshot.Summaries); err != nil {
+		return fmt.Errorf("error restore snapshot: %w", err)
 	}
 
-	file, err := os.Open(fw.cfg.FileStoragePath)
+	replayed, err := fw.wal.replay(snapshot.Seq, func(record walRecord) error {
+		ctx := WithSampleTime(ctx, record.At)
+		if record.Admin != nil {
+			return record.Admin.apply(ctx, fw.storage)
+		}
+		if record.Key != "" {
+			_, applyErr := fw.storage.ApplyOnce(ctx, record.Key, record.Counters, record.Gauges, record.distributions())
+			return applyErr
+		}
+		if err := fw.restoreDistributions(ctx, record.Histograms, record.Summaries); err != nil {
+			return err
+		}
+		return fw.storage.UpdateCounterAndGauges(ctx, record.Counters, record.Gauges)
+	})
 	if err != nil {
-		return fmt.Errorf("error opening file %s: %w", fw.cfg.FileStoragePath, err)
+		return fmt.Errorf("error replay wal: %w", err)
 	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			err = fmt.Errorf("error closing file %s: %w", fw.cfg.FileStoragePath, closeErr)
-		}
-	}()
+	fw.logger.Infow("Restored metrics", "snapshot_seq", snapshot.Seq, "wal_records", replayed)
 
-	var data struct {
-		Gauges   map[string]float64 `json:"gauges"`
-		Counters map[string]uint64  `json:"counters"`
-	}
+	return nil
+}
 
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&data); err != nil {
-		return fmt.Errorf("failed to decode data from file: %w", err)
+// restoreDistributions прибавляет гистограммы и заменяет summary в памяти.
+func (fw *FileStorageWrapper) restoreDistributions(
+	ctx context.Context,
+	histograms map[string]Histogram,
+	summaries map[string]Summary,
+) error {
+	for name, histogram := range histograms {
+		if _, err := fw.storage.SetHistogram(ctx, name, histogram); err != nil {
+			return fmt.Errorf("error restore histogram '%s': %w", name, err)
+		}
 	}
-
-	for k, v := range data.Gauges {
-		_, err := fw.storage.SetGauge(ctx, k, v)
-		if err != nil {
-			return fmt.Errorf("error set gauges: %w", err)
+	for name, summary := range summaries {
+		if _, err := fw.storage.SetSummary(ctx, name, summary); err != nil {
+			return fmt.Errorf("error restore summary '%s': %w", name, err)
 		}
 	}
-	for k, v := range data.Counters {
-		_, err := fw.storage.SetCounter(ctx, k, v)
-		if err != nil {
-			return fmt.Errorf("error set counters: %w", err)
+	return nil
+}
+
+func (fw *FileStorageWrapper) readSnapshot() (fileSnapshot, error) {
+	var snapshot fileSnapshot
+
+	data, err := os.ReadFile(fw.cfg.FileStoragePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fw.logger.Info("loadFromFile: File does not exist")
+			return snapshot, nil
 		}
+		return snapshot, fmt.Errorf("error reading file %s: %w", fw.cfg.FileStoragePath, err)
 	}
 
-	return nil
+	if len(data) == 0 {
+		fw.logger.Warn("loadFromFile: File is empty, skipping restore")
+		return snapshot, nil
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("failed to decode data from file: %w", err)
+	}
+	return snapshot, nil
 }
 
-func (fw *FileStorageWrapper) autoSave(ctx context.Context) error {
+// autoSave сохраняет снимок раз в StoreInterval секунд до отмены контекста. Ошибка снимка записывается
+// в журнал, и следующая попытка выполняется на следующем тике: до неё журнал продолжает расти.
+func (fw *FileStorageWrapper) autoSave(ctx context.Context) {
 	if fw.cfg.StoreInterval <= 0 {
-		return nil
+		return
 	}
 
 	ticker := time.NewTicker(time.Duration(fw.cfg.StoreInterval) * time.Second)
@@ -237,15 +493,40 @@ func (fw *FileStorageWrapper) autoSave(ctx context.Context) error {
 		case <-ctx.Done():
 			_ = fw.saveToFile(context.Background())
 			fw.logger.Info("AutoSave stopped due to context cancel")
-			return nil
+			return
 		case <-ticker.C:
 			if err := fw.saveToFile(ctx); err != nil {
-				return fmt.Errorf("error saving: %w", err)
+				fw.logger.Infow("Error saving snapshot, retrying on next tick", "error", err)
 			}
 		}
 	}
 }
 
 func (fw *FileStorageWrapper) Shutdown(ctx context.Context) {
-	_ = fw.saveToFile(ctx)
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if err := fw.snapshotLocked(ctx); err != nil {
+		fw.logger.Infow("Error saving snapshot on shutdown", "error", err)
+	}
+	if fw.wal != nil {
+		if err := fw.wal.close(); err != nil {
+			fw.logger.Infow("Error closing wal", "error", err)
+		}
+		fw.wal = nil
+	}
+}
+
+// Ping сообщает об ошибке после Shutdown, когда журнал уже закрыт.
+func (fw *FileStorageWrapper) Ping(ctx context.Context) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.wal == nil {
+		return errors.New("file storage is closed")
+	}
+	return nil
+}
+
+func walPath(storagePath string) string {
+	return storagePath + ".wal"
 }
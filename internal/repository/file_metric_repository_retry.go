@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"metrics/internal/config"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -76,10 +78,10 @@ func (fr *FileRetryStorageWrapper) SetCounter(ctx context.Context, name string,
 	err := retry(ctx, func() error {
 		var err error
 		result, err = fr.fileStorage.SetCounter(ctx, name, value)
-		if err != nil {
+		if err != nil && !errors.Is(err, ErrCounterOverflow) {
 			return &RetriableError{Err: err}
 		}
-		return nil
+		return err
 	})
 	return result, err
 }
@@ -130,12 +132,176 @@ func (fr *FileRetryStorageWrapper) UpdateCounterAndGauges(
 ) error {
 	return retry(ctx, func() error {
 		err := fr.fileStorage.UpdateCounterAndGauges(ctx, counters, gauges)
+		if err != nil && !errors.Is(err, ErrCounterOverflow) {
+			return &RetriableError{Err: err}
+		}
+		return err
+	})
+}
+
+func (fr *FileRetryStorageWrapper) ApplyOnce(
+	ctx context.Context,
+	key string,
+	counters map[string]uint64,
+	gauges map[string]float64,
+	distributions Distributions,
+) (bool, error) {
+	var applied bool
+	err := retry(ctx, func() error {
+		var err error
+		applied, err = fr.fileStorage.ApplyOnce(ctx, key, counters, gauges, distributions)
+		if err != nil && !errors.Is(err, ErrCounterOverflow) && !errors.Is(err, ErrInvalidDistribution) {
+			return &RetriableError{Err: err}
+		}
+		return err
+	})
+	return applied, err
+}
+
+func (fr *FileRetryStorageWrapper) GaugeHistory(
+	ctx context.Context,
+	name string,
+	from, to time.Time,
+) ([]GaugeSample, error) {
+	var result []GaugeSample
+	err := retry(ctx, func() error {
+		var err error
+		result, err = fr.fileStorage.GaugeHistory(ctx, name, from, to)
 		if err != nil {
 			return &RetriableError{Err: err}
 		}
 		return nil
 	})
+	return result, err
+}
+
+func (fr *FileRetryStorageWrapper) CounterHistory(
+	ctx context.Context,
+	name string,
+	from, to time.Time,
+) ([]CounterSample, error) {
+	var result []CounterSample
+	err := retry(ctx, func() error {
+		var err error
+		result, err = fr.fileStorage.CounterHistory(ctx, name, from, to)
+		if err != nil {
+			return &RetriableError{Err: err}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// SetHistogram не повторяет запись с неверной гистограммой.
+func (fr *FileRetryStorageWrapper) SetHistogram(ctx context.Context, name string, value Histogram) (Histogram, error) {
+	var result Histogram
+	err := retry(ctx, func() error {
+		var err error
+		result, err = fr.fileStorage.SetHistogram(ctx, name, value)
+		if err != nil && !errors.Is(err, ErrInvalidDistribution) {
+			return &RetriableError{Err: err}
+		}
+		return err
+	})
+	return result, err
+}
+
+func (fr *FileRetryStorageWrapper) GetHistogram(ctx context.Context, name string) (Histogram, error) {
+	var result Histogram
+	err := retry(ctx, func() error {
+		var err error
+		result, err = fr.fileStorage.GetHistogram(ctx, name)
+		if err != nil {
+			return &RetriableError{Err: err}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (fr *FileRetryStorageWrapper) Histograms(ctx context.Context) (map[string]Histogram, error) {
+	var result map[string]Histogram
+	err := retry(ctx, func() error {
+		var err error
+		result, err = fr.fileStorage.Histograms(ctx)
+		if err != nil {
+			return &RetriableError{Err: err}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// SetSummary не повторяет запись с неверным summary.
+func (fr *FileRetryStorageWrapper) SetSummary(ctx context.Context, name string, value Summary) (Summary, error) {
+	var result Summary
+	err := retry(ctx, func() error {
+		var err error
+		result, err = fr.fileStorage.SetSummary(ctx, name, value)
+		if err != nil && !errors.Is(err, ErrInvalidDistribution) {
+			return &RetriableError{Err: err}
+		}
+		return err
+	})
+	return result, err
+}
+
+func (fr *FileRetryStorageWrapper) GetSummary(ctx context.Context, name string) (Summary, error) {
+	var result Summary
+	err := retry(ctx, func() error {
+		var err error
+		result, err = fr.fileStorage.GetSummary(ctx, name)
+		if err != nil {
+			return &RetriableError{Err: err}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (fr *FileRetryStorageWrapper) Summaries(ctx context.Context) (map[string]Summary, error) {
+	var result map[string]Summary
+	err := retry(ctx, func() error {
+		var err error
+		result, err = fr.fileStorage.Summaries(ctx)
+		if err != nil {
+			return &RetriableError{Err: err}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (fr *FileRetryStorageWrapper) Delete(ctx context.Context, mtype MetricType, name string) error {
+	return retry(ctx, func() error {
+		return retriableAdmin(fr.fileStorage.Delete(ctx, mtype, name))
+	})
+}
+
+func (fr *FileRetryStorageWrapper) ResetCounter(ctx context.Context, name string) error {
+	return retry(ctx, func() error {
+		return retriableAdmin(fr.fileStorage.ResetCounter(ctx, name))
+	})
+}
+
+func (fr *FileRetryStorageWrapper) Rename(ctx context.Context, mtype MetricType, name, newName string) error {
+	return retry(ctx, func() error {
+		return retriableAdmin(fr.fileStorage.Rename(ctx, mtype, name, newName))
+	})
+}
+
+// retriableAdmin помечает ошибку административной операции как повторяемую,
+// если серия не отсутствует и не занята.
+func retriableAdmin(err error) error {
+	if err != nil && !errors.Is(err, ErrSeriesNotFound) && !errors.Is(err, ErrSeriesExists) {
+		return &RetriableError{Err: err}
+	}
+	return err
 }
 
 func (fr *FileRetryStorageWrapper) Shutdown(ctx context.Context) {
 }
+
+func (fr *FileRetryStorageWrapper) Ping(ctx context.Context) error {
+	return fr.fileStorage.Ping(ctx)
+}
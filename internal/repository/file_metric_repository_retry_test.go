@@ -4,6 +4,7 @@ import (
 	"context"
 	"metrics/internal/config"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -14,14 +15,8 @@ import (
 
 func setupFileRetryStorageWrapper(t *testing.T) *FileRetryStorageWrapper {
 	t.Helper()
-	const tempFileNamePattern = "metrics_test_retry.json"
-	tempFile := createTempFile(t, tempFileNamePattern)
-	defer func() {
-		_ = os.Remove(tempFile.Name())
-	}()
-
 	cfg := &config.ServerConfig{
-		FileStoragePath: tempFileNamePattern,
+		FileStoragePath: filepath.Join(t.TempDir(), "metrics_test_retry.json"),
 		StoreInterval:   1,
 		Restore:         false,
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"metrics/internal/config"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -22,14 +23,8 @@ func createTempFile(t *testing.T, pattern string) *os.File {
 
 func setupTestFileStorage(t *testing.T) *FileStorageWrapper {
 	t.Helper()
-	const tempFileNamePattern = "metrics_test.json"
-	tempFile := createTempFile(t, tempFileNamePattern)
-	defer func() {
-		_ = os.Remove(tempFile.Name())
-	}()
-
 	cfg := &config.ServerConfig{
-		FileStoragePath: tempFileNamePattern,
+		FileStoragePath: filepath.Join(t.TempDir(), "metrics_test.json"),
 		StoreInterval:   1,
 		Restore:         false,
 	}
@@ -47,6 +42,7 @@ func TestSaveToFileAndLoadFromFile(t *testing.T) {
 	tempFile := createTempFile(t, tempFileNamePattern)
 	defer func() {
 		_ = os.Remove(tempFile.Name())
+		_ = os.Remove(walPath(tempFile.Name()))
 	}()
 
 	cfg := &config.ServerConfig{
@@ -220,3 +216,54 @@ func TestAutoSave(t *testing.T) {
 	}
 	_ = os.Remove(fs.cfg.FileStoragePath)
 }
+
+func TestAutoSaveContinuesAfterError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	path := filepath.Join(t.TempDir(), "metrics_test.json")
+	cfg := &config.ServerConfig{FileStoragePath: path, StoreInterval: 0}
+	fs, err := NewFileStorageWrapper(ctx, cfg, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("failed to create FileStorageWrapper: %v", err)
+	}
+	if _, err = fs.SetGauge(ctx, "test_gauge", 100.5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Непустой каталог на месте снимка: переименование временного файла завершается ошибкой.
+	_ = os.Remove(path)
+	if err = os.MkdirAll(filepath.Join(path, "busy"), 0o700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	cfg.StoreInterval = 1
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fs.autoSave(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	time.Sleep(1500 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("autosave stopped after snapshot error")
+	default:
+	}
+
+	if err = os.RemoveAll(path); err != nil {
+		t.Fatalf("failed to remove directory: %v", err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("autosave did not write the snapshot after the error")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// walRecord запись журнала упреждающей записи. Counters содержит приращения, Gauges — новые значения.
+type walRecord struct {
+	Counters map[string]uint64  `json:"counters,omitempty"`
+	Gauges   map[string]float64 `json:"gauges,omitempty"`
+	// Histograms содержит приращения гистограмм, Summaries — новые значения.
+	Histograms map[string]Histogram `json:"histograms,omitempty"`
+	Summaries  map[string]Summary   `json:"summaries,omitempty"`
+	// Admin административная операция над серией, в такой записи нет значений метрик.
+	Admin *walAdmin `json:"admin,omitempty"`
+	// At время сэмплов записи, восстанавливается в истории при воспроизведении журнала.
+	At time.Time `json:"at"`
+	// Key ключ идемпотентности, восстанавливается при воспроизведении журнала.
+	Key string `json:"key,omitempty"`
+	Seq uint64 `json:"seq"`
+}
+
+// distributions возвращает гистограммы и summary записи.
+func (r walRecord) distributions() Distributions {
+	return Distributions{Histograms: r.Histograms, Summaries: r.Summaries}
+}
+
+// Административные операции журнала.
+const (
+	walDelete       = "delete"
+	walResetCounter = "reset"
+	walRename       = "rename"
+)
+
+// walAdmin удаление, обнуление или переименование серии.
+type walAdmin struct {
+	Op      string     `json:"op"`
+	Type    MetricType `json:"type"`
+	Name    string     `json:"name"`
+	NewName string     `json:"new_name,omitempty"`
+}
+
+// apply применяет операцию к хранилищу.
+func (a walAdmin) apply(ctx context.Context, storage MetricStorage) error {
+	switch a.Op {
+	case walDelete:
+		return storage.Delete(ctx, a.Type, a.Name)
+	case walResetCounter:
+		return storage.ResetCounter(ctx, a.Name)
+	case walRename:
+		return storage.Rename(ctx, a.Type, a.Name, a.NewName)
+	default:
+		return fmt.Errorf("unknown wal operation '%s'", a.Op)
+	}
+}
+
+// check проверяет операцию по памяти, не изменяя её, чтобы записать в журнал только выполнимую операцию.
+func (a walAdmin) check(storage *MemStorage) error {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	switch a.Op {
+	case walDelete:
+		return storage.checkSeries(a.Type, a.Name)
+	case walResetCounter:
+		return storage.checkSeries(MetricCounter, a.Name)
+	case walRename:
+		return storage.checkRename(a.Type, a.Name, a.NewName)
+	default:
+		return fmt.Errorf("unknown wal operation '%s'", a.Op)
+	}
+}
+
+// fileSnapshot формат файла снимка. Seq — номер последней записи журнала, вошедшей в снимок.
+type fileSnapshot struct {
+	Gauges     map[string]float64   `json:"gauges"`
+	Counters   map[string]uint64    `json:"counters"`
+	Histograms map[string]Histogram `json:"histograms,omitempty"`
+	Summaries  map[string]Summary   `json:"summaries,omitempty"`
+	Seq        uint64               `json:"wal_seq,omitempty"`
+}
+
+// writeAheadLog журнал изменений в формате JSON Lines. Не потокобезопасен.
+type writeAheadLog struct {
+	file *os.File
+	path string
+	size int64
+	seq  uint64
+	// sync включает fsync после каждой записи.
+	sync bool
+}
+
+func openWAL(path string, sync bool) (*writeAheadLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error open wal %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("error stat wal %s: %w", path, err)
+	}
+	return &writeAheadLog{file: file, path: path, size: info.Size(), sync: sync}, nil
+}
+
+// replay применяет записи с номером больше after. Недописанный или повреждённый хвост
+// журнала (например, после сбоя во время записи) отрезается.
+func (w *writeAheadLog) replay(after uint64, apply func(walRecord) error) (int, error) {
+	w.seq = after
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("error seek wal %s: %w", w.path, err)
+	}
+
+	reader := bufio.NewReader(w.file)
+	var offset int64
+	applied := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return applied, fmt.Errorf("error read wal %s: %w", w.path, err)
+		}
+
+		var record walRecord
+		if err := json.Unmarshal(bytes.TrimSpace(line), &record); err != nil {
+			break
+		}
+		if record.Seq > w.seq {
+			if err := apply(record); err != nil {
+				return applied, fmt.Errorf("error apply wal record %d: %w", record.Seq, err)
+			}
+			w.seq = record.Seq
+			applied++
+		}
+		offset += int64(len(line))
+	}
+
+	if err := w.truncate(offset); err != nil {
+		return applied, err
+	}
+	return applied, nil
+}
+
+// append дописывает запись, присваивая ей следующий номер.
+func (w *writeAheadLog) append(record walRecord) error {
+	record.Seq = w.seq + 1
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error encode wal record: %w", err)
+	}
+	data = append(data, '\n')
+
+	if _, err := w.file.WriteAt(data, w.size); err != nil {
+		// Частично записанная строка отрезается, чтобы не испортить следующие записи.
+		_ = w.file.Truncate(w.size)
+		return fmt.Errorf("error write wal %s: %w", w.path, err)
+	}
+	if w.sync {
+		if err := w.file.Sync(); err != nil {
+			return fmt.Errorf("error sync wal %s: %w", w.path, err)
+		}
+	}
+
+	w.size += int64(len(data))
+	w.seq = record.Seq
+	return nil
+}
+
+// reset очищает журнал после записи снимка. Нумерация записей продолжается.
+func (w *writeAheadLog) reset() error {
+	return w.truncate(0)
+}
+
+func (w *writeAheadLog) truncate(size int64) error {
+	if size == w.size {
+		return nil
+	}
+	if err := w.file.Truncate(size); err != nil {
+		return fmt.Errorf("error truncate wal %s: %w", w.path, err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("error sync wal %s: %w", w.path, err)
+	}
+	w.size = size
+	return nil
+}
+
+func (w *writeAheadLog) close() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("error close wal %s: %w", w.path, err)
+	}
+	return nil
+}
+
+// writeFileAtomic записывает файл через временный файл, fsync и rename,
+// поэтому при сбое на диске остаётся либо старая, либо новая версия.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpName)
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", tmpName, path, err)
+	}
+
+	dirFile, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open dir %s: %w", dir, err)
+	}
+	defer func() {
+		_ = dirFile.Close()
+	}()
+	if err = dirFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync dir %s: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"context"
+	"metrics/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newWALTestStorage(t *testing.T, path string, restore bool) *FileStorageWrapper {
+	t.Helper()
+	cfg := &config.ServerConfig{FileStoragePath: path, StoreInterval: 0, Restore: restore}
+	fs, err := NewFileStorageWrapper(context.Background(), cfg, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	return fs
+}
+
+// crash имитирует аварийное завершение: журнал закрывается без снимка.
+func crash(t *testing.T, fs *FileStorageWrapper) {
+	t.Helper()
+	require.NoError(t, fs.wal.close())
+}
+
+func TestFileStorage_ReplaysWALAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	_, err := fs.SetCounter(ctx, "requests", 3)
+	require.NoError(t, err)
+	_, err = fs.SetGauge(ctx, "load", 1.5)
+	require.NoError(t, err)
+	require.NoError(t, fs.UpdateCounterAndGauges(ctx, map[string]uint64{"requests": 4}, map[string]float64{"load": 2.5}))
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+
+	counter, err := restored.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), counter)
+	gauge, err := restored.GetGauge(ctx, "load")
+	require.NoError(t, err)
+	assert.Equal(t, 2.5, gauge)
+}
+
+func TestFileStorage_RestoresDistributions(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	summary := Summary{Quantiles: []Quantile{{Quantile: 0.99, Value: 0.8}}, Sum: 12, Count: 40}
+
+	fs := newWALTestStorage(t, path, false)
+	observed := NewHistogram([]float64{0.1, 1})
+	observed.Observe(0.05)
+	_, err := fs.SetHistogram(ctx, "latency", observed)
+	require.NoError(t, err)
+	require.NoError(t, fs.saveToFile(ctx))
+
+	observed = NewHistogram([]float64{0.1, 1})
+	observed.Observe(0.5)
+	_, err = fs.SetHistogram(ctx, "latency", observed)
+	require.NoError(t, err)
+	_, err = fs.SetSummary(ctx, "rpc", summary)
+	require.NoError(t, err)
+	_, err = fs.SetHistogram(ctx, "latency", NewHistogram([]float64{5}))
+	require.ErrorIs(t, err, ErrInvalidDistribution, "неверная гистограмма не попадает в журнал")
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+
+	histogram, err := restored.GetHistogram(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 1, 0}, histogram.Counts, "снимок и хвост журнала")
+	restoredSummary, err := restored.GetSummary(ctx, "rpc")
+	require.NoError(t, err)
+	assert.Equal(t, summary, restoredSummary)
+}
+
+func TestFileStorage_RejectsCounterOverflow(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	_, err := fs.SetCounter(ctx, "requests", MaxCounter)
+	require.NoError(t, err)
+	_, err = fs.SetCounter(ctx, "requests", 1)
+	assert.ErrorIs(t, err, ErrCounterOverflow)
+	err = fs.UpdateCounterAndGauges(ctx, map[string]uint64{"requests": 1}, nil)
+	assert.ErrorIs(t, err, ErrCounterOverflow)
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+
+	counter, err := restored.GetCounter(ctx, "requests")
+	require.NoError(t, err, "отклонённые изменения не попадают в журнал")
+	assert.Equal(t, uint64(MaxCounter), counter)
+}
+
+func TestFileStorage_ReplaysAdminOperations(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	err := fs.UpdateCounterAndGauges(ctx,
+		map[string]uint64{"requests": 5, "errors": 2},
+		map[string]float64{"CPUutilization8": 12.5})
+	require.NoError(t, err)
+	require.NoError(t, fs.Delete(ctx, MetricGauge, "CPUutilization8"))
+	require.NoError(t, fs.ResetCounter(ctx, "errors"))
+	require.NoError(t, fs.Rename(ctx, MetricCounter, "requests", "http_requests"))
+	assert.ErrorIs(t, fs.Delete(ctx, MetricGauge, "CPUutilization8"), ErrSeriesNotFound)
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+
+	gauges, err := restored.Gauges(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, gauges)
+	counters, err := restored.Counters(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"http_requests": 5, "errors": 0}, counters)
+}
+
+func TestFileStorage_ReplaysSampleTime(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	at := time.Unix(1700000010, 0)
+
+	fs := newWALTestStorage(t, path, false)
+	_, err := fs.SetGauge(WithSampleTime(ctx, at), "load", 1.5)
+	require.NoError(t, err)
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+
+	samples, err := restored.GaugeHistory(ctx, "load", time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.True(t, at.Equal(samples[0].Timestamp))
+}
+
+func TestFileStorage_SnapshotCompactsWAL(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	_, err := fs.SetCounter(ctx, "requests", 5)
+	require.NoError(t, err)
+	require.NoError(t, fs.saveToFile(ctx))
+
+	info, err := os.Stat(walPath(path))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size(), "после снимка журнал очищается")
+
+	_, err = fs.SetCounter(ctx, "requests", 2)
+	require.NoError(t, err)
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+	counter, err := restored.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), counter, "снимок плюс хвост журнала")
+}
+
+func TestFileStorage_SkipsWALRecordsInSnapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	_, err := fs.SetCounter(ctx, "requests", 5)
+	require.NoError(t, err)
+
+	// Сбой между записью снимка и очисткой журнала.
+	walData, err := os.ReadFile(walPath(path))
+	require.NoError(t, err)
+	require.NoError(t, fs.saveToFile(ctx))
+	require.NoError(t, os.WriteFile(walPath(path), walData, 0o600))
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+	counter, err := restored.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), counter, "записи, вошедшие в снимок, не применяются повторно")
+}
+
+func TestFileStorage_TruncatesTornWALTail(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	_, err := fs.SetCounter(ctx, "requests", 5)
+	require.NoError(t, err)
+	crash(t, fs)
+
+	file, err := os.OpenFile(walPath(path), os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"counters":{"requests":`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	restored := newWALTestStorage(t, path, true)
+	counter, err := restored.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), counter)
+
+	_, err = restored.SetCounter(ctx, "requests", 1)
+	require.NoError(t, err)
+	crash(t, restored)
+
+	again := newWALTestStorage(t, path, true)
+	defer again.Shutdown(ctx)
+	counter, err = again.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), counter, "запись после отрезанного хвоста не теряется")
+}
+
+func TestFileStorage_RestoresIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	applied, err := fs.ApplyOnce(ctx, "agent:1", map[string]uint64{"requests": 5}, nil, Distributions{})
+	require.NoError(t, err)
+	assert.True(t, applied)
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+	applied, err = restored.ApplyOnce(ctx, "agent:1", map[string]uint64{"requests": 5}, nil, Distributions{})
+	require.NoError(t, err)
+	assert.False(t, applied, "повтор после перезапуска не применяется")
+
+	counter, err := restored.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), counter)
+}
+
+func TestFileStorage_ApplyOnceDistributions(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	_, err := fs.SetHistogram(ctx, "latency", NewHistogram([]float64{1}))
+	require.NoError(t, err)
+
+	wrong := NewHistogram([]float64{5})
+	wrong.Observe(0.5)
+	_, err = fs.ApplyOnce(ctx, "agent:1", map[string]uint64{"requests": 1}, nil,
+		Distributions{Histograms: map[string]Histogram{"latency": wrong}})
+	require.ErrorIs(t, err, ErrInvalidDistribution)
+	_, err = fs.GetCounter(ctx, "requests")
+	assert.Error(t, err, "отклонённый пакет не применяется частично")
+
+	histogram := NewHistogram([]float64{1})
+	histogram.Observe(0.5)
+	summary := Summary{Quantiles: []Quantile{{Quantile: 0.5, Value: 2}}, Sum: 2, Count: 1}
+	applied, err := fs.ApplyOnce(ctx, "agent:1", map[string]uint64{"requests": 1}, nil, Distributions{
+		Histograms: map[string]Histogram{"latency": histogram},
+		Summaries:  map[string]Summary{"pause": summary},
+	})
+	require.NoError(t, err)
+	assert.True(t, applied, "ключ отклонённого пакета не запоминается")
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+	stored, err := restored.GetHistogram(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stored.Count)
+	storedSummary, err := restored.GetSummary(ctx, "pause")
+	require.NoError(t, err)
+	assert.Equal(t, summary, storedSummary)
+
+	applied, err = restored.ApplyOnce(ctx, "agent:1", map[string]uint64{"requests": 1}, nil,
+		Distributions{Histograms: map[string]Histogram{"latency": histogram}})
+	require.NoError(t, err)
+	assert.False(t, applied)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snapshot.json")
+
+	require.NoError(t, writeFileAtomic(path, []byte("old")))
+	require.NoError(t, writeFileAtomic(path, []byte("new")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "временные файлы не остаются")
+}
+
+func TestFileStorage_ApplyOnceRetriesAfterWALError(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	wal := fs.wal
+	fs.wal = nil
+	_, err := fs.ApplyOnce(ctx, "agent:1", map[string]uint64{"requests": 3}, nil, Distributions{})
+	require.Error(t, err)
+	fs.wal = wal
+
+	applied, err := fs.ApplyOnce(ctx, "agent:1", map[string]uint64{"requests": 3}, nil, Distributions{})
+	require.NoError(t, err)
+	assert.True(t, applied, "повтор запроса, не записанного в журнал, применяется")
+	crash(t, fs)
+
+	restored := newWALTestStorage(t, path, true)
+	defer restored.Shutdown(ctx)
+
+	value, err := restored.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), value)
+}
+
+func TestFileStorage_AdminOperationKeepsMemoryOnWALError(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs := newWALTestStorage(t, path, false)
+	defer fs.Shutdown(ctx)
+	_, err := fs.SetGauge(ctx, "load", 1.5)
+	require.NoError(t, err)
+
+	wal := fs.wal
+	fs.wal = nil
+	require.Error(t, fs.Delete(ctx, MetricGauge, "load"))
+	require.Error(t, fs.Rename(ctx, MetricGauge, "load", "load_avg"))
+	fs.wal = wal
+
+	value, err := fs.GetGauge(ctx, "load")
+	require.NoError(t, err, "операция, не записанная в журнал, не меняет память")
+	assert.Equal(t, 1.5, value)
+	assert.ErrorIs(t, fs.Rename(ctx, MetricGauge, "missing", "other"), ErrSeriesNotFound)
+}
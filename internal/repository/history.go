@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"sort"
+	"time"
+)
+
+// historyCapacity ограничивает количество сэмплов, хранимых в памяти для одной метрики.
+const historyCapacity = 1000
+
+// GaugeSample значение gauge, зафиксированное при обновлении.
+type GaugeSample struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// CounterSample накопленное значение counter, зафиксированное при обновлении.
+type CounterSample struct {
+	Timestamp time.Time
+	Value     uint64
+}
+
+type timedValue[T any] struct {
+	at    time.Time
+	value T
+}
+
+// sampleRing кольцевой буфер фиксированной ёмкости: при переполнении вытесняются самые старые сэмплы.
+type sampleRing[T any] struct {
+	items []timedValue[T]
+	next  int
+	full  bool
+}
+
+func newSampleRing[T any](capacity int) *sampleRing[T] {
+	return &sampleRing[T]{items: make([]timedValue[T], capacity)}
+}
+
+func (r *sampleRing[T]) push(at time.Time, value T) {
+	r.items[r.next] = timedValue[T]{at: at, value: value}
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// between возвращает сэмплы из интервала [from, to] в порядке времени.
+// Сэмплы с одинаковым временем остаются в порядке добавления.
+func (r *sampleRing[T]) between(from, to time.Time) []timedValue[T] {
+	size, start := r.next, 0
+	if r.full {
+		size, start = len(r.items), r.next
+	}
+
+	result := make([]timedValue[T], 0, size)
+	for i := range size {
+		item := r.items[(start+i)%len(r.items)]
+		if item.at.Before(from) || item.at.After(to) {
+			continue
+		}
+		result = append(result, item)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].at.Before(result[j].at)
+	})
+	return result
+}
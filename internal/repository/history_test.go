@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleRing_EvictsOldest(t *testing.T) {
+	ring := newSampleRing[float64](3)
+	start := time.Unix(1000, 0)
+	for i := range 5 {
+		ring.push(start.Add(time.Duration(i)*time.Second), float64(i))
+	}
+
+	items := ring.between(time.Time{}, start.Add(time.Hour))
+
+	assert.Len(t, items, 3, "в буфере должно остаться не больше capacity сэмплов")
+	assert.Equal(t, 2.0, items[0].value)
+	assert.Equal(t, 4.0, items[2].value)
+}
+
+func TestSampleRing_Between(t *testing.T) {
+	ring := newSampleRing[uint64](10)
+	start := time.Unix(1000, 0)
+	for i := range 5 {
+		ring.push(start.Add(time.Duration(i)*time.Minute), uint64(i))
+	}
+
+	items := ring.between(start.Add(time.Minute), start.Add(3*time.Minute))
+
+	assert.Len(t, items, 3)
+	assert.Equal(t, uint64(1), items[0].value)
+	assert.Equal(t, uint64(3), items[2].value)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import "time"
+
+// idempotencyTTL время, в течение которого повтор запроса с тем же ключом не применяется повторно.
+const idempotencyTTL = 10 * time.Minute
+
+// appliedKeys хранит недавно применённые ключи идемпотентности. Не потокобезопасен.
+type appliedKeys struct {
+	keys      map[string]time.Time
+	lastPrune time.Time
+}
+
+func newAppliedKeys() *appliedKeys {
+	return &appliedKeys{keys: make(map[string]time.Time)}
+}
+
+// remember запоминает ключ и возвращает false, если он уже применялся в пределах TTL.
+func (a *appliedKeys) remember(key string, now time.Time) bool {
+	if now.Sub(a.lastPrune) > time.Minute {
+		for k, appliedAt := range a.keys {
+			if now.Sub(appliedAt) > idempotencyTTL {
+				delete(a.keys, k)
+			}
+		}
+		a.lastPrune = now
+	}
+
+	if a.contains(key, now) {
+		return false
+	}
+	a.keys[key] = now
+	return true
+}
+
+// contains сообщает, что ключ уже применялся в пределах TTL.
+func (a *appliedKeys) contains(key string, now time.Time) bool {
+	appliedAt, exists := a.keys[key]
+	return exists && now.Sub(appliedAt) <= idempotencyTTL
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppliedKeys_Remember(t *testing.T) {
+	keys := newAppliedKeys()
+	now := time.Now()
+
+	assert.True(t, keys.remember("agent:1", now), "новый ключ должен применяться")
+	assert.False(t, keys.remember("agent:1", now.Add(time.Second)), "повтор ключа не должен применяться")
+	assert.True(t, keys.remember("agent:2", now.Add(time.Second)), "другой ключ должен применяться")
+	assert.True(t, keys.remember("agent:1", now.Add(idempotencyTTL+2*time.Minute)), "истёкший ключ применяется снова")
+}
+
+func TestAppliedKeys_Prune(t *testing.T) {
+	keys := newAppliedKeys()
+	now := time.Now()
+
+	keys.remember("old", now)
+	keys.remember("fresh", now.Add(idempotencyTTL+2*time.Minute))
+
+	assert.NotContains(t, keys.keys, "old")
+	assert.Contains(t, keys.keys, "fresh")
+}
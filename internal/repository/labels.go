@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Labels метки серии метрики, например {"host": "web-1"}.
+type Labels map[string]string
+
+var ErrInvalidLabel = errors.New("invalid label")
+
+// ErrInvalidMetricName имя метрики содержит символы, которыми ключ серии отделяет метки.
+var ErrInvalidMetricName = errors.New("invalid metric name")
+
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Validate проверяет, что имена меток допустимы в Prometheus и не пусты.
+func (l Labels) Validate() error {
+	for name := range l {
+		if !labelNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidLabel, name)
+		}
+	}
+	return nil
+}
+
+// ValidateMetricName проверяет, что имя метрики не содержит '{', '}' и '"'. Иначе имя без меток
+// совпало бы с ключом серии с метками, а PostgreSQL разобрал бы его на имя и метки.
+func ValidateMetricName(name string) error {
+	if strings.ContainsAny(name, `{}"`) {
+		return fmt.Errorf("%w: %q", ErrInvalidMetricName, name)
+	}
+	return nil
+}
+
+// SeriesKey возвращает ключ серии, по которому хранилища различают метрики с одинаковым именем.
+// Без меток ключ совпадает с именем, иначе имеет вид name{a="1",b="2"} с метками по алфавиту.
+func SeriesKey(name string, labels Labels) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	names := make([]string, 0, len(labels))
+	for labelName := range labels {
+		names = append(names, labelName)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, labelName := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labelName)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[labelName]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// ParseSeriesKey разбирает ключ, построенный SeriesKey. Ключ, который не удаётся разобрать,
+// считается именем метрики без меток.
+func ParseSeriesKey(key string) (string, Labels) {
+	open := strings.IndexByte(key, '{')
+	if open <= 0 || !strings.HasSuffix(key, "}") {
+		return key, Labels{}
+	}
+
+	labels, err := parseLabels(key[open+1 : len(key)-1])
+	if err != nil {
+		return key, Labels{}
+	}
+	return key[:open], labels
+}
+
+func parseLabels(s string) (Labels, error) {
+	labels := Labels{}
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, ErrInvalidLabel
+		}
+		labelName := s[:eq]
+
+		quoted, err := strconv.QuotedPrefix(s[eq+1:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidLabel, err)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidLabel, err)
+		}
+		labels[labelName] = value
+
+		s = s[eq+1+len(quoted):]
+		if s != "" {
+			if s[0] != ',' {
+				return nil, ErrInvalidLabel
+			}
+			s = s[1:]
+		}
+	}
+	if err := labels.Validate(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		metric   string
+		labels   Labels
+		expected string
+	}{
+		{name: "no labels", metric: "Alloc", labels: nil, expected: "Alloc"},
+		{name: "sorted labels", metric: "CPU", labels: Labels{"host": "b", "core": "1"}, expected: `CPU{core="1",host="b"}`},
+		{name: "escaped value", metric: "CPU", labels: Labels{"host": `a"b,c`}, expected: `CPU{host="a\"b,c"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := SeriesKey(tt.metric, tt.labels)
+			assert.Equal(t, tt.expected, key)
+
+			name, labels := ParseSeriesKey(key)
+			assert.Equal(t, tt.metric, name)
+			if len(tt.labels) == 0 {
+				assert.Empty(t, labels)
+			} else {
+				assert.Equal(t, tt.labels, labels)
+			}
+		})
+	}
+}
+
+func TestParseSeriesKey_Fallback(t *testing.T) {
+	for _, key := range []string{"{}", "weird{name", `a{b}`, `a{1x="y"}`} {
+		name, labels := ParseSeriesKey(key)
+		assert.Equal(t, key, name)
+		assert.Empty(t, labels)
+	}
+}
+
+func TestLabels_Validate(t *testing.T) {
+	assert.NoError(t, Labels{"host": "a", "_core2": "b"}.Validate())
+	assert.ErrorIs(t, Labels{"1host": "a"}.Validate(), ErrInvalidLabel)
+	assert.ErrorIs(t, Labels{"ho-st": "a"}.Validate(), ErrInvalidLabel)
+	assert.ErrorIs(t, Labels{"": "a"}.Validate(), ErrInvalidLabel)
+}
+
+func TestValidateMetricName(t *testing.T) {
+	assert.NoError(t, ValidateMetricName("cpu.usage_total"))
+	assert.ErrorIs(t, ValidateMetricName(`cpu{host="a"}`), ErrInvalidMetricName)
+	assert.ErrorIs(t, ValidateMetricName("cpu}"), ErrInvalidMetricName)
+	assert.ErrorIs(t, ValidateMetricName(`cpu"`), ErrInvalidMetricName)
+}
@@ -4,33 +4,49 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
 type MemStorage struct {
-	gauges   map[string]float64
-	counters map[string]uint64
-	mu       *sync.RWMutex
+	gauges         map[string]float64
+	counters       map[string]uint64
+	gaugeHistory   map[string]*sampleRing[float64]
+	counterHistory map[string]*sampleRing[uint64]
+	histograms     map[string]Histogram
+	summaries      map[string]Summary
+	applied        *appliedKeys
+	mu             *sync.RWMutex
 }
 
 func (ms *MemStorage) Shutdown(ctx context.Context) {
 }
 
+func (ms *MemStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
 func NewMemStorage() (MetricStorage, error) {
-	memStorage := &MemStorage{
-		mu:       &sync.RWMutex{},
-		gauges:   make(map[string]float64),
-		counters: make(map[string]uint64),
-	}
+	return newMemStorage(), nil
+}
 
-	return memStorage, nil
+func newMemStorage() *MemStorage {
+	return &MemStorage{
+		mu:             &sync.RWMutex{},
+		gauges:         make(map[string]float64),
+		counters:       make(map[string]uint64),
+		gaugeHistory:   make(map[string]*sampleRing[float64]),
+		counterHistory: make(map[string]*sampleRing[uint64]),
+		histograms:     make(map[string]Histogram),
+		summaries:      make(map[string]Summary),
+		applied:        newAppliedKeys(),
+	}
 }
 
 func (ms *MemStorage) SetGauge(ctx context.Context, name string, value float64) (float64, error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	ms.gauges[name] = value
 
-	return ms.gauges[name], nil
+	return ms.setGauge(name, value, sampleTime(ctx)), nil
 }
 
 func (ms *MemStorage) GetGauge(ctx context.Context, name string) (float64, error) {
@@ -46,9 +62,11 @@ func (ms *MemStorage) GetGauge(ctx context.Context, name string) (float64, error
 func (ms *MemStorage) SetCounter(ctx context.Context, name string, value uint64) (uint64, error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	ms.counters[name] += value
 
-	return ms.counters[name], nil
+	if err := ms.checkCounters(map[string]uint64{name: value}); err != nil {
+		return 0, err
+	}
+	return ms.addCounter(name, value, sampleTime(ctx)), nil
 }
 
 func (ms *MemStorage) GetCounter(ctx context.Context, name string) (uint64, error) {
@@ -86,19 +104,368 @@ func (ms *MemStorage) UpdateCounterAndGauges(
 	counters map[string]uint64,
 	gauges map[string]float64,
 ) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	// Counter проверяются до записи, чтобы переполнение одной серии не применило пакет частично.
+	if err := ms.checkCounters(counters); err != nil {
+		return fmt.Errorf("error saving counter: %w", err)
+	}
+
+	at := sampleTime(ctx)
 	for counterName, counterValue := range counters {
-		_, err := ms.SetCounter(ctx, counterName, counterValue)
-		if err != nil {
-			return fmt.Errorf("error saving counter: %w", err)
-		}
+		ms.addCounter(counterName, counterValue, at)
+	}
+	for gaugeName, gaugeValue := range gauges {
+		ms.setGauge(gaugeName, gaugeValue, at)
+	}
+
+	return nil
+}
+
+func (ms *MemStorage) ApplyOnce(
+	ctx context.Context,
+	key string,
+	counters map[string]uint64,
+	gauges map[string]float64,
+	distributions Distributions,
+) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.applied == nil {
+		ms.applied = newAppliedKeys()
 	}
+	now := time.Now()
+	if ms.applied.contains(key, now) {
+		return false, nil
+	}
+	// Отклонённый запрос не считается применённым, повтор с тем же ключом проверяется заново.
+	if err := ms.checkCounters(counters); err != nil {
+		return false, fmt.Errorf("error saving counter: %w", err)
+	}
+	if err := ms.checkDistributions(distributions); err != nil {
+		return false, fmt.Errorf("error saving distribution: %w", err)
+	}
+	ms.applied.remember(key, now)
 
+	at := sampleTime(ctx)
+	for counterName, counterValue := range counters {
+		ms.addCounter(counterName, counterValue, at)
+	}
 	for gaugeName, gaugeValue := range gauges {
-		_, err := ms.SetGauge(ctx, gaugeName, gaugeValue)
-		if err != nil {
-			return fmt.Errorf("error saving metrics: %w", err)
+		ms.setGauge(gaugeName, gaugeValue, at)
+	}
+	for name, histogram := range distributions.Histograms {
+		// Гистограмма проверена checkDistributions, поэтому сложение не возвращает ошибку.
+		_, _ = ms.addHistogram(name, histogram)
+	}
+	if len(distributions.Summaries) > 0 && ms.summaries == nil {
+		ms.summaries = make(map[string]Summary)
+	}
+	for name, summary := range distributions.Summaries {
+		ms.summaries[name] = summary.Clone()
+	}
+
+	return true, nil
+}
+
+func (ms *MemStorage) GaugeHistory(ctx context.Context, name string, from, to time.Time) ([]GaugeSample, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	ring, exists := ms.gaugeHistory[name]
+	if !exists {
+		return []GaugeSample{}, nil
+	}
+
+	items := ring.between(from, to)
+	samples := make([]GaugeSample, 0, len(items))
+	for _, item := range items {
+		samples = append(samples, GaugeSample{Timestamp: item.at, Value: item.value})
+	}
+	return samples, nil
+}
+
+func (ms *MemStorage) CounterHistory(ctx context.Context, name string, from, to time.Time) ([]CounterSample, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	ring, exists := ms.counterHistory[name]
+	if !exists {
+		return []CounterSample{}, nil
+	}
+
+	items := ring.between(from, to)
+	samples := make([]CounterSample, 0, len(items))
+	for _, item := range items {
+		samples = append(samples, CounterSample{Timestamp: item.at, Value: item.value})
+	}
+	return samples, nil
+}
+
+func (ms *MemStorage) SetHistogram(ctx context.Context, name string, value Histogram) (Histogram, error) {
+	if err := value.Validate(); err != nil {
+		return Histogram{}, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	result, err := ms.addHistogram(name, value)
+	if err != nil {
+		return Histogram{}, err
+	}
+	return result.Clone(), nil
+}
+
+func (ms *MemStorage) GetHistogram(ctx context.Context, name string) (Histogram, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	value, exists := ms.histograms[name]
+	if !exists {
+		return Histogram{}, fmt.Errorf("histogram metric '%s' not found", name)
+	}
+	return value.Clone(), nil
+}
+
+func (ms *MemStorage) Histograms(ctx context.Context) (map[string]Histogram, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	result := make(map[string]Histogram, len(ms.histograms))
+	for k, v := range ms.histograms {
+		result[k] = v.Clone()
+	}
+	return result, nil
+}
+
+func (ms *MemStorage) SetSummary(ctx context.Context, name string, value Summary) (Summary, error) {
+	if err := value.Validate(); err != nil {
+		return Summary{}, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.summaries == nil {
+		ms.summaries = make(map[string]Summary)
+	}
+	ms.summaries[name] = value.Clone()
+	return value.Clone(), nil
+}
+
+func (ms *MemStorage) GetSummary(ctx context.Context, name string) (Summary, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	value, exists := ms.summaries[name]
+	if !exists {
+		return Summary{}, fmt.Errorf("summary metric '%s' not found", name)
+	}
+	return value.Clone(), nil
+}
+
+func (ms *MemStorage) Summaries(ctx context.Context) (map[string]Summary, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	result := make(map[string]Summary, len(ms.summaries))
+	for k, v := range ms.summaries {
+		result[k] = v.Clone()
+	}
+	return result, nil
+}
+
+func (ms *MemStorage) Delete(ctx context.Context, mtype MetricType, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ms.checkSeries(mtype, name); err != nil {
+		return err
+	}
+	switch mtype {
+	case MetricGauge:
+		delete(ms.gauges, name)
+		delete(ms.gaugeHistory, name)
+	case MetricCounter:
+		delete(ms.counters, name)
+		delete(ms.counterHistory, name)
+	case MetricHistogram:
+		delete(ms.histograms, name)
+	case MetricSummary:
+		delete(ms.summaries, name)
+	}
+	return nil
+}
+
+func (ms *MemStorage) ResetCounter(ctx context.Context, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ms.checkSeries(MetricCounter, name); err != nil {
+		return err
+	}
+	ms.counters[name] = 0
+	ms.recordCounter(name, 0, sampleTime(ctx))
+	return nil
+}
+
+func (ms *MemStorage) Rename(ctx context.Context, mtype MetricType, name, newName string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ms.checkRename(mtype, name, newName); err != nil {
+		return err
+	}
+	switch mtype {
+	case MetricGauge:
+		renameKey(ms.gauges, name, newName)
+		renameKey(ms.gaugeHistory, name, newName)
+	case MetricCounter:
+		renameKey(ms.counters, name, newName)
+		renameKey(ms.counterHistory, name, newName)
+	case MetricHistogram:
+		renameKey(ms.histograms, name, newName)
+	case MetricSummary:
+		renameKey(ms.summaries, name, newName)
+	}
+	return nil
+}
+
+// checkSeries проверяет, что серия типа mtype существует.
+func (ms *MemStorage) checkSeries(mtype MetricType, name string) error {
+	var exists bool
+	switch mtype {
+	case MetricGauge:
+		_, exists = ms.gauges[name]
+	case MetricCounter:
+		_, exists = ms.counters[name]
+	case MetricHistogram:
+		_, exists = ms.histograms[name]
+	case MetricSummary:
+		_, exists = ms.summaries[name]
+	default:
+		return fmt.Errorf("unknown metric type '%s'", mtype)
+	}
+	if !exists {
+		return fmt.Errorf("%s '%s': %w", mtype, name, ErrSeriesNotFound)
+	}
+	return nil
+}
+
+// checkRename проверяет, что серия name существует, а newName свободно.
+func (ms *MemStorage) checkRename(mtype MetricType, name, newName string) error {
+	if err := ms.checkSeries(mtype, name); err != nil {
+		return err
+	}
+	if err := ms.checkSeries(mtype, newName); err == nil {
+		return fmt.Errorf("%s '%s': %w", mtype, newName, ErrSeriesExists)
+	}
+	return nil
+}
+
+// check проверяет приращения counter и распределения по памяти, не изменяя её.
+func (ms *MemStorage) check(counters map[string]uint64, distributions Distributions) error {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if err := ms.checkCounters(counters); err != nil {
+		return err
+	}
+	return ms.checkDistributions(distributions)
+}
+
+// isApplied сообщает, что запрос с ключом key уже применялся.
+func (ms *MemStorage) isApplied(key string) bool {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return ms.applied != nil && ms.applied.contains(key, time.Now())
+}
+
+func renameKey[V any](values map[string]V, from, to string) {
+	if value, exists := values[from]; exists {
+		delete(values, from)
+		values[to] = value
+	}
+}
+
+// setGauge и addCounter вызываются под захваченной блокировкой.
+func (ms *MemStorage) setGauge(name string, value float64, at time.Time) float64 {
+	ms.gauges[name] = value
+	ms.recordGauge(name, value, at)
+	return value
+}
+
+// addHistogram прибавляет гистограмму к серии, вызывается под захваченной блокировкой.
+func (ms *MemStorage) addHistogram(name string, value Histogram) (Histogram, error) {
+	current, exists := ms.histograms[name]
+	if !exists {
+		current = NewHistogram(value.Bounds)
+	}
+	result, err := current.Add(value)
+	if err != nil {
+		return Histogram{}, err
+	}
+	if ms.histograms == nil {
+		ms.histograms = make(map[string]Histogram)
+	}
+	ms.histograms[name] = result
+	return result, nil
+}
+
+// checkDistributions проверяет гистограммы и summary, а границы гистограмм — по границам серий.
+func (ms *MemStorage) checkDistributions(distributions Distributions) error {
+	for name, histogram := range distributions.Histograms {
+		if err := histogram.Validate(); err != nil {
+			return fmt.Errorf("histogram '%s': %w", name, err)
+		}
+		if current, exists := ms.histograms[name]; exists {
+			if _, err := current.Add(histogram); err != nil {
+				return fmt.Errorf("histogram '%s': %w", name, err)
+			}
+		}
+	}
+	for name, summary := range distributions.Summaries {
+		if err := summary.Validate(); err != nil {
+			return fmt.Errorf("summary '%s': %w", name, err)
 		}
 	}
+	return nil
+}
 
+// checkCounters проверяет, что прибавление counters не переполнит значения серий.
+func (ms *MemStorage) checkCounters(counters map[string]uint64) error {
+	for name, delta := range counters {
+		if _, err := AddCounter(ms.counters[name], delta); err != nil {
+			return fmt.Errorf("counter '%s': %w", name, err)
+		}
+	}
 	return nil
 }
+
+func (ms *MemStorage) addCounter(name string, value uint64, at time.Time) uint64 {
+	ms.counters[name] += value
+	ms.recordCounter(name, ms.counters[name], at)
+	return ms.counters[name]
+}
+
+func (ms *MemStorage) recordGauge(name string, value float64, at time.Time) {
+	if ms.gaugeHistory == nil {
+		ms.gaugeHistory = make(map[string]*sampleRing[float64])
+	}
+	ring, exists := ms.gaugeHistory[name]
+	if !exists {
+		ring = newSampleRing[float64](historyCapacity)
+		ms.gaugeHistory[name] = ring
+	}
+	ring.push(at, value)
+}
+
+func (ms *MemStorage) recordCounter(name string, value uint64, at time.Time) {
+	if ms.counterHistory == nil {
+		ms.counterHistory = make(map[string]*sampleRing[uint64])
+	}
+	ring, exists := ms.counterHistory[name]
+	if !exists {
+		ring = newSampleRing[uint64](historyCapacity)
+		ms.counterHistory[name] = ring
+	}
+	ring.push(at, value)
+}
@@ -3,8 +3,10 @@ package repository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMemStorage_SetAndGetGauge(t *testing.T) {
@@ -99,3 +101,204 @@ func TestMemStorage_UpdateCounterAndGauges(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	}
 }
+
+func TestMemStorage_GaugeHistory(t *testing.T) {
+	ctx := context.Background()
+	ms, _ := NewMemStorage()
+	from := time.Now()
+
+	_, _ = ms.SetGauge(ctx, "HeapAlloc", 1.5)
+	_, _ = ms.SetGauge(ctx, "HeapAlloc", 2.5)
+
+	samples, err := ms.GaugeHistory(ctx, "HeapAlloc", from, time.Now())
+
+	assert.NoError(t, err, "ошибка не должна быть")
+	assert.Len(t, samples, 2)
+	assert.Equal(t, 1.5, samples[0].Value)
+	assert.Equal(t, 2.5, samples[1].Value)
+}
+
+func TestMemStorage_CounterHistory(t *testing.T) {
+	ctx := context.Background()
+	ms, _ := NewMemStorage()
+	from := time.Now()
+
+	_, _ = ms.SetCounter(ctx, "PollCount", 5)
+	_, _ = ms.SetCounter(ctx, "PollCount", 10)
+
+	samples, err := ms.CounterHistory(ctx, "PollCount", from, time.Now())
+
+	assert.NoError(t, err, "ошибка не должна быть")
+	assert.Len(t, samples, 2)
+	assert.Equal(t, uint64(5), samples[0].Value)
+	assert.Equal(t, uint64(15), samples[1].Value, "в истории хранится накопленное значение counter")
+
+	empty, err := ms.CounterHistory(ctx, "unknown", from, time.Now())
+	assert.NoError(t, err, "ошибка не должна быть")
+	assert.Empty(t, empty)
+}
+
+func TestMemStorage_HistoryWithSampleTime(t *testing.T) {
+	ctx := context.Background()
+	ms, _ := NewMemStorage()
+	later := time.Unix(1700000020, 0)
+	earlier := time.Unix(1700000010, 0)
+
+	_, _ = ms.SetGauge(WithSampleTime(ctx, later), "HeapAlloc", 2.5)
+	_, _ = ms.SetGauge(WithSampleTime(ctx, earlier), "HeapAlloc", 1.5)
+
+	samples, err := ms.GaugeHistory(ctx, "HeapAlloc", time.Time{}, time.Now())
+
+	assert.NoError(t, err, "ошибка не должна быть")
+	assert.Len(t, samples, 2)
+	assert.Equal(t, earlier, samples[0].Timestamp, "история упорядочена по времени сэмплов")
+	assert.Equal(t, later, samples[1].Timestamp)
+}
+
+func TestMemStorage_ApplyOnce(t *testing.T) {
+	ctx := context.Background()
+	ms, _ := NewMemStorage()
+
+	counters := map[string]uint64{"PollCount": 5}
+	gauges := map[string]float64{"Alloc": 1.5}
+
+	applied, err := ms.ApplyOnce(ctx, "agent:batch", counters, gauges, Distributions{})
+	assert.NoError(t, err)
+	assert.True(t, applied)
+
+	applied, err = ms.ApplyOnce(ctx, "agent:batch", counters, map[string]float64{"Alloc": 2.5}, Distributions{})
+	assert.NoError(t, err)
+	assert.False(t, applied, "повторный пакет не должен применяться")
+
+	counter, err := ms.GetCounter(ctx, "PollCount")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), counter, "counter не должен увеличиться повторно")
+
+	gauge, err := ms.GetGauge(ctx, "Alloc")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, gauge)
+}
+
+func TestMemStorage_CounterOverflow(t *testing.T) {
+	ctx := context.Background()
+	ms, _ := NewMemStorage()
+
+	_, err := ms.SetCounter(ctx, "PollCount", MaxCounter)
+	assert.NoError(t, err)
+
+	_, err = ms.SetCounter(ctx, "PollCount", 1)
+	assert.ErrorIs(t, err, ErrCounterOverflow)
+
+	err = ms.UpdateCounterAndGauges(ctx, map[string]uint64{"PollCount": 1}, map[string]float64{"Alloc": 1.5})
+	assert.ErrorIs(t, err, ErrCounterOverflow)
+	_, err = ms.GetGauge(ctx, "Alloc")
+	assert.Error(t, err, "пакет с переполнением не применяется частично")
+
+	applied, err := ms.ApplyOnce(ctx, "agent:batch", map[string]uint64{"PollCount": 1}, nil, Distributions{})
+	assert.ErrorIs(t, err, ErrCounterOverflow)
+	assert.False(t, applied)
+
+	applied, err = ms.ApplyOnce(ctx, "agent:batch", nil, map[string]float64{"Alloc": 1.5}, Distributions{})
+	assert.NoError(t, err)
+	assert.True(t, applied, "ключ отклонённого пакета не запоминается")
+
+	counter, err := ms.GetCounter(ctx, "PollCount")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(MaxCounter), counter)
+}
+
+func TestMemStorage_Histogram(t *testing.T) {
+	ctx := context.Background()
+	ms, _ := NewMemStorage()
+
+	_, err := ms.GetHistogram(ctx, "latency")
+	assert.EqualError(t, err, "histogram metric 'latency' not found")
+
+	first := NewHistogram([]float64{0.1, 1})
+	first.Observe(0.05)
+	_, err = ms.SetHistogram(ctx, "latency", first)
+	assert.NoError(t, err)
+
+	second := NewHistogram([]float64{0.1, 1})
+	second.Observe(0.5)
+	second.Observe(5)
+	result, err := ms.SetHistogram(ctx, "latency", second)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 1, 1}, result.Counts)
+	assert.Equal(t, uint64(3), result.Count)
+
+	_, err = ms.SetHistogram(ctx, "latency", NewHistogram([]float64{1}))
+	assert.ErrorIs(t, err, ErrInvalidDistribution, "границы должны совпадать с границами серии")
+
+	histograms, err := ms.Histograms(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Histogram{"latency": result}, histograms)
+}
+
+func TestMemStorage_Summary(t *testing.T) {
+	ctx := context.Background()
+	ms, _ := NewMemStorage()
+
+	_, err := ms.SetSummary(ctx, "rpc", Summary{Quantiles: []Quantile{{Quantile: 0.5, Value: 1}}, Sum: 3, Count: 2})
+	assert.NoError(t, err)
+	latest := Summary{Quantiles: []Quantile{{Quantile: 0.5, Value: 2}, {Quantile: 0.9, Value: 4}}, Sum: 9, Count: 3}
+	_, err = ms.SetSummary(ctx, "rpc", latest)
+	assert.NoError(t, err)
+
+	value, err := ms.GetSummary(ctx, "rpc")
+	assert.NoError(t, err)
+	assert.Equal(t, latest, value, "summary заменяется, а не накапливается")
+
+	summaries, err := ms.Summaries(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Summary{"rpc": latest}, summaries)
+}
+
+func TestMemStorage_AdminOperations(t *testing.T) {
+	ctx := context.Background()
+	ms, _ := NewMemStorage()
+	require.NoError(t, ms.UpdateCounterAndGauges(ctx,
+		map[string]uint64{"PollCount": 7},
+		map[string]float64{"CPUutilization8": 12.5, "Alloc": 1}))
+
+	t.Run("Delete removes series and history", func(t *testing.T) {
+		require.NoError(t, ms.Delete(ctx, MetricGauge, "CPUutilization8"))
+		_, err := ms.GetGauge(ctx, "CPUutilization8")
+		assert.Error(t, err)
+		samples, err := ms.GaugeHistory(ctx, "CPUutilization8", time.Time{}, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, samples)
+
+		assert.ErrorIs(t, ms.Delete(ctx, MetricGauge, "CPUutilization8"), ErrSeriesNotFound)
+		assert.ErrorIs(t, ms.Delete(ctx, MetricCounter, "Alloc"), ErrSeriesNotFound, "тип учитывается")
+	})
+
+	t.Run("ResetCounter records zero sample", func(t *testing.T) {
+		require.NoError(t, ms.ResetCounter(ctx, "PollCount"))
+		value, err := ms.GetCounter(ctx, "PollCount")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), value)
+		samples, err := ms.CounterHistory(ctx, "PollCount", time.Time{}, time.Now())
+		require.NoError(t, err)
+		require.Len(t, samples, 2)
+		assert.Equal(t, uint64(0), samples[1].Value)
+
+		assert.ErrorIs(t, ms.ResetCounter(ctx, "Alloc"), ErrSeriesNotFound)
+	})
+
+	t.Run("Rename moves series and history", func(t *testing.T) {
+		newKey := SeriesKey("Alloc", Labels{"host": "web-1"})
+		require.NoError(t, ms.Rename(ctx, MetricGauge, "Alloc", newKey))
+		value, err := ms.GetGauge(ctx, newKey)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, value)
+		samples, err := ms.GaugeHistory(ctx, newKey, time.Time{}, time.Now())
+		require.NoError(t, err)
+		assert.Len(t, samples, 1)
+
+		_, err = ms.SetGauge(ctx, "Alloc", 2)
+		require.NoError(t, err)
+		assert.ErrorIs(t, ms.Rename(ctx, MetricGauge, "Alloc", newKey), ErrSeriesExists)
+		assert.ErrorIs(t, ms.Rename(ctx, MetricGauge, "Missing", "Other"), ErrSeriesNotFound)
+	})
+}
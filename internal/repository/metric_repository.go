@@ -1,7 +1,20 @@
 package repository
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
+var (
+	// ErrSeriesNotFound серия указанного типа не найдена.
+	ErrSeriesNotFound = errors.New("series not found")
+	// ErrSeriesExists серия с новым ключом уже существует.
+	ErrSeriesExists = errors.New("series already exists")
+)
+
+// MetricStorage хранилище метрик. Параметр name — ключ серии (см. SeriesKey),
+// для метрик без меток он совпадает с именем.
 type MetricStorage interface {
 	SetGauge(ctx context.Context, name string, value float64) (float64, error)
 	GetGauge(ctx context.Context, name string) (float64, error)
@@ -10,5 +23,35 @@ type MetricStorage interface {
 	Gauges(ctx context.Context) (map[string]float64, error)
 	Counters(ctx context.Context) (map[string]uint64, error)
 	UpdateCounterAndGauges(ctx context.Context, counters map[string]uint64, gauges map[string]float64) error
+	// ApplyOnce атомарно применяет обновления вместе с гистограммами и summary, если ключ идемпотентности
+	// ещё не встречался. Возвращает false, если запрос с этим ключом уже был применён. Отклонённый пакет,
+	// например с границами корзин, не совпадающими с границами серии, не запоминает ключ.
+	ApplyOnce(
+		ctx context.Context,
+		key string,
+		counters map[string]uint64,
+		gauges map[string]float64,
+		distributions Distributions,
+	) (bool, error)
+	GaugeHistory(ctx context.Context, name string, from, to time.Time) ([]GaugeSample, error)
+	CounterHistory(ctx context.Context, name string, from, to time.Time) ([]CounterSample, error)
+	// SetHistogram прибавляет наблюдения к серии и возвращает накопленную гистограмму.
+	// Границы корзин должны совпадать с границами серии, иначе возвращается ErrInvalidDistribution.
+	SetHistogram(ctx context.Context, name string, value Histogram) (Histogram, error)
+	GetHistogram(ctx context.Context, name string) (Histogram, error)
+	Histograms(ctx context.Context) (map[string]Histogram, error)
+	// SetSummary заменяет значение серии.
+	SetSummary(ctx context.Context, name string, value Summary) (Summary, error)
+	GetSummary(ctx context.Context, name string) (Summary, error)
+	Summaries(ctx context.Context) (map[string]Summary, error)
+	// Delete удаляет серию типа mtype вместе с историей.
+	Delete(ctx context.Context, mtype MetricType, name string) error
+	// ResetCounter обнуляет counter и записывает нулевой сэмпл в историю.
+	ResetCounter(ctx context.Context, name string) error
+	// Rename переносит серию типа mtype вместе с историей на ключ newName.
+	// Если серия newName уже есть, возвращается ErrSeriesExists.
+	Rename(ctx context.Context, mtype MetricType, name, newName string) error
+	// Ping проверяет, что хранилище готово принимать запросы.
+	Ping(ctx context.Context) error
 	Shutdown(ctx context.Context)
 }
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP TABLE metric_samples;
+
+COMMIT;
\ No newline at end of file
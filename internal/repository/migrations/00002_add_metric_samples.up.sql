@@ -0,0 +1,14 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS metric_samples (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    name VARCHAR(200) NOT NULL,
+    mtype VARCHAR(200) NOT NULL,
+    value DOUBLE PRECISION NULL,
+    delta BIGINT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS metric_samples_series_idx ON metric_samples (name, mtype, created_at);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,14 @@
+BEGIN TRANSACTION;
+
+DELETE FROM metrics WHERE labels <> '{}';
+DELETE FROM metrics a USING metrics b WHERE a.name = b.name AND a.id > b.id;
+DROP INDEX IF EXISTS metrics_series_key;
+ALTER TABLE metrics DROP COLUMN labels;
+ALTER TABLE metrics ADD CONSTRAINT metrics_name_key UNIQUE (name);
+
+DELETE FROM metric_samples WHERE labels <> '{}';
+DROP INDEX IF EXISTS metric_samples_series_idx;
+ALTER TABLE metric_samples DROP COLUMN labels;
+CREATE INDEX IF NOT EXISTS metric_samples_series_idx ON metric_samples (name, mtype, created_at);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,11 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS metrics_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS metrics_series_key ON metrics (name, mtype, labels);
+
+ALTER TABLE metric_samples ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+DROP INDEX IF EXISTS metric_samples_series_idx;
+CREATE INDEX IF NOT EXISTS metric_samples_series_idx ON metric_samples (name, mtype, labels, created_at);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP TABLE agent_credentials;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,10 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS agent_credentials (
+    agent_id VARCHAR(255) PRIMARY KEY,
+    key TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    revoked_at TIMESTAMPTZ
+);
+
+COMMIT;
\ No newline at end of file
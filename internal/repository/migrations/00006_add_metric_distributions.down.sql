@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+DELETE FROM metrics WHERE mtype IN ('histogram', 'summary');
+ALTER TABLE metrics DROP COLUMN distribution;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS distribution JSONB NULL;
+
+COMMIT;
\ No newline at end of file
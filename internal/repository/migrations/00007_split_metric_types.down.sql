@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS metrics_value_type_check;
+
+COMMIT;
\ No newline at end of file
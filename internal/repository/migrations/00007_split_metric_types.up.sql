@@ -0,0 +1,21 @@
+BEGIN TRANSACTION;
+
+INSERT INTO metrics (name, labels, mtype, delta)
+SELECT name, labels, 'counter', delta FROM metrics WHERE mtype = 'gauge' AND delta IS NOT NULL
+ON CONFLICT (name, mtype, labels) DO NOTHING;
+
+INSERT INTO metrics (name, labels, mtype, value)
+SELECT name, labels, 'gauge', value FROM metrics WHERE mtype = 'counter' AND value IS NOT NULL
+ON CONFLICT (name, mtype, labels) DO NOTHING;
+
+UPDATE metrics SET delta = NULL WHERE mtype <> 'counter' AND delta IS NOT NULL;
+UPDATE metrics SET value = NULL WHERE mtype <> 'gauge' AND value IS NOT NULL;
+UPDATE metrics SET delta = 0 WHERE mtype = 'counter' AND delta IS NULL;
+
+ALTER TABLE metrics ADD CONSTRAINT metrics_value_type_check CHECK (
+    (mtype = 'counter' AND delta IS NOT NULL AND value IS NULL) OR
+    (mtype = 'gauge' AND delta IS NULL) OR
+    (mtype NOT IN ('counter', 'gauge') AND delta IS NULL AND value IS NULL)
+);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type sampleTimeCtx struct{}
+
+// WithSampleTime задаёт время, с которым обновления в рамках ctx записываются в историю.
+// Без него используется время обновления.
+func WithSampleTime(ctx context.Context, at time.Time) context.Context {
+	if at.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, sampleTimeCtx{}, at)
+}
+
+// sampleTime возвращает время сэмпла из контекста или текущее время.
+func sampleTime(ctx context.Context) time.Time {
+	if at, ok := ctx.Value(sampleTimeCtx{}).(time.Time); ok {
+		return at
+	}
+	return time.Now()
+}
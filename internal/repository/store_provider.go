@@ -12,6 +12,13 @@ import (
 
 type MetricType string
 
+const (
+	MetricGauge     MetricType = "gauge"
+	MetricCounter   MetricType = "counter"
+	MetricHistogram MetricType = "histogram"
+	MetricSummary   MetricType = "summary"
+)
+
 type RetriableError struct {
 	Err error
 }
@@ -25,6 +32,18 @@ func (e *RetriableError) Unwrap() error {
 }
 
 func NewMetricStorage(ctx context.Context, cfg *config.ServerConfig, logger *zap.SugaredLogger) (MetricStorage, error) {
+	storage, err := newMetricStorage(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RejectTypeConflicts() {
+		return NewTypeGuardStorage(storage), nil
+	}
+
+	return storage, nil
+}
+
+func newMetricStorage(ctx context.Context, cfg *config.ServerConfig, logger *zap.SugaredLogger) (MetricStorage, error) {
 	storageType := resolve(cfg)
 
 	switch storageType {
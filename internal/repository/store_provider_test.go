@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"metrics/internal/config"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,10 +21,14 @@ func TestNewMetricStorage(t *testing.T) {
 			name: "Memory storage",
 			cfg:  &config.ServerConfig{},
 		},
+		{
+			name: "Memory storage with type guard",
+			cfg:  &config.ServerConfig{MetricTypeConflict: config.TypeConflictReject},
+		},
 		{
 			name: "File retry storage",
 			cfg: &config.ServerConfig{
-				FileStoragePath: "/some/path",
+				FileStoragePath: filepath.Join(t.TempDir(), "metrics.json"),
 			},
 		},
 	}
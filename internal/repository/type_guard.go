@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTypeConflict имя метрики уже занято метрикой другого типа.
+var ErrTypeConflict = errors.New("metric type conflict")
+
+// TypeGuardStorage отклоняет создание серии, если метрика с тем же именем уже хранится с другим типом.
+// Метки не учитываются: имя метрики имеет один тип, как в Prometheus. Серии, которые разошлись по типам
+// до включения проверки, продолжают обновляться. Записи через обёртку выполняются последовательно.
+type TypeGuardStorage struct {
+	MetricStorage
+	types map[string]map[MetricType]struct{}
+	mu    sync.Mutex
+}
+
+func NewTypeGuardStorage(storage MetricStorage) *TypeGuardStorage {
+	return &TypeGuardStorage{MetricStorage: storage}
+}
+
+func (g *TypeGuardStorage) SetGauge(ctx context.Context, name string, value float64) (float64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.check(ctx, map[string]MetricType{name: MetricGauge}); err != nil {
+		return 0, err
+	}
+	result, err := g.MetricStorage.SetGauge(ctx, name, value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set gauge: %w", err)
+	}
+	g.remember(name, MetricGauge)
+	return result, nil
+}
+
+func (g *TypeGuardStorage) SetCounter(ctx context.Context, name string, value uint64) (uint64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.check(ctx, map[string]MetricType{name: MetricCounter}); err != nil {
+		return 0, err
+	}
+	result, err := g.MetricStorage.SetCounter(ctx, name, value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set counter: %w", err)
+	}
+	g.remember(name, MetricCounter)
+	return result, nil
+}
+
+func (g *TypeGuardStorage) UpdateCounterAndGauges(
+	ctx context.Context,
+	counters map[string]uint64,
+	gauges map[string]float64,
+) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	series, err := batchTypes(counters, gauges)
+	if err != nil {
+		return err
+	}
+	if err = g.check(ctx, series); err != nil {
+		return err
+	}
+	if err = g.MetricStorage.UpdateCounterAndGauges(ctx, counters, gauges); err != nil {
+		return fmt.Errorf("failed to update metrics: %w", err)
+	}
+	g.rememberAll(series)
+	return nil
+}
+
+// CheckTypes проверяет пакет так же, как UpdateCounterAndGauges, но не записывает его.
+func (g *TypeGuardStorage) CheckTypes(ctx context.Context, counters map[string]uint64, gauges map[string]float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	series, err := batchTypes(counters, gauges)
+	if err != nil {
+		return err
+	}
+	return g.check(ctx, series)
+}
+
+func (g *TypeGuardStorage) ApplyOnce(
+	ctx context.Context,
+	key string,
+	counters map[string]uint64,
+	gauges map[string]float64,
+	distributions Distributions,
+) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	series, err := batchTypes(counters, gauges)
+	if err != nil {
+		return false, err
+	}
+	for key := range distributions.Histograms {
+		series[key] = MetricHistogram
+	}
+	for key := range distributions.Summaries {
+		series[key] = MetricSummary
+	}
+	if err = g.check(ctx, series); err != nil {
+		return false, err
+	}
+	applied, err := g.MetricStorage.ApplyOnce(ctx, key, counters, gauges, distributions)
+	if err != nil {
+		return false, fmt.Errorf("failed to apply metrics: %w", err)
+	}
+	g.rememberAll(series)
+	return applied, nil
+}
+
+func (g *TypeGuardStorage) SetHistogram(ctx context.Context, name string, value Histogram) (Histogram, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.check(ctx, map[string]MetricType{name: MetricHistogram}); err != nil {
+		return Histogram{}, err
+	}
+	result, err := g.MetricStorage.SetHistogram(ctx, name, value)
+	if err != nil {
+		return Histogram{}, fmt.Errorf("failed to set histogram: %w", err)
+	}
+	g.remember(name, MetricHistogram)
+	return result, nil
+}
+
+func (g *TypeGuardStorage) SetSummary(ctx context.Context, name string, value Summary) (Summary, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.check(ctx, map[string]MetricType{name: MetricSummary}); err != nil {
+		return Summary{}, err
+	}
+	result, err := g.MetricStorage.SetSummary(ctx, name, value)
+	if err != nil {
+		return Summary{}, fmt.Errorf("failed to set summary: %w", err)
+	}
+	g.remember(name, MetricSummary)
+	return result, nil
+}
+
+// Delete удаляет серию и сбрасывает индекс типов: у имени могли остаться серии с другими метками.
+func (g *TypeGuardStorage) Delete(ctx context.Context, mtype MetricType, name string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.MetricStorage.Delete(ctx, mtype, name); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", mtype, err)
+	}
+	g.types = nil
+	return nil
+}
+
+// Rename проверяет, что новое имя свободно или занято тем же типом.
+func (g *TypeGuardStorage) Rename(ctx context.Context, mtype MetricType, name, newName string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.check(ctx, map[string]MetricType{newName: mtype}); err != nil {
+		return err
+	}
+	if err := g.MetricStorage.Rename(ctx, mtype, name, newName); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", mtype, err)
+	}
+	g.types = nil
+	return nil
+}
+
+// batchTypes сопоставляет серии пакета с типами. Counter и gauge с одним именем в пакете — конфликт.
+func batchTypes(counters map[string]uint64, gauges map[string]float64) (map[string]MetricType, error) {
+	series := make(map[string]MetricType, len(counters)+len(gauges))
+	names := make(map[string]MetricType, len(counters)+len(gauges))
+	for key := range counters {
+		series[key] = MetricCounter
+		name, _ := ParseSeriesKey(key)
+		names[name] = MetricCounter
+	}
+	for key := range gauges {
+		series[key] = MetricGauge
+		name, _ := ParseSeriesKey(key)
+		if names[name] == MetricCounter {
+			return nil, fmt.Errorf("%w: %s is sent as counter and gauge in one batch", ErrTypeConflict, name)
+		}
+	}
+	return series, nil
+}
+
+// check проверяет, что имя каждой серии свободно или уже хранится с тем же типом.
+// Вызывается под g.mu.
+func (g *TypeGuardStorage) check(ctx context.Context, series map[string]MetricType) error {
+	if err := g.load(ctx); err != nil {
+		return err
+	}
+	for key, mtype := range series {
+		name, _ := ParseSeriesKey(key)
+		stored := g.types[name]
+		if _, ok := stored[mtype]; ok || len(stored) == 0 {
+			continue
+		}
+		for other := range stored {
+			return fmt.Errorf("%w: %s is stored as %s, cannot write %s", ErrTypeConflict, name, other, mtype)
+		}
+	}
+	return nil
+}
+
+// load заполняет индекс типов по содержимому хранилища при первой записи.
+func (g *TypeGuardStorage) load(ctx context.Context) error {
+	if g.types != nil {
+		return nil
+	}
+	types := make(map[string]map[MetricType]struct{})
+	add := func(key string, mtype MetricType) {
+		name, _ := ParseSeriesKey(key)
+		if types[name] == nil {
+			types[name] = make(map[MetricType]struct{})
+		}
+		types[name][mtype] = struct{}{}
+	}
+
+	gauges, err := g.MetricStorage.Gauges(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load gauge types: %w", err)
+	}
+	for key := range gauges {
+		add(key, MetricGauge)
+	}
+	counters, err := g.MetricStorage.Counters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load counter types: %w", err)
+	}
+	for key := range counters {
+		add(key, MetricCounter)
+	}
+	histograms, err := g.MetricStorage.Histograms(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load histogram types: %w", err)
+	}
+	for key := range histograms {
+		add(key, MetricHistogram)
+	}
+	summaries, err := g.MetricStorage.Summaries(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load summary types: %w", err)
+	}
+	for key := range summaries {
+		add(key, MetricSummary)
+	}
+
+	g.types = types
+	return nil
+}
+
+func (g *TypeGuardStorage) remember(key string, mtype MetricType) {
+	name, _ := ParseSeriesKey(key)
+	if g.types[name] == nil {
+		g.types[name] = make(map[MetricType]struct{})
+	}
+	g.types[name][mtype] = struct{}{}
+}
+
+func (g *TypeGuardStorage) rememberAll(series map[string]MetricType) {
+	for key, mtype := range series {
+		g.remember(key, mtype)
+	}
+}
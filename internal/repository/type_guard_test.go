@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeGuardStorage(t *testing.T) {
+	ctx := context.Background()
+	ms, _ := NewMemStorage()
+	_, err := ms.SetGauge(ctx, "Legacy", 1)
+	require.NoError(t, err)
+	_, err = ms.SetCounter(ctx, "Legacy", 1)
+	require.NoError(t, err)
+
+	guard := NewTypeGuardStorage(ms)
+
+	t.Run("Name keeps its first type", func(t *testing.T) {
+		_, err := guard.SetCounter(ctx, "PollCount", 1)
+		require.NoError(t, err)
+		_, err = guard.SetCounter(ctx, SeriesKey("PollCount", Labels{"host": "web-1"}), 1)
+		require.NoError(t, err, "тот же тип с другими метками")
+
+		_, err = guard.SetGauge(ctx, SeriesKey("PollCount", Labels{"host": "web-2"}), 1)
+		assert.ErrorIs(t, err, ErrTypeConflict)
+		_, err = guard.SetHistogram(ctx, "PollCount", NewHistogram([]float64{1}))
+		assert.ErrorIs(t, err, ErrTypeConflict)
+
+		_, err = ms.GetGauge(ctx, "PollCount")
+		assert.Error(t, err, "отклонённая запись не доходит до хранилища")
+	})
+
+	t.Run("Batch is rejected as a whole", func(t *testing.T) {
+		err := guard.UpdateCounterAndGauges(ctx, map[string]uint64{"Requests": 1}, map[string]float64{"PollCount": 1})
+		assert.ErrorIs(t, err, ErrTypeConflict)
+		_, err = ms.GetCounter(ctx, "Requests")
+		assert.Error(t, err)
+
+		_, err = guard.ApplyOnce(ctx, "agent:1", map[string]uint64{"Mixed": 1}, map[string]float64{"Mixed": 1}, Distributions{})
+		assert.ErrorIs(t, err, ErrTypeConflict, "counter и gauge с одним именем в пакете")
+
+		applied, err := guard.ApplyOnce(ctx, "agent:2", map[string]uint64{"Requests": 1}, map[string]float64{"Alloc": 1}, Distributions{})
+		require.NoError(t, err)
+		assert.True(t, applied)
+	})
+
+	t.Run("Existing conflicts keep updating", func(t *testing.T) {
+		_, err := guard.SetGauge(ctx, "Legacy", 2)
+		assert.NoError(t, err)
+		_, err = guard.SetCounter(ctx, "Legacy", 2)
+		assert.NoError(t, err)
+		_, err = guard.SetSummary(ctx, "Legacy", Summary{})
+		assert.ErrorIs(t, err, ErrTypeConflict)
+	})
+
+	t.Run("Delete frees the name", func(t *testing.T) {
+		_, err := guard.SetGauge(ctx, "Requests", 1)
+		assert.ErrorIs(t, err, ErrTypeConflict)
+		require.NoError(t, guard.Delete(ctx, MetricCounter, "Requests"))
+		_, err = guard.SetGauge(ctx, "Requests", 1)
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, guard.Rename(ctx, MetricGauge, "Requests", "PollCount"), ErrTypeConflict)
+		require.NoError(t, guard.Rename(ctx, MetricGauge, "Requests", "RequestRate"))
+		_, err = guard.SetCounter(ctx, "Requests", 1)
+		assert.NoError(t, err)
+	})
+}
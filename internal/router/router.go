@@ -3,10 +3,14 @@ package router
 import (
 	"crypto/rsa"
 	"log"
+	"metrics/internal/alerting"
+	"metrics/internal/audit"
+	"metrics/internal/auth"
 	"metrics/internal/config"
 	"metrics/internal/handlers/api"
 	"metrics/internal/handlers/web"
 	middleware2 "metrics/internal/middleware"
+	"metrics/internal/otlp"
 	"metrics/internal/repository"
 	"metrics/internal/security"
 	"metrics/internal/service"
@@ -20,23 +24,36 @@ import (
 	"go.uber.org/zap"
 )
 
+// ConfigureServerHandler собирает маршруты сервера. Изменения метрик публикуются в hub.
+// Если задано хранилище credentials, запросы проверяются по индивидуальным ключам агентов
+// вместо общего ключа cfg.Key и доступны административные маршруты, действия которых пишутся в auditLog.
+// Состояние алертов доступно, если передан alerts.
 func ConfigureServerHandler(
 	memStorage repository.MetricStorage,
+	hub *service.Hub,
+	alerts *alerting.Engine,
+	credentials auth.Store,
+	auditLog audit.Log,
 	cfg *config.ServerConfig,
 	logger *zap.SugaredLogger,
 ) http.Handler {
 	router := chi.NewRouter()
 
+	sharedKey := cfg.Key
+	if credentials != nil {
+		sharedKey = ""
+	}
+
 	router.Use(
 		middleware2.LoggingMiddleware(logger),
 		middleware2.DecompressionMiddleware(logger),
-		middleware2.CheckHashMiddleware(logger, cfg.Key),
-		middleware2.ResponseHashMiddleware(cfg.Key),
+		middleware2.CheckHashMiddleware(logger, sharedKey),
+		middleware2.ResponseHashMiddleware(sharedKey),
 		middleware2.ResponseCompressionMiddleware(logger),
 		middleware2.CheckTrustedSubnetMiddleware(logger, cfg.TrustedNet),
 	)
 
-	register(router, cfg, memStorage, logger)
+	register(router, cfg, memStorage, hub, alerts, credentials, auditLog, logger)
 
 	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
 		handlerLogger := logger.With("router", "NotFound")
@@ -54,9 +71,13 @@ func register(
 	r *chi.Mux,
 	cfg *config.ServerConfig,
 	memStorage repository.MetricStorage,
+	hub *service.Hub,
+	alerts *alerting.Engine,
+	credentials auth.Store,
+	auditLog audit.Log,
 	logger *zap.SugaredLogger,
 ) {
-	metricService := service.NewMetricService(memStorage, logger)
+	metricService := service.NewMetricService(memStorage, hub, logger)
 	apiHandler := api.NewHandler(metricService, logger)
 	webHandler := web.NewHandler(metricService, logger)
 
@@ -69,20 +90,62 @@ func register(
 		}
 	}
 
+	// Подпись проверяется после расшифровки: агент подписывает исходное тело.
+	authorize := func(scope auth.Scope) func(http.Handler) http.Handler {
+		return middleware2.AuthorizeMiddleware(logger, credentials, scope)
+	}
+
 	r.Route("/updates", func(r chi.Router) {
 		r.Use(middleware2.DecryptMiddleware(privateKey))
+		r.Use(authorize(auth.ScopeWrite))
+		r.Use(middleware2.IdempotencyKeyMiddleware)
 		r.Post("/", apiHandler.UpdatesHandler())
 	})
 	r.Route("/update", func(r chi.Router) {
 		r.Use(middleware2.DecryptMiddleware(privateKey))
+		r.Use(authorize(auth.ScopeWrite))
+		r.Use(middleware2.IdempotencyKeyMiddleware)
 		r.Post("/", apiHandler.UpdateHandler())
 		r.Post("/{metricType}/{metricName}/{metricValue}", webHandler.UpdateHandler())
 	})
-	r.Route("/value", func(r chi.Router) {
-		r.Post("/", apiHandler.GetHandler())
-		r.Get("/{metricType}/{metricName}", webHandler.GetHandler())
+	r.Group(func(r chi.Router) {
+		r.Use(authorize(auth.ScopeWrite))
+		r.Post("/write", apiHandler.WriteHandler())
+		r.Post("/api/v2/write", apiHandler.WriteHandler())
+		r.Post("/v1/metrics", apiHandler.OTLPMetricsHandler(otlp.NewReceiver(metricService)))
 	})
-	r.Get("/", webHandler.ListHandler())
+	// Дашборд и его API открываются в браузере, поэтому чтение принимает и HTTP Basic.
+	r.Group(func(r chi.Router) {
+		r.Use(middleware2.BrowserAuthorizeMiddleware(logger, credentials, auth.ScopeRead))
+		r.Route("/value", func(r chi.Router) {
+			r.Post("/", apiHandler.GetHandler())
+			r.Get("/{metricType}/{metricName}", webHandler.GetHandler())
+		})
+		r.Get("/history/{metricType}/{metricName}", apiHandler.HistoryHandler())
+		r.Get("/", webHandler.ListHandler())
+		r.Get("/dashboard/{metricType}/{metricName}", webHandler.MetricPageHandler())
+		r.Get("/api/metrics", apiHandler.ListHandler())
+		r.Get("/metrics", webHandler.PrometheusHandler())
+		r.Get("/stream", apiHandler.StreamHandler())
+		r.Get("/ws", apiHandler.WebSocketHandler())
+		if alerts != nil {
+			r.Get("/alerts", api.NewAlertsHandler(alerts, logger).ListHandler())
+		}
+	})
+	if credentials != nil {
+		adminService := service.NewAdminService(memStorage, hub, auditLog, logger)
+		adminHandler := api.NewAdminHandler(credentials, adminService, logger)
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(authorize(auth.ScopeAdmin))
+			r.Post("/agents/{agentID}/revoke", adminHandler.RevokeAgentHandler())
+			r.Route("/metrics/{metricType}/{metricName}", func(r chi.Router) {
+				r.Delete("/", adminHandler.DeleteMetricHandler())
+				r.Post("/reset", adminHandler.ResetCounterHandler())
+				r.Post("/rename", adminHandler.RenameMetricHandler())
+			})
+		})
+	}
 	r.Get("/ping", webHandler.HealthHandler(cfg.DatabaseDsn))
+	r.Handle("/assets/*", webHandler.AssetsHandler())
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 }
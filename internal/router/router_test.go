@@ -1,10 +1,15 @@
 package router
 
 import (
+	"metrics/internal/audit"
+	"metrics/internal/auth"
 	"metrics/internal/config"
 	"metrics/internal/repository"
+	"metrics/internal/security"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"go.uber.org/zap"
@@ -13,6 +18,7 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRouter(t *testing.T) {
@@ -22,17 +28,18 @@ func TestRouter(t *testing.T) {
 		expectedCode int
 	}{
 		{method: http.MethodGet, path: "/", expectedCode: http.StatusOK},
+		{method: http.MethodGet, path: "/metrics", expectedCode: http.StatusOK},
 	}
 
 	for _, tc := range testCases {
-		t.Run(tc.method, func(t *testing.T) {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
 			configs := config.ServerConfig{}
 			logger := zap.NewNop()
 			sugar := logger.Sugar()
 
 			memStorage, _ := repository.NewMemStorage()
 			router := chi.NewRouter()
-			register(router, &configs, memStorage, sugar)
+			register(router, &configs, memStorage, nil, nil, nil, nil, sugar)
 			srv := httptest.NewServer(router)
 			defer srv.Close()
 
@@ -47,3 +54,64 @@ func TestRouter(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_AgentAuth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	content := `{"agents": [
+		{"id": "writer", "key": "writer-key", "scopes": ["write"]},
+		{"id": "ops", "key": "ops-key", "scopes": ["admin"]}
+	]}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	credentials, err := auth.NewFileStore(path)
+	require.NoError(t, err)
+
+	memStorage, _ := repository.NewMemStorage()
+	router := chi.NewRouter()
+	register(router, &config.ServerConfig{}, memStorage, nil, nil, credentials, audit.NewLoggerLog(zap.NewNop().Sugar()), zap.NewNop().Sugar())
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	signed := func(agentID, key, body string) *resty.Request {
+		return resty.New().R().
+			SetHeader(auth.AgentIDHeader, agentID).
+			SetHeader("HashSHA256", security.HMACSHA256Base64([]byte(body), []byte(key))).
+			SetBody(body)
+	}
+
+	body := `{"id":"Alloc","type":"gauge","value":1}`
+	resp, err := resty.New().R().SetBody(body).Post(srv.URL + "/update/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode(), "запрос без подписи отклоняется")
+
+	resp, err = signed("writer", "writer-key", body).Post(srv.URL + "/update/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	resp, err = signed("writer", "writer-key", "").Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode(), "у агента нет права read")
+
+	resp, err = signed("writer", "writer-key", "").Post(srv.URL + "/admin/agents/writer/revoke")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	resp, err = signed("writer", "writer-key", "").Delete(srv.URL + "/admin/metrics/gauge/Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	resp, err = signed("ops", "ops-key", "").Delete(srv.URL + "/admin/metrics/gauge/Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+	resp, err = signed("ops", "ops-key", "").Post(srv.URL + "/admin/agents/writer/revoke")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+	resp, err = signed("writer", "writer-key", body).Post(srv.URL + "/update/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode(), "отозванный агент отклоняется сразу")
+
+	resp, err = resty.New().R().Get(srv.URL + "/ping")
+	require.NoError(t, err)
+	assert.NotEqual(t, http.StatusUnauthorized, resp.StatusCode(), "/ping доступен без подписи")
+}
@@ -0,0 +1,162 @@
+package security
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// EnvelopeVersion текущая версия формата конверта.
+const EnvelopeVersion byte = 1
+
+const (
+	aesKeySize   = 32
+	keyIDSize    = 8
+	wrappedLenSz = 2
+)
+
+// envelopeMagic отличает конверт от устаревшего шифротекста RSA PKCS1v15.
+var envelopeMagic = []byte("MENV")
+
+var (
+	ErrMalformedEnvelope   = errors.New("malformed envelope")
+	ErrUnsupportedEnvelope = errors.New("unsupported envelope version")
+	ErrEnvelopeKeyMismatch = errors.New("envelope encrypted for another key")
+)
+
+// KeyID возвращает короткий идентификатор открытого ключа: первые 8 байт SHA-256 от PKCS1-представления.
+func KeyID(pub *rsa.PublicKey) string {
+	sum := sha256.Sum256(x509.MarshalPKCS1PublicKey(pub))
+	return hex.EncodeToString(sum[:keyIDSize])
+}
+
+// IsEnvelope сообщает, начинаются ли данные с сигнатуры конверта.
+func IsEnvelope(data []byte) bool {
+	return bytes.HasPrefix(data, envelopeMagic)
+}
+
+// EncryptEnvelope шифрует данные случайным ключом AES-256-GCM и оборачивает этот ключ RSA-OAEP.
+// Формат: MENV | версия | длина key ID | key ID | длина обёрнутого ключа | обёрнутый ключ | nonce | шифротекст.
+// Заголовок целиком участвует в GCM как дополнительные данные, поэтому его подмена обнаруживается.
+func EncryptEnvelope(data []byte, pub *rsa.PublicKey) ([]byte, error) {
+	if pub == nil {
+		return nil, errors.New("public key is nil")
+	}
+
+	dataKey := make([]byte, aesKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, dataKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+
+	keyID := []byte(KeyID(pub))
+	header := make([]byte, 0, len(envelopeMagic)+2+len(keyID)+wrappedLenSz+len(wrappedKey))
+	header = append(header, envelopeMagic...)
+	header = append(header, EnvelopeVersion, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrappedKey)))
+	header = append(header, wrappedKey...)
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	envelope := append(header, nonce...)
+	return gcm.Seal(envelope, nonce, data, header), nil
+}
+
+// DecryptEnvelope проверяет заголовок конверта, разворачивает ключ и расшифровывает данные.
+func DecryptEnvelope(privateKey *rsa.PrivateKey, envelope []byte) ([]byte, error) {
+	if privateKey == nil {
+		return nil, errors.New("private key is nil")
+	}
+	if !IsEnvelope(envelope) {
+		return nil, ErrMalformedEnvelope
+	}
+
+	rest := envelope[len(envelopeMagic):]
+	if len(rest) < 2 {
+		return nil, ErrMalformedEnvelope
+	}
+	version, keyIDLen := rest[0], int(rest[1])
+	if version != EnvelopeVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedEnvelope, version)
+	}
+	rest = rest[2:]
+
+	if len(rest) < keyIDLen+wrappedLenSz {
+		return nil, ErrMalformedEnvelope
+	}
+	keyID := string(rest[:keyIDLen])
+	if keyID != KeyID(&privateKey.PublicKey) {
+		return nil, fmt.Errorf("%w: %s", ErrEnvelopeKeyMismatch, keyID)
+	}
+	rest = rest[keyIDLen:]
+
+	wrappedLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[wrappedLenSz:]
+	if len(rest) < wrappedLen {
+		return nil, ErrMalformedEnvelope
+	}
+	wrappedKey := rest[:wrappedLen]
+	rest = rest[wrappedLen:]
+	header := envelope[:len(envelope)-len(rest)]
+
+	dataKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, wrappedKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < gcm.NonceSize() {
+		return nil, ErrMalformedEnvelope
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+
+	data, err := gcm.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt envelope: %w", err)
+	}
+	return data, nil
+}
+
+// Decrypt расшифровывает конверт, а данные в устаревшем формате — напрямую через RSA PKCS1v15.
+func Decrypt(privateKey *rsa.PrivateKey, data []byte) ([]byte, error) {
+	if IsEnvelope(data) {
+		return DecryptEnvelope(privateKey, data)
+	}
+	return DecryptRSA(privateKey, data)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create aes cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return gcm, nil
+}
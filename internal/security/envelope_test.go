@@ -0,0 +1,76 @@
+package security
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelopeRoundTripLargePayload(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte(`{"id":"HeapAlloc","type":"gauge","value":1}`), 5000)
+	envelope, err := EncryptEnvelope(data, &privateKey.PublicKey)
+	require.NoError(t, err)
+	require.True(t, IsEnvelope(envelope))
+
+	decrypted, err := Decrypt(privateKey, envelope)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+}
+
+func TestEnvelopeRejectsForeignKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	envelope, err := EncryptEnvelope([]byte("payload"), &privateKey.PublicKey)
+	require.NoError(t, err)
+
+	_, err = DecryptEnvelope(otherKey, envelope)
+	require.ErrorIs(t, err, ErrEnvelopeKeyMismatch)
+}
+
+func TestEnvelopeDetectsTampering(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	envelope, err := EncryptEnvelope([]byte("payload"), &privateKey.PublicKey)
+	require.NoError(t, err)
+
+	envelope[len(envelope)-1] ^= 0xff
+	_, err = DecryptEnvelope(privateKey, envelope)
+	require.Error(t, err)
+
+	_, err = DecryptEnvelope(privateKey, envelope[:10])
+	require.ErrorIs(t, err, ErrMalformedEnvelope)
+}
+
+func TestEnvelopeRejectsUnknownVersion(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	envelope, err := EncryptEnvelope([]byte("payload"), &privateKey.PublicKey)
+	require.NoError(t, err)
+
+	envelope[len(envelopeMagic)] = EnvelopeVersion + 1
+	_, err = DecryptEnvelope(privateKey, envelope)
+	require.ErrorIs(t, err, ErrUnsupportedEnvelope)
+}
+
+func TestDecryptFallsBackToLegacyRSA(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	legacy, err := EncryptWithPublicKey([]byte("hello"), &privateKey.PublicKey)
+	require.NoError(t, err)
+
+	decrypted, err := Decrypt(privateKey, legacy)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), decrypted)
+}
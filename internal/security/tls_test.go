@@ -0,0 +1,115 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCert создаёт самоподписанный сертификат так же, как cmd/cert:
+// он одновременно служит сертификатом сервера, клиента и CA.
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: bigInt(1),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(dir, "public.cert")
+	keyPath := filepath.Join(dir, "private.cert")
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	require.NoError(t, os.WriteFile(certPath, certPem, 0o600))
+	require.NoError(t, os.WriteFile(keyPath, keyPem, 0o600))
+	return certPath, keyPath
+}
+
+func startTLSServer(t *testing.T, cfg *tls.Config) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = cfg
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func tlsGet(t *testing.T, cfg *tls.Config, url string) error {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestTLS_MutualAuthentication(t *testing.T) {
+	certPath, keyPath := writeSelfSignedCert(t)
+
+	serverCfg, err := ServerTLSConfig(certPath, keyPath, certPath)
+	require.NoError(t, err)
+	srv := startTLSServer(t, serverCfg)
+
+	clientCfg, err := ClientTLSConfig(certPath, keyPath, certPath)
+	require.NoError(t, err)
+	assert.NoError(t, tlsGet(t, clientCfg, srv.URL))
+
+	withoutCert, err := ClientTLSConfig("", "", certPath)
+	require.NoError(t, err)
+	assert.Error(t, tlsGet(t, withoutCert, srv.URL), "без клиентского сертификата соединение отклоняется")
+}
+
+func TestTLS_ServerVerification(t *testing.T) {
+	certPath, keyPath := writeSelfSignedCert(t)
+
+	serverCfg, err := ServerTLSConfig(certPath, keyPath, "")
+	require.NoError(t, err)
+	srv := startTLSServer(t, serverCfg)
+
+	clientCfg, err := ClientTLSConfig("", "", certPath)
+	require.NoError(t, err)
+	assert.NoError(t, tlsGet(t, clientCfg, srv.URL))
+
+	systemRoots, err := ClientTLSConfig("", "", "")
+	require.NoError(t, err)
+	assert.Error(t, tlsGet(t, systemRoots, srv.URL), "сертификат не из доверенного CA отклоняется")
+}
+
+func TestTLSConfig_InvalidFiles(t *testing.T) {
+	certPath, keyPath := writeSelfSignedCert(t)
+
+	_, err := ServerTLSConfig(certPath, "", "")
+	assert.Error(t, err)
+
+	_, err = ClientTLSConfig(certPath, "", "")
+	assert.Error(t, err)
+
+	_, err = ServerTLSConfig(certPath, keyPath, keyPath)
+	assert.Error(t, err, "в файле CA нет сертификатов")
+}
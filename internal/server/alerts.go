@@ -0,0 +1,40 @@
+package server
+
+import (
+	"fmt"
+	"metrics/internal/alerting"
+	"metrics/internal/config"
+	"metrics/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	alertWebhookWaitMin = time.Second
+	alertWebhookWaitMax = 30 * time.Second
+)
+
+// NewAlerting создаёт вычислитель правил алертов и отправителя уведомлений в webhook.
+// Если алерты выключены, возвращает nil.
+func NewAlerting(
+	memStorage repository.MetricStorage,
+	cfg *config.ServerConfig,
+	logger *zap.SugaredLogger,
+) (*alerting.Engine, *alerting.Webhooks, error) {
+	if !cfg.AlertsEnabled() {
+		return nil, nil, nil
+	}
+
+	webhooks := alerting.NewWebhooks(cfg.AlertWebhooks, alerting.Retry{
+		Max:     cfg.AlertWebhookRetries,
+		WaitMin: alertWebhookWaitMin,
+		WaitMax: alertWebhookWaitMax,
+	}, logger)
+	interval := time.Duration(cfg.AlertInterval) * time.Second
+	engine, err := alerting.NewEngine(cfg.AlertRulesFile, interval, memStorage, webhooks, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load alert rules: %w", err)
+	}
+	return engine, webhooks, nil
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"fmt"
+	"metrics/internal/audit"
+	"metrics/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// NewAuditLog открывает журнал аудита административных действий. Если файл не задан,
+// записи пишутся в лог сервера.
+func NewAuditLog(cfg *config.ServerConfig, logger *zap.SugaredLogger) (audit.Log, error) {
+	if cfg.AuditLogFile == "" {
+		return audit.NewLoggerLog(logger), nil
+	}
+	auditLog, err := audit.OpenFile(cfg.AuditLogFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return auditLog, nil
+}
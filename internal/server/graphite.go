@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"metrics/internal/config"
+	"metrics/internal/graphite"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// ServeGraphite принимает метрики Graphite plaintext и блокируется до отмены контекста.
+// Перед возвратом принятые значения записываются в хранилище.
+func ServeGraphite(
+	ctx context.Context,
+	memStorage repository.MetricStorage,
+	hub *service.Hub,
+	cfg *config.ServerConfig,
+	logger *zap.SugaredLogger,
+) error {
+	rules, err := graphite.NewRules(cfg.GraphiteRules)
+	if err != nil {
+		return fmt.Errorf("invalid graphite rules: %w", err)
+	}
+	limits := graphite.Limits{
+		MaxLineLength:  cfg.GraphiteMaxLineLength,
+		MaxConnections: cfg.GraphiteMaxConnections,
+		MaxErrors:      cfg.GraphiteMaxErrors,
+	}
+
+	metricService := service.NewMetricService(memStorage, hub, logger)
+	listener, err := graphite.Listen(cfg.GraphiteAddress, rules, limits, metricService, logger)
+	if err != nil {
+		return fmt.Errorf("failed to run graphite listener: %w", err)
+	}
+	listener.Run(ctx)
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	pb "metrics/internal/proto/v1"
+	"metrics/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	healthCheckInterval = 5 * time.Second
+	healthCheckTimeout  = 2 * time.Second
+)
+
+// watchStorageHealth проверяет готовность хранилища и выставляет статус health-сервиса
+// для сервера в целом и для сервиса Metrics, пока не закрыт done.
+func watchStorageHealth(
+	done <-chan struct{},
+	healthServer *health.Server,
+	storage repository.MetricStorage,
+	logger *zap.SugaredLogger,
+) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		current := checkStorage(storage)
+		if current != last {
+			logger.Infow("storage health changed", "status", current.String())
+			last = current
+		}
+		healthServer.SetServingStatus("", current)
+		healthServer.SetServingStatus(pb.Metrics_ServiceDesc.ServiceName, current)
+
+		select {
+		case <-done:
+			healthServer.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkStorage(storage repository.MetricStorage) healthpb.HealthCheckResponse_ServingStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	if err := storage.Ping(ctx); err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	pb "metrics/internal/proto/v1"
+	"metrics/internal/repository"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type pingStorage struct {
+	repository.MetricStorage
+	healthy atomic.Bool
+}
+
+func (s *pingStorage) Ping(ctx context.Context) error {
+	if !s.healthy.Load() {
+		return errors.New("database is down")
+	}
+	return nil
+}
+
+func servingStatus(t *testing.T, healthServer *health.Server, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.GetStatus()
+}
+
+func TestWatchStorageHealth(t *testing.T) {
+	storage := &pingStorage{}
+	healthServer := health.NewServer()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		watchStorageHealth(done, healthServer, storage, zap.NewNop().Sugar())
+	}()
+
+	require.Eventually(t, func() bool {
+		return servingStatus(t, healthServer, "") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING,
+		servingStatus(t, healthServer, pb.Metrics_ServiceDesc.ServiceName))
+
+	close(done)
+	<-stopped
+
+	storage.healthy.Store(true)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, checkStorage(storage))
+}
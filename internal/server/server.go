@@ -1,15 +1,27 @@
 package server
 
 import (
+	"crypto/rsa"
 	"fmt"
+	"metrics/internal/alerting"
+	"metrics/internal/audit"
+	"metrics/internal/auth"
 	"metrics/internal/config"
+	"metrics/internal/interceptor"
 	"metrics/internal/repository"
 	"metrics/internal/router"
+	"metrics/internal/security"
 	"metrics/internal/service"
 	"net"
 	"net/http"
+	"time"
 
 	"google.golang.org/grpc"
+	grpccredentials "google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 
 	_ "net/http/pprof"
@@ -22,12 +34,16 @@ import (
 
 func ConfigureServerHandler(
 	memStorage repository.MetricStorage,
+	hub *service.Hub,
+	alerts *alerting.Engine,
+	credentials auth.Store,
+	auditLog audit.Log,
 	cfg *config.ServerConfig,
 	logger *zap.SugaredLogger,
 ) (*http.Server, error) {
 	handlerLogger := logger.With("r", "r")
 
-	r := router.ConfigureServerHandler(memStorage, cfg, logger)
+	r := router.ConfigureServerHandler(memStorage, hub, alerts, credentials, auditLog, cfg, logger)
 	handlerLogger.Infow(
 		"Starting server",
 		"addr", cfg.Address,
@@ -36,6 +52,20 @@ func ConfigureServerHandler(
 		Addr:    cfg.Address,
 		Handler: r,
 	}
+
+	if cfg.TLSEnabled() {
+		tlsConfig, err := security.ServerTLSConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSCA)
+		if err != nil {
+			return srv, fmt.Errorf("failed to configure tls: %w", err)
+		}
+		srv.TLSConfig = tlsConfig
+		handlerLogger.Infow("TLS enabled", "mtls", cfg.TLSCA != "")
+		if err := srv.ListenAndServeTLS("", ""); err != nil {
+			return srv, fmt.Errorf("listen and server has failed: %w", err)
+		}
+		return srv, nil
+	}
+
 	if err := srv.ListenAndServe(); err != nil {
 		return srv, fmt.Errorf("listen and server has failed: %w", err)
 	}
@@ -54,20 +84,119 @@ func InitPprof() (*http.Server, error) {
 	return pprofServer, nil
 }
 
-func Serve(memStorage repository.MetricStorage, logger *zap.SugaredLogger) (*grpc.Server, error) {
-	lis, err := net.Listen("tcp", "localhost:8081")
+// methodScopes права агента, необходимые для вызова методов gRPC при включённой проверке агентов.
+// Методы вне таблицы требуют права admin.
+var methodScopes = map[string]auth.Scope{
+	pb.Metrics_SendMetrics_FullMethodName:   auth.ScopeWrite,
+	pb.Metrics_StreamMetrics_FullMethodName: auth.ScopeWrite,
+	pb.Metrics_Watch_FullMethodName:         auth.ScopeRead,
+	pb.Metrics_GetMetric_FullMethodName:     auth.ScopeRead,
+	pb.Metrics_ListMetrics_FullMethodName:   auth.ScopeRead,
+	pb.Metrics_DeleteMetric_FullMethodName:  auth.ScopeAdmin,
+	pb.Metrics_ResetCounter_FullMethodName:  auth.ScopeAdmin,
+	pb.Metrics_RenameMetric_FullMethodName:  auth.ScopeAdmin,
+	healthpb.Health_Check_FullMethodName:    interceptor.ScopePublic,
+	healthpb.Health_Watch_FullMethodName:    interceptor.ScopePublic,
+}
+
+// grpcKeepaliveMinTime минимальный интервал keepalive-пингов клиента.
+// grpc-go не даёт клиенту пинговать чаще 10 секунд, поэтому клиентские настройки не приводят к GOAWAY.
+const grpcKeepaliveMinTime = 5 * time.Second
+
+// grpcTransportOptions настраивает keepalive и ограничение размера сообщений gRPC-сервера.
+func grpcTransportOptions(cfg *config.ServerConfig) []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             grpcKeepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+	}
+	if cfg.GrpcKeepaliveTime > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    time.Duration(cfg.GrpcKeepaliveTime) * time.Second,
+			Timeout: time.Duration(cfg.GrpcKeepaliveTimeout) * time.Second,
+		}))
+	}
+	if cfg.GrpcMaxMessageSize > 0 {
+		opts = append(opts,
+			grpc.MaxRecvMsgSize(cfg.GrpcMaxMessageSize),
+			grpc.MaxSendMsgSize(cfg.GrpcMaxMessageSize),
+		)
+	}
+	return opts
+}
+
+// Serve запускает gRPC-сервер с перехватчиками, повторяющими цепочку HTTP middleware.
+func Serve(
+	memStorage repository.MetricStorage,
+	hub *service.Hub,
+	credentials auth.Store,
+	auditLog audit.Log,
+	cfg *config.ServerConfig,
+	logger *zap.SugaredLogger,
+) (*grpc.Server, error) {
+	var privateKey *rsa.PrivateKey
+	if cfg.CryptoKey != "" {
+		var err error
+		privateKey, err = security.LoadRSAPrivateKeyFromFile(cfg.CryptoKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key: %w", err)
+		}
+	}
+
+	sharedKey := cfg.Key
+	if credentials != nil {
+		sharedKey = ""
+	}
+	authenticator := interceptor.NewAuthenticator(logger, sharedKey, credentials, methodScopes)
+
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			interceptor.UnaryLogging(logger),
+			interceptor.UnaryTrustedSubnet(logger, cfg.TrustedNet),
+			interceptor.UnaryDecrypt(logger, privateKey),
+			authenticator.Unary(),
+		),
+		grpc.ChainStreamInterceptor(
+			interceptor.StreamLogging(logger),
+			interceptor.StreamTrustedSubnet(logger, cfg.TrustedNet),
+			interceptor.StreamDecrypt(logger, privateKey),
+			authenticator.Stream(),
+		),
+	}
+	if cfg.TLSEnabled() {
+		tlsConfig, err := security.ServerTLSConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure tls: %w", err)
+		}
+		serverOptions = append(serverOptions, grpc.Creds(grpccredentials.NewTLS(tlsConfig)))
+	}
+	serverOptions = append(serverOptions, grpcTransportOptions(cfg)...)
+
+	lis, err := net.Listen("tcp", cfg.GrpcAddress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to run gRPC server: %w", err)
 	}
 	logger.Infow(
 		"Starting grpc server",
-		"addr", "localhost:8081",
+		"addr", cfg.GrpcAddress,
 	)
 
-	metricService := service.NewMetricService(memStorage, logger)
+	metricService := service.NewMetricService(memStorage, hub, logger)
+	// Административные методы, как и /admin в HTTP API, доступны только агентам с правом admin.
+	var adminService service.AdminService
+	if credentials != nil {
+		adminService = service.NewAdminService(memStorage, hub, auditLog, logger)
+	}
+
+	grpcServer := grpc.NewServer(serverOptions...)
+	pb.RegisterMetricsServer(grpcServer, rpc.NewServer(metricService, adminService, logger))
 
-	grpcServer := grpc.NewServer()
-	pb.RegisterMetricsServer(grpcServer, rpc.NewServer(metricService, logger))
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthDone := make(chan struct{})
+	defer close(healthDone)
+	go watchStorageHealth(healthDone, healthServer, memStorage, logger)
 
 	reflection.Register(grpcServer)
 	err = grpcServer.Serve(lis)
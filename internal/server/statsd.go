@@ -0,0 +1,34 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"metrics/internal/config"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"metrics/internal/statsd"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ServeStatsD принимает метрики StatsD и блокируется до отмены контекста.
+// Перед возвратом последнее окно агрегации сбрасывается в хранилище.
+func ServeStatsD(
+	ctx context.Context,
+	memStorage repository.MetricStorage,
+	hub *service.Hub,
+	cfg *config.ServerConfig,
+	logger *zap.SugaredLogger,
+) error {
+	metricService := service.NewMetricService(memStorage, hub, logger)
+	flushInterval := time.Duration(cfg.StatsdFlushInterval) * time.Millisecond
+
+	listener, err := statsd.Listen(cfg.StatsdAddress, flushInterval, metricService, logger)
+	if err != nil {
+		return fmt.Errorf("failed to run statsd listener: %w", err)
+	}
+	listener.Run(ctx)
+
+	return nil
+}
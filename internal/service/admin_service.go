@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"metrics/internal/audit"
+	"metrics/internal/auth"
+	"metrics/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrMetricExists серия, в которую переименовывается метрика, уже существует.
+	ErrMetricExists = errors.New("metric already exists")
+	// ErrInvalidAdminRequest тип, имя или метки в запросе административной операции заданы неверно.
+	ErrInvalidAdminRequest = errors.New("invalid admin request")
+)
+
+// MetricsRenameRequest запрос переименования серии. Метки серии сохраняются.
+type MetricsRenameRequest struct {
+	// Метки серии.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Имя метрики.
+	ID string `json:"id"`
+	// Тип метрики: counter, gauge, histogram или summary.
+	MType string `json:"type"`
+	// Новое имя метрики.
+	NewID string `json:"new_id"`
+}
+
+// AdminService административные операции над сериями метрик. Каждое действие, в том числе
+// отклонённое, записывается в журнал аудита с идентификатором агента из контекста.
+type AdminService interface {
+	// Delete удаляет серию вместе с историей.
+	Delete(ctx context.Context, req MetricsGetRequest) error
+	// ResetCounter обнуляет counter.
+	ResetCounter(ctx context.Context, req MetricsGetRequest) error
+	// Rename переносит серию вместе с историей под новое имя.
+	Rename(ctx context.Context, req MetricsRenameRequest) error
+}
+
+type adminService struct {
+	metrics *metricService
+	audit   audit.Log
+	logger  *zap.SugaredLogger
+}
+
+// NewAdminService создаёт сервис администрирования метрик. Новые значения после обнуления
+// и переименования публикуются в hub.
+func NewAdminService(
+	metricRepository repository.MetricStorage,
+	hub *Hub,
+	auditLog audit.Log,
+	logger *zap.SugaredLogger,
+) AdminService {
+	return &adminService{
+		metrics: &metricService{
+			MetricRepository: metricRepository,
+			hub:              hub,
+			logger:           logger,
+		},
+		audit:  auditLog,
+		logger: logger,
+	}
+}
+
+func (s *adminService) Delete(ctx context.Context, req MetricsGetRequest) error {
+	err := s.delete(ctx, req)
+	s.record(ctx, audit.Entry{Action: audit.ActionDelete, Type: req.MType, ID: req.ID, Labels: req.Labels}, err)
+	return err
+}
+
+func (s *adminService) delete(ctx context.Context, req MetricsGetRequest) error {
+	mtype, err := adminSeries(req.MType, req.ID, req.Labels)
+	if err != nil {
+		return err
+	}
+	return adminError(s.metrics.MetricRepository.Delete(ctx, mtype, repository.SeriesKey(req.ID, req.Labels)))
+}
+
+func (s *adminService) ResetCounter(ctx context.Context, req MetricsGetRequest) error {
+	err := s.resetCounter(ctx, req)
+	s.record(ctx, audit.Entry{Action: audit.ActionResetCounter, Type: req.MType, ID: req.ID, Labels: req.Labels}, err)
+	return err
+}
+
+func (s *adminService) resetCounter(ctx context.Context, req MetricsGetRequest) error {
+	mtype, err := adminSeries(req.MType, req.ID, req.Labels)
+	if err != nil {
+		return err
+	}
+	if mtype != repository.MetricCounter {
+		return fmt.Errorf("%w: only counter can be reset, got %s", ErrInvalidAdminRequest, req.MType)
+	}
+	err = s.metrics.MetricRepository.ResetCounter(ctx, repository.SeriesKey(req.ID, req.Labels))
+	if err != nil {
+		return adminError(err)
+	}
+	s.publish(ctx, req)
+	return nil
+}
+
+func (s *adminService) Rename(ctx context.Context, req MetricsRenameRequest) error {
+	err := s.rename(ctx, req)
+	entry := audit.Entry{Action: audit.ActionRename, Type: req.MType, ID: req.ID, Labels: req.Labels, NewID: req.NewID}
+	s.record(ctx, entry, err)
+	return err
+}
+
+func (s *adminService) rename(ctx context.Context, req MetricsRenameRequest) error {
+	mtype, err := adminSeries(req.MType, req.ID, req.Labels)
+	if err != nil {
+		return err
+	}
+	if req.NewID == "" || req.NewID == req.ID {
+		return fmt.Errorf("%w: new name must differ from '%s'", ErrInvalidAdminRequest, req.ID)
+	}
+	if err = repository.ValidateMetricName(req.NewID); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidAdminRequest, err)
+	}
+	key, newKey := repository.SeriesKey(req.ID, req.Labels), repository.SeriesKey(req.NewID, req.Labels)
+	if err = s.metrics.MetricRepository.Rename(ctx, mtype, key, newKey); err != nil {
+		return adminError(err)
+	}
+	s.publish(ctx, MetricsGetRequest{ID: req.NewID, MType: req.MType, Labels: req.Labels})
+	return nil
+}
+
+// publish отправляет подписчикам текущее значение серии после обнуления или переименования.
+func (s *adminService) publish(ctx context.Context, req MetricsGetRequest) {
+	if !s.metrics.hub.HasSubscribers() {
+		return
+	}
+	response, err := s.metrics.Get(ctx, req)
+	if err != nil {
+		s.logger.Infow("failed to read changed metric", "metric", req.ID, "error", err)
+		return
+	}
+	s.metrics.publish(*response)
+}
+
+// record записывает действие в журнал аудита. Ошибка журнала не отменяет выполненное действие.
+func (s *adminService) record(ctx context.Context, entry audit.Entry, err error) {
+	entry.Time = time.Now()
+	if credential, ok := auth.CredentialFromContext(ctx); ok {
+		entry.Actor = credential.AgentID
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if auditErr := s.audit.Record(ctx, entry); auditErr != nil {
+		s.logger.Errorw("failed to write audit entry", "action", entry.Action, "id", entry.ID, "error", auditErr)
+	}
+}
+
+// adminSeries проверяет тип, имя и метки серии.
+func adminSeries(mtype, id string, labels map[string]string) (repository.MetricType, error) {
+	switch mtype {
+	case MetricTypeCounter, MetricTypeGauge, MetricTypeHistogram, MetricTypeSummary:
+	default:
+		return "", fmt.Errorf("%w: unknown metric type '%s'", ErrInvalidAdminRequest, mtype)
+	}
+	if id == "" {
+		return "", fmt.Errorf("%w: metric name is empty", ErrInvalidAdminRequest)
+	}
+	if err := validateSeries(id, labels); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidAdminRequest, err)
+	}
+	return repository.MetricType(mtype), nil
+}
+
+// adminError сопоставляет ошибки хранилища с ошибками сервиса.
+func adminError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repository.ErrSeriesNotFound):
+		return fmt.Errorf("%w: %w", ErrMetricNotFound, err)
+	case errors.Is(err, repository.ErrSeriesExists):
+		return fmt.Errorf("%w: %w", ErrMetricExists, err)
+	case errors.Is(err, repository.ErrTypeConflict):
+		return fmt.Errorf("%w: %w", ErrInvalidAdminRequest, err)
+	default:
+		return fmt.Errorf("storage error: %w", err)
+	}
+}
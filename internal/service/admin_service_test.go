@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"metrics/internal/audit"
+	"metrics/internal/auth"
+	"metrics/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type auditRecorder struct {
+	entries []audit.Entry
+	err     error
+}
+
+func (r *auditRecorder) Record(ctx context.Context, entry audit.Entry) error {
+	r.entries = append(r.entries, entry)
+	return r.err
+}
+
+func (r *auditRecorder) Close() error {
+	return nil
+}
+
+func TestAdminService(t *testing.T) {
+	ctx := auth.WithCredential(context.Background(), auth.Credential{AgentID: "ops", Scopes: []auth.Scope{auth.ScopeAdmin}})
+	memStorage, _ := repository.NewMemStorage()
+	hub := NewHub(16)
+	auditLog := &auditRecorder{}
+	admin := NewAdminService(memStorage, hub, auditLog, zap.NewNop().Sugar())
+
+	labels := map[string]string{"host": "web-8"}
+	require.NoError(t, memStorage.UpdateCounterAndGauges(ctx,
+		map[string]uint64{"PollCount": 10},
+		map[string]float64{repository.SeriesKey("CPUutilization8", labels): 12.5}))
+
+	t.Run("Delete", func(t *testing.T) {
+		err := admin.Delete(ctx, MetricsGetRequest{ID: "CPUutilization8", MType: "gauge", Labels: labels})
+		require.NoError(t, err)
+		gauges, _ := memStorage.Gauges(ctx)
+		assert.Empty(t, gauges)
+
+		err = admin.Delete(ctx, MetricsGetRequest{ID: "CPUutilization8", MType: "gauge", Labels: labels})
+		assert.ErrorIs(t, err, ErrMetricNotFound)
+		err = admin.Delete(ctx, MetricsGetRequest{ID: "PollCount", MType: "timer"})
+		assert.ErrorIs(t, err, ErrInvalidAdminRequest)
+	})
+
+	t.Run("ResetCounter publishes new value", func(t *testing.T) {
+		sub := hub.Subscribe(WatchFilter{})
+		defer sub.Close()
+
+		require.NoError(t, admin.ResetCounter(ctx, MetricsGetRequest{ID: "PollCount", MType: "counter"}))
+		event := <-sub.Events()
+		assert.Equal(t, "PollCount", event.ID)
+		assert.Equal(t, int64(0), *event.Delta)
+
+		err := admin.ResetCounter(ctx, MetricsGetRequest{ID: "PollCount", MType: "gauge"})
+		assert.ErrorIs(t, err, ErrInvalidAdminRequest)
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		require.NoError(t, admin.Rename(ctx, MetricsRenameRequest{ID: "PollCount", MType: "counter", NewID: "Polls"}))
+		value, err := memStorage.GetCounter(ctx, "Polls")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), value)
+
+		_, err = memStorage.SetCounter(ctx, "PollCount", 1)
+		require.NoError(t, err)
+		err = admin.Rename(ctx, MetricsRenameRequest{ID: "PollCount", MType: "counter", NewID: "Polls"})
+		assert.ErrorIs(t, err, ErrMetricExists)
+		err = admin.Rename(ctx, MetricsRenameRequest{ID: "PollCount", MType: "counter"})
+		assert.ErrorIs(t, err, ErrInvalidAdminRequest)
+	})
+
+	t.Run("Every action is audited", func(t *testing.T) {
+		require.Len(t, auditLog.entries, 8)
+		first := auditLog.entries[0]
+		assert.Equal(t, "ops", first.Actor)
+		assert.Equal(t, audit.ActionDelete, first.Action)
+		assert.Equal(t, labels, first.Labels)
+		assert.Empty(t, first.Error)
+		assert.False(t, first.Time.IsZero())
+
+		rejected := auditLog.entries[1]
+		assert.Contains(t, rejected.Error, "metric not found")
+
+		renamed := auditLog.entries[5]
+		assert.Equal(t, audit.ActionRename, renamed.Action)
+		assert.Equal(t, "Polls", renamed.NewID)
+	})
+
+	t.Run("Audit failure does not undo the action", func(t *testing.T) {
+		auditLog.err = errors.New("disk full")
+		require.NoError(t, admin.Delete(ctx, MetricsGetRequest{ID: "Polls", MType: "counter"}))
+		_, err := memStorage.GetCounter(ctx, "Polls")
+		assert.Error(t, err)
+	})
+}
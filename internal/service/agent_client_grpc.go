@@ -1,11 +1,18 @@
 package service
 
 import (
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	pb "metrics/internal/proto/v1"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
 )
 
 type GrpcClient struct {
@@ -13,6 +20,22 @@ type GrpcClient struct {
 	conn *grpc.ClientConn
 }
 
+// GrpcClientOptions настройки gRPC-соединения агента.
+type GrpcClientOptions struct {
+	// Адрес сервера в формате host:port.
+	Address string
+	// Таймаут одной попытки установки соединения.
+	DialTimeout time.Duration
+	// Интервал keepalive-пингов, 0 отключает пинги.
+	KeepaliveTime time.Duration
+	// Время ожидания ответа на keepalive-пинг.
+	KeepaliveTimeout time.Duration
+	// Максимальный размер отправляемого и принимаемого сообщения в байтах.
+	MaxMessageSize int
+	// Число попыток вызова при ответе UNAVAILABLE, 1 отключает повторы.
+	MaxAttempts int
+}
+
 func (dc *GrpcClient) Close() error {
 	err := dc.conn.Close()
 	if err != nil {
@@ -21,11 +44,45 @@ func (dc *GrpcClient) Close() error {
 	return nil
 }
 
-func NewGrpcClient() (*GrpcClient, error) {
+// NewGrpcClient создаёт gRPC-клиент агента. Без tlsConfig соединение не шифруется.
+func NewGrpcClient(options GrpcClientOptions, tlsConfig *tls.Config) (*GrpcClient, error) {
+	transportCredentials := insecure.NewCredentials()
+	if tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+	callOptions := []grpc.CallOption{grpc.UseCompressor(gzip.Name)}
+	if options.MaxMessageSize > 0 {
+		callOptions = append(callOptions,
+			grpc.MaxCallSendMsgSize(options.MaxMessageSize),
+			grpc.MaxCallRecvMsgSize(options.MaxMessageSize),
+		)
+	}
 	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithDefaultCallOptions(callOptions...),
+	}
+	if options.DialTimeout > 0 {
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: options.DialTimeout,
+		}))
+	}
+	if options.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                options.KeepaliveTime,
+			Timeout:             options.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
+	if options.MaxAttempts > 1 {
+		serviceConfig, err := retryServiceConfig(options.MaxAttempts)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithDefaultServiceConfig(serviceConfig))
 	}
-	conn, err := grpc.NewClient("localhost:8081", opts...)
+
+	conn, err := grpc.NewClient(options.Address, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a new client: %w", err)
 	}
@@ -37,3 +94,27 @@ func NewGrpcClient() (*GrpcClient, error) {
 		MetricsClient: client,
 	}, nil
 }
+
+// retryServiceConfig возвращает service config с политикой повторов для всех методов Metrics.
+// Повторяются только вызовы, завершившиеся UNAVAILABLE: сервер их не обрабатывал.
+func retryServiceConfig(maxAttempts int) (string, error) {
+	serviceConfig := map[string]any{
+		"methodConfig": []any{
+			map[string]any{
+				"name": []any{map[string]any{"service": pb.Metrics_ServiceDesc.ServiceName}},
+				"retryPolicy": map[string]any{
+					"maxAttempts":          maxAttempts,
+					"initialBackoff":       "0.1s",
+					"maxBackoff":           "1s",
+					"backoffMultiplier":    2,
+					"retryableStatusCodes": []string{"UNAVAILABLE"},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(serviceConfig)
+	if err != nil {
+		return "", fmt.Errorf("marshal service config: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	pb "metrics/internal/proto/v1"
+	pbModel "metrics/internal/proto/v1/model"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyMetricsServer отвечает UNAVAILABLE на первые failures вызовов.
+type flakyMetricsServer struct {
+	pb.UnimplementedMetricsServer
+	calls    atomic.Int64
+	failures int64
+}
+
+func (s *flakyMetricsServer) SendMetrics(
+	ctx context.Context,
+	in *pbModel.MetricsRequest,
+) (*pbModel.MetricsResponse, error) {
+	if s.calls.Add(1) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	return &pbModel.MetricsResponse{}, nil
+}
+
+func startMetricsServer(t *testing.T, srv pb.MetricsServer, opts ...grpc.ServerOption) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterMetricsServer(grpcServer, srv)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	return lis.Addr().String()
+}
+
+func TestNewGrpcClient_RetriesUnavailable(t *testing.T) {
+	srv := &flakyMetricsServer{failures: 2}
+	address := startMetricsServer(t, srv)
+
+	client, err := NewGrpcClient(GrpcClientOptions{
+		Address:          address,
+		DialTimeout:      time.Second,
+		KeepaliveTime:    30 * time.Second,
+		KeepaliveTimeout: 10 * time.Second,
+		MaxMessageSize:   1 << 20,
+		MaxAttempts:      3,
+	}, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.SendMetrics(ctx, &pbModel.MetricsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), srv.calls.Load())
+}
+
+func TestNewGrpcClient_NoRetryWithSingleAttempt(t *testing.T) {
+	srv := &flakyMetricsServer{failures: 1}
+	address := startMetricsServer(t, srv)
+
+	client, err := NewGrpcClient(GrpcClientOptions{Address: address, MaxAttempts: 1}, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.SendMetrics(ctx, &pbModel.MetricsRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int64(1), srv.calls.Load())
+}
+
+func TestRetryServiceConfig(t *testing.T) {
+	raw, err := retryServiceConfig(4)
+	require.NoError(t, err)
+
+	var parsed struct {
+		MethodConfig []struct {
+			Name []struct {
+				Service string `json:"service"`
+			} `json:"name"`
+			RetryPolicy struct {
+				RetryableStatusCodes []string `json:"retryableStatusCodes"`
+				MaxAttempts          int      `json:"maxAttempts"`
+			} `json:"retryPolicy"`
+		} `json:"methodConfig"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(raw), &parsed))
+	require.Len(t, parsed.MethodConfig, 1)
+	assert.Equal(t, pb.Metrics_ServiceDesc.ServiceName, parsed.MethodConfig[0].Name[0].Service)
+	assert.Equal(t, 4, parsed.MethodConfig[0].RetryPolicy.MaxAttempts)
+	assert.Equal(t, []string{"UNAVAILABLE"}, parsed.MethodConfig[0].RetryPolicy.RetryableStatusCodes)
+}
@@ -5,9 +5,11 @@ import (
 	"compress/gzip"
 	"context"
 	"crypto/rsa"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
+	"metrics/internal/auth"
 	"metrics/internal/security"
 	"net"
 	"net/http"
@@ -26,9 +28,21 @@ type Client struct {
 	Key         string
 }
 
-func NewClient(serverAddr, key string, cryptoPath string, logger *zap.SugaredLogger) *Client {
+// NewClient создаёт HTTP-клиент агента. Запросы подписываются ключом key и помечаются
+// идентификатором agentID. При непустом tlsConfig соединения устанавливаются по TLS.
+func NewClient(
+	serverAddr, agentID, key string,
+	cryptoPath string,
+	tlsConfig *tls.Config,
+	logger *zap.SugaredLogger,
+) *Client {
 	handlerLogger := logger.With("agent", "client")
 	retryClient := retryablehttp.NewClient()
+	if tlsConfig != nil {
+		if transport, ok := retryClient.HTTPClient.Transport.(*http.Transport); ok {
+			transport.TLSClientConfig = tlsConfig
+		}
+	}
 	retryClient.RetryMax = 3
 	retryClient.Backoff = customBackoff
 	retryClient.CheckRetry = createRetryPolicy(handlerLogger)
@@ -37,6 +51,9 @@ func NewClient(serverAddr, key string, cryptoPath string, logger *zap.SugaredLog
 		SetBaseURL(serverAddr).
 		SetHeader("Content-Encoding", "gzip").
 		SetHeader("Content-Type", "application/json")
+	if agentID != "" {
+		restyClient.SetHeader(auth.AgentIDHeader, agentID)
+	}
 
 	var publicKey *rsa.PublicKey
 	if cryptoPath != "" {
@@ -134,9 +151,9 @@ func (c *Client) encrypt(data []byte) ([]byte, error) {
 	if c.PublicKey == nil {
 		return nil, errors.New("public key is not loaded")
 	}
-	encrypted, err := security.EncryptWithPublicKey(data, c.PublicKey)
+	encrypted, err := security.EncryptEnvelope(data, c.PublicKey)
 	if err != nil {
-		return nil, fmt.Errorf("encrypt with public key: %w", err)
+		return nil, fmt.Errorf("encrypt envelope: %w", err)
 	}
 	return encrypted, nil
 }
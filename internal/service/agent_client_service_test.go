@@ -17,7 +17,7 @@ func TestNewClient(t *testing.T) {
 	serverAddr := "http://example.com"
 	key := "test-key"
 
-	client := NewClient(serverAddr, key, "", sugar)
+	client := NewClient(serverAddr, "agent-1", key, "", nil, sugar)
 
 	assert.NotNil(t, client)
 
@@ -31,6 +31,7 @@ func TestNewClient(t *testing.T) {
 	assert.Equal(t, serverAddr, client.RestyClient.BaseURL)
 	assert.Contains(t, header.Get("Content-Encoding"), "gzip")
 	assert.Contains(t, header.Get("Content-Type"), "application/json")
+	assert.Equal(t, "agent-1", header.Get("X-Agent-ID"))
 }
 
 func TestReadBody(t *testing.T) {
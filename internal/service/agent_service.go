@@ -3,11 +3,16 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/go-resty/resty/v2"
 )
 
+// ErrRejected означает, что сервер отклонил запрос и повторная отправка не поможет.
+var ErrRejected = errors.New("request rejected by server")
+
 type MetricSender interface {
 	SendIncrement(ctx context.Context, req AgentMetricsCounterRequest) error
 	SendMetric(ctx context.Context, req AgentMetricsGaugeUpdateRequest) error
@@ -23,26 +28,33 @@ func NewHTTPMetricSender(client *resty.Client) *HTTPMetricSender {
 }
 
 type AgentMetricsCounterRequest struct {
-	Delta *int64 `json:"delta,omitempty"`
-	ID    string `json:"id"`
-	MType string `json:"type"`
+	Delta  *int64            `json:"delta,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	ID     string            `json:"id"`
+	MType  string            `json:"type"`
+	// IdempotencyKey передаётся заголовком Idempotency-Key, а не в теле.
+	IdempotencyKey string `json:"-"`
 }
 
 type AgentMetricsGaugeUpdateRequest struct {
-	Value *float64 `json:"value,omitempty"`
-	ID    string   `json:"id"`
-	MType string   `json:"type"`
+	Value  *float64          `json:"value,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	ID     string            `json:"id"`
+	MType  string            `json:"type"`
 }
 
 type AgentMetricsUpdateRequest struct {
-	Delta *int64   `json:"delta,omitempty"`
-	Value *float64 `json:"value,omitempty"`
-	ID    string   `json:"id"`
-	MType string   `json:"type"`
+	Delta  *int64            `json:"delta,omitempty"`
+	Value  *float64          `json:"value,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	ID     string            `json:"id"`
+	MType  string            `json:"type"`
 }
 
 type AgentMetricsUpdateRequests struct {
-	Metrics []AgentMetricsUpdateRequest `json:"metrics"`
+	// IdempotencyKey передаётся заголовком Idempotency-Key, а не в теле.
+	IdempotencyKey string                      `json:"-"`
+	Metrics        []AgentMetricsUpdateRequest `json:"metrics"`
 }
 
 func (s *HTTPMetricSender) SendIncrement(ctx context.Context, request AgentMetricsCounterRequest) error {
@@ -51,14 +63,14 @@ func (s *HTTPMetricSender) SendIncrement(ctx context.Context, request AgentMetri
 		return fmt.Errorf("error serializing the structure: %w", err)
 	}
 
-	_, err = s.client.R().
+	resp, err := s.request(request.IdempotencyKey).
 		SetBody(requestData).
 		Post("/update/")
 	if err != nil {
 		return fmt.Errorf("failed to send increment: %w", err)
 	}
 
-	return nil
+	return checkResponse(resp)
 }
 
 func (s *HTTPMetricSender) SendMetric(ctx context.Context, request AgentMetricsGaugeUpdateRequest) error {
@@ -67,28 +79,50 @@ func (s *HTTPMetricSender) SendMetric(ctx context.Context, request AgentMetricsG
 		return fmt.Errorf("error serializing the structure: %w", err)
 	}
 
-	_, err = s.client.R().
+	resp, err := s.client.R().
 		SetBody(requestData).
 		Post("/update/")
 	if err != nil {
 		return fmt.Errorf("failed to send metric %s: %w", request.ID, err)
 	}
 
-	return nil
+	return checkResponse(resp)
 }
 
 func (s *HTTPMetricSender) SendMetricsBatch(ctx context.Context, request AgentMetricsUpdateRequests) error {
-	requestData, err := json.Marshal(request)
+	// Обработчик /updates принимает массив метрик, а не объект-обёртку.
+	requestData, err := json.Marshal(request.Metrics)
 	if err != nil {
 		return fmt.Errorf("error serializing the structure: %w", err)
 	}
 
-	_, err = s.client.R().
+	resp, err := s.request(request.IdempotencyKey).
 		SetBody(requestData).
 		Post("/updates")
 	if err != nil {
 		return fmt.Errorf("failed to send metric %w", err)
 	}
 
-	return nil
+	return checkResponse(resp)
+}
+
+// request создаёт запрос и добавляет заголовок идемпотентности, если ключ задан.
+// Ключ одинаков для всех повторов, которые выполняет retryablehttp.
+func (s *HTTPMetricSender) request(idempotencyKey string) *resty.Request {
+	r := s.client.R()
+	if idempotencyKey != "" {
+		r.SetHeader("Idempotency-Key", idempotencyKey)
+	}
+	return r
+}
+
+// checkResponse превращает ответ с ошибкой в error. Ответы 4xx помечаются ErrRejected.
+func checkResponse(resp *resty.Response) error {
+	if !resp.IsError() {
+		return nil
+	}
+	if resp.StatusCode() < http.StatusInternalServerError {
+		return fmt.Errorf("%w: status %d", ErrRejected, resp.StatusCode())
+	}
+	return fmt.Errorf("server error: status %d", resp.StatusCode())
 }
@@ -2,45 +2,85 @@ package service
 
 import (
 	"context"
+	"crypto/rsa"
 	"fmt"
+	"metrics/internal/interceptor"
 	pb "metrics/internal/proto/v1"
 	pbModel "metrics/internal/proto/v1/model"
+	"metrics/internal/security"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 type GRPCMetricSender struct {
-	client pb.MetricsClient
+	client    pb.MetricsClient
+	publicKey *rsa.PublicKey
+	// stream поток StreamMetrics, nil — отчёты отправляются унарным SendMetrics.
+	stream  *metricStream
+	agentID string
+	key     string
+}
+
+// NewGRPCMetricSender создаёт отправителя метрик по gRPC. Запросы помечаются идентификатором
+// agentID и подписываются ключом key в метаданных, как HTTP-запросы агента в заголовках.
+func NewGRPCMetricSender(client pb.MetricsClient, publicKey *rsa.PublicKey, agentID, key string) *GRPCMetricSender {
+	return &GRPCMetricSender{client: client, publicKey: publicKey, agentID: agentID, key: key}
 }
 
-func NewGRPCMetricSender(client pb.MetricsClient) *GRPCMetricSender {
-	return &GRPCMetricSender{client: client}
+// NewGRPCStreamSender создаёт отправителя, который передаёт отчёты в одном потоке StreamMetrics
+// и ждёт подтверждения каждого. Без подтверждения в потоке может быть не больше window отчётов.
+func NewGRPCStreamSender(
+	client pb.MetricsClient,
+	publicKey *rsa.PublicKey,
+	agentID, key string,
+	window int,
+) *GRPCMetricSender {
+	sender := NewGRPCMetricSender(client, publicKey, agentID, key)
+	sender.stream = newMetricStream(client, window, sender.streamMetadata, sender.prepareStream)
+	return sender
+}
+
+// Close закрывает поток StreamMetrics, если он открыт.
+func (s *GRPCMetricSender) Close() {
+	if s.stream != nil {
+		s.stream.close()
+	}
 }
 
 func (s *GRPCMetricSender) SendIncrement(ctx context.Context, req AgentMetricsCounterRequest) error {
 	metric := &pbModel.Metric{
-		Id:    &req.ID,
-		Type:  &req.MType,
-		Delta: req.Delta,
+		Id:     &req.ID,
+		Type:   &req.MType,
+		Delta:  req.Delta,
+		Labels: req.Labels,
 	}
 
-	return s.sendSingle(ctx, metric)
+	return s.sendSingle(ctx, metric, req.IdempotencyKey)
 }
 
 func (s *GRPCMetricSender) SendMetric(ctx context.Context, req AgentMetricsGaugeUpdateRequest) error {
 	metric := &pbModel.Metric{
-		Id:    &req.ID,
-		Type:  &req.MType,
-		Value: req.Value,
+		Id:     &req.ID,
+		Type:   &req.MType,
+		Value:  req.Value,
+		Labels: req.Labels,
 	}
 
-	return s.sendSingle(ctx, metric)
+	return s.sendSingle(ctx, metric, "")
 }
 
-func (s *GRPCMetricSender) sendSingle(ctx context.Context, metric *pbModel.Metric) error {
+func (s *GRPCMetricSender) sendSingle(ctx context.Context, metric *pbModel.Metric, idempotencyKey string) error {
 	request := &pbModel.MetricsRequest{
 		Metrics: []*pbModel.Metric{metric},
 	}
+	if idempotencyKey != "" {
+		request.IdempotencyKey = &idempotencyKey
+	}
 
-	_, err := s.client.SendMetrics(ctx, request)
+	err := s.send(ctx, request)
 	if err != nil {
 		return fmt.Errorf("failed to send metric via gRPC: %w", err)
 	}
@@ -52,20 +92,118 @@ func (s *GRPCMetricSender) SendMetricsBatch(ctx context.Context, req AgentMetric
 	grpcMetrics := make([]*pbModel.Metric, 0, len(req.Metrics))
 	for _, m := range req.Metrics {
 		metric := &pbModel.Metric{
-			Id:    &m.ID,
-			Type:  &m.MType,
-			Delta: m.Delta,
-			Value: m.Value,
+			Id:     &m.ID,
+			Type:   &m.MType,
+			Delta:  m.Delta,
+			Value:  m.Value,
+			Labels: m.Labels,
 		}
 		grpcMetrics = append(grpcMetrics, metric)
 	}
 
-	_, err := s.client.SendMetrics(ctx, &pbModel.MetricsRequest{
+	request := &pbModel.MetricsRequest{
 		Metrics: grpcMetrics,
-	})
+	}
+	if req.IdempotencyKey != "" {
+		request.IdempotencyKey = &req.IdempotencyKey
+	}
+
+	err := s.send(ctx, request)
 	if err != nil {
 		return fmt.Errorf("failed to send batch via gRPC: %w", err)
 	}
 
 	return nil
 }
+
+// send подписывает запрос и запечатывает его в конверт, если задан открытый ключ сервера.
+// В потоковом режиме подписываются и открытие потока, и каждый отчёт (см. prepareStream).
+func (s *GRPCMetricSender) send(ctx context.Context, request *pbModel.MetricsRequest) error {
+	if s.stream != nil {
+		return s.stream.send(ctx, request)
+	}
+
+	ctx, err := s.outgoingContext(ctx, request)
+	if err != nil {
+		return err
+	}
+	request, err = s.seal(request)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.SendMetrics(ctx, request)
+	if err != nil {
+		return fmt.Errorf("send metrics: %w", classifyError(err))
+	}
+	return nil
+}
+
+// prepareStream подписывает отчёт потока nonce открытия и номером сообщения, затем запечатывает
+// его в конверт вместе с подписью: сервер проверяет подпись после расшифровки.
+func (s *GRPCMetricSender) prepareStream(
+	request *pbModel.MetricsRequest,
+	nonce string,
+	seq uint64,
+) (*pbModel.MetricsRequest, error) {
+	if s.key != "" {
+		signature, err := interceptor.SignStreamMessage(request, nonce, seq, s.key)
+		if err != nil {
+			return nil, fmt.Errorf("sign stream message: %w", err)
+		}
+		request = proto.Clone(request).(*pbModel.MetricsRequest)
+		request.Signature = &signature
+	}
+	return s.seal(request)
+}
+
+// seal запечатывает запрос в конверт открытым ключом сервера.
+func (s *GRPCMetricSender) seal(request *pbModel.MetricsRequest) (*pbModel.MetricsRequest, error) {
+	if s.publicKey == nil {
+		return request, nil
+	}
+	data, err := proto.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	envelope, err := security.EncryptEnvelope(data, s.publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt envelope: %w", err)
+	}
+	return &pbModel.MetricsRequest{Envelope: envelope}, nil
+}
+
+// classifyError помечает ошибки, при которых повторная отправка не поможет, как ErrRejected.
+func classifyError(err error) error {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied:
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	default:
+		return err
+	}
+}
+
+// agentMetadata метаданные агента: идентификатор и адрес.
+func (s *GRPCMetricSender) agentMetadata() []string {
+	pairs := make([]string, 0, 6)
+	if s.agentID != "" {
+		pairs = append(pairs, interceptor.MetadataAgentID, s.agentID)
+	}
+	if ip, err := getLocalIP(); err == nil {
+		pairs = append(pairs, interceptor.MetadataRealIP, ip)
+	}
+	return pairs
+}
+
+// outgoingContext добавляет метаданные агента и подпись открытого запроса.
+func (s *GRPCMetricSender) outgoingContext(ctx context.Context, request *pbModel.MetricsRequest) (context.Context, error) {
+	pairs := s.agentMetadata()
+	if s.key != "" {
+		hash, err := interceptor.Sign(request, s.key)
+		if err != nil {
+			return nil, fmt.Errorf("sign request: %w", err)
+		}
+		pairs = append(pairs, interceptor.MetadataHash, hash)
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...), nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"metrics/internal/interceptor"
+	pb "metrics/internal/proto/v1"
+	pbModel "metrics/internal/proto/v1/model"
+	"metrics/internal/security"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+type captureMetricsClient struct {
+	pb.MetricsClient
+	request *pbModel.MetricsRequest
+	md      metadata.MD
+}
+
+func (c *captureMetricsClient) SendMetrics(
+	ctx context.Context,
+	in *pbModel.MetricsRequest,
+	opts ...grpc.CallOption,
+) (*pbModel.MetricsResponse, error) {
+	c.request = in
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	return &pbModel.MetricsResponse{}, nil
+}
+
+func TestGRPCMetricSender_SendMetricsBatchEncrypted(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	client := &captureMetricsClient{}
+	sender := NewGRPCMetricSender(client, &privateKey.PublicKey, "agent-1", "secret")
+
+	value := 1.5
+	err = sender.SendMetricsBatch(context.Background(), AgentMetricsUpdateRequests{
+		Metrics: []AgentMetricsUpdateRequest{{
+			ID:     "Alloc",
+			MType:  "gauge",
+			Value:  &value,
+			Labels: map[string]string{"host": "web-1"},
+		}},
+		IdempotencyKey: "agent-1:batch-1",
+	})
+	require.NoError(t, err)
+	require.Empty(t, client.request.GetMetrics(), "метрики не должны передаваться в открытом виде")
+
+	data, err := security.DecryptEnvelope(privateKey, client.request.GetEnvelope())
+	require.NoError(t, err)
+
+	var opened pbModel.MetricsRequest
+	require.NoError(t, proto.Unmarshal(data, &opened))
+	require.Len(t, opened.GetMetrics(), 1)
+	require.Equal(t, "Alloc", opened.GetMetrics()[0].GetId())
+	require.Equal(t, map[string]string{"host": "web-1"}, opened.GetMetrics()[0].GetLabels())
+	require.Equal(t, "agent-1:batch-1", opened.GetIdempotencyKey())
+
+	require.Equal(t, []string{"agent-1"}, client.md.Get(interceptor.MetadataAgentID))
+	hash, err := interceptor.Sign(&opened, "secret")
+	require.NoError(t, err)
+	require.Equal(t, []string{hash}, client.md.Get(interceptor.MetadataHash), "подписывается открытый запрос")
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"metrics/internal/interceptor"
+	pb "metrics/internal/proto/v1"
+	pbModel "metrics/internal/proto/v1/model"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DefaultStreamWindow число отчётов, отправленных в поток без подтверждения.
+	DefaultStreamWindow = 16
+
+	streamOpenAttempts   = 5
+	streamInitialBackoff = 100 * time.Millisecond
+	streamMaxBackoff     = time.Second
+)
+
+// metricStream долгоживущий поток StreamMetrics. Отчёты подтверждаются сервером по порядку,
+// поэтому ожидающие подтверждения отправители хранятся в очереди. В полёте не больше window отчётов.
+// При обрыве все ожидающие отчёты завершаются ошибкой, следующий отчёт открывает поток заново.
+type metricStream struct {
+	client   pb.MetricsClient
+	current  *streamConn
+	metadata func() ([]string, error)
+	// prepare подписывает отчёт nonce открытия и номером сообщения и запечатывает его в конверт.
+	prepare func(request *pbModel.MetricsRequest, nonce string, seq uint64) (*pbModel.MetricsRequest, error)
+	window  chan struct{}
+	mu      sync.Mutex
+}
+
+// streamConn одно открытие потока.
+type streamConn struct {
+	err     error
+	stream  pb.Metrics_StreamMetricsClient
+	cancel  context.CancelFunc
+	pending []chan error
+	// nonce подписи открытия, seq номер последнего отправленного сообщения (защищён sendMu).
+	nonce string
+	seq   uint64
+	// sendMu упорядочивает запись в поток и очередь ожидания, pendingMu защищает очередь.
+	// Мьютексы разделены, чтобы чтение подтверждений не ждало заблокированной отправки.
+	sendMu    sync.Mutex
+	pendingMu sync.Mutex
+}
+
+func newMetricStream(
+	client pb.MetricsClient,
+	window int,
+	md func() ([]string, error),
+	prepare func(request *pbModel.MetricsRequest, nonce string, seq uint64) (*pbModel.MetricsRequest, error),
+) *metricStream {
+	if window <= 0 {
+		window = DefaultStreamWindow
+	}
+	return &metricStream{
+		client:   client,
+		metadata: md,
+		prepare:  prepare,
+		window:   make(chan struct{}, window),
+	}
+}
+
+// send отправляет отчёт в поток и ждёт его подтверждения.
+func (s *metricStream) send(ctx context.Context, request *pbModel.MetricsRequest) error {
+	select {
+	case s.window <- struct{}{}:
+		defer func() { <-s.window }()
+	case <-ctx.Done():
+		return fmt.Errorf("wait for stream window: %w", ctx.Err())
+	}
+
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := make(chan error, 1)
+	conn.sendMu.Lock()
+	// Номер назначается под sendMu, поэтому сообщения уходят в порядке номеров.
+	prepared, err := s.prepare(request, conn.nonce, conn.seq+1)
+	if err != nil {
+		conn.sendMu.Unlock()
+		return err
+	}
+	if !conn.enqueue(result) {
+		conn.sendMu.Unlock()
+		return fmt.Errorf("stream closed: %w", conn.failure())
+	}
+	conn.seq++
+	err = conn.stream.Send(prepared)
+	conn.sendMu.Unlock()
+	if err != nil {
+		// Причину обрыва вернёт чтение подтверждений, оно же завершит ожидание.
+		conn.cancel()
+	}
+
+	select {
+	case err = <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("wait for ack: %w", ctx.Err())
+	}
+}
+
+// conn возвращает открытый поток, при необходимости открывая его с экспоненциальной задержкой.
+func (s *metricStream) conn(ctx context.Context) (*streamConn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current != nil {
+		return s.current, nil
+	}
+
+	backoff := streamInitialBackoff
+	for attempt := 1; ; attempt++ {
+		// Подпись открытия одноразовая, поэтому каждая попытка подписывается заново.
+		pairs, err := s.metadata()
+		if err != nil {
+			return nil, err
+		}
+		streamCtx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(), pairs...))
+		stream, openErr := s.client.StreamMetrics(streamCtx)
+		if openErr == nil {
+			conn := &streamConn{stream: stream, cancel: cancel, nonce: metadataPair(pairs, interceptor.MetadataNonce)}
+			s.current = conn
+			go s.receive(conn)
+			return conn, nil
+		}
+		cancel()
+
+		if attempt == streamOpenAttempts {
+			return nil, fmt.Errorf("open stream: %w", classifyError(openErr))
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("open stream: %w", ctx.Err())
+		}
+		backoff = min(backoff*2, streamMaxBackoff)
+	}
+}
+
+// receive читает подтверждения и передаёт их ожидающим отправителям по порядку.
+func (s *metricStream) receive(conn *streamConn) {
+	for {
+		ack, err := conn.stream.Recv()
+		if err != nil {
+			s.drop(conn)
+			conn.fail(classifyError(err))
+			return
+		}
+		if next := conn.dequeue(); next != nil {
+			next <- ackError(ack)
+		}
+	}
+}
+
+func (s *metricStream) drop(conn *streamConn) {
+	s.mu.Lock()
+	if s.current == conn {
+		s.current = nil
+	}
+	s.mu.Unlock()
+	conn.cancel()
+}
+
+// close закрывает поток. Неподтверждённые отчёты завершаются ошибкой.
+func (s *metricStream) close() {
+	s.mu.Lock()
+	conn := s.current
+	s.current = nil
+	s.mu.Unlock()
+
+	if conn != nil {
+		conn.sendMu.Lock()
+		_ = conn.stream.CloseSend()
+		conn.sendMu.Unlock()
+		conn.cancel()
+	}
+}
+
+func (c *streamConn) enqueue(result chan error) bool {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if c.err != nil {
+		return false
+	}
+	c.pending = append(c.pending, result)
+	return true
+}
+
+func (c *streamConn) dequeue() chan error {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if len(c.pending) == 0 {
+		return nil
+	}
+	next := c.pending[0]
+	c.pending = c.pending[1:]
+	return next
+}
+
+func (c *streamConn) fail(err error) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	c.err = err
+	for _, result := range c.pending {
+		result <- fmt.Errorf("stream broken: %w", err)
+	}
+	c.pending = nil
+}
+
+func (c *streamConn) failure() error {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	return c.err
+}
+
+// ackError переводит статус подтверждения в ошибку отправки.
+func ackError(ack *pbModel.MetricsAck) error {
+	switch ack.GetStatus() {
+	case "ok":
+		return nil
+	case "rejected":
+		return fmt.Errorf("%w: %s", ErrRejected, ack.GetError())
+	default:
+		return errors.New("server failed to apply report: " + ack.GetError())
+	}
+}
+
+// streamMetadata метаданные открытия потока: идентификатор и адрес агента, подпись имени метода,
+// времени открытия и nonce.
+func (s *GRPCMetricSender) streamMetadata() ([]string, error) {
+	pairs := s.agentMetadata()
+	if s.key != "" {
+		signature, err := interceptor.StreamMetadata(pb.Metrics_StreamMetrics_FullMethodName, s.key)
+		if err != nil {
+			return nil, fmt.Errorf("sign stream: %w", err)
+		}
+		pairs = append(pairs, signature...)
+	}
+	return pairs, nil
+}
+
+// metadataPair возвращает значение ключа из пар метаданных.
+func metadataPair(pairs []string, key string) string {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i] == key {
+			return pairs[i+1]
+		}
+	}
+	return ""
+}
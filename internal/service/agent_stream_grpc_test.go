@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"metrics/internal/interceptor"
+	pb "metrics/internal/proto/v1"
+	pbModel "metrics/internal/proto/v1/model"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ackingMetricsServer подтверждает отчёты потока. Отчёт с ключом "bad" отклоняется,
+// отчёт с ключом "break" обрывает поток без подтверждения.
+type ackingMetricsServer struct {
+	pb.UnimplementedMetricsServer
+	received []string
+	opens    int
+	mu       sync.Mutex
+}
+
+func (s *ackingMetricsServer) StreamMetrics(stream pb.Metrics_StreamMetricsServer) error {
+	s.mu.Lock()
+	s.opens++
+	s.mu.Unlock()
+
+	var sequence uint64
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		sequence++
+
+		key := req.GetIdempotencyKey()
+		if key == "break" {
+			return status.Error(codes.Unavailable, "connection reset")
+		}
+		s.mu.Lock()
+		s.received = append(s.received, key)
+		s.mu.Unlock()
+
+		ack := &pbModel.MetricsAck{Sequence: proto.Uint64(sequence), IdempotencyKey: proto.String(key), Status: proto.String("ok")}
+		if key == "bad" {
+			ack.Status = proto.String("rejected")
+			ack.Error = proto.String("invalid label")
+		}
+		if err = stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *ackingMetricsServer) snapshot() ([]string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.received...), s.opens
+}
+
+func newTestStreamSender(t *testing.T, srv pb.MetricsServer) *GRPCMetricSender {
+	t.Helper()
+	client, err := NewGrpcClient(GrpcClientOptions{Address: startMetricsServer(t, srv), MaxAttempts: 1}, nil)
+	require.NoError(t, err)
+	sender := NewGRPCStreamSender(client, nil, "agent-1", "secret", 4)
+	t.Cleanup(func() {
+		sender.Close()
+		_ = client.Close()
+	})
+	return sender
+}
+
+func sendBatch(sender *GRPCMetricSender, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	value := 1.0
+	return sender.SendMetricsBatch(ctx, AgentMetricsUpdateRequests{
+		Metrics:        []AgentMetricsUpdateRequest{{ID: "Alloc", MType: "gauge", Value: &value}},
+		IdempotencyKey: key,
+	})
+}
+
+func TestGRPCStreamSender_OneStreamWithAcks(t *testing.T) {
+	srv := &ackingMetricsServer{}
+	sender := newTestStreamSender(t, srv)
+
+	require.NoError(t, sendBatch(sender, "agent-1:1"))
+	require.NoError(t, sendBatch(sender, "agent-1:2"))
+
+	err := sendBatch(sender, "bad")
+	assert.ErrorIs(t, err, ErrRejected, "отклонённый отчёт не повторяется")
+
+	require.NoError(t, sendBatch(sender, "agent-1:3"), "отклонение не закрывает поток")
+
+	received, opens := srv.snapshot()
+	assert.Equal(t, []string{"agent-1:1", "agent-1:2", "bad", "agent-1:3"}, received)
+	assert.Equal(t, 1, opens)
+}
+
+func TestGRPCStreamSender_SignsMessages(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		serverKey string
+		wantErr   error
+	}{
+		{name: "same key", serverKey: "secret"},
+		{name: "other key", serverKey: "other", wantErr: ErrRejected},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := interceptor.NewAuthenticator(zap.NewNop().Sugar(), tt.serverKey, nil, nil)
+			address := startMetricsServer(t, &ackingMetricsServer{}, grpc.StreamInterceptor(authenticator.Stream()))
+			client, err := NewGrpcClient(GrpcClientOptions{Address: address, MaxAttempts: 1}, nil)
+			require.NoError(t, err)
+			sender := NewGRPCStreamSender(client, nil, "agent-1", "secret", 4)
+			t.Cleanup(func() {
+				sender.Close()
+				_ = client.Close()
+			})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, sendBatch(sender, "agent-1:1"), tt.wantErr)
+				return
+			}
+			for _, key := range []string{"agent-1:1", "agent-1:2", "agent-1:3"} {
+				require.NoError(t, sendBatch(sender, key), "каждое сообщение подписано своим номером")
+			}
+		})
+	}
+}
+
+func TestGRPCStreamSender_ConcurrentSends(t *testing.T) {
+	srv := &ackingMetricsServer{}
+	sender := newTestStreamSender(t, srv)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- sendBatch(sender, "agent-1:batch")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	received, opens := srv.snapshot()
+	assert.Len(t, received, 20)
+	assert.Equal(t, 1, opens)
+}
+
+func TestGRPCStreamSender_Reconnects(t *testing.T) {
+	srv := &ackingMetricsServer{}
+	sender := newTestStreamSender(t, srv)
+
+	require.NoError(t, sendBatch(sender, "agent-1:1"))
+
+	err := sendBatch(sender, "break")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrRejected, "обрыв потока можно повторить")
+
+	require.Eventually(t, func() bool {
+		return sendBatch(sender, "agent-1:2") == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	received, opens := srv.snapshot()
+	assert.Equal(t, []string{"agent-1:1", "agent-1:2"}, received)
+	assert.Equal(t, 2, opens)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSlowSubscriber означает, что подписчик не успевал читать события и был отключён.
+	ErrSlowSubscriber = errors.New("subscriber is too slow")
+	// ErrWatchUnavailable означает, что сервис создан без хаба и подписка невозможна.
+	ErrWatchUnavailable = errors.New("metric watch is not available")
+	// ErrHubClosed означает, что хаб закрыт при остановке сервера.
+	ErrHubClosed = errors.New("metric hub is closed")
+	// ErrInvalidFilter означает некорректный фильтр подписки.
+	ErrInvalidFilter = errors.New("invalid watch filter")
+
+	errSubscriptionClosed = errors.New("subscription closed")
+)
+
+// MetricEvent изменение метрики. Для counter Delta содержит текущее значение счётчика, как в Get.
+type MetricEvent struct {
+	// Время изменения.
+	Timestamp time.Time `json:"timestamp"`
+	MetricsResponse
+}
+
+// WatchFilter фильтр подписки на изменения метрик.
+type WatchFilter struct {
+	// Префикс имени метрики, пустой — все метрики.
+	Prefix string
+	// Шаблон имени метрики (*, ?, [...]), пустой — все метрики.
+	Pattern string
+	// Тип метрики: counter, gauge, histogram или summary, пустой — все типы.
+	MType string
+}
+
+// Validate проверяет тип и шаблон фильтра.
+func (f WatchFilter) Validate() error {
+	switch f.MType {
+	case "", MetricTypeCounter, MetricTypeGauge, MetricTypeHistogram, MetricTypeSummary:
+	default:
+		return fmt.Errorf("%w: unknown metric type %q", ErrInvalidFilter, f.MType)
+	}
+	if _, err := path.Match(f.Pattern, ""); err != nil {
+		return fmt.Errorf("%w: pattern %q: %w", ErrInvalidFilter, f.Pattern, err)
+	}
+	return nil
+}
+
+// Match сообщает, подходит ли событие под фильтр.
+func (f WatchFilter) Match(event MetricEvent) bool {
+	if f.MType != "" && f.MType != event.MType {
+		return false
+	}
+	if f.Pattern != "" {
+		if matched, _ := path.Match(f.Pattern, event.ID); !matched {
+			return false
+		}
+	}
+	return strings.HasPrefix(event.ID, f.Prefix)
+}
+
+// Hub рассылает изменения метрик подписчикам. Публикация не блокируется:
+// подписчик, у которого заполнен буфер, отключается с ошибкой ErrSlowSubscriber.
+type Hub struct {
+	subscribers map[*Subscription]struct{}
+	buffer      int
+	closed      bool
+	mu          sync.Mutex
+}
+
+// NewHub создаёт хаб с буфером buffer событий на подписчика.
+func NewHub(buffer int) *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscription]struct{}),
+		buffer:      buffer,
+	}
+}
+
+// Subscription подписка на изменения метрик.
+type Subscription struct {
+	err    error
+	hub    *Hub
+	events chan MetricEvent
+	filter WatchFilter
+}
+
+// Subscribe добавляет подписчика с фильтром filter.
+// Подписка на закрытый хаб сразу закрыта с ошибкой ErrHubClosed.
+func (h *Hub) Subscribe(filter WatchFilter) *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		events: make(chan MetricEvent, h.buffer),
+		filter: filter,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		sub.err = ErrHubClosed
+		close(sub.events)
+		return sub
+	}
+	h.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+// Close отключает всех подписчиков с ошибкой ErrHubClosed, чтобы потоковые вызовы
+// завершились при остановке сервера. Повторный вызов безопасен.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		h.removeLocked(sub, ErrHubClosed)
+	}
+}
+
+// HasSubscribers сообщает, есть ли подписчики. Nil-хаб подписчиков не имеет.
+func (h *Hub) HasSubscribers() bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers) > 0
+}
+
+// Publish рассылает события подходящим подписчикам.
+func (h *Hub) Publish(events []MetricEvent) {
+	if h == nil || len(events) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		for _, event := range events {
+			if !sub.filter.Match(event) {
+				continue
+			}
+			select {
+			case sub.events <- event:
+			default:
+				h.removeLocked(sub, ErrSlowSubscriber)
+			}
+			if sub.err != nil {
+				break
+			}
+		}
+	}
+}
+
+func (h *Hub) removeLocked(sub *Subscription, err error) {
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+	delete(h.subscribers, sub)
+	sub.err = err
+	close(sub.events)
+}
+
+// Events возвращает канал событий. Канал закрывается при отключении подписки.
+func (s *Subscription) Events() <-chan MetricEvent {
+	return s.events
+}
+
+// Err возвращает причину отключения после закрытия канала событий,
+// nil — если подписка закрыта через Close.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if errors.Is(s.err, errSubscriptionClosed) {
+		return nil
+	}
+	return s.err
+}
+
+// Close отписывается от хаба. Повторный вызов безопасен.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s, errSubscriptionClosed)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"metrics/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func gaugeEvent(id string, value float64) MetricEvent {
+	return MetricEvent{MetricsResponse: MetricsResponse{ID: id, MType: "gauge", Value: &value}}
+}
+
+func TestHub_FilterAndClose(t *testing.T) {
+	hub := NewHub(4)
+	sub := hub.Subscribe(WatchFilter{Prefix: "Heap", MType: "gauge"})
+	assert.True(t, hub.HasSubscribers())
+
+	delta := int64(1)
+	hub.Publish([]MetricEvent{
+		gaugeEvent("Alloc", 1),
+		gaugeEvent("HeapAlloc", 2),
+		{MetricsResponse: MetricsResponse{ID: "HeapObjects", MType: "counter", Delta: &delta}},
+	})
+
+	event := <-sub.Events()
+	assert.Equal(t, "HeapAlloc", event.ID)
+	assert.Empty(t, sub.Events(), "события вне фильтра не доставляются")
+
+	sub.Close()
+	sub.Close()
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.NoError(t, sub.Err())
+	assert.False(t, hub.HasSubscribers())
+}
+
+func TestHub_SlowSubscriberIsDropped(t *testing.T) {
+	hub := NewHub(1)
+	slow := hub.Subscribe(WatchFilter{})
+	fast := hub.Subscribe(WatchFilter{})
+
+	hub.Publish([]MetricEvent{gaugeEvent("Alloc", 1)})
+	<-fast.Events()
+	hub.Publish([]MetricEvent{gaugeEvent("Alloc", 2)})
+
+	<-slow.Events()
+	_, ok := <-slow.Events()
+	assert.False(t, ok, "переполненный подписчик отключается")
+	assert.ErrorIs(t, slow.Err(), ErrSlowSubscriber)
+
+	event := <-fast.Events()
+	assert.Equal(t, 2.0, *event.Value, "остальные подписчики получают события")
+}
+
+func TestMetricService_PublishesChanges(t *testing.T) {
+	ctx := context.Background()
+	memStorage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	hub := NewHub(16)
+	metricService := NewMetricService(memStorage, hub, zap.NewNop().Sugar())
+
+	sub, err := metricService.Subscribe(WatchFilter{})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	delta := int64(5)
+	value := 1.5
+	require.NoError(t, metricService.UpdateMultiple(ctx, []MetricsUpdateRequest{
+		{ID: "PollCount", MType: "counter", Delta: &delta},
+		{ID: "PollCount", MType: "counter", Delta: &delta},
+		{ID: "Alloc", MType: "gauge", Value: &value},
+	}))
+
+	first := <-sub.Events()
+	assert.Equal(t, "PollCount", first.ID)
+	assert.Equal(t, int64(10), *first.Delta, "counter публикуется текущим значением один раз на пакет")
+	second := <-sub.Events()
+	assert.Equal(t, "Alloc", second.ID)
+	assert.Equal(t, 1.5, *second.Value)
+
+	dupCtx := WithIdempotencyKey(ctx, "agent:1")
+	require.NoError(t, metricService.UpdateMultiple(dupCtx, []MetricsUpdateRequest{{ID: "Alloc", MType: "gauge", Value: &value}}))
+	<-sub.Events()
+	require.NoError(t, metricService.UpdateMultiple(dupCtx, []MetricsUpdateRequest{{ID: "Alloc", MType: "gauge", Value: &value}}))
+	assert.Empty(t, sub.Events(), "повтор по ключу идемпотентности не публикуется")
+
+	_, err = NewMetricService(memStorage, nil, zap.NewNop().Sugar()).Subscribe(WatchFilter{})
+	assert.ErrorIs(t, err, ErrWatchUnavailable)
+}
+
+func TestWatchFilter_Pattern(t *testing.T) {
+	filter := WatchFilter{Pattern: "Heap*", MType: "gauge"}
+	require.NoError(t, filter.Validate())
+	assert.True(t, filter.Match(gaugeEvent("HeapAlloc", 1)))
+	assert.False(t, filter.Match(gaugeEvent("Alloc", 1)))
+
+	assert.ErrorIs(t, WatchFilter{Pattern: "[Heap"}.Validate(), ErrInvalidFilter)
+	assert.ErrorIs(t, WatchFilter{MType: "timer"}.Validate(), ErrInvalidFilter)
+
+	metricService := NewMetricService(nil, NewHub(1), zap.NewNop().Sugar())
+	_, err := metricService.Subscribe(WatchFilter{Pattern: "[Heap"})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub(1)
+	sub := hub.Subscribe(WatchFilter{})
+
+	hub.Close()
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), ErrHubClosed)
+	sub.Close()
+
+	late := hub.Subscribe(WatchFilter{})
+	_, ok = <-late.Events()
+	assert.False(t, ok, "подписка на закрытый хаб сразу закрыта")
+	assert.ErrorIs(t, late.Err(), ErrHubClosed)
+	assert.False(t, hub.HasSubscribers())
+}
@@ -0,0 +1,19 @@
+package service
+
+import "context"
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey сохраняет ключ идемпотентности запроса в контексте.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// IdempotencyKeyFromContext возвращает ключ идемпотентности, если он был передан клиентом.
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyCtx{}).(string)
+	return key, ok && key != ""
+}
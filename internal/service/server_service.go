@@ -5,12 +5,39 @@ import (
 	"errors"
 	"fmt"
 	"metrics/internal/repository"
+	"sort"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 var ErrMetricNotFound = errors.New("metric not found")
 
+// ErrNegativeDelta приращение counter отрицательно: counter только растёт.
+var ErrNegativeDelta = errors.New("counter delta must not be negative")
+
+// IsInvalidMetric сообщает, что метрики отклонены проверкой, а не из-за недоступности хранилища,
+// и повтор того же запроса не поможет.
+func IsInvalidMetric(err error) bool {
+	return errors.Is(err, repository.ErrInvalidLabel) ||
+		errors.Is(err, repository.ErrInvalidMetricName) ||
+		errors.Is(err, repository.ErrInvalidDistribution) ||
+		errors.Is(err, repository.ErrCounterOverflow) ||
+		errors.Is(err, repository.ErrTypeConflict) ||
+		errors.Is(err, ErrNegativeDelta)
+}
+
+// Типы метрик.
+const (
+	MetricTypeCounter   = "counter"
+	MetricTypeGauge     = "gauge"
+	MetricTypeHistogram = "histogram"
+	MetricTypeSummary   = "summary"
+)
+
+// DefaultHistogramBuckets границы корзин для наблюдений histogram, если в запросе не заданы buckets.
+var DefaultHistogramBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
 // MetricsUpdateRequests Структура, содержащая данные метрик.
 type MetricsUpdateRequests struct {
 	Metrics []MetricsUpdateRequest `json:"metrics"`
@@ -18,9 +45,11 @@ type MetricsUpdateRequests struct {
 
 // MetricsGetRequest Структура для запроса получения метрики.
 type MetricsGetRequest struct {
+	// Метки серии.
+	Labels map[string]string `json:"labels,omitempty"`
 	// Имя метрики.
 	ID string `json:"id"`
-	// Тип метрики: counter или gauge.
+	// Тип метрики: counter, gauge, histogram или summary.
 	MType string `json:"type"`
 }
 
@@ -30,6 +59,12 @@ type MetricsResponse struct {
 	Delta *int64 `json:"delta,omitempty"`
 	// Значение gauge.
 	Value *float64 `json:"value,omitempty"`
+	// Накопленная гистограмма histogram.
+	Histogram *repository.Histogram `json:"histogram,omitempty"`
+	// Значение summary.
+	Summary *repository.Summary `json:"summary,omitempty"`
+	// Метки серии.
+	Labels map[string]string `json:"labels,omitempty"`
 	// Тип метрики: counter или gauge.
 	ID string `json:"id"`
 	// Имя метрики.
@@ -37,12 +72,50 @@ type MetricsResponse struct {
 }
 
 // MetricsData представляет структуру для хранения данных метрик.
-// Она содержит два поля: Gauges для хранения значений типа gauge и Counters для хранения значений типа counter.
+// Она содержит поля: Gauges для хранения значений типа gauge, Counters для хранения значений типа counter,
+// Histograms и Summaries для распределений. Ключи — ключи серий (см. repository.SeriesKey).
 type MetricsData struct {
 	// Метрики.
 	Gauges map[string]float64
 	// Счетчики.
 	Counters map[string]uint64
+	// Гистограммы.
+	Histograms map[string]repository.Histogram
+	// Квантили.
+	Summaries map[string]repository.Summary
+}
+
+// Metrics возвращает метрики списком, упорядоченным по имени, типу и меткам серии.
+func (d MetricsData) Metrics() []MetricsResponse {
+	metrics := make([]MetricsResponse, 0, len(d.Gauges)+len(d.Counters)+len(d.Histograms)+len(d.Summaries))
+	for key, value := range d.Gauges {
+		name, labels := repository.ParseSeriesKey(key)
+		metrics = append(metrics, MetricsResponse{ID: name, MType: MetricTypeGauge, Value: &value, Labels: labels})
+	}
+	for key, value := range d.Counters {
+		name, labels := repository.ParseSeriesKey(key)
+		delta := int64(value)
+		metrics = append(metrics, MetricsResponse{ID: name, MType: MetricTypeCounter, Delta: &delta, Labels: labels})
+	}
+	for key, value := range d.Histograms {
+		name, labels := repository.ParseSeriesKey(key)
+		metrics = append(metrics, MetricsResponse{ID: name, MType: MetricTypeHistogram, Histogram: &value, Labels: labels})
+	}
+	for key, value := range d.Summaries {
+		name, labels := repository.ParseSeriesKey(key)
+		metrics = append(metrics, MetricsResponse{ID: name, MType: MetricTypeSummary, Summary: &value, Labels: labels})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].ID != metrics[j].ID {
+			return metrics[i].ID < metrics[j].ID
+		}
+		if metrics[i].MType != metrics[j].MType {
+			return metrics[i].MType < metrics[j].MType
+		}
+		return repository.SeriesKey("", metrics[i].Labels) < repository.SeriesKey("", metrics[j].Labels)
+	})
+	return metrics
 }
 
 // MetricsUpdateRequest Структура для обновления метрики.
@@ -51,10 +124,58 @@ type MetricsUpdateRequest struct {
 	Delta *int64 `json:"delta,omitempty"`
 	// Значение gauge.
 	Value *float64 `json:"value,omitempty"`
+	// Готовые корзины histogram, прибавляются к серии.
+	Histogram *repository.Histogram `json:"histogram,omitempty"`
+	// Значение summary, заменяет предыдущее.
+	Summary *repository.Summary `json:"summary,omitempty"`
+	// Метки серии, например {"host": "web-1"}.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Отдельные наблюдения histogram вместо готовых корзин.
+	Observations []float64 `json:"observations,omitempty"`
+	// Границы корзин для observations, по умолчанию DefaultHistogramBuckets.
+	Buckets []float64 `json:"buckets,omitempty"`
+	// Имя метрики.
+	ID string `json:"id"`
+	// Тип метрики: counter, gauge, histogram или summary.
+	MType string `json:"type"`
+}
+
+// MetricsHistoryRequest Структура для запроса истории метрики.
+type MetricsHistoryRequest struct {
+	// Начало интервала.
+	From time.Time
+	// Конец интервала.
+	To time.Time
+	// Метки серии.
+	Labels map[string]string
+	// Имя метрики.
+	ID string
+	// Тип метрики: counter или gauge.
+	MType string
+	// Шаг прореживания, 0 — вернуть все сэмплы.
+	Step time.Duration
+}
+
+// MetricsHistoryPoint Значение метрики в момент времени.
+type MetricsHistoryPoint struct {
+	// Время обновления.
+	Timestamp time.Time `json:"timestamp"`
+	// Значение counter.
+	Delta *int64 `json:"delta,omitempty"`
+	// Значение gauge.
+	Value *float64 `json:"value,omitempty"`
+}
+
+// MetricsHistoryResponse Структура для вывода истории метрики.
+type MetricsHistoryResponse struct {
+	// Метки серии.
+	Labels map[string]string `json:"labels,omitempty"`
 	// Имя метрики.
 	ID string `json:"id"`
 	// Тип метрики: counter или gauge.
 	MType string `json:"type"`
+	// Значения в порядке времени.
+	Points []MetricsHistoryPoint `json:"points"`
 }
 
 type MetricService interface {
@@ -70,20 +191,38 @@ type MetricService interface {
 		ctx context.Context,
 		metrics []MetricsUpdateRequest,
 	) error
+	// Validate проверяет метрики, как UpdateMultiple, не записывая их.
+	Validate(
+		ctx context.Context,
+		metrics []MetricsUpdateRequest,
+	) error
 	GetMetrics(ctx context.Context) MetricsData
+	// ListMetrics возвращает все серии, как GetMetrics, но с ошибкой чтения хранилища.
+	ListMetrics(ctx context.Context) (MetricsData, error)
+	History(
+		ctx context.Context,
+		req MetricsHistoryRequest,
+	) (*MetricsHistoryResponse, error)
+	// Subscribe подписывает на изменения метрик, подходящих под фильтр.
+	Subscribe(filter WatchFilter) (*Subscription, error)
 }
 
 type metricService struct {
 	MetricRepository repository.MetricStorage
+	hub              *Hub
 	logger           *zap.SugaredLogger
 }
 
+// NewMetricService создаёт сервис метрик. Изменения метрик публикуются в hub,
+// nil отключает подписки.
 func NewMetricService(
 	metricRepository repository.MetricStorage,
+	hub *Hub,
 	logger *zap.SugaredLogger,
 ) MetricService {
 	return &metricService{
 		MetricRepository: metricRepository,
+		hub:              hub,
 		logger:           logger,
 	}
 }
@@ -92,32 +231,61 @@ func (s *metricService) Get(
 	ctx context.Context,
 	req MetricsGetRequest,
 ) (*MetricsResponse, error) {
+	key := repository.SeriesKey(req.ID, req.Labels)
 	if req.MType == "counter" {
-		counter, err := s.MetricRepository.GetCounter(ctx, req.ID)
+		counter, err := s.MetricRepository.GetCounter(ctx, key)
 		if err != nil {
 			return nil, ErrMetricNotFound
 		}
 
 		counterValue := int64(counter)
 		return &MetricsResponse{
-			ID:    req.ID,
-			MType: req.MType,
-			Delta: &counterValue,
-			Value: nil,
+			ID:     req.ID,
+			MType:  req.MType,
+			Delta:  &counterValue,
+			Value:  nil,
+			Labels: req.Labels,
 		}, nil
 	}
 
 	if req.MType == "gauge" {
-		gauge, err := s.MetricRepository.GetGauge(ctx, req.ID)
+		gauge, err := s.MetricRepository.GetGauge(ctx, key)
 		if err != nil {
 			return nil, ErrMetricNotFound
 		}
 		gaugeValue := gauge
 		return &MetricsResponse{
-			ID:    req.ID,
-			MType: req.MType,
-			Delta: nil,
-			Value: &gaugeValue,
+			ID:     req.ID,
+			MType:  req.MType,
+			Delta:  nil,
+			Value:  &gaugeValue,
+			Labels: req.Labels,
+		}, nil
+	}
+
+	if req.MType == MetricTypeHistogram {
+		histogram, err := s.MetricRepository.GetHistogram(ctx, key)
+		if err != nil {
+			return nil, ErrMetricNotFound
+		}
+		return &MetricsResponse{
+			ID:        req.ID,
+			MType:     req.MType,
+			Histogram: &histogram,
+			Labels:    req.Labels,
+		}, nil
+	}
+
+	if req.MType == MetricTypeSummary {
+		summary, err := s.MetricRepository.GetSummary(ctx, key)
+		if err != nil {
+			return nil, ErrMetricNotFound
+		}
+		return &MetricsResponse{
+			ID:      req.ID,
+			MType:   req.MType,
+			Summary: &summary,
+			Labels:  req.Labels,
 		}, nil
 	}
 
@@ -128,24 +296,37 @@ func (s *metricService) Update(
 	ctx context.Context,
 	req MetricsUpdateRequest,
 ) (*MetricsResponse, error) {
+	if err := validateSeries(req.ID, req.Labels); err != nil {
+		return nil, fmt.Errorf("metric %s: %w", req.ID, err)
+	}
+
+	if key, ok := IdempotencyKeyFromContext(ctx); ok {
+		return s.updateOnce(ctx, key, req)
+	}
+
+	seriesKey := repository.SeriesKey(req.ID, req.Labels)
 	if req.MType == "counter" {
 		if req.Delta == nil {
 			return nil, errors.New("delta field cannot be nil for counter type")
 		}
-		delta := *req.Delta
-		deltaValue := uint64(delta)
-		counter, err := s.MetricRepository.SetCounter(ctx, req.ID, deltaValue)
+		if *req.Delta < 0 {
+			return nil, fmt.Errorf("metric %s: %w", req.ID, ErrNegativeDelta)
+		}
+		counter, err := s.MetricRepository.SetCounter(ctx, seriesKey, uint64(*req.Delta))
 		if err != nil {
-			return nil, errors.New("value cannot be save")
+			return nil, fmt.Errorf("value cannot be save: %w", err)
 		}
 
 		counterValue := int64(counter)
-		return &MetricsResponse{
-			ID:    req.ID,
-			MType: req.MType,
-			Delta: &counterValue,
-			Value: nil,
-		}, nil
+		response := &MetricsResponse{
+			ID:     req.ID,
+			MType:  req.MType,
+			Delta:  &counterValue,
+			Value:  nil,
+			Labels: req.Labels,
+		}
+		s.publish(*response)
+		return response, nil
 	}
 
 	if req.MType == "gauge" {
@@ -153,60 +334,482 @@ func (s *metricService) Update(
 			return nil, errors.New("value field cannot be nil for gauge type")
 		}
 		value := *req.Value
-		gauge, err := s.MetricRepository.SetGauge(ctx, req.ID, value)
+		gauge, err := s.MetricRepository.SetGauge(ctx, seriesKey, value)
 		if err != nil {
 			return nil, errors.New("value cannot be save")
 		}
 
 		gaugeValue := gauge
 
-		return &MetricsResponse{
-			ID:    req.ID,
-			MType: req.MType,
-			Delta: nil,
-			Value: &gaugeValue,
-		}, nil
+		response := &MetricsResponse{
+			ID:     req.ID,
+			MType:  req.MType,
+			Delta:  nil,
+			Value:  &gaugeValue,
+			Labels: req.Labels,
+		}
+		s.publish(*response)
+		return response, nil
+	}
+
+	if req.MType == MetricTypeHistogram || req.MType == MetricTypeSummary {
+		return s.updateDistribution(ctx, seriesKey, req)
 	}
 
 	return nil, ErrMetricNotFound
 }
 
+// updateDistribution прибавляет гистограмму к серии или заменяет значение summary.
+func (s *metricService) updateDistribution(
+	ctx context.Context,
+	seriesKey string,
+	req MetricsUpdateRequest,
+) (*MetricsResponse, error) {
+	var batch distributionBatch
+	if err := batch.add(seriesKey, req); err != nil {
+		return nil, fmt.Errorf("metric %s: %w", req.ID, err)
+	}
+
+	response := &MetricsResponse{ID: req.ID, MType: req.MType, Labels: req.Labels}
+	if req.MType == MetricTypeHistogram {
+		histogram, err := s.MetricRepository.SetHistogram(ctx, seriesKey, batch.histograms[seriesKey])
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", req.ID, err)
+		}
+		response.Histogram = &histogram
+	} else {
+		summary, err := s.MetricRepository.SetSummary(ctx, seriesKey, batch.summaries[seriesKey])
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", req.ID, err)
+		}
+		response.Summary = &summary
+	}
+	s.publish(*response)
+	return response, nil
+}
+
+// updateOnce применяет обновление одной метрики не более одного раза для ключа
+// и возвращает текущее значение, в том числе для повторного запроса.
+func (s *metricService) updateOnce(
+	ctx context.Context,
+	key string,
+	req MetricsUpdateRequest,
+) (*MetricsResponse, error) {
+	counters := make(map[string]uint64)
+	gauges := make(map[string]float64)
+	var distributions distributionBatch
+	seriesKey := repository.SeriesKey(req.ID, req.Labels)
+	switch req.MType {
+	case "counter":
+		if req.Delta == nil {
+			return nil, errors.New("delta field cannot be nil for counter type")
+		}
+		if *req.Delta < 0 {
+			return nil, fmt.Errorf("metric %s: %w", req.ID, ErrNegativeDelta)
+		}
+		counters[seriesKey] = uint64(*req.Delta)
+	case "gauge":
+		if req.Value == nil {
+			return nil, errors.New("value field cannot be nil for gauge type")
+		}
+		gauges[seriesKey] = *req.Value
+	case MetricTypeHistogram, MetricTypeSummary:
+		if err := distributions.add(seriesKey, req); err != nil {
+			return nil, fmt.Errorf("metric %s: %w", req.ID, err)
+		}
+	default:
+		return nil, ErrMetricNotFound
+	}
+
+	applied, err := s.MetricRepository.ApplyOnce(ctx, key, counters, gauges, distributions.toRepository())
+	if err != nil {
+		return nil, fmt.Errorf("value cannot be save: %w", err)
+	}
+	if !applied {
+		s.logger.Infow("Duplicate update skipped", "idempotency_key", key, "metric", req.ID)
+	}
+
+	response, err := s.Get(ctx, MetricsGetRequest{ID: req.ID, MType: req.MType, Labels: req.Labels})
+	if err != nil {
+		return nil, err
+	}
+	if applied {
+		s.publish(*response)
+	}
+	return response, nil
+}
+
 func (s *metricService) UpdateMultiple(
 	ctx context.Context,
 	metrics []MetricsUpdateRequest,
 ) error {
-	gauges := make(map[string]float64)
-	counters := make(map[string]uint64)
+	batch, err := newUpdateBatch(metrics)
+	if err != nil {
+		return err
+	}
+
+	if key, ok := IdempotencyKeyFromContext(ctx); ok {
+		// Гистограммы и summary записываются вместе с ключом, чтобы повтор после ошибки не был пропущен.
+		applied, err := s.MetricRepository.ApplyOnce(ctx, key, batch.counters, batch.gauges, batch.distributions.toRepository())
+		if err != nil {
+			return fmt.Errorf("failed ApplyOnce in service: %w", err)
+		}
+		if !applied {
+			s.logger.Infow("Duplicate batch skipped", "idempotency_key", key)
+			return nil
+		}
+		s.publishChanged(ctx, batch.changed, batch.order)
+		return nil
+	}
+
+	err = s.MetricRepository.UpdateCounterAndGauges(ctx, batch.counters, batch.gauges)
+	if err != nil {
+		return fmt.Errorf("failed UpdateCounterAndGauges in service: %w", err)
+	}
+	if err = s.applyDistributions(ctx, batch.distributions); err != nil {
+		return fmt.Errorf("failed to apply distributions in service: %w", err)
+	}
+	s.publishChanged(ctx, batch.changed, batch.order)
+
+	return nil
+}
+
+// typeChecker хранилище, которое отклоняет конфликт типов и может проверить пакет без записи.
+type typeChecker interface {
+	CheckTypes(ctx context.Context, counters map[string]uint64, gauges map[string]float64) error
+}
+
+// Validate проверяет метрики так же, как UpdateMultiple, но не записывает их. Сумма приращений counter
+// сравнивается с сохранённым значением, а если хранилище отклоняет конфликт типов — проверяются и типы.
+// Проверка не блокирует другие записи, поэтому запись после неё всё ещё может вернуть ошибку.
+func (s *metricService) Validate(ctx context.Context, metrics []MetricsUpdateRequest) error {
+	batch, err := newUpdateBatch(metrics)
+	if err != nil {
+		return err
+	}
+
+	if len(batch.counters) > 0 {
+		counters, err := s.MetricRepository.Counters(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read counters in service: %w", err)
+		}
+		for seriesKey, delta := range batch.counters {
+			if _, err = repository.AddCounter(counters[seriesKey], delta); err != nil {
+				return fmt.Errorf("counter %s: %w", seriesKey, err)
+			}
+		}
+	}
+
+	if checker, ok := s.MetricRepository.(typeChecker); ok {
+		if err = checker.CheckTypes(ctx, batch.counters, batch.gauges); err != nil {
+			return fmt.Errorf("failed to check metric types in service: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateBatch обновления запроса, сгруппированные по сериям.
+type updateBatch struct {
+	gauges   map[string]float64
+	counters map[string]uint64
+	// changed первые вхождения изменённых серий в порядке запроса для публикации в хаб.
+	changed       map[string]MetricsGetRequest
+	distributions distributionBatch
+	order         []string
+}
+
+// newUpdateBatch проверяет метрики и складывает приращения counter одной серии.
+// Для gauge остаётся последнее значение.
+func newUpdateBatch(metrics []MetricsUpdateRequest) (*updateBatch, error) {
+	batch := &updateBatch{
+		gauges:   make(map[string]float64),
+		counters: make(map[string]uint64),
+		changed:  make(map[string]MetricsGetRequest),
+		order:    make([]string, 0, len(metrics)),
+	}
 
 	for _, metric := range metrics {
-		if metric.Delta == nil && metric.Value == nil {
+		distribution := metric.MType == MetricTypeHistogram || metric.MType == MetricTypeSummary
+		if metric.Delta == nil && metric.Value == nil && !distribution {
+			continue
+		}
+		if err := validateSeries(metric.ID, metric.Labels); err != nil {
+			return nil, fmt.Errorf("metric %s: %w", metric.ID, err)
+		}
+
+		seriesKey := repository.SeriesKey(metric.ID, metric.Labels)
+		if distribution {
+			if err := batch.distributions.add(seriesKey, metric); err != nil {
+				return nil, fmt.Errorf("metric %s: %w", metric.ID, err)
+			}
+			batch.order = appendChanged(batch.changed, batch.order, metric, metric.MType, seriesKey)
 			continue
 		}
 		if metric.Delta != nil {
-			counters[metric.ID] += uint64(*metric.Delta)
+			if *metric.Delta < 0 {
+				return nil, fmt.Errorf("metric %s: %w", metric.ID, ErrNegativeDelta)
+			}
+			sum, err := repository.AddCounter(batch.counters[seriesKey], uint64(*metric.Delta))
+			if err != nil {
+				return nil, fmt.Errorf("metric %s: %w", metric.ID, err)
+			}
+			batch.counters[seriesKey] = sum
+			batch.order = appendChanged(batch.changed, batch.order, metric, "counter", seriesKey)
 		}
 
 		if metric.Value != nil {
-			gauges[metric.ID] = *metric.Value
+			batch.gauges[seriesKey] = *metric.Value
+			batch.order = appendChanged(batch.changed, batch.order, metric, "gauge", seriesKey)
+		}
+	}
+	return batch, nil
+}
+
+// distributionBatch гистограммы и summary запроса. Гистограммы одной серии складываются,
+// для summary остаётся последнее значение.
+type distributionBatch struct {
+	histograms map[string]repository.Histogram
+	summaries  map[string]repository.Summary
+}
+
+// toRepository возвращает гистограммы и summary пакета для хранилища.
+func (b distributionBatch) toRepository() repository.Distributions {
+	return repository.Distributions{Histograms: b.histograms, Summaries: b.summaries}
+}
+
+func (b *distributionBatch) add(seriesKey string, metric MetricsUpdateRequest) error {
+	if metric.MType == MetricTypeSummary {
+		if metric.Summary == nil {
+			return errors.New("summary field cannot be nil for summary type")
+		}
+		if err := metric.Summary.Validate(); err != nil {
+			return err
+		}
+		if b.summaries == nil {
+			b.summaries = make(map[string]repository.Summary)
 		}
+		b.summaries[seriesKey] = metric.Summary.Clone()
+		return nil
 	}
 
-	err := s.MetricRepository.UpdateCounterAndGauges(ctx, counters, gauges)
+	update, err := histogramUpdate(metric)
 	if err != nil {
-		return fmt.Errorf("failed UpdateCounterAndGauges in service: %w", err)
+		return err
+	}
+	if b.histograms == nil {
+		b.histograms = make(map[string]repository.Histogram)
+	}
+	if current, ok := b.histograms[seriesKey]; ok {
+		if update, err = current.Add(update); err != nil {
+			return err
+		}
 	}
+	b.histograms[seriesKey] = update
+	return nil
+}
 
+// applyDistributions записывает гистограммы и summary пакета после counter и gauge.
+func (s *metricService) applyDistributions(ctx context.Context, batch distributionBatch) error {
+	for seriesKey, histogram := range batch.histograms {
+		if _, err := s.MetricRepository.SetHistogram(ctx, seriesKey, histogram); err != nil {
+			return fmt.Errorf("histogram %s: %w", seriesKey, err)
+		}
+	}
+	for seriesKey, summary := range batch.summaries {
+		if _, err := s.MetricRepository.SetSummary(ctx, seriesKey, summary); err != nil {
+			return fmt.Errorf("summary %s: %w", seriesKey, err)
+		}
+	}
 	return nil
 }
 
+// histogramUpdate возвращает приращение гистограммы: готовые корзины или разложенные по корзинам наблюдения.
+func histogramUpdate(metric MetricsUpdateRequest) (repository.Histogram, error) {
+	switch {
+	case metric.Histogram != nil && len(metric.Observations) > 0:
+		return repository.Histogram{}, fmt.Errorf("%w: histogram and observations cannot be combined",
+			repository.ErrInvalidDistribution)
+	case metric.Histogram != nil:
+		histogram := metric.Histogram.Clone()
+		if histogram.Count == 0 {
+			for _, count := range histogram.Counts {
+				histogram.Count += count
+			}
+		}
+		return histogram, histogram.Validate()
+	case len(metric.Observations) > 0:
+		bounds := metric.Buckets
+		if bounds == nil {
+			bounds = DefaultHistogramBuckets
+		}
+		histogram := repository.NewHistogram(bounds)
+		for _, observation := range metric.Observations {
+			histogram.Observe(observation)
+		}
+		return histogram, histogram.Validate()
+	default:
+		return repository.Histogram{}, errors.New("histogram or observations field cannot be nil for histogram type")
+	}
+}
+
+func appendChanged(
+	changed map[string]MetricsGetRequest,
+	order []string,
+	metric MetricsUpdateRequest,
+	mtype, seriesKey string,
+) []string {
+	key := mtype + "/" + seriesKey
+	if _, ok := changed[key]; ok {
+		return order
+	}
+	changed[key] = MetricsGetRequest{ID: metric.ID, MType: mtype, Labels: metric.Labels}
+	return append(order, key)
+}
+
+// publishChanged читает текущие значения изменённых серий и публикует их в хаб.
+// Без подписчиков значения не читаются.
+func (s *metricService) publishChanged(ctx context.Context, changed map[string]MetricsGetRequest, order []string) {
+	if !s.hub.HasSubscribers() {
+		return
+	}
+	now := time.Now()
+	events := make([]MetricEvent, 0, len(order))
+	for _, key := range order {
+		response, err := s.Get(ctx, changed[key])
+		if err != nil {
+			s.logger.Infow("failed to read changed metric", "metric", changed[key].ID, "error", err)
+			continue
+		}
+		events = append(events, MetricEvent{Timestamp: now, MetricsResponse: *response})
+	}
+	s.hub.Publish(events)
+}
+
+func (s *metricService) publish(response MetricsResponse) {
+	if !s.hub.HasSubscribers() {
+		return
+	}
+	s.hub.Publish([]MetricEvent{{Timestamp: time.Now(), MetricsResponse: response}})
+}
+
+func (s *metricService) Subscribe(filter WatchFilter) (*Subscription, error) {
+	if s.hub == nil {
+		return nil, ErrWatchUnavailable
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return s.hub.Subscribe(filter), nil
+}
+
 func (s *metricService) GetMetrics(ctx context.Context) MetricsData {
 	gauges, _ := s.MetricRepository.Gauges(ctx)
 	counters, _ := s.MetricRepository.Counters(ctx)
+	histograms, _ := s.MetricRepository.Histograms(ctx)
+	summaries, _ := s.MetricRepository.Summaries(ctx)
 
 	data := MetricsData{
-		Gauges:   gauges,
-		Counters: counters,
+		Gauges:     gauges,
+		Counters:   counters,
+		Histograms: histograms,
+		Summaries:  summaries,
 	}
 
 	return data
 }
+
+func (s *metricService) ListMetrics(ctx context.Context) (MetricsData, error) {
+	gauges, err := s.MetricRepository.Gauges(ctx)
+	if err != nil {
+		return MetricsData{}, fmt.Errorf("failed Gauges in service: %w", err)
+	}
+	counters, err := s.MetricRepository.Counters(ctx)
+	if err != nil {
+		return MetricsData{}, fmt.Errorf("failed Counters in service: %w", err)
+	}
+	histograms, err := s.MetricRepository.Histograms(ctx)
+	if err != nil {
+		return MetricsData{}, fmt.Errorf("failed Histograms in service: %w", err)
+	}
+	summaries, err := s.MetricRepository.Summaries(ctx)
+	if err != nil {
+		return MetricsData{}, fmt.Errorf("failed Summaries in service: %w", err)
+	}
+
+	return MetricsData{
+		Gauges:     gauges,
+		Counters:   counters,
+		Histograms: histograms,
+		Summaries:  summaries,
+	}, nil
+}
+
+func (s *metricService) History(
+	ctx context.Context,
+	req MetricsHistoryRequest,
+) (*MetricsHistoryResponse, error) {
+	to := req.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	seriesKey := repository.SeriesKey(req.ID, req.Labels)
+	points := make([]MetricsHistoryPoint, 0)
+	switch req.MType {
+	case "counter":
+		samples, err := s.MetricRepository.CounterHistory(ctx, seriesKey, req.From, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed CounterHistory in service: %w", err)
+		}
+		for _, sample := range samples {
+			delta := int64(sample.Value)
+			points = append(points, MetricsHistoryPoint{Timestamp: sample.Timestamp, Delta: &delta})
+		}
+	case "gauge":
+		samples, err := s.MetricRepository.GaugeHistory(ctx, seriesKey, req.From, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed GaugeHistory in service: %w", err)
+		}
+		for _, sample := range samples {
+			value := sample.Value
+			points = append(points, MetricsHistoryPoint{Timestamp: sample.Timestamp, Value: &value})
+		}
+	default:
+		return nil, ErrMetricNotFound
+	}
+
+	return &MetricsHistoryResponse{
+		ID:     req.ID,
+		MType:  req.MType,
+		Labels: req.Labels,
+		Points: downsample(points, req.From, req.Step),
+	}, nil
+}
+
+// downsample оставляет последнее значение в каждом окне длиной step, отсчитываемом от from.
+func downsample(points []MetricsHistoryPoint, from time.Time, step time.Duration) []MetricsHistoryPoint {
+	if step <= 0 || len(points) == 0 {
+		return points
+	}
+
+	result := make([]MetricsHistoryPoint, 0, len(points))
+	lastBucket := int64(-1)
+	for _, point := range points {
+		bucket := int64(point.Timestamp.Sub(from) / step)
+		if bucket == lastBucket {
+			result[len(result)-1] = point
+			continue
+		}
+		result = append(result, point)
+		lastBucket = bucket
+	}
+	return result
+}
+
+// validateSeries проверяет имя и метки серии.
+func validateSeries(id string, labels map[string]string) error {
+	if err := repository.ValidateMetricName(id); err != nil {
+		return err
+	}
+	return repository.Labels(labels).Validate()
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"metrics/internal/repository"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGet(t *testing.T) {
@@ -38,7 +40,7 @@ func TestGet(t *testing.T) {
 			MType: "counter",
 		}
 
-		metricService := NewMetricService(memStorage, sugar)
+		metricService := NewMetricService(memStorage, nil, sugar)
 		resp, err := metricService.Get(ctx, req)
 		assert.NoError(t, err)
 		assert.NotNil(t, resp)
@@ -54,7 +56,7 @@ func TestGet(t *testing.T) {
 			MType: "gauge",
 		}
 
-		metricService := NewMetricService(memStorage, sugar)
+		metricService := NewMetricService(memStorage, nil, sugar)
 		resp, err := metricService.Get(ctx, req)
 		assert.NoError(t, err)
 		assert.NotNil(t, resp)
@@ -70,7 +72,7 @@ func TestGet(t *testing.T) {
 			MType: "counter",
 		}
 
-		metricService := NewMetricService(memStorage, sugar)
+		metricService := NewMetricService(memStorage, nil, sugar)
 		resp, err := metricService.Get(ctx, req)
 		assert.Error(t, err)
 		assert.Nil(t, resp)
@@ -82,7 +84,7 @@ func TestGet(t *testing.T) {
 			MType: "invalid",
 		}
 
-		metricService := NewMetricService(memStorage, sugar)
+		metricService := NewMetricService(memStorage, nil, sugar)
 		resp, err := metricService.Get(ctx, req)
 		assert.Error(t, err)
 		assert.Nil(t, resp)
@@ -119,7 +121,7 @@ func TestUpdate(t *testing.T) {
 		}
 		*req.Delta = 10
 
-		metricService := NewMetricService(memStorage, sugar)
+		metricService := NewMetricService(memStorage, nil, sugar)
 		resp, err := metricService.Update(ctx, req)
 
 		assert.NoError(t, err)
@@ -138,7 +140,7 @@ func TestUpdate(t *testing.T) {
 		}
 		*req.Value = 150.5
 
-		metricService := NewMetricService(memStorage, sugar)
+		metricService := NewMetricService(memStorage, nil, sugar)
 		resp, err := metricService.Update(ctx, req)
 
 		assert.NoError(t, err)
@@ -156,7 +158,7 @@ func TestUpdate(t *testing.T) {
 			MType: "counter",
 		}
 
-		metricService := NewMetricService(memStorage, sugar)
+		metricService := NewMetricService(memStorage, nil, sugar)
 		resp, err := metricService.Update(ctx, req)
 
 		assert.Error(t, err)
@@ -171,7 +173,7 @@ func TestUpdate(t *testing.T) {
 			MType: "gauge",
 		}
 
-		metricService := NewMetricService(memStorage, sugar)
+		metricService := NewMetricService(memStorage, nil, sugar)
 		resp, err := metricService.Update(ctx, req)
 
 		assert.Error(t, err)
@@ -203,7 +205,7 @@ func TestGetMetrics(t *testing.T) {
 	}
 
 	t.Run("Get metrics", func(t *testing.T) {
-		metricService := NewMetricService(memStorage, sugar)
+		metricService := NewMetricService(memStorage, nil, sugar)
 		resp := metricService.GetMetrics(ctx)
 
 		assert.Equal(t, resp.Counters[counterID], counterValue)
@@ -216,7 +218,7 @@ func TestUpdateMultiple(t *testing.T) {
 	memStorage, _ := repository.NewMemStorage()
 	logger := zap.NewNop()
 	sugar := logger.Sugar()
-	metricService := NewMetricService(memStorage, sugar)
+	metricService := NewMetricService(memStorage, nil, sugar)
 
 	counterID := "testCounter"
 	gaugeID := "testGauge"
@@ -268,3 +270,366 @@ func TestUpdateMultiple(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	memStorage, _ := repository.NewMemStorage()
+	logger := zap.NewNop()
+	sugar := logger.Sugar()
+	metricService := NewMetricService(memStorage, nil, sugar)
+
+	from := time.Now()
+	_, _ = memStorage.SetGauge(ctx, "HeapAlloc", 1)
+	_, _ = memStorage.SetGauge(ctx, "HeapAlloc", 2)
+	_, _ = memStorage.SetCounter(ctx, "PollCount", 3)
+
+	t.Run("Gauge history", func(t *testing.T) {
+		resp, err := metricService.History(ctx, MetricsHistoryRequest{ID: "HeapAlloc", MType: "gauge", From: from})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Points, 2)
+		assert.Equal(t, 2.0, *resp.Points[1].Value)
+	})
+
+	t.Run("Counter history", func(t *testing.T) {
+		resp, err := metricService.History(ctx, MetricsHistoryRequest{ID: "PollCount", MType: "counter", From: from})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Points, 1)
+		assert.Equal(t, int64(3), *resp.Points[0].Delta)
+	})
+
+	t.Run("Step keeps last value per window", func(t *testing.T) {
+		resp, err := metricService.History(ctx, MetricsHistoryRequest{
+			ID:    "HeapAlloc",
+			MType: "gauge",
+			From:  from,
+			Step:  time.Hour,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Points, 1)
+		assert.Equal(t, 2.0, *resp.Points[0].Value)
+	})
+
+	t.Run("Invalid metric type", func(t *testing.T) {
+		resp, err := metricService.History(ctx, MetricsHistoryRequest{ID: "HeapAlloc", MType: "invalid"})
+		assert.ErrorIs(t, err, ErrMetricNotFound)
+		assert.Nil(t, resp)
+	})
+}
+
+func TestUpdateIdempotent(t *testing.T) {
+	ctx := WithIdempotencyKey(context.Background(), "agent-1:batch-1")
+	memStorage, _ := repository.NewMemStorage()
+	metricService := NewMetricService(memStorage, nil, zap.NewNop().Sugar())
+
+	delta := int64(5)
+	req := MetricsUpdateRequest{ID: "PollCount", MType: "counter", Delta: &delta}
+
+	t.Run("Retry of single update is not applied twice", func(t *testing.T) {
+		resp, err := metricService.Update(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), *resp.Delta)
+
+		resp, err = metricService.Update(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), *resp.Delta)
+	})
+
+	t.Run("Retry of batch is not applied twice", func(t *testing.T) {
+		batchCtx := WithIdempotencyKey(context.Background(), "agent-1:batch-2")
+		metrics := []MetricsUpdateRequest{req}
+
+		assert.NoError(t, metricService.UpdateMultiple(batchCtx, metrics))
+		assert.NoError(t, metricService.UpdateMultiple(batchCtx, metrics))
+
+		counter, err := memStorage.GetCounter(context.Background(), "PollCount")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(10), counter)
+	})
+}
+
+func TestUpdateIdempotentDistributions(t *testing.T) {
+	memStorage, _ := repository.NewMemStorage()
+	metricService := NewMetricService(memStorage, nil, zap.NewNop().Sugar())
+	_, err := memStorage.SetHistogram(context.Background(), "RequestDuration", repository.NewHistogram([]float64{1}))
+	require.NoError(t, err)
+
+	t.Run("Rejected histogram does not consume the key", func(t *testing.T) {
+		ctx := WithIdempotencyKey(context.Background(), "agent-1:histogram-1")
+		wrongBounds := []MetricsUpdateRequest{
+			{ID: "RequestDuration", MType: MetricTypeHistogram, Buckets: []float64{5}, Observations: []float64{0.5}},
+		}
+		assert.ErrorIs(t, metricService.UpdateMultiple(ctx, wrongBounds), repository.ErrInvalidDistribution)
+
+		retry := []MetricsUpdateRequest{
+			{ID: "RequestDuration", MType: MetricTypeHistogram, Buckets: []float64{1}, Observations: []float64{0.5}},
+		}
+		require.NoError(t, metricService.UpdateMultiple(ctx, retry))
+		require.NoError(t, metricService.UpdateMultiple(ctx, retry))
+
+		histogram, err := memStorage.GetHistogram(context.Background(), "RequestDuration")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), histogram.Count, "повтор с тем же ключом применяется один раз")
+	})
+
+	t.Run("Single summary update is applied with the key", func(t *testing.T) {
+		ctx := WithIdempotencyKey(context.Background(), "agent-1:summary-1")
+		summary := repository.Summary{Quantiles: []repository.Quantile{{Quantile: 0.5, Value: 2}}, Sum: 2, Count: 1}
+		req := MetricsUpdateRequest{ID: "GCPause", MType: MetricTypeSummary, Summary: &summary}
+
+		resp, err := metricService.Update(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, resp.Summary)
+		assert.Equal(t, uint64(1), resp.Summary.Count)
+	})
+}
+
+func TestUpdateLabels(t *testing.T) {
+	ctx := context.Background()
+	memStorage, _ := repository.NewMemStorage()
+	metricService := NewMetricService(memStorage, nil, zap.NewNop().Sugar())
+
+	first, second := 10.0, 20.0
+	metrics := []MetricsUpdateRequest{
+		{ID: "CPUutilization1", MType: "gauge", Value: &first, Labels: map[string]string{"host": "web-1"}},
+		{ID: "CPUutilization1", MType: "gauge", Value: &second, Labels: map[string]string{"host": "web-2"}},
+	}
+
+	t.Run("Series with different labels do not overwrite each other", func(t *testing.T) {
+		assert.NoError(t, metricService.UpdateMultiple(ctx, metrics))
+
+		resp, err := metricService.Get(ctx, MetricsGetRequest{
+			ID:     "CPUutilization1",
+			MType:  "gauge",
+			Labels: map[string]string{"host": "web-1"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, first, *resp.Value)
+		assert.Equal(t, map[string]string{"host": "web-1"}, resp.Labels)
+
+		resp, err = metricService.Get(ctx, MetricsGetRequest{
+			ID:     "CPUutilization1",
+			MType:  "gauge",
+			Labels: map[string]string{"host": "web-2"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, second, *resp.Value)
+	})
+
+	t.Run("Unlabeled series is separate", func(t *testing.T) {
+		_, err := metricService.Get(ctx, MetricsGetRequest{ID: "CPUutilization1", MType: "gauge"})
+		assert.ErrorIs(t, err, ErrMetricNotFound)
+	})
+
+	t.Run("Invalid label name is rejected", func(t *testing.T) {
+		_, err := metricService.Update(ctx, MetricsUpdateRequest{
+			ID:     "CPUutilization1",
+			MType:  "gauge",
+			Value:  &first,
+			Labels: map[string]string{"bad-name": "x"},
+		})
+		assert.ErrorIs(t, err, repository.ErrInvalidLabel)
+	})
+
+	t.Run("Name with series key syntax is rejected", func(t *testing.T) {
+		_, err := metricService.Update(ctx, MetricsUpdateRequest{ID: `CPUutilization1{host="web-1"}`, MType: "gauge", Value: &first})
+		assert.ErrorIs(t, err, repository.ErrInvalidMetricName)
+
+		err = metricService.UpdateMultiple(ctx, []MetricsUpdateRequest{{ID: `CPUutilization1{host="web-1"}`, MType: "gauge", Value: &first}})
+		assert.ErrorIs(t, err, repository.ErrInvalidMetricName)
+	})
+}
+
+func TestUpdateHistogram(t *testing.T) {
+	ctx := context.Background()
+	memStorage, _ := repository.NewMemStorage()
+	metricService := NewMetricService(memStorage, nil, zap.NewNop().Sugar())
+
+	t.Run("Observations are bucketed and accumulated", func(t *testing.T) {
+		req := MetricsUpdateRequest{
+			ID:           "RequestDuration",
+			MType:        "histogram",
+			Buckets:      []float64{0.1, 1},
+			Observations: []float64{0.05, 0.5, 3},
+		}
+		_, err := metricService.Update(ctx, req)
+		require.NoError(t, err)
+
+		resp, err := metricService.Update(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, resp.Histogram)
+		assert.Equal(t, []uint64{2, 2, 2}, resp.Histogram.Counts)
+		assert.Equal(t, uint64(6), resp.Histogram.Count)
+		assert.InDelta(t, 7.1, resp.Histogram.Sum, 1e-9)
+	})
+
+	t.Run("Pre-aggregated buckets are added", func(t *testing.T) {
+		resp, err := metricService.Update(ctx, MetricsUpdateRequest{
+			ID:    "RequestDuration",
+			MType: "histogram",
+			Histogram: &repository.Histogram{
+				Bounds: []float64{0.1, 1},
+				Counts: []uint64{1, 0, 0},
+				Sum:    0.01,
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{3, 2, 2}, resp.Histogram.Counts)
+		assert.Equal(t, uint64(7), resp.Histogram.Count)
+	})
+
+	t.Run("Default buckets are used for observations", func(t *testing.T) {
+		resp, err := metricService.Update(ctx, MetricsUpdateRequest{
+			ID:           "Latency",
+			MType:        "histogram",
+			Observations: []float64{0.2},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, DefaultHistogramBuckets, resp.Histogram.Bounds)
+	})
+
+	t.Run("Mismatched bounds are rejected", func(t *testing.T) {
+		_, err := metricService.Update(ctx, MetricsUpdateRequest{
+			ID:           "RequestDuration",
+			MType:        "histogram",
+			Buckets:      []float64{1, 10},
+			Observations: []float64{2},
+		})
+		assert.ErrorIs(t, err, repository.ErrInvalidDistribution)
+	})
+
+	t.Run("Histogram without data is rejected", func(t *testing.T) {
+		_, err := metricService.Update(ctx, MetricsUpdateRequest{ID: "RequestDuration", MType: "histogram"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Get returns histogram", func(t *testing.T) {
+		resp, err := metricService.Get(ctx, MetricsGetRequest{ID: "RequestDuration", MType: "histogram"})
+		require.NoError(t, err)
+		require.NotNil(t, resp.Histogram)
+		assert.Equal(t, uint64(7), resp.Histogram.Count)
+	})
+}
+
+func TestUpdateSummary(t *testing.T) {
+	ctx := context.Background()
+	memStorage, _ := repository.NewMemStorage()
+	metricService := NewMetricService(memStorage, nil, zap.NewNop().Sugar())
+
+	first := repository.Summary{Quantiles: []repository.Quantile{{Quantile: 0.5, Value: 1}}, Sum: 10, Count: 4}
+	second := repository.Summary{Quantiles: []repository.Quantile{{Quantile: 0.99, Value: 7}}, Sum: 30, Count: 9}
+
+	t.Run("New value replaces previous", func(t *testing.T) {
+		_, err := metricService.Update(ctx, MetricsUpdateRequest{ID: "GCPause", MType: "summary", Summary: &first})
+		require.NoError(t, err)
+		_, err = metricService.Update(ctx, MetricsUpdateRequest{ID: "GCPause", MType: "summary", Summary: &second})
+		require.NoError(t, err)
+
+		resp, err := metricService.Get(ctx, MetricsGetRequest{ID: "GCPause", MType: "summary"})
+		require.NoError(t, err)
+		assert.Equal(t, &second, resp.Summary)
+	})
+
+	t.Run("Invalid quantile is rejected", func(t *testing.T) {
+		invalid := repository.Summary{Quantiles: []repository.Quantile{{Quantile: 1.5, Value: 1}}}
+		_, err := metricService.Update(ctx, MetricsUpdateRequest{ID: "GCPause", MType: "summary", Summary: &invalid})
+		assert.ErrorIs(t, err, repository.ErrInvalidDistribution)
+	})
+}
+
+func TestUpdateMultipleDistributions(t *testing.T) {
+	ctx := context.Background()
+	memStorage, _ := repository.NewMemStorage()
+	metricService := NewMetricService(memStorage, nil, zap.NewNop().Sugar())
+
+	delta := int64(1)
+	summary := repository.Summary{Quantiles: []repository.Quantile{{Quantile: 0.5, Value: 2}}, Sum: 2, Count: 1}
+	err := metricService.UpdateMultiple(ctx, []MetricsUpdateRequest{
+		{ID: "PollCount", MType: "counter", Delta: &delta},
+		{ID: "RequestDuration", MType: "histogram", Buckets: []float64{1}, Observations: []float64{0.5}},
+		{ID: "RequestDuration", MType: "histogram", Buckets: []float64{1}, Observations: []float64{2}},
+		{ID: "GCPause", MType: "summary", Summary: &summary},
+	})
+	require.NoError(t, err)
+
+	data := metricService.GetMetrics(ctx)
+	assert.Equal(t, uint64(1), data.Counters["PollCount"])
+	assert.Equal(t, []uint64{1, 1}, data.Histograms["RequestDuration"].Counts)
+	assert.Equal(t, summary, data.Summaries["GCPause"])
+}
+
+func TestUpdateCounterValidation(t *testing.T) {
+	ctx := context.Background()
+	memStorage, _ := repository.NewMemStorage()
+	metricService := NewMetricService(memStorage, nil, zap.NewNop().Sugar())
+
+	negative := int64(-5)
+	maxDelta := int64(repository.MaxCounter)
+	one := int64(1)
+
+	t.Run("Negative delta is rejected", func(t *testing.T) {
+		_, err := metricService.Update(ctx, MetricsUpdateRequest{ID: "PollCount", MType: "counter", Delta: &negative})
+		assert.ErrorIs(t, err, ErrNegativeDelta)
+
+		err = metricService.UpdateMultiple(ctx, []MetricsUpdateRequest{{ID: "PollCount", MType: "counter", Delta: &negative}})
+		assert.ErrorIs(t, err, ErrNegativeDelta)
+
+		_, err = metricService.Update(WithIdempotencyKey(ctx, "agent:1"),
+			MetricsUpdateRequest{ID: "PollCount", MType: "counter", Delta: &negative})
+		assert.ErrorIs(t, err, ErrNegativeDelta)
+
+		_, err = memStorage.GetCounter(ctx, "PollCount")
+		assert.Error(t, err, "counter не создаётся")
+	})
+
+	t.Run("Overflow is rejected", func(t *testing.T) {
+		err := metricService.UpdateMultiple(ctx, []MetricsUpdateRequest{
+			{ID: "PollCount", MType: "counter", Delta: &maxDelta},
+			{ID: "PollCount", MType: "counter", Delta: &one},
+		})
+		assert.ErrorIs(t, err, repository.ErrCounterOverflow, "переполнение суммы внутри пакета")
+
+		_, err = metricService.Update(ctx, MetricsUpdateRequest{ID: "PollCount", MType: "counter", Delta: &maxDelta})
+		require.NoError(t, err)
+
+		_, err = metricService.Update(ctx, MetricsUpdateRequest{ID: "PollCount", MType: "counter", Delta: &one})
+		assert.ErrorIs(t, err, repository.ErrCounterOverflow)
+
+		resp, err := metricService.Get(ctx, MetricsGetRequest{ID: "PollCount", MType: "counter"})
+		require.NoError(t, err)
+		assert.Equal(t, maxDelta, *resp.Delta)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+	memStorage, _ := repository.NewMemStorage()
+	storage := repository.NewTypeGuardStorage(memStorage)
+	metricService := NewMetricService(storage, nil, zap.NewNop().Sugar())
+
+	_, err := storage.SetCounter(ctx, "PollCount", repository.MaxCounter-1)
+	require.NoError(t, err)
+	_, err = storage.SetGauge(ctx, "Alloc", 1)
+	require.NoError(t, err)
+
+	one, value := int64(1), 2.0
+	assert.NoError(t, metricService.Validate(ctx, []MetricsUpdateRequest{
+		{ID: "PollCount", MType: "counter", Delta: &one},
+		{ID: "Alloc", MType: "gauge", Value: &value},
+	}))
+
+	err = metricService.Validate(ctx, []MetricsUpdateRequest{
+		{ID: "PollCount", MType: "counter", Delta: &one},
+		{ID: "PollCount", MType: "counter", Delta: &one},
+	})
+	assert.ErrorIs(t, err, repository.ErrCounterOverflow, "приращения складываются с сохранённым значением")
+
+	err = metricService.Validate(ctx, []MetricsUpdateRequest{{ID: "Alloc", MType: "counter", Delta: &one}})
+	assert.ErrorIs(t, err, repository.ErrTypeConflict)
+
+	err = metricService.Validate(ctx, []MetricsUpdateRequest{{ID: "Bad{name}", MType: "gauge", Value: &value}})
+	assert.ErrorIs(t, err, repository.ErrInvalidMetricName)
+
+	counter, err := memStorage.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(repository.MaxCounter-1), counter, "проверка не записывает метрики")
+}
@@ -0,0 +1,514 @@
+// Package spool реализует очередь записей на диске для отправки после восстановления связи с сервером.
+//
+// Записи дописываются в сегменты <seq>.seg в формате длина | CRC32 | данные и читаются
+// в порядке добавления. Позиция чтения сохраняется в файле cursor, поэтому после перезапуска
+// отправка продолжается с первой неподтверждённой записи.
+package spool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentExt  = ".seg"
+	cursorFile  = "cursor"
+	headerSize  = 8
+	maxRecord   = 64 << 20
+	dirPerm     = 0o750
+	filePerm    = 0o600
+	seqNameBase = 10
+)
+
+var ErrRecordTooLarge = errors.New("record exceeds segment size")
+
+// Options ограничения очереди.
+type Options struct {
+	// OnDrop вызывается для каждой неотправленной записи удаляемого сегмента.
+	OnDrop func(record []byte)
+	// Максимальный суммарный размер сегментов, старые сегменты удаляются при превышении.
+	MaxBytes int64
+	// Максимальный возраст сегмента по времени последней записи.
+	MaxAge time.Duration
+	// Размер сегмента, после которого начинается новый.
+	SegmentBytes int64
+}
+
+// Stats состояние очереди.
+type Stats struct {
+	// Неотправленные записи.
+	Depth int
+	// Размер сегментов на диске.
+	Bytes int64
+	// Записи, удалённые по ограничениям размера и возраста.
+	Dropped uint64
+}
+
+// Position позиция записи, возвращаемая Peek и подтверждаемая Ack.
+type Position struct {
+	seq    uint64
+	offset int64
+	next   int64
+}
+
+type segment struct {
+	modTime time.Time
+	seq     uint64
+	size    int64
+	records int
+}
+
+type Spool struct {
+	active   *os.File
+	dir      string
+	segments []*segment
+	opts     Options
+	cursor   Position
+	depth    int
+	dropped  uint64
+	mu       sync.Mutex
+}
+
+// Open открывает очередь в каталоге, создавая его при необходимости. Повреждённый
+// хвост сегмента (например, после сбоя во время записи) отбрасывается.
+func Open(dir string, opts Options) (*Spool, error) {
+	if opts.SegmentBytes <= 0 || (opts.MaxBytes > 0 && opts.SegmentBytes > opts.MaxBytes) {
+		return nil, fmt.Errorf("invalid segment size %d for max size %d", opts.SegmentBytes, opts.MaxBytes)
+	}
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("create spool dir: %w", err)
+	}
+
+	s := &Spool{dir: dir, opts: opts}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.enforceLimits(time.Now()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Append дописывает запись в очередь и сбрасывает её на диск.
+func (s *Spool) Append(record []byte) error {
+	size := int64(headerSize + len(record))
+	if size > s.opts.SegmentBytes {
+		return fmt.Errorf("%w: %d bytes", ErrRecordTooLarge, len(record))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.lastSegment()
+	if s.active == nil || last == nil || last.size+size > s.opts.SegmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+		last = s.lastSegment()
+	}
+
+	buf := make([]byte, headerSize, size)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(record)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(record))
+	buf = append(buf, record...)
+
+	if _, err := s.active.Write(buf); err != nil {
+		return fmt.Errorf("write spool record: %w", err)
+	}
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("sync spool segment: %w", err)
+	}
+
+	now := time.Now()
+	last.size += size
+	last.records++
+	last.modTime = now
+	s.depth++
+
+	return s.enforceLimits(now)
+}
+
+// Peek возвращает самую старую неподтверждённую запись. ok равен false, если очередь пуста.
+func (s *Spool) Peek() (record []byte, pos Position, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.depth > 0 {
+		seg := s.segmentBySeq(s.cursor.seq)
+		if seg == nil {
+			if len(s.segments) == 0 {
+				return nil, Position{}, false, nil
+			}
+			s.cursor = Position{seq: s.segments[0].seq}
+			continue
+		}
+		if s.cursor.offset >= seg.size {
+			if s.active != nil && seg == s.lastSegment() {
+				return nil, Position{}, false, nil
+			}
+			if err := s.advanceSegment(seg); err != nil {
+				return nil, Position{}, false, err
+			}
+			continue
+		}
+
+		record, err := s.readAt(seg.seq, s.cursor.offset)
+		if err != nil {
+			return nil, Position{}, false, err
+		}
+		pos := s.cursor
+		pos.next = s.cursor.offset + int64(headerSize+len(record))
+		return record, pos, true, nil
+	}
+	return nil, Position{}, false, nil
+}
+
+// Ack подтверждает отправку записи, полученной из Peek. Если запись уже была удалена
+// по ограничениям очереди, подтверждение игнорируется.
+func (s *Spool) Ack(pos Position) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pos.seq != s.cursor.seq || pos.offset != s.cursor.offset {
+		return nil
+	}
+	s.cursor.offset = pos.next
+	s.depth--
+
+	if seg := s.segmentBySeq(s.cursor.seq); seg != nil && s.cursor.offset >= seg.size {
+		return s.advanceSegment(seg)
+	}
+	return s.saveCursor()
+}
+
+// Stats возвращает текущее состояние очереди.
+func (s *Spool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var size int64
+	for _, seg := range s.segments {
+		size += seg.size
+	}
+	return Stats{Depth: s.depth, Bytes: size, Dropped: s.dropped}
+}
+
+// Close закрывает текущий сегмент.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return nil
+	}
+	err := s.active.Close()
+	s.active = nil
+	if err != nil {
+		return fmt.Errorf("close spool segment: %w", err)
+	}
+	return nil
+}
+
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("read spool dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), seqNameBase, 64)
+		if err != nil {
+			continue
+		}
+		seg, err := s.scanSegment(seq)
+		if err != nil {
+			return err
+		}
+		s.segments = append(s.segments, seg)
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+
+	s.cursor = s.loadCursor()
+	if len(s.segments) > 0 && s.segmentBySeq(s.cursor.seq) == nil {
+		s.cursor = Position{seq: s.segments[0].seq}
+	}
+
+	for _, seg := range s.segments {
+		switch {
+		case seg.seq < s.cursor.seq:
+			continue
+		case seg.seq == s.cursor.seq:
+			consumed, err := s.countRecords(seg.seq, s.cursor.offset)
+			if err != nil {
+				return err
+			}
+			s.depth += seg.records - consumed
+		default:
+			s.depth += seg.records
+		}
+	}
+	return nil
+}
+
+// scanSegment считает записи сегмента и обрезает повреждённый хвост.
+func (s *Spool) scanSegment(seq uint64) (*segment, error) {
+	path := s.segmentPath(seq)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat spool segment: %w", err)
+	}
+
+	records, valid, err := s.scan(seq, -1)
+	if err != nil {
+		return nil, err
+	}
+	if valid < info.Size() {
+		if err := os.Truncate(path, valid); err != nil {
+			return nil, fmt.Errorf("truncate spool segment: %w", err)
+		}
+	}
+	return &segment{seq: seq, size: valid, records: records, modTime: info.ModTime()}, nil
+}
+
+// countRecords возвращает число целых записей до смещения offset.
+func (s *Spool) countRecords(seq uint64, offset int64) (int, error) {
+	records, _, err := s.scan(seq, offset)
+	return records, err
+}
+
+// scan читает записи сегмента до limit (или до конца при limit < 0) и возвращает
+// их количество и размер корректной части сегмента.
+func (s *Spool) scan(seq uint64, limit int64) (int, int64, error) {
+	f, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		return 0, 0, fmt.Errorf("open spool segment: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	reader := bufio.NewReader(f)
+	var records int
+	var offset int64
+	for limit < 0 || offset < limit {
+		record, err := readRecord(reader)
+		if err != nil {
+			break
+		}
+		offset += int64(headerSize + len(record))
+		records++
+	}
+	return records, offset, nil
+}
+
+func (s *Spool) readAt(seq uint64, offset int64) ([]byte, error) {
+	f, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		return nil, fmt.Errorf("open spool segment: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek spool segment: %w", err)
+	}
+	record, err := readRecord(f)
+	if err != nil {
+		return nil, fmt.Errorf("read spool record: %w", err)
+	}
+	return record, nil
+}
+
+func readRecord(r io.Reader) ([]byte, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("read record header: %w", err)
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxRecord {
+		return nil, errors.New("corrupted record length")
+	}
+	record := make([]byte, length)
+	if _, err := io.ReadFull(r, record); err != nil {
+		return nil, fmt.Errorf("read record data: %w", err)
+	}
+	if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errors.New("corrupted record checksum")
+	}
+	return record, nil
+}
+
+func (s *Spool) rotate() error {
+	if s.active != nil {
+		if err := s.active.Close(); err != nil {
+			return fmt.Errorf("close spool segment: %w", err)
+		}
+		s.active = nil
+	}
+
+	var seq uint64 = 1
+	if last := s.lastSegment(); last != nil {
+		seq = last.seq + 1
+	}
+	f, err := os.OpenFile(s.segmentPath(seq), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return fmt.Errorf("create spool segment: %w", err)
+	}
+	s.active = f
+	s.segments = append(s.segments, &segment{seq: seq, modTime: time.Now()})
+	if len(s.segments) == 1 {
+		s.cursor = Position{seq: seq}
+	}
+	return nil
+}
+
+// enforceLimits удаляет старейшие сегменты, пока очередь превышает ограничения.
+// Текущий сегмент не удаляется.
+func (s *Spool) enforceLimits(now time.Time) error {
+	for len(s.segments) > 0 {
+		oldest := s.segments[0]
+		if s.active != nil && oldest == s.lastSegment() {
+			return nil
+		}
+
+		var size int64
+		for _, seg := range s.segments {
+			size += seg.size
+		}
+		overSize := s.opts.MaxBytes > 0 && size > s.opts.MaxBytes
+		expired := s.opts.MaxAge > 0 && now.Sub(oldest.modTime) > s.opts.MaxAge
+		if !overSize && !expired {
+			return nil
+		}
+		if err := s.dropSegment(oldest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Spool) dropSegment(seg *segment) error {
+	var from int64
+	if seg.seq == s.cursor.seq {
+		from = s.cursor.offset
+	}
+	if seg.seq >= s.cursor.seq {
+		dropped, err := s.dropRecords(seg.seq, from)
+		if err != nil {
+			return err
+		}
+		s.depth -= dropped
+		s.dropped += uint64(dropped)
+	}
+	return s.advanceSegment(seg)
+}
+
+// dropRecords передаёт неотправленные записи сегмента в OnDrop и возвращает их количество.
+func (s *Spool) dropRecords(seq uint64, from int64) (int, error) {
+	f, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		return 0, fmt.Errorf("open spool segment: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err = f.Seek(from, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seek spool segment: %w", err)
+	}
+	reader := bufio.NewReader(f)
+	var dropped int
+	for {
+		record, err := readRecord(reader)
+		if err != nil {
+			break
+		}
+		dropped++
+		if s.opts.OnDrop != nil {
+			s.opts.OnDrop(record)
+		}
+	}
+	return dropped, nil
+}
+
+// advanceSegment удаляет прочитанный сегмент и переводит курсор на следующий.
+// Текущий сегмент для записи не удаляется.
+func (s *Spool) advanceSegment(seg *segment) error {
+	if s.active != nil && seg == s.lastSegment() {
+		return s.saveCursor()
+	}
+
+	if err := os.Remove(s.segmentPath(seg.seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove spool segment: %w", err)
+	}
+	for i, candidate := range s.segments {
+		if candidate == seg {
+			s.segments = append(s.segments[:i], s.segments[i+1:]...)
+			break
+		}
+	}
+
+	if seg.seq >= s.cursor.seq {
+		s.cursor = Position{}
+		if len(s.segments) > 0 {
+			s.cursor.seq = s.segments[0].seq
+		}
+	}
+	return s.saveCursor()
+}
+
+func (s *Spool) loadCursor() Position {
+	data, err := os.ReadFile(filepath.Join(s.dir, cursorFile))
+	if err != nil {
+		return Position{}
+	}
+	var pos Position
+	if _, err := fmt.Sscanf(string(data), "%d %d", &pos.seq, &pos.offset); err != nil {
+		return Position{}
+	}
+	return pos
+}
+
+// saveCursor атомарно сохраняет позицию чтения через временный файл.
+func (s *Spool) saveCursor() error {
+	path := filepath.Join(s.dir, cursorFile)
+	tmp := path + ".tmp"
+	data := fmt.Sprintf("%d %d", s.cursor.seq, s.cursor.offset)
+	if err := os.WriteFile(tmp, []byte(data), filePerm); err != nil {
+		return fmt.Errorf("write spool cursor: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename spool cursor: %w", err)
+	}
+	return nil
+}
+
+func (s *Spool) segmentBySeq(seq uint64) *segment {
+	for _, seg := range s.segments {
+		if seg.seq == seq {
+			return seg
+		}
+	}
+	return nil
+}
+
+func (s *Spool) lastSegment() *segment {
+	if len(s.segments) == 0 {
+		return nil
+	}
+	return s.segments[len(s.segments)-1]
+}
+
+func (s *Spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
@@ -0,0 +1,170 @@
+package spool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func drain(t *testing.T, s *Spool) []string {
+	t.Helper()
+	var records []string
+	for {
+		record, pos, ok, err := s.Peek()
+		require.NoError(t, err)
+		if !ok {
+			return records
+		}
+		records = append(records, string(record))
+		require.NoError(t, s.Ack(pos))
+	}
+}
+
+func TestSpool_AppendAndReplayInOrder(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{SegmentBytes: 64})
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	for i := range 10 {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%d", i))))
+	}
+	assert.Equal(t, 10, s.Stats().Depth)
+
+	records := drain(t, s)
+	require.Len(t, records, 10)
+	for i, record := range records {
+		assert.Equal(t, fmt.Sprintf("record-%d", i), record)
+	}
+	assert.Equal(t, 0, s.Stats().Depth)
+}
+
+func TestSpool_PeekWithoutAckReturnsSameRecord(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{SegmentBytes: 1024})
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	require.NoError(t, s.Append([]byte("first")))
+	require.NoError(t, s.Append([]byte("second")))
+
+	first, _, ok, err := s.Peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	again, _, _, err := s.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "без Ack запись остаётся в голове очереди")
+}
+
+func TestSpool_ResumeAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentBytes: 40})
+	require.NoError(t, err)
+	for i := range 5 {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%d", i))))
+	}
+	_, pos, _, err := s.Peek()
+	require.NoError(t, err)
+	require.NoError(t, s.Ack(pos))
+	require.NoError(t, s.Close())
+
+	reopened, err := Open(dir, Options{SegmentBytes: 40})
+	require.NoError(t, err)
+	defer func() { _ = reopened.Close() }()
+
+	assert.Equal(t, 4, reopened.Stats().Depth)
+	require.NoError(t, reopened.Append([]byte("record-5")))
+	assert.Equal(t, []string{"record-1", "record-2", "record-3", "record-4", "record-5"}, drain(t, reopened))
+}
+
+func TestSpool_TruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentBytes: 1024})
+	require.NoError(t, err)
+	require.NoError(t, s.Append([]byte("complete")))
+	require.NoError(t, s.Close())
+
+	path := filepath.Join(dir, fmt.Sprintf("%020d%s", 1, segmentExt))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, filePerm)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 10, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := Open(dir, Options{SegmentBytes: 1024})
+	require.NoError(t, err)
+	defer func() { _ = reopened.Close() }()
+
+	assert.Equal(t, []string{"complete"}, drain(t, reopened))
+}
+
+func TestSpool_MaxBytesDropsOldest(t *testing.T) {
+	var dropped []string
+	s, err := Open(t.TempDir(), Options{
+		SegmentBytes: 20,
+		MaxBytes:     40,
+		OnDrop:       func(record []byte) { dropped = append(dropped, string(record)) },
+	})
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	for i := range 4 {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("rec-%d", i))))
+	}
+
+	stats := s.Stats()
+	assert.Equal(t, []string{"rec-0"}, dropped)
+	assert.Equal(t, uint64(1), stats.Dropped)
+	assert.LessOrEqual(t, stats.Bytes, int64(40))
+	assert.Equal(t, []string{"rec-1", "rec-2", "rec-3"}, drain(t, s))
+}
+
+func TestSpool_MaxAgeDropsExpiredSegments(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentBytes: 1024})
+	require.NoError(t, err)
+	require.NoError(t, s.Append([]byte("old")))
+	require.NoError(t, s.Close())
+
+	old := time.Now().Add(-2 * time.Hour)
+	path := filepath.Join(dir, fmt.Sprintf("%020d%s", 1, segmentExt))
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	var dropped int
+	reopened, err := Open(dir, Options{SegmentBytes: 1024, MaxAge: time.Hour, OnDrop: func([]byte) { dropped++ }})
+	require.NoError(t, err)
+	defer func() { _ = reopened.Close() }()
+
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, 0, reopened.Stats().Depth)
+	assert.Empty(t, drain(t, reopened))
+}
+
+func TestSpool_RecordTooLarge(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{SegmentBytes: 16})
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	assert.ErrorIs(t, s.Append(make([]byte, 32)), ErrRecordTooLarge)
+}
+
+func TestSpool_AckAfterDropIsIgnored(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{SegmentBytes: 20, MaxBytes: 40})
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	require.NoError(t, s.Append([]byte("rec-0")))
+	_, pos, ok, err := s.Peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	for i := 1; i < 4; i++ {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("rec-%d", i))))
+	}
+	require.NoError(t, s.Ack(pos))
+
+	assert.Equal(t, []string{"rec-1", "rec-2", "rec-3"}, drain(t, s))
+}
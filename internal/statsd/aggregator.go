@@ -0,0 +1,183 @@
+package statsd
+
+import (
+	"math"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"sort"
+	"sync"
+)
+
+// timerPercentile перцентиль таймера, публикуемый как <name>.p95.
+const timerPercentile = 0.95
+
+type series struct {
+	labels repository.Labels
+	name   string
+}
+
+type gaugeState struct {
+	series
+	value    float64
+	relative bool
+}
+
+type counterState struct {
+	series
+	value float64
+}
+
+type timerState struct {
+	series
+	values []float64
+	count  float64
+}
+
+type setState struct {
+	series
+	members map[string]struct{}
+}
+
+// GaugeBase возвращает текущее значение gauge для применения относительного изменения.
+type GaugeBase func(name string, labels repository.Labels) float64
+
+// Aggregator накапливает сэмплы StatsD до сброса окна. Безопасен для конкурентного использования.
+type Aggregator struct {
+	counters map[string]*counterState
+	gauges   map[string]*gaugeState
+	timers   map[string]*timerState
+	sets     map[string]*setState
+	mu       sync.Mutex
+}
+
+// NewAggregator создаёт пустой агрегатор.
+func NewAggregator() *Aggregator {
+	a := &Aggregator{}
+	a.reset()
+	return a
+}
+
+func (a *Aggregator) reset() {
+	a.counters = make(map[string]*counterState)
+	a.gauges = make(map[string]*gaugeState)
+	a.timers = make(map[string]*timerState)
+	a.sets = make(map[string]*setState)
+}
+
+// Add добавляет сэмпл в текущее окно.
+func (a *Aggregator) Add(sample Sample) {
+	key := repository.SeriesKey(sample.Name, sample.Labels)
+	s := series{name: sample.Name, labels: sample.Labels}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch sample.Kind {
+	case KindCounter:
+		state, ok := a.counters[key]
+		if !ok {
+			state = &counterState{series: s}
+			a.counters[key] = state
+		}
+		state.value += sample.Value / sample.Rate
+	case KindGauge:
+		state, ok := a.gauges[key]
+		switch {
+		case !sample.Relative:
+			a.gauges[key] = &gaugeState{series: s, value: sample.Value}
+		case ok:
+			state.value += sample.Value
+		default:
+			a.gauges[key] = &gaugeState{series: s, value: sample.Value, relative: true}
+		}
+	case KindTimer:
+		state, ok := a.timers[key]
+		if !ok {
+			state = &timerState{series: s}
+			a.timers[key] = state
+		}
+		state.values = append(state.values, sample.Value)
+		state.count += 1 / sample.Rate
+	case KindSet:
+		state, ok := a.sets[key]
+		if !ok {
+			state = &setState{series: s, members: make(map[string]struct{})}
+			a.sets[key] = state
+		}
+		state.members[sample.Member] = struct{}{}
+	}
+}
+
+// Flush закрывает окно и возвращает накопленные значения как обновления метрик.
+// Counter округляется до целого, отрицательная сумма за окно отбрасывается: counter сервера
+// только растёт. Таймеры публикуются как gauge <name>.count, .min, .max, .mean и .p95,
+// множества — как gauge с числом уникальных значений. Относительные gauge без абсолютного
+// значения в окне применяются к значению, которое возвращает base.
+func (a *Aggregator) Flush(base GaugeBase) []service.MetricsUpdateRequest {
+	a.mu.Lock()
+	counters, gauges, timers, sets := a.counters, a.gauges, a.timers, a.sets
+	a.reset()
+	a.mu.Unlock()
+
+	metrics := make([]service.MetricsUpdateRequest, 0, len(counters)+len(gauges)+len(timers)*5+len(sets))
+	for _, key := range sortedKeys(counters) {
+		state := counters[key]
+		delta := int64(math.Round(state.value))
+		if delta <= 0 {
+			continue
+		}
+		metrics = append(metrics, counterUpdate(state.series, delta))
+	}
+	for _, key := range sortedKeys(gauges) {
+		state := gauges[key]
+		value := state.value
+		if state.relative {
+			value += base(state.name, state.labels)
+		}
+		metrics = append(metrics, gaugeUpdate(state.series, "", value))
+	}
+	for _, key := range sortedKeys(timers) {
+		metrics = append(metrics, timerUpdates(timers[key])...)
+	}
+	for _, key := range sortedKeys(sets) {
+		state := sets[key]
+		metrics = append(metrics, gaugeUpdate(state.series, "", float64(len(state.members))))
+	}
+	return metrics
+}
+
+func timerUpdates(state *timerState) []service.MetricsUpdateRequest {
+	values := state.values
+	sort.Float64s(values)
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	rank := int(math.Ceil(timerPercentile*float64(len(values)))) - 1
+
+	return []service.MetricsUpdateRequest{
+		gaugeUpdate(state.series, ".count", state.count),
+		gaugeUpdate(state.series, ".min", values[0]),
+		gaugeUpdate(state.series, ".max", values[len(values)-1]),
+		gaugeUpdate(state.series, ".mean", sum/float64(len(values))),
+		gaugeUpdate(state.series, ".p95", values[max(rank, 0)]),
+	}
+}
+
+func counterUpdate(s series, delta int64) service.MetricsUpdateRequest {
+	return service.MetricsUpdateRequest{ID: s.name, MType: "counter", Delta: &delta, Labels: s.labels}
+}
+
+func gaugeUpdate(s series, suffix string, value float64) service.MetricsUpdateRequest {
+	return service.MetricsUpdateRequest{ID: s.name + suffix, MType: "gauge", Value: &value, Labels: s.labels}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
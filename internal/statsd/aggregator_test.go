@@ -0,0 +1,102 @@
+package statsd
+
+import (
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noBase(string, repository.Labels) float64 {
+	return 0
+}
+
+func values(t *testing.T, metrics []service.MetricsUpdateRequest) map[string]float64 {
+	t.Helper()
+	result := make(map[string]float64, len(metrics))
+	for _, m := range metrics {
+		key := m.MType + "/" + repository.SeriesKey(m.ID, m.Labels)
+		switch {
+		case m.Delta != nil:
+			result[key] = float64(*m.Delta)
+		case m.Value != nil:
+			result[key] = *m.Value
+		default:
+			t.Fatalf("metric %s without value", key)
+		}
+	}
+	return result
+}
+
+func add(t *testing.T, a *Aggregator, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		sample, err := ParseLine(line)
+		require.NoError(t, err)
+		a.Add(sample)
+	}
+}
+
+func TestAggregator_CountersAndGauges(t *testing.T) {
+	a := NewAggregator()
+	add(t, a,
+		"requests:1|c",
+		"requests:2|c",
+		"requests:1|c|@0.25",
+		"requests:1|c|#host:web-1",
+		"load:1.5|g",
+		"load:2.5|g",
+	)
+
+	assert.Equal(t, map[string]float64{
+		"counter/requests":               7,
+		`counter/requests{host="web-1"}`: 1,
+		"gauge/load":                     2.5,
+	}, values(t, a.Flush(noBase)))
+
+	assert.Empty(t, a.Flush(noBase), "окно очищается после сброса")
+
+	add(t, a, "requests:-3|c", "requests:1|c")
+	assert.Empty(t, a.Flush(noBase), "отрицательная сумма counter отбрасывается")
+}
+
+func TestAggregator_RelativeGauge(t *testing.T) {
+	a := NewAggregator()
+	add(t, a, "queue:+3|g", "queue:-1|g", "level:10|g", "level:+5|g")
+
+	base := func(name string, _ repository.Labels) float64 {
+		if name == "queue" {
+			return 40
+		}
+		return 0
+	}
+	assert.Equal(t, map[string]float64{
+		"gauge/queue": 42,
+		"gauge/level": 15,
+	}, values(t, a.Flush(base)))
+}
+
+func TestAggregator_TimersAndSets(t *testing.T) {
+	a := NewAggregator()
+	for i := 1; i <= 20; i++ {
+		sample := Sample{Name: "latency", Kind: KindTimer, Value: float64(i), Rate: 1}
+		a.Add(sample)
+	}
+	add(t, a, "users:alice|s", "users:bob|s", "users:alice|s", "rpc:10|ms|@0.5")
+
+	assert.Equal(t, map[string]float64{
+		"gauge/latency.count": 20,
+		"gauge/latency.min":   1,
+		"gauge/latency.max":   20,
+		"gauge/latency.mean":  10.5,
+		"gauge/latency.p95":   19,
+		"gauge/rpc.count":     2,
+		"gauge/rpc.min":       10,
+		"gauge/rpc.max":       10,
+		"gauge/rpc.mean":      10,
+		"gauge/rpc.p95":       10,
+		"gauge/users":         2,
+	}, values(t, a.Flush(noBase)))
+}
@@ -0,0 +1,251 @@
+package statsd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxPacketSize максимальный размер UDP-пакета.
+	maxPacketSize = 65535
+	// maxLoggedLine длина строки, после которой некорректная строка обрезается в журнале.
+	maxLoggedLine = 256
+	// MalformedMetric counter с числом отброшенных некорректных строк.
+	MalformedMetric = "StatsdMalformedLines"
+)
+
+// Listener принимает метрики StatsD по UDP и TCP на одном адресе
+// и раз в окно сброса передаёт накопленные значения в MetricService.UpdateMultiple.
+type Listener struct {
+	service       service.MetricService
+	logger        *zap.SugaredLogger
+	aggregator    *Aggregator
+	udp           net.PacketConn
+	tcp           net.Listener
+	flushInterval time.Duration
+	malformed     atomic.Int64
+	// pendingMalformed некорректные строки с прошлого сброса.
+	pendingMalformed atomic.Int64
+}
+
+// Listen открывает UDP- и TCP-сокеты на адресе address.
+func Listen(
+	address string,
+	flushInterval time.Duration,
+	metricService service.MetricService,
+	logger *zap.SugaredLogger,
+) (*Listener, error) {
+	if flushInterval <= 0 {
+		return nil, fmt.Errorf("flush interval must be positive, got %s", flushInterval)
+	}
+
+	udp, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen statsd udp: %w", err)
+	}
+	tcp, err := net.Listen("tcp", address)
+	if err != nil {
+		_ = udp.Close()
+		return nil, fmt.Errorf("failed to listen statsd tcp: %w", err)
+	}
+
+	return &Listener{
+		service:       metricService,
+		logger:        logger.With("component", "statsd"),
+		aggregator:    NewAggregator(),
+		udp:           udp,
+		tcp:           tcp,
+		flushInterval: flushInterval,
+	}, nil
+}
+
+// UDPAddr адрес UDP-сокета.
+func (l *Listener) UDPAddr() net.Addr {
+	return l.udp.LocalAddr()
+}
+
+// TCPAddr адрес TCP-сокета.
+func (l *Listener) TCPAddr() net.Addr {
+	return l.tcp.Addr()
+}
+
+// Malformed число отброшенных некорректных строк с момента запуска.
+func (l *Listener) Malformed() int64 {
+	return l.malformed.Load()
+}
+
+// Run принимает метрики и блокируется до отмены контекста.
+// После отмены сокеты закрываются, а накопленное окно сбрасывается в хранилище.
+func (l *Listener) Run(ctx context.Context) {
+	l.logger.Infow("Starting statsd listener", "addr", l.udp.LocalAddr().String())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		l.serveUDP()
+	}()
+	go func() {
+		defer wg.Done()
+		l.serveTCP(ctx)
+	}()
+
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+			l.flush(ctx)
+		}
+	}
+
+	_ = l.udp.Close()
+	_ = l.tcp.Close()
+	wg.Wait()
+
+	l.flush(context.WithoutCancel(ctx))
+}
+
+func (l *Listener) serveUDP() {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := l.udp.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				l.logger.Infow("failed to read statsd packet", "error", err)
+				continue
+			}
+			return
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			l.handleLine(line)
+		}
+	}
+}
+
+func (l *Listener) serveTCP(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := l.tcp.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				l.logger.Infow("failed to accept statsd connection", "error", err)
+				continue
+			}
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (l *Listener) serveConn(ctx context.Context, conn net.Conn) {
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer func() {
+		stop()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxPacketSize)
+	for scanner.Scan() {
+		l.handleLine(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		l.logger.Infow("failed to read statsd connection", "remote", conn.RemoteAddr().String(), "error", err)
+	}
+}
+
+func (l *Listener) handleLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	sample, err := ParseLine(line)
+	if err != nil {
+		l.countMalformed()
+		if len(line) > maxLoggedLine {
+			line = line[:maxLoggedLine]
+		}
+		l.logger.Infow("malformed statsd line", "line", line, "error", err)
+		return
+	}
+	l.aggregator.Add(sample)
+}
+
+func (l *Listener) flush(ctx context.Context) {
+	metrics := l.aggregator.Flush(func(name string, labels repository.Labels) float64 {
+		return l.currentGauge(ctx, name, labels)
+	})
+	if len(metrics) > 0 {
+		l.updateSamples(ctx, metrics)
+	}
+
+	if malformed := l.pendingMalformed.Swap(0); malformed > 0 {
+		own := []service.MetricsUpdateRequest{{ID: MalformedMetric, MType: "counter", Delta: &malformed}}
+		if err := l.service.UpdateMultiple(ctx, own); err != nil {
+			l.logger.Infow("failed to flush statsd metrics", "metrics", len(own), "error", err)
+		}
+	}
+}
+
+func (l *Listener) countMalformed() {
+	l.malformed.Add(1)
+	l.pendingMalformed.Add(1)
+}
+
+// updateSamples записывает агрегаты одним пакетом. Если пакет отклонён из-за некорректной метрики,
+// агрегаты записываются по одному, а отклонённые учитываются как некорректные строки.
+func (l *Listener) updateSamples(ctx context.Context, metrics []service.MetricsUpdateRequest) {
+	err := l.service.UpdateMultiple(ctx, metrics)
+	if !service.IsInvalidMetric(err) {
+		if err != nil {
+			l.logger.Infow("failed to flush statsd metrics", "metrics", len(metrics), "error", err)
+		}
+		return
+	}
+
+	for _, metric := range metrics {
+		err = l.service.UpdateMultiple(ctx, []service.MetricsUpdateRequest{metric})
+		switch {
+		case service.IsInvalidMetric(err):
+			l.countMalformed()
+			l.logger.Infow("statsd metric rejected", "metric", metric.ID, "error", err)
+		case err != nil:
+			l.logger.Infow("failed to flush statsd metric", "metric", metric.ID, "error", err)
+		}
+	}
+}
+
+// currentGauge возвращает сохранённое значение gauge, 0 для новой серии.
+func (l *Listener) currentGauge(ctx context.Context, name string, labels repository.Labels) float64 {
+	response, err := l.service.Get(ctx, service.MetricsGetRequest{ID: name, MType: "gauge", Labels: labels})
+	if err != nil || response.Value == nil {
+		return 0
+	}
+	return *response.Value
+}
@@ -0,0 +1,104 @@
+package statsd
+
+import (
+	"context"
+	"metrics/internal/repository"
+	"metrics/internal/service"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestListener_UDPAndTCP(t *testing.T) {
+	memStorage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	logger := zap.NewNop().Sugar()
+	metricService := service.NewMetricService(memStorage, nil, logger)
+
+	listener, err := Listen("127.0.0.1:0", time.Hour, metricService, logger)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.Run(ctx)
+	}()
+
+	udpConn, err := net.Dial("udp", listener.UDPAddr().String())
+	require.NoError(t, err)
+	_, err = udpConn.Write([]byte("requests:2|c\nload:3.5|g\nbroken"))
+	require.NoError(t, err)
+	require.NoError(t, udpConn.Close())
+
+	tcpConn, err := net.Dial("tcp", listener.TCPAddr().String())
+	require.NoError(t, err)
+	_, err = tcpConn.Write([]byte("requests:3|c\nrequests:x|c\n"))
+	require.NoError(t, err)
+	require.NoError(t, tcpConn.Close())
+
+	require.Eventually(t, func() bool {
+		return listener.Malformed() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	counter, err := memStorage.GetCounter(context.Background(), "requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), counter)
+
+	gauge, err := memStorage.GetGauge(context.Background(), "load")
+	require.NoError(t, err)
+	assert.InDelta(t, 3.5, gauge, 1e-9)
+
+	malformed, err := memStorage.GetCounter(context.Background(), MalformedMetric)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), malformed)
+}
+
+func TestListener_RejectedSampleKeepsWindow(t *testing.T) {
+	memStorage, err := repository.NewMemStorage()
+	require.NoError(t, err)
+	storage := repository.NewTypeGuardStorage(memStorage)
+	_, err = storage.SetGauge(context.Background(), "load", 1)
+	require.NoError(t, err)
+	logger := zap.NewNop().Sugar()
+	metricService := service.NewMetricService(storage, nil, logger)
+
+	listener, err := Listen("127.0.0.1:0", time.Hour, metricService, logger)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.Run(ctx)
+	}()
+
+	conn, err := net.Dial("tcp", listener.TCPAddr().String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("requests:3|c\nload:1|c\nbroken\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.Eventually(t, func() bool {
+		return listener.Malformed() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	counter, err := memStorage.GetCounter(context.Background(), "requests")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), counter, "отклонённый агрегат не отбрасывает остальное окно")
+	assert.Equal(t, int64(2), listener.Malformed())
+
+	malformed, err := memStorage.GetCounter(context.Background(), MalformedMetric)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), malformed)
+}
@@ -0,0 +1,116 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"metrics/internal/repository"
+	"strconv"
+	"strings"
+)
+
+// Kind тип метрики StatsD.
+type Kind string
+
+const (
+	KindCounter Kind = "c"
+	KindGauge   Kind = "g"
+	KindTimer   Kind = "ms"
+	KindSet     Kind = "s"
+)
+
+var ErrMalformedLine = errors.New("malformed statsd line")
+
+// Sample разобранная строка StatsD.
+type Sample struct {
+	// Метки из тегов DogStatsD (#tag:value).
+	Labels repository.Labels
+	// Имя метрики.
+	Name string
+	// Тип метрики.
+	Kind Kind
+	// Исходное значение элемента множества.
+	Member string
+	// Значение метрики.
+	Value float64
+	// Частота выборки из (0, 1], 1 — без выборки.
+	Rate float64
+	// Значение gauge со знаком изменяет текущее значение, а не заменяет его.
+	Relative bool
+}
+
+// ParseLine разбирает строку вида name:value|type[|@rate][|#tag:value,...].
+// Типы h и d принимаются как таймеры.
+func ParseLine(line string) (Sample, error) {
+	colon := strings.IndexByte(line, ':')
+	if colon <= 0 {
+		return Sample{}, fmt.Errorf("%w: missing name", ErrMalformedLine)
+	}
+	sample := Sample{Name: line[:colon], Rate: 1}
+	if err := repository.ValidateMetricName(sample.Name); err != nil {
+		return Sample{}, fmt.Errorf("%w: %w", ErrMalformedLine, err)
+	}
+
+	parts := strings.Split(line[colon+1:], "|")
+	if len(parts) < 2 || parts[0] == "" {
+		return Sample{}, fmt.Errorf("%w: missing value or type", ErrMalformedLine)
+	}
+	rawValue := parts[0]
+
+	switch parts[1] {
+	case "c":
+		sample.Kind = KindCounter
+	case "g":
+		sample.Kind = KindGauge
+		sample.Relative = rawValue[0] == '+' || rawValue[0] == '-'
+	case "ms", "h", "d":
+		sample.Kind = KindTimer
+	case "s":
+		sample.Kind = KindSet
+		sample.Member = rawValue
+	default:
+		return Sample{}, fmt.Errorf("%w: unknown type %q", ErrMalformedLine, parts[1])
+	}
+
+	if sample.Kind != KindSet {
+		value, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return Sample{}, fmt.Errorf("%w: value %q", ErrMalformedLine, rawValue)
+		}
+		sample.Value = value
+	}
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			rate, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return Sample{}, fmt.Errorf("%w: sample rate %q", ErrMalformedLine, part)
+			}
+			sample.Rate = rate
+		case strings.HasPrefix(part, "#"):
+			labels, err := parseTags(part[1:])
+			if err != nil {
+				return Sample{}, err
+			}
+			sample.Labels = labels
+		default:
+			return Sample{}, fmt.Errorf("%w: unknown field %q", ErrMalformedLine, part)
+		}
+	}
+
+	return sample, nil
+}
+
+// parseTags разбирает теги DogStatsD. Тег без значения получает пустое значение.
+func parseTags(s string) (repository.Labels, error) {
+	labels := repository.Labels{}
+	for _, tag := range strings.Split(s, ",") {
+		name, value, _ := strings.Cut(tag, ":")
+		labels[name] = value
+	}
+	if err := labels.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedLine, err)
+	}
+	return labels, nil
+}
@@ -0,0 +1,91 @@
+package statsd
+
+import (
+	"metrics/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Sample
+	}{
+		{
+			name: "counter",
+			line: "requests:1|c",
+			want: Sample{Name: "requests", Kind: KindCounter, Value: 1, Rate: 1},
+		},
+		{
+			name: "counter with sample rate",
+			line: "requests:2|c|@0.5",
+			want: Sample{Name: "requests", Kind: KindCounter, Value: 2, Rate: 0.5},
+		},
+		{
+			name: "gauge",
+			line: "load:3.2|g",
+			want: Sample{Name: "load", Kind: KindGauge, Value: 3.2, Rate: 1},
+		},
+		{
+			name: "relative gauge",
+			line: "queue:-4|g",
+			want: Sample{Name: "queue", Kind: KindGauge, Value: -4, Rate: 1, Relative: true},
+		},
+		{
+			name: "histogram as timer",
+			line: "latency:12|h",
+			want: Sample{Name: "latency", Kind: KindTimer, Value: 12, Rate: 1},
+		},
+		{
+			name: "set",
+			line: "users:alice|s",
+			want: Sample{Name: "users", Kind: KindSet, Member: "alice", Rate: 1},
+		},
+		{
+			name: "tags",
+			line: "latency:320|ms|@0.1|#host:web-1,canary",
+			want: Sample{
+				Name:   "latency",
+				Kind:   KindTimer,
+				Value:  320,
+				Rate:   0.1,
+				Labels: repository.Labels{"host": "web-1", "canary": ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLine(tt.line)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseLine_Malformed(t *testing.T) {
+	lines := []string{
+		"requests",
+		":1|c",
+		"requests:1",
+		"requests:|c",
+		"requests:abc|c",
+		"requests:NaN|c",
+		"temperature:+Inf|g",
+		"latency:-inf|ms",
+		"requests:1|x",
+		"requests:1|c|@0",
+		"requests:1|c|@2",
+		"requests:1|c|rate",
+		"requests:1|c|#bad-tag:1",
+		`requests{a="1"}:1|c`,
+	}
+
+	for _, line := range lines {
+		_, err := ParseLine(line)
+		assert.ErrorIs(t, err, ErrMalformedLine, line)
+	}
+}
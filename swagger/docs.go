@@ -17,14 +17,14 @@ const docTemplate = `{
     "paths": {
         "/": {
             "get": {
-                "description": "Генерирует HTML-страницу с перечнем метрик (gauge и counter)",
+                "description": "Генерирует HTML-страницу с таблицей метрик (gauge, counter, histogram и summary): сортировка, поиск, фильтр по типу,\nграфики истории и обновление значений через /stream или /api/metrics",
                 "produces": [
                     "text/html"
                 ],
                 "tags": [
                     "Info"
                 ],
-                "summary": "Список метрик",
+                "summary": "Панель метрик",
                 "responses": {
                     "200": {
                         "description": "HTML страница с метриками",
@@ -41,6 +41,561 @@ const docTemplate = `{
                 }
             }
         },
+        "/admin/agents/{agentID}/revoke": {
+            "post": {
+                "description": "Отзывает учётные данные агента без перезапуска сервера. Требуется право admin",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Отзыв учётных данных агента",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор агента",
+                        "name": "agentID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор администратора",
+                        "name": "X-Agent-ID",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подпись тела запроса ключом администратора",
+                        "name": "HashSHA256",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Учётные данные отозваны"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Agent not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/metrics/{metricType}/{metricName}": {
+            "delete": {
+                "description": "Удаляет серию вместе с историей и записывает действие в журнал аудита. Требуется право admin",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Удаление метрики",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип метрики: counter, gauge, histogram или summary",
+                        "name": "metricType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя метрики",
+                        "name": "metricName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Метка серии в виде name:value, можно повторять",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор администратора",
+                        "name": "X-Agent-ID",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подпись тела запроса ключом администратора",
+                        "name": "HashSHA256",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Метрика удалена"
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Metric not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/metrics/{metricType}/{metricName}/rename": {
+            "post": {
+                "description": "Переносит серию вместе с историей под новое имя и записывает действие в журнал аудита. Требуется право admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Переименование метрики",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип метрики: counter, gauge, histogram или summary",
+                        "name": "metricType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя метрики",
+                        "name": "metricName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Метка серии в виде name:value, можно повторять",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Новое имя метрики",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.MetricRenameRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор администратора",
+                        "name": "X-Agent-ID",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подпись тела запроса ключом администратора",
+                        "name": "HashSHA256",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Метрика переименована"
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Metric not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Metric with the new name already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/metrics/{metricType}/{metricName}/reset": {
+            "post": {
+                "description": "Обнуляет counter, записывает нулевое значение в историю и действие в журнал аудита. Требуется право admin",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Обнуление counter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип метрики, только counter",
+                        "name": "metricType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя метрики",
+                        "name": "metricName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Метка серии в виде name:value, можно повторять",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор администратора",
+                        "name": "X-Agent-ID",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подпись тела запроса ключом администратора",
+                        "name": "HashSHA256",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Counter обнулён"
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Metric not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts": {
+            "get": {
+                "description": "Возвращает все правила алертов с состоянием inactive, pending, firing или resolved, упорядоченные по имени",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Состояние алертов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/alerting.Alert"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/metrics": {
+            "get": {
+                "description": "Возвращает текущие значения всех серий, упорядоченные по имени, типу и меткам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Json"
+                ],
+                "summary": "Список метрик в JSON",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.MetricsResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dashboard/{metricType}/{metricName}": {
+            "get": {
+                "description": "Показывает значение, метки и график истории одной серии",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Info"
+                ],
+                "summary": "Страница метрики",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип метрики (counter, gauge, histogram или summary)",
+                        "name": "metricType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя метрики",
+                        "name": "metricName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Метка серии в виде name:value, можно повторять",
+                        "name": "label",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "HTML страница метрики",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Метрика не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/history/{metricType}/{metricName}": {
+            "get": {
+                "description": "Возвращает значения метрики за интервал времени",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Json"
+                ],
+                "summary": "История метрики",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип метрики (counter или gauge)",
+                        "name": "metricType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя метрики",
+                        "name": "metricName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало интервала (RFC3339 или unix-время в секундах)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец интервала (RFC3339 или unix-время в секундах), по умолчанию текущее время",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Шаг прореживания (например 30s или число секунд)",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Метка серии в виде name:value, можно повторять",
+                        "name": "label",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.MetricsHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Возвращает все метрики в текстовом формате Prometheus или OpenMetrics (по заголовку Accept)",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Info"
+                ],
+                "summary": "Метрики в формате Prometheus",
+                "responses": {
+                    "200": {
+                        "description": "Метрики в формате Prometheus",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/ping": {
             "get": {
                 "consumes": [
@@ -52,16 +607,62 @@ const docTemplate = `{
                 "tags": [
                     "Info"
                 ],
-                "summary": "Проверка состояния сервиса",
+                "summary": "Проверка состояния сервиса",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/stream": {
+            "get": {
+                "description": "Отправляет каждое принятое обновление метрики событием metric с текущим значением в JSON.\nКлиент, не успевающий читать события, получает событие error и отключается.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Stream"
+                ],
+                "summary": "Поток изменений метрик (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Шаблон имени метрики (*, ?, [...])",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип метрики (counter, gauge, histogram или summary)",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.MetricEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный фильтр",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "503": {
+                        "description": "Поток недоступен",
                         "schema": {
                             "type": "string"
                         }
@@ -89,8 +690,14 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.MetricsUpdateRequest"
+                            "$ref": "#/definitions/service.MetricsUpdateRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор с тем же ключом не применяется",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -117,7 +724,7 @@ const docTemplate = `{
         },
         "/update/{metricType}/{metricName}/{metricValue}": {
             "post": {
-                "description": "Обновляет значение метрики по её типу (counter или gauge) на основе переданных параметров",
+                "description": "Обновляет значение метрики по её типу (counter или gauge) на основе переданных параметров.\nДля histogram значение — одно наблюдение, раскладываемое по корзинам по умолчанию",
                 "consumes": [
                     "text/plain"
                 ],
@@ -131,7 +738,7 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Тип метрики (counter или gauge)",
+                        "description": "Тип метрики (counter, gauge или histogram)",
                         "name": "metricType",
                         "in": "path",
                         "required": true
@@ -195,9 +802,15 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/dto.MetricsUpdateRequest"
+                                "$ref": "#/definitions/service.MetricsUpdateRequest"
                             }
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор с тем же ключом не применяется",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -222,6 +835,49 @@ const docTemplate = `{
                 }
             }
         },
+        "/v1/metrics": {
+            "post": {
+                "description": "Принимает ExportMetricsServiceRequest в protobuf или JSON и отвечает ExportMetricsServiceResponse в том же формате.\nSum сохраняется как counter, Gauge — как gauge, гистограммы и summary отклоняются в partial_success.",
+                "consumes": [
+                    "application/json",
+                    "application/x-protobuf"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-protobuf"
+                ],
+                "tags": [
+                    "OTLP"
+                ],
+                "summary": "Приём метрик OTLP",
+                "responses": {
+                    "200": {
+                        "description": "ExportMetricsServiceResponse",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос или метрики отклонены проверкой",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый формат",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Хранилище недоступно, запрос можно повторить",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/value": {
             "post": {
                 "description": "Получает значение метрики по имени и типу",
@@ -242,7 +898,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.MetricsGetRequest"
+                            "$ref": "#/definitions/service.MetricsGetRequest"
                         }
                     }
                 ],
@@ -250,7 +906,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.MetricsResponse"
+                            "$ref": "#/definitions/service.MetricsResponse"
                         }
                     },
                     "400": {
@@ -276,12 +932,13 @@ const docTemplate = `{
         },
         "/value/{metricType}/{metricName}": {
             "get": {
-                "description": "Возвращает значение метрики по ее типу (counter или gauge) в формате текста",
+                "description": "Возвращает значение метрики по ее типу в формате текста. Для histogram и summary\nвозвращается JSON с корзинами или квантилями, суммой и числом наблюдений.\nБез меток, если серии без меток нет, возвращается единственная серия с этим именем",
                 "consumes": [
                     "text/plain"
                 ],
                 "produces": [
-                    "text/plain"
+                    "text/plain",
+                    "application/json"
                 ],
                 "tags": [
                     "Text"
@@ -290,7 +947,7 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Тип метрики (counter или gauge)",
+                        "description": "Тип метрики (counter, gauge, histogram или summary)",
                         "name": "metricType",
                         "in": "path",
                         "required": true
@@ -301,6 +958,16 @@ const docTemplate = `{
                         "name": "metricName",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Метка серии в виде name:value, можно повторять",
+                        "name": "label",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -330,33 +997,393 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/write": {
+            "post": {
+                "description": "Принимает точки InfluxDB line protocol (совместимо с /write v1 и /api/v2/write).\nЦелые поля сохраняются как counter, дробные и логические — как gauge с именем measurement_field.\nТеги становятся метками серии, строковые поля пропускаются.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Influx"
+                ],
+                "summary": "Запись метрик InfluxDB",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Точность временных меток: ns, us, ms, s (v1 также n, u, m, h)",
+                        "name": "precision",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Строки line protocol",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Точки записаны",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос или метрики отклонены проверкой, ничего не записано",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "description": "Отправляет каждое принятое обновление метрики текстовым сообщением с текущим значением в JSON.\nКлиент, не успевающий читать события, отключается с кодом закрытия 1013.",
+                "tags": [
+                    "Stream"
+                ],
+                "summary": "Поток изменений метрик (WebSocket)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Шаблон имени метрики (*, ?, [...])",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип метрики (counter, gauge, histogram или summary)",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/service.MetricEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный фильтр",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Поток недоступен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
-        "dto.MetricsGetRequest": {
+        "api.MetricRenameRequest": {
+            "type": "object",
+            "properties": {
+                "new_id": {
+                    "description": "Новое имя метрики, метки серии сохраняются.",
+                    "type": "string"
+                }
+            }
+        },
+        "alerting.Alert": {
+            "type": "object",
+            "properties": {
+                "active_at": {
+                    "description": "Время перехода в pending.",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Описание правила.",
+                    "type": "string"
+                },
+                "expr": {
+                    "description": "Условие правила.",
+                    "type": "string"
+                },
+                "fired_at": {
+                    "description": "Время срабатывания.",
+                    "type": "string"
+                },
+                "labels": {
+                    "description": "Метки правила.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "description": "Имя правила.",
+                    "type": "string"
+                },
+                "no_data": {
+                    "description": "В хранилище нет серий, подходящих под условие.",
+                    "type": "boolean"
+                },
+                "resolved_at": {
+                    "description": "Время снятия.",
+                    "type": "string"
+                },
+                "series": {
+                    "description": "Ключ серии, для которой вычислено условие.",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "Состояние.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/alerting.State"
+                        }
+                    ]
+                },
+                "value": {
+                    "description": "Значение при последнем вычислении, для rate — скорость в секунду.",
+                    "type": "number"
+                }
+            }
+        },
+        "alerting.State": {
+            "type": "string",
+            "enum": [
+                "inactive",
+                "pending",
+                "firing",
+                "resolved"
+            ],
+            "x-enum-comments": {
+                "StateFiring": "условие выполняется дольше заданного в правиле времени.",
+                "StateInactive": "условие не выполняется.",
+                "StatePending": "условие выполняется меньше заданного в правиле времени.",
+                "StateResolved": "условие перестало выполняться после срабатывания."
+            },
+            "x-enum-varnames": [
+                "StateInactive",
+                "StatePending",
+                "StateFiring",
+                "StateResolved"
+            ]
+        },
+        "repository.Histogram": {
+            "type": "object",
+            "properties": {
+                "bounds": {
+                    "description": "Верхние границы корзин по возрастанию, корзина +Inf не указывается.",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "count": {
+                    "description": "Число наблюдений.",
+                    "type": "integer"
+                },
+                "counts": {
+                    "description": "Число наблюдений в каждой корзине (не накопительное), на одно больше числа границ.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "sum": {
+                    "description": "Сумма наблюдений.",
+                    "type": "number"
+                }
+            }
+        },
+        "repository.Quantile": {
+            "type": "object",
+            "properties": {
+                "quantile": {
+                    "description": "Квантиль от 0 до 1, например 0.99.",
+                    "type": "number"
+                },
+                "value": {
+                    "description": "Значение квантиля.",
+                    "type": "number"
+                }
+            }
+        },
+        "repository.Summary": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Число наблюдений.",
+                    "type": "integer"
+                },
+                "quantiles": {
+                    "description": "Квантили по возрастанию.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.Quantile"
+                    }
+                },
+                "sum": {
+                    "description": "Сумма наблюдений.",
+                    "type": "number"
+                }
+            }
+        },
+        "service.MetricEvent": {
+            "type": "object",
+            "properties": {
+                "delta": {
+                    "description": "Значение counter.",
+                    "type": "integer"
+                },
+                "histogram": {
+                    "description": "Накопленная гистограмма histogram.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/repository.Histogram"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "Тип метрики: counter или gauge.",
+                    "type": "string"
+                },
+                "labels": {
+                    "description": "Метки серии.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "summary": {
+                    "description": "Значение summary.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/repository.Summary"
+                        }
+                    ]
+                },
+                "timestamp": {
+                    "description": "Время изменения.",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Имя метрики.",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Значение gauge.",
+                    "type": "number"
+                }
+            }
+        },
+        "service.MetricsGetRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "Имя метрики.",
+                    "type": "string"
+                },
+                "labels": {
+                    "description": "Метки серии.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "description": "Тип метрики: counter, gauge, histogram или summary.",
+                    "type": "string"
+                }
+            }
+        },
+        "service.MetricsHistoryPoint": {
+            "type": "object",
+            "properties": {
+                "delta": {
+                    "description": "Значение counter.",
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "description": "Время обновления.",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Значение gauge.",
+                    "type": "number"
+                }
+            }
+        },
+        "service.MetricsHistoryResponse": {
             "type": "object",
             "properties": {
                 "id": {
                     "description": "Имя метрики.",
                     "type": "string"
                 },
+                "labels": {
+                    "description": "Метки серии.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "points": {
+                    "description": "Значения в порядке времени.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.MetricsHistoryPoint"
+                    }
+                },
                 "type": {
                     "description": "Тип метрики: counter или gauge.",
                     "type": "string"
                 }
             }
         },
-        "dto.MetricsResponse": {
+        "service.MetricsResponse": {
             "type": "object",
             "properties": {
                 "delta": {
                     "description": "Значение counter.",
                     "type": "integer"
                 },
+                "histogram": {
+                    "description": "Накопленная гистограмма histogram.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/repository.Histogram"
+                        }
+                    ]
+                },
                 "id": {
                     "description": "Тип метрики: counter или gauge.",
                     "type": "string"
                 },
+                "labels": {
+                    "description": "Метки серии.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "summary": {
+                    "description": "Значение summary.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/repository.Summary"
+                        }
+                    ]
+                },
                 "type": {
                     "description": "Имя метрики.",
                     "type": "string"
@@ -367,19 +1394,56 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.MetricsUpdateRequest": {
+        "service.MetricsUpdateRequest": {
             "type": "object",
             "properties": {
+                "buckets": {
+                    "description": "Границы корзин для observations, по умолчанию DefaultHistogramBuckets.",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
                 "delta": {
                     "description": "Значение counter.",
                     "type": "integer"
                 },
+                "histogram": {
+                    "description": "Готовые корзины histogram, прибавляются к серии.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/repository.Histogram"
+                        }
+                    ]
+                },
                 "id": {
                     "description": "Имя метрики.",
                     "type": "string"
                 },
+                "labels": {
+                    "description": "Метки серии, например {\"host\": \"web-1\"}.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "observations": {
+                    "description": "Отдельные наблюдения histogram вместо готовых корзин.",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "summary": {
+                    "description": "Значение summary, заменяет предыдущее.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/repository.Summary"
+                        }
+                    ]
+                },
                 "type": {
-                    "description": "Тип метрики: counter или gauge.",
+                    "description": "Тип метрики: counter, gauge, histogram или summary.",
                     "type": "string"
                 },
                 "value": {